@@ -0,0 +1,395 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	protobuf "google.golang.org/protobuf/proto"
+
+	"grpc_client/internal/bench"
+	"grpc_client/internal/client"
+	"grpc_client/internal/config"
+	"grpc_client/internal/file"
+	"grpc_client/internal/runner"
+	"grpc_client/internal/vars"
+)
+
+var (
+	benchOpts       bench.Options
+	benchController string
+	benchWorkers    int
+	benchWorker     string
+	benchToken      string
+	benchDashboard  bool
+)
+
+// benchTokenEnv holds the default of --token, shared by a controller and its workers
+const benchTokenEnv = "GRPC_CLIENT_BENCH_TOKEN"
+
+var benchCmd = &cobra.Command{
+	Use:   "bench [scenario.grpc]",
+	Short: "Load test a gRPC method or scenario",
+	Long: `Call a gRPC method repeatedly from concurrent workers and report
+throughput, latency percentiles, and status codes.
+
+Given a .grpc file instead of --address/--service/--method, each worker
+acts as a virtual user that runs the whole file (e.g. login, create, poll)
+in order. Every virtual user starts from the file's [Variables], the
+profile's variables, --var-file, and --var, and keeps its own captures;
+placeholders that cannot be resolved fail before the benchmark starts.
+Latency is then measured
+end to end per scenario, and a scenario counts as an error when any of its
+requests fails or any assertion does not pass.
+
+--qps limits the global rate across all workers using a shared token
+bucket (with --burst controlling how many calls may be issued at once);
+the summary reports the achieved rate next to the requested one.
+
+--arrival-rate switches to an open model: calls are started on a fixed
+schedule (--arrival constant or poisson) whether or not earlier calls
+have completed, and latency is measured from each call's scheduled start.
+This avoids coordinated omission, where a slow server throttles a closed
+loop of workers and hides the queueing delay real clients would see.
+--concurrency is ignored in this mode.
+
+While running, a dashboard on stderr shows the current QPS, error rate,
+and p99 over the last few seconds along with the calls in flight; it is
+replaced by the final summary on exit (disable with --dashboard=false).
+
+For load beyond a single host, start a controller with --controller and
+--workers, then run "bench --worker <controller address>" on each load
+generator. Workers need the same proto files; the call (or scenario) and
+each worker's share of --total, --concurrency, --qps, and --arrival-rate
+are sent by the controller, which aggregates every sample into one summary.
+The controller resolves the paths of a scenario (certificates, golden files,
+{{file}}) against the scenario file, so workers run from the same directory
+need those files at the same relative paths. The call includes its credentials, so the controller only sends it to
+workers presenting the same --token (or $GRPC_CLIENT_BENCH_TOKEN). The
+connection is not encrypted: keep it on a trusted network.
+
+--render ghz or --render fortio writes the summary as a ghz or Fortio JSON
+report, so results can be loaded into their viewers and comparison tools.
+
+Example:
+  grpc_client bench -p ./protos \
+    --address http://localhost:8080 \
+    --service example.UserService \
+    --method GetUser \
+    --data '{"user_id": "123"}' \
+    --concurrency 50 --total 10000 --qps 500
+
+  grpc_client bench -p ./protos \
+    --address http://localhost:8080 \
+    --service example.UserService \
+    --method GetUser \
+    --data '{"user_id": "123"}' \
+    --arrival-rate 200 --duration 30s
+
+  grpc_client bench -p ./protos ./checkout.grpc --concurrency 20 --duration 1m
+
+  # Distributed: one controller and three workers
+  export GRPC_CLIENT_BENCH_TOKEN=$(openssl rand -hex 16)
+  grpc_client bench -p ./protos ./checkout.grpc --controller :7000 --workers 3 --duration 1m
+  grpc_client bench -p ./protos --worker controller-host:7000
+`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		// Stop early (and still report) on Ctrl-C
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if (benchWorker != "" || benchController != "") && benchToken == "" {
+			return fmt.Errorf("--token (or $%s) is required with --controller and --worker", benchTokenEnv)
+		}
+		if benchWorker != "" {
+			logger.Infof("Joining controller at %s", benchWorker)
+			return bench.Work(ctx, benchWorker, benchToken, func(raw json.RawMessage) (bench.CallFunc, error) {
+				var spec benchSpec
+				if err := json.Unmarshal(raw, &spec); err != nil {
+					return nil, fmt.Errorf("invalid job from controller: %w", err)
+				}
+				return spec.newCall()
+			})
+		}
+
+		out, err := newRenderer()
+		if err != nil {
+			return err
+		}
+		defer closeRenderer(out, &err)
+
+		// --duration alone runs for the whole time rather than stopping at the default --total
+		if benchOpts.Duration > 0 && !cmd.Flags().Changed("total") {
+			benchOpts.Total = 0
+		}
+		if benchOpts.Total <= 0 && benchOpts.Duration <= 0 {
+			return fmt.Errorf("either --total or --duration must be set")
+		}
+		if benchOpts.ArrivalRate > 0 {
+			if benchOpts.QPS > 0 {
+				return fmt.Errorf("--qps cannot be combined with --arrival-rate")
+			}
+			if benchOpts.Arrival != bench.ArrivalConstant && benchOpts.Arrival != bench.ArrivalPoisson {
+				return fmt.Errorf("invalid --arrival %q, must be one of: %s, %s", benchOpts.Arrival, bench.ArrivalConstant, bench.ArrivalPoisson)
+			}
+		}
+
+		spec := benchSpec{
+			Address:   address,
+			Service:   service,
+			Method:    method,
+			Data:      data,
+			Prefix:    prefix,
+			Headers:   headers,
+			Basic:     basic,
+			Bearer:    bearer,
+			Authority: authority,
+			TLS:       tlsFlags(),
+			Protocol:  protocol,
+			Timeout:   timeout,
+		}
+		var target, called string
+		if len(args) == 1 {
+			// Parsed here, so that workers get paths resolved against the scenario file
+			requests, err := file.ParseMultiple(args[0])
+			if err != nil {
+				return err
+			}
+			scope, err := globalScope()
+			if err != nil {
+				return err
+			}
+			spec.Scenario, spec.Requests, spec.Profile = args[0], requests, profile
+			spec.Variables = scope.Resolve(vars.Layer{})
+			called = args[0]
+		} else {
+			if address == "" || service == "" || method == "" {
+				return fmt.Errorf("--address, --service, and --method are required without a scenario file")
+			}
+			target, called = address, service+"."+method
+		}
+
+		// Built even when distributing, so a bad spec fails before workers join
+		call, err := spec.newCall()
+		if err != nil {
+			return err
+		}
+
+		// Show live statistics while the benchmark runs
+		stopDashboard := func() {}
+		if benchDashboard && isTerminal(os.Stderr) {
+			call, stopDashboard = startDashboard(ctx, call)
+			defer stopDashboard()
+		}
+
+		var summary *bench.Summary
+		if benchController != "" {
+			ln, err := net.Listen("tcp", benchController)
+			if err != nil {
+				return fmt.Errorf("failed to listen for workers: %w", err)
+			}
+			defer func() {
+				_ = ln.Close()
+			}()
+			if !isLoopback(ln.Addr()) {
+				logger.Warnf("Workers connect to %s without TLS: the token and the call's credentials are sent in cleartext", ln.Addr())
+			}
+			logger.Infof("Waiting for %d worker(s) on %s", benchWorkers, ln.Addr())
+
+			summary, err = bench.Coordinate(ctx, ln, benchWorkers, benchToken, benchOpts, spec)
+			if err != nil {
+				return err
+			}
+		} else {
+			summary = bench.Run(ctx, benchOpts, call)
+		}
+		summary.Target = target
+		summary.Call = called
+
+		// Replace the dashboard with the final summary
+		stopDashboard()
+
+		return out.Bench(summary)
+	},
+}
+
+// dashboardWindow is the span of the dashboard's rolling statistics
+const dashboardWindow = 5 * time.Second
+
+// startDashboard draws live statistics on stderr until the returned stop
+// function is called (it may be called more than once). It returns call
+// instrumented to count the calls in flight.
+func startDashboard(ctx context.Context, call bench.CallFunc) (bench.CallFunc, func()) {
+	monitor := bench.NewMonitor(dashboardWindow)
+	benchOpts.Observe = monitor.Observe
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		bench.ShowDashboard(ctx, os.Stderr, monitor, benchOpts.Total, 500*time.Millisecond)
+		close(done)
+	}()
+
+	return monitor.Wrap(call), func() {
+		cancel()
+		<-done
+	}
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// isLoopback reports whether addr only accepts connections from this host
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
+
+// benchSpec describes what a bench calls. A controller sends it to its
+// workers, which build the call against their own proto files.
+type benchSpec struct {
+	Address   string
+	Service   string
+	Method    string
+	Data      string
+	Prefix    string
+	Headers   []string
+	Basic     string
+	Bearer    string
+	Authority string
+	TLS       client.TLSConfig
+	Protocol  string
+	Timeout   time.Duration
+	Scenario  string              // Scenario file name; when set, Requests replaces the fields above
+	Requests  []*file.RequestFile // Requests of the scenario file, with paths resolved against it
+	Profile   *config.Profile     // Profile applied to the scenario requests, if any
+
+	// Variables of the profile, --var-file, and --var, which override the
+	// [Variables] sections of the scenario
+	Variables map[string]interface{}
+}
+
+// newCall builds the CallFunc described by the spec
+func (s benchSpec) newCall() (bench.CallFunc, error) {
+	if s.Scenario != "" {
+		bearer, authority = s.Bearer, s.Authority
+		setTLSFlags(s.TLS)
+		// Copies, as the controller still sends the spec's requests to its workers
+		requests := make([]*file.RequestFile, len(s.Requests))
+		for i, req := range s.Requests {
+			req = req.Clone()
+			requests[i] = req
+			if s.Profile != nil {
+				if err := s.Profile.Apply(req); err != nil {
+					return nil, fmt.Errorf("profile: %w", err)
+				}
+			}
+			applyRequestBearer(req)
+			applyRequestAuthority(req)
+		}
+		scope := &vars.Scope{}
+		scope.Add("controller", s.Variables)
+		return prepareScenario(requests, scope.Resolve(fileVariables(requests)))
+	}
+
+	// prepareCall reads the call flags, which a worker takes from the spec
+	address, service, method, data = s.Address, s.Service, s.Method, s.Data
+	prefix, headers, basic, bearer, authority = s.Prefix, s.Headers, s.Basic, s.Bearer, s.Authority
+	setTLSFlags(s.TLS)
+	protocol, timeout = s.Protocol, s.Timeout
+	return prepareBenchCall()
+}
+
+// prepareBenchCall builds a CallFunc for the single method described by the
+// call flags
+func prepareBenchCall() (bench.CallFunc, error) {
+	call, err := prepareCall()
+	if err != nil {
+		return nil, err
+	}
+
+	// Every call sends the same message
+	requestSize := protobuf.Size(call.input)
+
+	return func(ctx context.Context) bench.Outcome {
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		out := bench.Outcome{RequestSize: requestSize}
+		resp, err := call.client.Call(callCtx, call.method, call.input)
+		var rpcErr *client.Error
+		switch {
+		case err == nil:
+			out.Status = client.StatusOK
+			out.ResponseSize = resp.Size
+		case errors.As(err, &rpcErr):
+			out.Status = rpcErr.Status()
+		default:
+			out.Status = "unknown"
+		}
+		return out
+	}, nil
+}
+
+// prepareScenario builds a CallFunc that runs every request of a scenario
+// in order, each run starting from its own copy of variables
+func prepareScenario(requests []*file.RequestFile, variables map[string]interface{}) (bench.CallFunc, error) {
+	// Missing variables would otherwise be sent as literal placeholders
+	if err := runner.CheckPlaceholders(requests, nil, variables); err != nil {
+		return nil, err
+	}
+
+	registry, err := loadProtos()
+	if err != nil {
+		return nil, err
+	}
+
+	// Fail fast on unknown methods rather than on every iteration
+	r := runner.New(registry)
+	r.TLS = tlsFlags()
+	r.Strict = true
+	for _, req := range requests {
+		if strings.Contains(req.Service+req.Method, "{{") {
+			continue // Only known once variables are resolved
+		}
+		if _, err := r.FindMethod(req); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(ctx context.Context) bench.Outcome {
+		return r.Scenario(ctx, requests, variables)
+	}, nil
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+	addCallFlags(benchCmd)
+
+	benchCmd.Flags().IntVarP(&benchOpts.Concurrency, "concurrency", "c", 10, "number of concurrent workers")
+	benchCmd.Flags().IntVarP(&benchOpts.Total, "total", "n", 200, "number of calls to make (0 = until --duration elapses; ignored when only --duration is set)")
+	benchCmd.Flags().DurationVarP(&benchOpts.Duration, "duration", "z", 0, "maximum run time (e.g. 30s; 0 = until --total calls are made)")
+	benchCmd.Flags().Float64Var(&benchOpts.QPS, "qps", 0, "global rate limit in calls per second across all workers (0 = unlimited)")
+	benchCmd.Flags().IntVar(&benchOpts.Burst, "burst", 1, "number of calls that may be issued at once when --qps is set")
+	benchCmd.Flags().Float64Var(&benchOpts.ArrivalRate, "arrival-rate", 0, "start calls at this rate per second regardless of in-flight calls (open model; 0 = closed model)")
+	benchCmd.Flags().StringVar(&benchOpts.Arrival, "arrival", bench.ArrivalPoisson, "inter-arrival distribution for --arrival-rate: constant or poisson")
+	benchCmd.Flags().StringVar(&benchController, "controller", "", "listen on this address (e.g. :7000) and distribute the load across --workers workers")
+	benchCmd.Flags().IntVar(&benchWorkers, "workers", 1, "number of workers to wait for with --controller")
+	benchCmd.Flags().StringVar(&benchWorker, "worker", "", "join the controller at this address (e.g. controller-host:7000) and generate its load")
+	benchCmd.Flags().StringVar(&benchToken, "token", os.Getenv(benchTokenEnv), "shared secret a worker presents to the controller before the call and its credentials are sent (default: $"+benchTokenEnv+")")
+	benchCmd.Flags().StringArrayVar(&varFlags, "var", nil, "set a scenario variable, overriding [Variables] sections (format: 'name=value', can be repeated)")
+	benchCmd.Flags().StringArrayVar(&varFiles, "var-file", nil, "load scenario variables from a JSON or YAML file (can be repeated, later files win)")
+	benchCmd.Flags().BoolVar(&benchDashboard, "dashboard", true, "show live QPS, error rate, p99, and in-flight calls while running (only when stderr is a terminal)")
+	benchCmd.MarkFlagsMutuallyExclusive("controller", "worker")
+}
@@ -2,14 +2,20 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 
 	"grpc_client/internal/client"
-	"grpc_client/internal/proto"
+	"grpc_client/internal/file"
+	"grpc_client/internal/render"
 )
 
 var (
@@ -19,8 +25,29 @@ var (
 	data     string
 	prefix   string
 	headers  []string
-	protocol string
-	timeout  time.Duration
+	basic    string
+	bearer   string
+	certFile string
+	caFile   string
+	insecure bool
+
+	serverName    string
+	showCerts     bool
+	verbose       bool
+	trace         bool
+	authority     string
+	tlsMinVersion string
+	tlsMaxVersion string
+	tlsCiphers    string
+	proxyUser     string
+	proxyHeaders  []string
+	spiffeSocket  string
+	tlsKeyLog     string
+	keyFile       string
+	protocol      string
+	timeout       time.Duration
+	dryRun        bool
+	outputFile    string
 )
 
 var callCmd = &cobra.Command{
@@ -36,84 +63,365 @@ Example:
     --data '{"user_id": "123"}' \
     --prefix /api/grpc \
     --header "Authorization: Bearer token123"
+
+  # Send basic auth credentials, encoded for you
+  grpc_client call -p ./protos -a :8080 -s example.UserService -m GetUser \
+    --basic alice:s3cret
+
+  # Print only a field of the response, e.g. for a shell pipeline
+  grpc_client call -p ./protos -a :8080 -s example.UserService -m GetUser \
+    --data '{"user_id": "123"}' --jq '.user.name'
+
+  # Save the response to a file
+  grpc_client call -p ./protos -a :8080 -s example.UserService -m GetUser \
+    --data '{"user_id": "123"}' -o user.json
+
+  # Print the users of a list RPC as TSV
+  grpc_client call -p ./protos -a :8080 -s example.UserService -m ListUsers \
+    --format-output '{{range .users}}{{.id}}\t{{.name}}\n{{end}}'
+
+  # Print the URL, headers, and encoded payload without sending anything
+  grpc_client call -p ./protos -a :8080 -s example.UserService -m GetUser \
+    --data '{"user_id": "123"}' --dry-run
 `,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		// Load proto definitions
-		registry, err := proto.LoadProtos(protoPath, importPaths)
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		out, err := newRenderer()
 		if err != nil {
-			return fmt.Errorf("failed to load protos: %w", err)
+			return err
 		}
+		defer closeRenderer(out, &err)
 
-		// Find the method descriptor
-		methodDesc, err := registry.FindMethod(service, method)
-		if err != nil {
-			// Provide helpful error with available services
-			services := registry.ListServices()
-			var available []string
-			for _, s := range services {
-				available = append(available, s.FullName)
-			}
-			return fmt.Errorf("%w\n\nAvailable services: %s", err, strings.Join(available, ", "))
+		if err := openSession(); err != nil {
+			return err
+		}
+		defer saveSession(&err)
+		if err := openOAuth(cmd.Context()); err != nil {
+			return err
 		}
 
-		// Parse headers
-		headerMap := make(map[string]string)
-		for _, h := range headers {
-			parts := strings.SplitN(h, ":", 2)
-			if len(parts) != 2 {
-				return fmt.Errorf("invalid header format %q, expected 'Key: Value'", h)
-			}
-			headerMap[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		if err := openTracer(); err != nil {
+			return err
+		}
+		defer flushTracer()
+		openHAR()
+		defer writeHAR(&err)
+		if err := openRetry(); err != nil {
+			return err
 		}
 
-		// Parse protocol
-		proto, err := client.ParseProtocol(protocol)
+		call, err := prepareCall()
 		if err != nil {
 			return err
 		}
 
-		// Create the client
-		c := client.NewClient(address, prefix, proto, headerMap)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
 
-		// Convert JSON input to proto message
-		inputMsg, err := client.JSONToProto(data, methodDesc.Input())
-		if err != nil {
-			return fmt.Errorf("failed to parse JSON input: %w", err)
+		if dryRun {
+			sent, err := call.client.DryRun(ctx, call.method, call.input)
+			if err != nil {
+				return err
+			}
+			body, err := jsonOptions.Format(call.input)
+			if err != nil {
+				return fmt.Errorf("failed to format request: %w", err)
+			}
+			return out.Request(&render.Request{
+				Service: service,
+				Method:  method,
+				URL:     sent.URL,
+				Header:  sent.Header,
+				Body:    body,
+				Payload: sent.Body,
+			})
 		}
 
 		// Make the call
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+		logger.Debugf("Calling %s/%s at %s", service, method, call.address)
+		progress := startProgress(0)
+		progress.Begin(1, progressLabel("", service, method))
+		start := time.Now()
+		response, err := call.client.Call(ctx, call.method, call.input)
+		elapsed := time.Since(start)
+		progress.Stop()
+
+		result := &render.Result{
+			Service:  service,
+			Method:   method,
+			Status:   client.StatusOK,
+			Duration: elapsed,
+
+			RequestSize:   protobuf.Size(call.input),
+			Started:       start,
+			RequestHeader: client.HTTPHeader(call.headers),
+		}
+		result.URL, _ = call.client.MethodURL(call.method)
+		result.Request, _ = jsonOptions.Format(call.input)
 
-		response, err := c.Call(ctx, methodDesc, inputMsg)
 		if err != nil {
+			// Machine-readable output reports the failed call too, as does
+			// --include, which shows the status and metadata of the error
+			var rpcErr *client.Error
+			if (outputFormat == "json" || include) && errors.As(err, &rpcErr) {
+				result.Status, result.Error, result.Header, result.Trailer = rpcErr.Status(), rpcErr.Error(), rpcErr.Header, rpcErr.Trailer
+				if rerr := out.Result(result); rerr != nil {
+					return rerr
+				}
+			}
 			return fmt.Errorf("RPC call failed: %w", err)
 		}
 
-		// Convert response to JSON
-		jsonOutput, err := client.ProtoToJSON(response)
+		// Convert response to JSON, or the protobuf text format
+		format := jsonOptions.Format
+		if messageFormat == "text" {
+			format = client.ProtoToText
+		}
+		body, err := format(response.Msg)
 		if err != nil {
 			return fmt.Errorf("failed to format response: %w", err)
 		}
-
-		fmt.Println(jsonOutput)
-		return nil
+		result.Body = body
+		result.ResponseSize = response.Size
+		result.Header, result.Trailer = response.Header, response.Trailer
+		if showCerts && response.TLS != nil {
+			result.Certificates = render.NewCertificates(response.TLS.PeerCertificates)
+		}
+		result.Output = outputFile
+		if err := writeOutput(result); err != nil {
+			return err
+		}
+		return out.Result(result)
 	},
 }
 
+// preparedCall is a client, method, and input message built from the call flags
+type preparedCall struct {
+	client     *client.Client
+	method     protoreflect.MethodDescriptor
+	input      protoreflect.ProtoMessage
+	address    string            // Normalized server address
+	headers    map[string]string // Parsed --header values
+	httpClient *http.Client      // Applies the TLS flags
+}
+
+// prepareCall loads the protos and builds the client and input message
+// described by the call flags (shared by call and bench)
+func prepareCall() (*preparedCall, error) {
+	// Load proto definitions
+	registry, err := loadProtos()
+	if err != nil {
+		return nil, err
+	}
+
+	// Checked here rather than marked required since a profile may set it
+	if address == "" {
+		return nil, errors.New(`required flag(s) "address" not set`)
+	}
+
+	// Find the method descriptor
+	methodDesc, err := registry.FindMethod(service, method)
+	if err != nil {
+		// Provide helpful error with available services
+		services := registry.ListServices()
+		var available []string
+		for _, s := range services {
+			available = append(available, s.FullName)
+		}
+		return nil, fmt.Errorf("%w\n\nAvailable services: %s", err, strings.Join(available, ", "))
+	}
+
+	// Parse headers
+	headerMap := make(map[string]string)
+	for _, h := range headers {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid header format %q, expected 'Key: Value'", h)
+		}
+		headerMap[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	if basic != "" {
+		authorization, err := client.BasicAuth(basic)
+		if err != nil {
+			return nil, err
+		}
+		client.SetHeader(headerMap, "Authorization", authorization)
+	}
+	applyBearer(headerMap)
+	if authority != "" {
+		client.SetHeader(headerMap, "Host", authority)
+	}
+
+	// Parse protocol
+	proto, err := client.ParseProtocol(protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	// Normalize the address; any path on it is combined with --prefix
+	serverURL, err := client.ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient, err := client.NewHTTPClient(tlsFlags())
+	if err != nil {
+		return nil, err
+	}
+	if activeSession != nil {
+		httpClient = client.WithJar(httpClient, activeSession.Jar)
+	}
+
+	// Convert JSON input to proto message
+	inputMsg, err := client.JSONToProto(data, methodDesc.Input())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON input: %w", err)
+	}
+
+	return &preparedCall{
+		client:     client.NewClient(serverURL.String(), prefix, proto, headerMap, append([]client.Option{client.WithHTTPClient(httpClient)}, clientOptions()...)...),
+		method:     methodDesc,
+		input:      inputMsg,
+		address:    serverURL.String(),
+		headers:    headerMap,
+		httpClient: httpClient,
+	}, nil
+}
+
+// clientOptions returns the client options of the output flags, --verbose,
+// --trace, --otel-endpoint, and --har
+func clientOptions() []client.Option {
+	var opts []client.Option
+	if verbose {
+		opts = append(opts, client.WithVerbose(redactor.Writer(os.Stderr), redactor.Header))
+	}
+	if trace {
+		opts = append(opts, client.WithTrace(redactor.Writer(os.Stderr)))
+	}
+	if tracer != nil {
+		opts = append(opts, client.WithTracer(tracer))
+	}
+	if harLog != nil {
+		opts = append(opts, client.WithHAR(harLog))
+	}
+	if retryPolicy != nil {
+		opts = append(opts, client.WithRetry(retryPolicy))
+	}
+	return opts
+}
+
+// bearerEnv is the environment variable holding the default --bearer token
+const bearerEnv = "GRPC_CLIENT_TOKEN"
+
+// applyBearer sets the Authorization header to the --bearer token, replacing
+// any other. Without --bearer, the token in $GRPC_CLIENT_TOKEN is used for
+// headers that have no Authorization header yet.
+func applyBearer(headers map[string]string) {
+	if bearer != "" {
+		client.SetHeader(headers, "Authorization", "Bearer "+bearer)
+	} else if token := os.Getenv(bearerEnv); token != "" && !client.HasHeader(headers, "Authorization") {
+		headers["Authorization"] = "Bearer " + token
+	}
+}
+
+// applyRequestBearer applies --bearer (or $GRPC_CLIENT_TOKEN) to a request
+// from a file, where a BasicAuth field also counts as an Authorization header
+func applyRequestBearer(req *file.RequestFile) {
+	if bearer != "" {
+		req.BasicAuth = ""
+	}
+	if req.BasicAuth == "" {
+		applyBearer(req.Headers)
+	}
+}
+
+// applyRequestAuthority sets the Host header of a request from a file to
+// --authority, if given
+func applyRequestAuthority(req *file.RequestFile) {
+	if authority != "" {
+		client.SetHeader(req.Headers, "Host", authority)
+	}
+}
+
+// tlsFlags returns the TLS and proxy settings given with --cert, --key,
+// --cacert, --insecure, --spiffe-socket, and the --tls-* and --proxy-* flags
+func tlsFlags() client.TLSConfig {
+	return client.TLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		CAFile:       caFile,
+		Insecure:     insecure,
+		ServerName:   serverName,
+		MinVersion:   tlsMinVersion,
+		MaxVersion:   tlsMaxVersion,
+		CipherSuites: tlsCiphers,
+		ProxyUser:    proxyUser,
+		ProxyHeaders: strings.Join(proxyHeaders, "\n"),
+		SPIFFESocket: spiffeSocket,
+		KeyLogFile:   tlsKeyLog,
+	}
+}
+
+// setTLSFlags sets the TLS flags to t, the inverse of tlsFlags
+func setTLSFlags(t client.TLSConfig) {
+	certFile, keyFile, caFile, insecure, serverName = t.CertFile, t.KeyFile, t.CAFile, t.Insecure, t.ServerName
+	tlsMinVersion, tlsMaxVersion, tlsCiphers, tlsKeyLog = t.MinVersion, t.MaxVersion, t.CipherSuites, t.KeyLogFile
+	proxyUser, proxyHeaders, spiffeSocket = t.ProxyUser, nil, t.SPIFFESocket
+	if t.ProxyHeaders != "" {
+		proxyHeaders = strings.Split(t.ProxyHeaders, "\n")
+	}
+}
+
+// addTLSFlags registers the TLS and proxy flags on cmd
+func addTLSFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&certFile, "cert", "", "PEM client certificate presented for mutual TLS")
+	cmd.Flags().StringVar(&keyFile, "key", "", "PEM private key of --cert (default: read from the --cert file)")
+	cmd.Flags().StringVar(&spiffeSocket, "spiffe-socket", "", "SPIFFE Workload API socket the client certificate (X.509 SVID) is fetched from instead of --cert, e.g. unix:///run/spire/agent.sock or $"+client.SPIFFEEndpointEnv)
+	cmd.MarkFlagsMutuallyExclusive("cert", "spiffe-socket")
+	cmd.Flags().StringVar(&caFile, "cacert", "", "PEM CA certificates trusted for the server instead of the system roots (a file or a directory)")
+	cmd.Flags().BoolVarP(&insecure, "insecure", "k", false, "skip verification of the server certificate (e.g. self-signed dev clusters)")
+	cmd.Flags().StringVar(&serverName, "servername", "", "host name sent for TLS SNI and verified against the server certificate (default: the address host)")
+	cmd.Flags().StringVar(&tlsMinVersion, "tls-min-version", "", "lowest TLS version offered: 1.0, 1.1, 1.2, or 1.3 (default: 1.2)")
+	cmd.Flags().StringVar(&tlsMaxVersion, "tls-max-version", "", "highest TLS version offered: 1.0, 1.1, 1.2, or 1.3 (default: 1.3)")
+	cmd.Flags().StringVar(&tlsCiphers, "tls-ciphers", "", "comma-separated TLS 1.0-1.2 cipher suites offered, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 (TLS 1.3 suites are not configurable)")
+	cmd.Flags().StringVar(&tlsKeyLog, "tls-keylog", "", "append the TLS session secrets to this file (NSS key log format) so captured traffic can be decrypted, e.g. in Wireshark")
+	cmd.Flags().StringVar(&proxyUser, "proxy-user", "", "credentials for the proxy taken from $HTTPS_PROXY or $HTTP_PROXY (format: 'user:password')")
+	cmd.Flags().StringArrayVar(&proxyHeaders, "proxy-header", nil, "header sent to the proxy with CONNECT for https addresses (format: 'Key: Value', can be repeated)")
+}
+
+// addCallFlags registers the flags describing a single RPC on cmd
+func addCallFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&address, "address", "a", "", "server address, e.g. http://localhost:8080, localhost:8080, :8080, or host:443+tls (required unless set by --profile)")
+	cmd.Flags().StringVarP(&service, "service", "s", "", "fully qualified service name (required)")
+	cmd.Flags().StringVarP(&method, "method", "m", "", "method name (required)")
+	cmd.Flags().StringVarP(&data, "data", "d", "{}", "JSON input for the request")
+	cmd.Flags().StringVar(&prefix, "prefix", "", "route prefix for gRPC-Web endpoints (e.g., /api/grpc)")
+	cmd.Flags().StringArrayVarP(&headers, "header", "H", nil, "HTTP headers (format: 'Key: Value', can be repeated)")
+	cmd.Flags().StringVar(&bearer, "bearer", "", "bearer token sent in the Authorization header (default: $"+bearerEnv+" if no Authorization is set)")
+	cmd.Flags().StringVar(&basic, "basic", "", "basic auth credentials (format: 'user:password'), sent base64-encoded in the Authorization header")
+	cmd.MarkFlagsMutuallyExclusive("basic", "bearer")
+	cmd.Flags().StringVar(&protocol, "protocol", "grpc-web", "protocol: grpc, grpc-web, or connect")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "request timeout")
+	cmd.Flags().StringVar(&authority, "authority", "", "HTTP authority (Host header) sent instead of the address host, e.g. to reach a virtual host through an IP or port-forward")
+	addTLSFlags(cmd)
+}
+
 func init() {
 	rootCmd.AddCommand(callCmd)
+	addCallFlags(callCmd)
+	callCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the URL, headers, and encoded payload of the request instead of sending it")
+	callCmd.Flags().BoolVar(&showCerts, "show-certs", false, "print the server certificate chain with the response")
+	addOutputFlag(callCmd)
+	callCmd.Flags().StringVarP(&outputFile, "output-file", "o", "", "write the response body to this file instead of printing it")
+	callCmd.Flags().BoolVar(&appendOutput, "append", false, "append the response to the --output-file instead of replacing its content")
+	callCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "print the HTTP exchange to stderr: URL, request and response headers, trailers, gRPC status, and message sizes")
+	callCmd.Flags().BoolVar(&trace, "trace", false, "dump the wire framing to stderr: each length-prefixed frame and the trailers frame, in hex and decoded")
+	addSessionFlag(callCmd)
+	addOAuthFlags(callCmd)
+	addOTelFlag(callCmd)
+	addRetryFlags(callCmd)
+	addHARFlag(callCmd)
+	addProgressFlag(callCmd)
 
-	callCmd.Flags().StringVarP(&address, "address", "a", "", "server address (required)")
-	callCmd.Flags().StringVarP(&service, "service", "s", "", "fully qualified service name (required)")
-	callCmd.Flags().StringVarP(&method, "method", "m", "", "method name (required)")
-	callCmd.Flags().StringVarP(&data, "data", "d", "{}", "JSON input for the request")
-	callCmd.Flags().StringVar(&prefix, "prefix", "", "route prefix for gRPC-Web endpoints (e.g., /api/grpc)")
-	callCmd.Flags().StringArrayVarP(&headers, "header", "H", nil, "HTTP headers (format: 'Key: Value', can be repeated)")
-	callCmd.Flags().StringVar(&protocol, "protocol", "grpc-web", "protocol: grpc, grpc-web, or connect")
-	callCmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "request timeout")
-
-	_ = callCmd.MarkFlagRequired("address")
 	_ = callCmd.MarkFlagRequired("service")
 	_ = callCmd.MarkFlagRequired("method")
 }
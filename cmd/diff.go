@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"grpc_client/internal/assert"
+	"grpc_client/internal/file"
+	"grpc_client/internal/render"
+)
+
+var (
+	diffAgainst string
+
+	// previousResults are the results of the --diff-against run, by
+	// resultKey
+	previousResults map[string][]*render.Result
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <a.json> <b.json>",
+	Short: "Show the differences between two responses or runs",
+	Long: `Compare two JSON documents, e.g. the responses of the same request from
+staging and production, and print their differences by jsonpath:
+
+  ~ $.user.name: "Alice" -> "Alice Smith"
+  - $.user.age: 30
+  + $.user.email: "alice@example.com"
+
+Object members are compared by key, so formatting and key order do not
+matter, and array elements by position.
+
+When both files hold the results of runs, as written by run --output json
+or --render json, the responses of each request are compared instead:
+their gRPC status and body. Requests are matched by file and position in
+the file, and those only in one of the runs are reported.
+
+With --normalize, dynamic values such as timestamps compare equal (see
+[Normalize] in the README). The command fails with exit code 5 when the
+documents differ.
+
+Example:
+  grpc_client diff old.json new.json
+
+  # Compare the answers of staging and production
+  grpc_client run -p ./protos --profile staging --output json ./tests > staging.json
+  grpc_client run -p ./protos --profile prod --output json ./tests > prod.json
+  grpc_client diff --normalize '$..create_time=timestamp' staging.json prod.json
+`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := parseNormalizeFlags(); err != nil {
+			return err
+		}
+		a, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		b, err := os.ReadFile(args[1])
+		if err != nil {
+			return err
+		}
+
+		runA, errA := render.ReadResults(bytes.NewReader(a))
+		runB, errB := render.ReadResults(bytes.NewReader(b))
+		var differences int
+		switch {
+		case errA == nil && errB == nil:
+			if differences, err = diffRuns(os.Stdout, args[0], args[1], runA, runB); err != nil {
+				return err
+			}
+		case errA == nil || errB == nil:
+			run, other := args[0], args[1]
+			if errA != nil {
+				run, other = other, run
+			}
+			return fmt.Errorf("%s holds the results of a run, but %s does not", run, other)
+		default:
+			changes, err := assert.DiffJSON(string(a), string(b), normalizations...)
+			if err != nil {
+				return err
+			}
+			if len(changes) > 0 {
+				fmt.Println(assert.FormatChanges(changes))
+				differences = 1
+			}
+		}
+		if differences > 0 {
+			return withExitCode(exitAssertions, fmt.Errorf("%s and %s differ", args[0], args[1]))
+		}
+		return nil
+	},
+}
+
+// diffRuns writes the differences of the responses of the requests in two
+// runs to w, and returns the number of requests that differ
+func diffRuns(w io.Writer, nameA, nameB string, a, b []*render.Result) (int, error) {
+	unmatched := make(map[string][]*render.Result)
+	for _, r := range b {
+		unmatched[resultKey(r)] = append(unmatched[resultKey(r)], r)
+	}
+
+	differences := 0
+	report := func(r *render.Result, lines ...string) {
+		if differences > 0 {
+			fmt.Fprintln(w)
+		}
+		differences++
+		fmt.Fprintf(w, "# %s\n%s\n", render.Describe(r), strings.Join(lines, "\n"))
+	}
+	for _, ra := range a {
+		key := resultKey(ra)
+		if len(unmatched[key]) == 0 {
+			report(ra, "only in "+nameA)
+			continue
+		}
+		rb := unmatched[key][0]
+		unmatched[key] = unmatched[key][1:]
+		if ra.Skipped != "" || rb.Skipped != "" {
+			continue // Nothing to compare
+		}
+		lines, err := diffResults(ra, rb, normalizations)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", render.Describe(ra), err)
+		}
+		if len(lines) > 0 {
+			report(ra, lines...)
+		}
+	}
+	for _, rb := range b {
+		if rest := unmatched[resultKey(rb)]; len(rest) > 0 && rest[0] == rb {
+			unmatched[resultKey(rb)] = rest[1:]
+			report(rb, "only in "+nameB)
+		}
+	}
+	return differences, nil
+}
+
+// diffResults returns the differences of the responses of two results of
+// the same request, one per line: their statuses, then their bodies as
+// JSON after applying rules
+func diffResults(a, b *render.Result, rules []file.Normalization) ([]string, error) {
+	var lines []string
+	if a.Status != b.Status {
+		lines = append(lines, fmt.Sprintf("~ status: %s -> %s", a.Status, b.Status))
+	}
+	if a.Body == "" && b.Body == "" {
+		return lines, nil
+	}
+	// A missing body, e.g. of a failed call, compares as null
+	bodyA, bodyB := cmp.Or(a.Body, "null"), cmp.Or(b.Body, "null")
+	changes, err := assert.DiffJSON(bodyA, bodyB, rules...)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range changes {
+		lines = append(lines, c.String())
+	}
+	return lines, nil
+}
+
+// resultKey identifies the request of a result across runs: its file and
+// position in the file
+func resultKey(r *render.Result) string {
+	return fmt.Sprintf("%s#%d", r.File, r.Index)
+}
+
+// openPrevious reads the results of the --diff-against run
+func openPrevious() error {
+	previousResults = nil
+	if diffAgainst == "" {
+		return nil
+	}
+	f, err := os.Open(diffAgainst)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	results, err := render.ReadResults(f)
+	if errors.Is(err, render.ErrNotResults) {
+		return fmt.Errorf("invalid --diff-against %s: %w, as written by run --output json", diffAgainst, err)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid --diff-against %s: %w", diffAgainst, err)
+	}
+	previousResults = make(map[string][]*render.Result)
+	for _, r := range results {
+		previousResults[resultKey(r)] = append(previousResults[resultKey(r)], r)
+	}
+	return nil
+}
+
+// checkPrevious compares the response of result, from req, with that of the
+// same request in the --diff-against run, adding the outcome to its
+// assertions. In repeated runs every iteration compares with the first
+// result of the request.
+func checkPrevious(result *render.Result, req *file.RequestFile) {
+	if previousResults == nil {
+		return
+	}
+	previous := previousResults[resultKey(result)]
+	if len(previous) == 0 || previous[0].Skipped != "" {
+		logger.Warnf("%s was not run in %s, not compared", render.Describe(result), diffAgainst)
+		return
+	}
+	lines, err := diffResults(previous[0], result, slices.Concat(normalizations, req.Normalize))
+	switch {
+	case err != nil:
+		result.Asserts = append(result.Asserts, render.Assertion{Message: fmt.Sprintf("ERROR: failed to compare with previous run %q: %v", diffAgainst, err)})
+	case len(lines) > 0:
+		result.Asserts = append(result.Asserts, render.Assertion{
+			Message: fmt.Sprintf("FAIL: response differs from previous run %q", diffAgainst),
+			Diff:    strings.Join(lines, "\n"),
+		})
+	default:
+		result.Asserts = append(result.Asserts, render.Assertion{Pass: true, Message: fmt.Sprintf("PASS: response matches previous run %q", diffAgainst)})
+	}
+}
+
+func init() {
+	addNormalizeFlag(diffCmd)
+	rootCmd.AddCommand(diffCmd)
+}
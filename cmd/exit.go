@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"errors"
+
+	"grpc_client/internal/client"
+)
+
+// Exit codes, so that scripts can tell classes of failures apart
+const (
+	exitOK         = 0
+	exitUsage      = 1 // Invalid flags, arguments, or input files, and other errors
+	exitProto      = 2 // The proto files cannot be loaded or compiled
+	exitTransport  = 3 // A call failed before the server answered, e.g. connection refused
+	exitRPC        = 4 // The server answered a call with an error status
+	exitAssertions = 5 // One or more assertions failed, or diff found differences
+)
+
+// errAssertionsFailed is returned by run when assertions fail
+var errAssertionsFailed = errors.New("one or more assertions failed")
+
+// exitError is an error that exits with a specific code
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode makes the command exit with code when it fails with err
+func withExitCode(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// exitCode returns the exit code of a command that failed with err
+func exitCode(err error) int {
+	var exit *exitError
+	var rpcErr *client.Error
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &exit):
+		return exit.code
+	case errors.Is(err, errAssertionsFailed):
+		return exitAssertions
+	case errors.As(err, &rpcErr) && rpcErr.Transport:
+		return exitTransport
+	case errors.As(err, &rpcErr):
+		return exitRPC
+	}
+	return exitUsage
+}
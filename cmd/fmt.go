@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"grpc_client/internal/file"
+)
+
+var fmtCheck bool
+
+var fmtCmd = &cobra.Command{
+	Use:   "fmt <path>...",
+	Short: "Rewrite .grpc files in canonical form",
+	Long: `Rewrite .grpc files with consistent formatting:
+- GRPC, Service, Method, Prefix, Protocol and Timeout first, then headers
+- header names in canonical casing (x-api-key becomes X-Api-Key)
+- the JSON body indented with two spaces
+- [Variables], [Captures], [Secrets], [Normalize], then [Asserts], with one
+  blank line between blocks
+- assertions with quoted keys and values, e.g. jsonpath "$.id" == "123"
+
+Comments are kept with the line they precede. Directories are searched
+recursively for .grpc files. Proto files are not needed.
+
+With --check, files are not modified; each unformatted file is reported and
+the command fails, which suits CI.
+
+Example:
+  grpc_client fmt ./requests
+  grpc_client fmt --check ./requests
+`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		out, err := newRenderer()
+		if err != nil {
+			return err
+		}
+		defer closeRenderer(out, &err)
+
+		paths, err := grpcFiles(args, nil)
+		if err != nil {
+			return err
+		}
+
+		unformatted := 0
+		for _, path := range paths {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read request file: %w", err)
+			}
+			formatted, err := file.Format(bytes.NewReader(content))
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if bytes.Equal(content, formatted) {
+				continue
+			}
+
+			if !fmtCheck {
+				if err := os.WriteFile(path, formatted, 0644); err != nil {
+					return fmt.Errorf("failed to write request file: %w", err)
+				}
+				continue
+			}
+			unformatted++
+			diag := file.Diagnostic{
+				File:     path,
+				Line:     firstDifference(content, formatted),
+				Severity: file.SeverityError,
+				Message:  "file is not formatted",
+			}
+			if err := out.Diagnostics([]file.Diagnostic{diag}); err != nil {
+				return err
+			}
+		}
+
+		if unformatted > 0 {
+			return fmt.Errorf("%d file(s) are not formatted", unformatted)
+		}
+		return nil
+	},
+}
+
+// firstDifference returns the 1-based number of the first line that differs
+// between a and b
+func firstDifference(a, b []byte) int {
+	aLines := bytes.Split(a, []byte("\n"))
+	bLines := bytes.Split(b, []byte("\n"))
+	for i := range min(len(aLines), len(bLines)) {
+		if !bytes.Equal(aLines[i], bLines[i]) {
+			return i + 1
+		}
+	}
+	return min(len(aLines), len(bLines))
+}
+
+func init() {
+	fmtCmd.Flags().BoolVar(&fmtCheck, "check", false, "report unformatted files instead of rewriting them")
+	rootCmd.AddCommand(fmtCmd)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+
+	"grpc_client/internal/gateway"
+)
+
+var gatewayCheckCmd = &cobra.Command{
+	Use:   "gateway-check",
+	Short: "Check which gRPC features a gateway or proxy supports",
+	Long: `Exercise an endpoint with a battery of edge cases over gRPC-Web, gRPC, and
+Connect, and print a compatibility matrix. This shows which features a
+deployment (e.g. Envoy, grpcwebproxy, or a Connect server) actually supports:
+
+  unary          the configured call reaches the service
+  errors         an unimplemented method comes back as a proper status
+  trailers       the grpc-status trailer survives the proxy
+  large-message  a 1 MiB request (the first string or bytes field is padded)
+  compression    a gzip-compressed request
+  long-deadline  a 24h deadline is accepted
+  text-mode      base64-encoded gRPC-Web (application/grpc-web-text)
+
+A check passes when the service answers, even with an error status such as
+not_found; unknown, internal, unavailable, and unimplemented are taken to come
+from the gateway.
+
+Example:
+  grpc_client gateway-check -p ./protos \
+    --address https://envoy.example.com \
+    --service example.UserService \
+    --method GetUser \
+    --data '{"user_id": "123"}'
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		out, err := newRenderer()
+		if err != nil {
+			return err
+		}
+		defer closeRenderer(out, &err)
+
+		call, err := prepareCall()
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		matrix := gateway.Run(ctx, &gateway.Target{
+			Address:    call.address,
+			Prefix:     prefix,
+			Headers:    call.headers,
+			HTTPClient: call.httpClient,
+			Method:     call.method,
+			Input:      call.input,
+			Timeout:    timeout,
+		})
+		return out.Gateway(matrix)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gatewayCheckCmd)
+	addCallFlags(gatewayCheckCmd)
+
+	// Every protocol is checked
+	_ = gatewayCheckCmd.Flags().MarkHidden("protocol")
+
+	_ = gatewayCheckCmd.MarkFlagRequired("service")
+	_ = gatewayCheckCmd.MarkFlagRequired("method")
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"grpc_client/internal/client"
+)
+
+var (
+	harPath string
+
+	// harLog records the HTTP exchanges of the calls when --har is set
+	harLog *client.HAR
+)
+
+// openHAR starts recording the HTTP exchanges for --har, if set
+func openHAR() {
+	if harPath != "" {
+		harLog = client.NewHAR(redactor.Header, redactor.Binary)
+	}
+}
+
+// writeHAR writes the recorded exchanges to the --har file, also when the
+// command failed (a failed call is often why it is wanted), reporting its
+// error only if the command itself succeeded
+func writeHAR(err *error) {
+	if harLog == nil {
+		return
+	}
+	var buf bytes.Buffer
+	herr := harLog.Write(redactor.Writer(&buf))
+	if herr == nil {
+		herr = os.WriteFile(harPath, buf.Bytes(), 0644)
+	}
+	if herr != nil {
+		if *err == nil {
+			*err = fmt.Errorf("failed to write HAR: %w", herr)
+		}
+		return
+	}
+	logger.Debugf("Wrote %d exchange(s) to %s", harLog.Len(), harPath)
+}
+
+// addHARFlag registers --har on cmd
+func addHARFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&harPath, "har", "", "write the HTTP exchanges of the calls to this file in HAR format, for browser devtools and other HTTP tooling")
+}
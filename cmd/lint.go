@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"grpc_client/internal/client"
+	"grpc_client/internal/file"
+	"grpc_client/internal/proto"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint <path>...",
+	Short: "Check .grpc files for problems without running them",
+	Long: `Parse .grpc files strictly and report every problem with its line number.
+The run command skips malformed capture and assertion lines silently; lint
+reports them, along with:
+- unknown assertion types and operators
+- unrecognized lines and invalid values (e.g. Timeout: soon)
+- services and methods missing from the proto definitions
+- bodies that do not match the method's input message
+- headers set more than once (warning)
+- captured variables that no later request uses (warning)
+
+Directories are searched recursively for .grpc files. The command fails if
+any error is found; warnings are reported only.
+
+Example:
+  grpc_client lint -p ./protos ./requests
+`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		out, err := newRenderer()
+		if err != nil {
+			return err
+		}
+		defer closeRenderer(out, &err)
+
+		registry, err := loadProtos()
+		if err != nil {
+			return err
+		}
+
+		paths, err := grpcFiles(args, nil)
+		if err != nil {
+			return err
+		}
+
+		errors := 0
+		for _, path := range paths {
+			diags, err := file.Lint(path, func(req *file.RequestFile) []file.Diagnostic {
+				return lintRequest(registry, req)
+			})
+			if err != nil {
+				diags = []file.Diagnostic{{File: path, Line: 1, Severity: file.SeverityError, Message: err.Error()}}
+			}
+			for _, d := range diags {
+				if d.Severity == file.SeverityError {
+					errors++
+				}
+			}
+			if err := out.Diagnostics(diags); err != nil {
+				return err
+			}
+		}
+
+		if errors > 0 {
+			return fmt.Errorf("lint found %d error(s)", errors)
+		}
+		return nil
+	},
+}
+
+// lintRequest checks a parsed request against the proto definitions
+func lintRequest(registry *proto.Registry, req *file.RequestFile) []file.Diagnostic {
+	if req.Service == "" || req.Method == "" {
+		// Already reported as a missing field
+		return nil
+	}
+	// Services and methods with variables are only known at run time
+	if strings.Contains(req.Service+req.Method, "{{") {
+		return nil
+	}
+	method, err := registry.FindMethod(req.Service, req.Method)
+	if err != nil {
+		return []file.Diagnostic{{Line: req.Line, Severity: file.SeverityError, Message: err.Error()}}
+	}
+	// Bodies with variables are only known at run time
+	if strings.Contains(req.Body, "{{") {
+		return nil
+	}
+	if _, err := client.JSONToProto(req.Body, method.Input()); err != nil {
+		return []file.Diagnostic{{Line: req.Line, Severity: file.SeverityError, Message: err.Error()}}
+	}
+	return nil
+}
+
+// grpcFiles expands the given paths into .grpc files, searching
+// directories recursively. Paths that do not exist are expanded as glob
+// patterns, so that they work in shells that do not expand them. Files
+// matching an exclude pattern are left out.
+func grpcFiles(paths, exclude []string) ([]string, error) {
+	var files []string
+	seen := map[string]bool{}
+	add := func(p string) {
+		if !seen[p] && !file.Excluded(p, exclude) {
+			seen[p] = true
+			files = append(files, p)
+		}
+	}
+	for _, pattern := range paths {
+		roots := []string{pattern}
+		if _, err := os.Lstat(pattern); err != nil && file.HasGlob(pattern) {
+			if roots, err = file.Glob(pattern); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+			if len(roots) == 0 {
+				return nil, fmt.Errorf("no files match %q", pattern)
+			}
+		}
+		for _, path := range roots {
+			err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				// Files named explicitly are taken whatever their extension
+				if p == path && !d.IsDir() {
+					add(p)
+					return nil
+				}
+				if !d.IsDir() && filepath.Ext(p) == ".grpc" {
+					add(p)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+}
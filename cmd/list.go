@@ -1,11 +1,7 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
-
-	"grpc_client/internal/proto"
 )
 
 var listCmd = &cobra.Command{
@@ -16,31 +12,19 @@ var listCmd = &cobra.Command{
 Example:
   grpc_client list -p ./protos
 `,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		registry, err := proto.LoadProtos(protoPath, importPaths)
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		out, err := newRenderer()
 		if err != nil {
-			return fmt.Errorf("failed to load protos: %w", err)
+			return err
 		}
+		defer closeRenderer(out, &err)
 
-		services := registry.ListServices()
-		if len(services) == 0 {
-			fmt.Println("No services found in proto files.")
-			return nil
-		}
-
-		fmt.Println("Services:")
-		for _, svc := range services {
-			fmt.Printf("  %s\n", svc.FullName)
-			for _, method := range svc.Methods {
-				fmt.Printf("    - %s (%s) → %s\n",
-					method.Name,
-					method.InputType,
-					method.OutputType,
-				)
-			}
+		registry, err := loadProtos()
+		if err != nil {
+			return err
 		}
 
-		return nil
+		return out.Services(registry.ListServices())
 	},
 }
 
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"grpc_client/internal/client"
+	"grpc_client/internal/oauth"
+)
+
+// oauthConfig is set by the --oauth-* flags
+var oauthConfig oauth.Config
+
+// oauthSecretEnv is the environment variable holding the default
+// --oauth-client-secret, so that it stays out of the shell history
+const oauthSecretEnv = "GRPC_CLIENT_OAUTH_SECRET"
+
+// oauthTimeout bounds the token request
+const oauthTimeout = 30 * time.Second
+
+// openOAuth obtains an access token from --oauth-token-url, if set, and
+// sends it as the --bearer token. With --session, the token is cached in
+// the session and reused by later invocations until it expires.
+func openOAuth(ctx context.Context) error {
+	if oauthConfig.TokenURL == "" {
+		return nil
+	}
+	if oauthConfig.ClientID == "" {
+		return fmt.Errorf("--oauth-client-id is required with --oauth-token-url")
+	}
+
+	key := oauthConfig.Key()
+	tok, cached := oauth.Token{}, false
+	if activeSession != nil {
+		tok, cached = activeSession.Token(key)
+	}
+	if cached {
+		logger.Debugf("Using the OAuth token cached in session %s", activeSession.Name)
+	} else {
+		httpClient, err := client.NewHTTPClient(client.TLSConfig{Insecure: insecure})
+		if err != nil {
+			return err
+		}
+		httpClient = &http.Client{Transport: httpClient.Transport, Timeout: oauthTimeout}
+		logger.Debugf("Requesting an OAuth token from %s", oauthConfig.TokenURL)
+		if tok, err = oauthConfig.Fetch(ctx, httpClient); err != nil {
+			return err
+		}
+		if activeSession != nil {
+			activeSession.SetToken(key, tok)
+		}
+	}
+
+	redactor.AddSecret(tok.AccessToken)
+	bearer = tok.AccessToken
+	return nil
+}
+
+// addOAuthFlags registers the --oauth-* flags on cmd, which must already
+// have --bearer
+func addOAuthFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&oauthConfig.TokenURL, "oauth-token-url", "", "OAuth 2.0 token endpoint to get a bearer token from with the client credentials grant (cached in --session until it expires)")
+	cmd.Flags().StringVar(&oauthConfig.ClientID, "oauth-client-id", "", "client ID for --oauth-token-url")
+	cmd.Flags().StringVar(&oauthConfig.ClientSecret, "oauth-client-secret", os.Getenv(oauthSecretEnv), "client secret for --oauth-token-url (default: $"+oauthSecretEnv+")")
+	cmd.Flags().StringSliceVar(&oauthConfig.Scopes, "oauth-scope", nil, "scope requested with --oauth-token-url (comma-separated, can be repeated)")
+	cmd.MarkFlagsMutuallyExclusive("oauth-token-url", "bearer")
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"grpc_client/internal/client"
+)
+
+// The standard OpenTelemetry environment variables
+const (
+	otelEndpointEnv = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	otelHeadersEnv  = "OTEL_EXPORTER_OTLP_HEADERS"
+	otelServiceEnv  = "OTEL_SERVICE_NAME"
+	traceparentEnv  = "TRACEPARENT"
+)
+
+var (
+	otelEndpoint string
+
+	// tracer records the spans of the calls when --otel-endpoint is set
+	tracer *client.Tracer
+)
+
+// openTracer creates the tracer of --otel-endpoint, if any
+func openTracer() error {
+	if otelEndpoint == "" {
+		return nil
+	}
+	t, err := client.NewTracer(otelEndpoint)
+	if err != nil {
+		return err
+	}
+	if name := os.Getenv(otelServiceEnv); name != "" {
+		t.Service = name
+	}
+	if t.Headers, err = parseOTelHeaders(os.Getenv(otelHeadersEnv)); err != nil {
+		return fmt.Errorf("$%s: %w", otelHeadersEnv, err)
+	}
+	if parent := os.Getenv(traceparentEnv); parent != "" {
+		if _, _, err := client.ParseTraceparent(parent); err != nil {
+			return fmt.Errorf("$%s: %w", traceparentEnv, err)
+		}
+		t.Parent = parent
+	}
+	tracer = t
+	return nil
+}
+
+// flushTracer exports the recorded spans. A collector that cannot be
+// reached is worth a warning, not failing the command.
+func flushTracer() {
+	if tracer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := tracer.Flush(ctx); err != nil {
+		logger.Warnf("%v", err)
+		return
+	}
+	logger.Debugf("Exported spans to %s", otelEndpoint)
+}
+
+// parseOTelHeaders parses headers in the format of
+// $OTEL_EXPORTER_OTLP_HEADERS: comma-separated key=value pairs with
+// URL-encoded values
+func parseOTelHeaders(s string) (http.Header, error) {
+	headers := http.Header{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid header %q, expected key=value", pair)
+		}
+		decoded, err := url.QueryUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid header %q: %w", pair, err)
+		}
+		headers.Add(strings.TrimSpace(key), decoded)
+	}
+	return headers, nil
+}
+
+// addOTelFlag registers --otel-endpoint on cmd
+func addOTelFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&otelEndpoint, "otel-endpoint", os.Getenv(otelEndpointEnv), "OTLP/HTTP endpoint of an OpenTelemetry collector to export a client span of each call to, propagated in a traceparent header (default: $"+otelEndpointEnv+")")
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"grpc_client/internal/config"
+)
+
+var (
+	configPath  string
+	profileName string
+
+	// profile is the profile selected with --profile, or nil
+	profile *config.Profile
+)
+
+// loadProfile loads the profile selected with --profile and applies it to the
+// call flags of cmd that were not set explicitly. Profile headers are sent
+// unless a --header of the same name overrides them.
+func loadProfile(cmd *cobra.Command) error {
+	if profileName == "" {
+		return nil
+	}
+
+	path := configPath
+	if path == "" {
+		found, err := config.Find(".")
+		if err != nil {
+			return err
+		}
+		if found == "" {
+			return fmt.Errorf("--profile %s: no %s found in the working directory or its parents", profileName, config.FileName)
+		}
+		path = found
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	if profile, err = cfg.Profile(profileName); err != nil {
+		return err
+	}
+	logger.Debugf("Using profile %s from %s", profileName, path)
+
+	flags := cmd.Flags()
+	if flags.Lookup("cert") != nil {
+		// An identity given on the command line replaces the profile's
+		if profile.TLS.Cert != "" && !flags.Changed("cert") && !flags.Changed("spiffe-socket") {
+			certFile = profile.TLS.Cert
+		}
+		if profile.TLS.Key != "" && !flags.Changed("key") {
+			keyFile = profile.TLS.Key
+		}
+		if profile.TLS.SPIFFESocket != "" && !flags.Changed("spiffe-socket") && !flags.Changed("cert") {
+			spiffeSocket = profile.TLS.SPIFFESocket
+		}
+		if profile.TLS.CACert != "" && !flags.Changed("cacert") {
+			caFile = profile.TLS.CACert
+		}
+		if profile.TLS.Insecure && !flags.Changed("insecure") {
+			insecure = true
+		}
+	}
+
+	// Only call, bench, and gateway-check have call flags
+	if flags.Lookup("address") == nil {
+		return nil
+	}
+	if url, err := profile.URL(); err != nil {
+		return fmt.Errorf("profile %s: %w", profileName, err)
+	} else if url != "" && !flags.Changed("address") {
+		address = url
+	}
+	if profile.Prefix != "" && !flags.Changed("prefix") {
+		prefix = profile.Prefix
+	}
+	if profile.Protocol != "" && !flags.Changed("protocol") {
+		protocol = profile.Protocol
+	}
+	var profileHeaders []string
+	for _, name := range slices.Sorted(maps.Keys(profile.Headers)) {
+		overridden := slices.ContainsFunc(headers, func(h string) bool {
+			flagName, _, _ := strings.Cut(h, ":")
+			return strings.EqualFold(strings.TrimSpace(flagName), name)
+		})
+		if !overridden {
+			profileHeaders = append(profileHeaders, name+": "+profile.Headers[name])
+		}
+	}
+	headers = append(profileHeaders, headers...)
+	return nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "profile from "+config.FileName+" supplying the address, prefix, protocol, TLS, headers, and variables")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "config file defining profiles (default: "+config.FileName+" in the working directory or its nearest parent)")
+}
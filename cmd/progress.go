@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"grpc_client/internal/logging"
+	"grpc_client/internal/render"
+)
+
+// noProgress turns the progress line off
+var noProgress bool
+
+// progressInterval is how often the progress line is redrawn
+const progressInterval = 100 * time.Millisecond
+
+// startProgress shows the progress of total requests (0 for a single call)
+// on stderr when it is a terminal. It returns nil, which shows nothing,
+// when other output shares stderr: --verbose, --trace, or debug logs.
+func startProgress(total int) *render.Progress {
+	if noProgress || verbose || trace || logger.Enabled(logging.Debug) || !isTerminal(os.Stderr) {
+		return nil
+	}
+	return render.StartProgress(os.Stderr, total, progressInterval)
+}
+
+// progressLabel describes a request on the progress line, e.g.
+// "Get user (example.UserService/GetUser)"
+func progressLabel(name, service, method string) string {
+	label := service + "/" + method
+	if name != "" {
+		label = name + " (" + label + ")"
+	}
+	return label
+}
+
+// addProgressFlag registers --no-progress on cmd
+func addProgressFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&noProgress, "no-progress", false, "do not show the request in flight and its elapsed time on stderr (only shown on a terminal)")
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"grpc_client/internal/render"
+)
+
+var (
+	// reportSpecs are the --report values, kind=path
+	reportSpecs []string
+
+	metricsOut  string
+	metricsPush string
+)
+
+// reportFile buffers a report and writes it to its file when closed, so
+// that nothing is created for invalid reports or runs that fail early
+type reportFile struct {
+	render.Renderer
+	path string
+	buf  *bytes.Buffer
+}
+
+func (r reportFile) Close() error {
+	if err := r.Renderer.Close(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(r.path, r.buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// openReports creates the renderers of the --report values, combined into
+// one (which does nothing without --report). Their files are written when
+// it is closed.
+func openReports() (render.Renderer, error) {
+	var reports []render.Renderer
+	for _, spec := range reportSpecs {
+		kind, path, ok := strings.Cut(spec, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid --report %q, expected kind=path, e.g. junit=report.xml", spec)
+		}
+		buf := &bytes.Buffer{}
+		report, err := render.NewReport(kind, buf)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, render.Redact(reportFile{Renderer: report, path: path, buf: buf}, redactor))
+	}
+	if metricsOut != "" {
+		buf := &bytes.Buffer{}
+		report, _ := render.NewReport("metrics", buf)
+		reports = append(reports, reportFile{Renderer: report, path: metricsOut, buf: buf})
+	}
+	if metricsPush != "" {
+		target, err := pushgatewayURL(metricsPush)
+		if err != nil {
+			return nil, err
+		}
+		buf := &bytes.Buffer{}
+		report, _ := render.NewReport("prometheus", buf)
+		reports = append(reports, metricsPusher{Renderer: report, url: target, buf: buf})
+	}
+	return render.Multi(reports...), nil
+}
+
+// pushgatewayURL returns the URL of the --metrics-push group: the job
+// grpc_client of a Pushgateway address, or the URL as given when it names
+// a group (.../metrics/job/<job>[/<label>/<value>...])
+func pushgatewayURL(address string) (string, error) {
+	u, err := url.Parse(address)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid --metrics-push %q, expected a Pushgateway URL, e.g. http://localhost:9091", address)
+	}
+	if !strings.Contains(u.Path, "/metrics/job/") {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/metrics/job/grpc_client"
+	}
+	return u.String(), nil
+}
+
+// metricsPusher pushes the metrics of a run to a Prometheus Pushgateway
+// when closed, replacing the metrics of the previous run of the group
+type metricsPusher struct {
+	render.Renderer
+	url string
+	buf *bytes.Buffer
+}
+
+func (p metricsPusher) Close() error {
+	if err := p.Renderer.Close(); err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, p.url, p.buf)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to push metrics: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
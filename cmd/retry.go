@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"grpc_client/internal/client"
+)
+
+var (
+	retries      int
+	retryOn      []string
+	retryBackoff string
+	retryDelay   time.Duration
+
+	// retryPolicy retries failed calls when --retry is set
+	retryPolicy *client.RetryPolicy
+)
+
+// maxRetryDelay bounds exponential retry delays
+const maxRetryDelay = 10 * time.Second
+
+// openRetry creates the retry policy of --retry, if any
+func openRetry() error {
+	if retries == 0 {
+		return nil
+	}
+	p := &client.RetryPolicy{
+		Retries:  retries,
+		Backoff:  retryBackoff,
+		Delay:    retryDelay,
+		MaxDelay: maxRetryDelay,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			logger.Warnf("%v, retrying in %s (attempt %d of %d)", err, delay.Round(time.Millisecond), attempt, retries+1)
+		},
+	}
+	for _, s := range retryOn {
+		code, err := client.ParseStatus(s)
+		if err != nil {
+			return err
+		}
+		p.On = append(p.On, code)
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	retryPolicy = p
+	return nil
+}
+
+// addRetryFlags registers --retry and its options on cmd
+func addRetryFlags(cmd *cobra.Command) {
+	defaultOn := make([]string, len(client.DefaultRetryOn))
+	for i, code := range client.DefaultRetryOn {
+		defaultOn[i] = client.StatusName(code)
+	}
+	cmd.Flags().IntVar(&retries, "retry", 0, "retry a call that fails with a retryable status up to this many times, within its timeout")
+	cmd.Flags().StringSliceVar(&retryOn, "retry-on", defaultOn, "comma-separated statuses retried by --retry, e.g. unavailable,deadline_exceeded")
+	cmd.Flags().StringVar(&retryBackoff, "retry-backoff", client.BackoffExponential, "delay between retries: exp, doubling from --retry-delay up to "+maxRetryDelay.String()+" with jitter, or const")
+	cmd.Flags().DurationVar(&retryDelay, "retry-delay", 200*time.Millisecond, "delay before the first retry")
+}
@@ -1,15 +1,44 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"grpc_client/internal/client"
+	"grpc_client/internal/logging"
+	"grpc_client/internal/proto"
+	"grpc_client/internal/redact"
+	"grpc_client/internal/render"
+	"grpc_client/internal/secret"
+	"grpc_client/internal/vars"
 )
 
 var (
-	protoPath   string
-	importPaths []string
+	protoPath      string
+	importPaths    []string
+	renderFormat   string
+	outputFormat   string
+	formatTemplate string
+	redactHeaders  []string
+	quiet          bool
+	include        bool
+	jqPath         string
+	formatOutput   string
+	appendOutput   bool
+	messageFormat  string
+	jsonOptions    = client.JSONOptions{OnPrecisionLoss: warnPrecisionLoss}
+	logLevel       string
+
+	// redactor masks sensitive headers and secrets in all output
+	redactor = redact.New()
+
+	// logger writes diagnostics to stderr, keeping stdout for responses
+	// and reports
+	logger = logging.New(os.Stderr, logging.Info)
 )
 
 var rootCmd = &cobra.Command{
@@ -33,16 +62,185 @@ Examples:
 `,
 }
 
-// Execute runs the root command
+// newRenderer creates the output renderer selected with --render
+// (or --format-template, which selects the template renderer)
+func newRenderer() (render.Renderer, error) {
+	format := renderFormat
+	if formatTemplate != "" {
+		format = "template=" + formatTemplate
+	}
+	switch outputFormat {
+	case "", "text":
+	case "json":
+		if format != "text" {
+			return nil, errors.New("--output json cannot be combined with --render or --format-template")
+		}
+		// One document per request, written as it completes
+		format = "ndjson"
+	default:
+		return nil, fmt.Errorf("invalid --output %q, must be one of: text, json", outputFormat)
+	}
+	switch messageFormat {
+	case "", "json":
+	case "text":
+		if jqPath != "" || formatOutput != "" {
+			return nil, errors.New("--jq and --format-output need JSON responses and cannot be combined with --output-format text")
+		}
+	default:
+		return nil, fmt.Errorf("invalid --output-format %q, must be one of: json, text", messageFormat)
+	}
+	var out render.Renderer
+	var err error
+	if quiet || include {
+		if format != "text" {
+			return nil, errors.New("--quiet and --include apply only to text output")
+		}
+		out = render.NewText(os.Stdout, render.TextOptions{Quiet: quiet, Include: include})
+	} else {
+		out, err = render.New(format, os.Stdout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if jqPath != "" {
+		out = render.Extract(out, jqPath)
+	}
+	if formatOutput != "" {
+		if out, err = render.FormatBodies(out, formatOutput); err != nil {
+			return nil, err
+		}
+	}
+	return render.Redact(out, redactor), nil
+}
+
+// addOutputFlag registers --output, --include, --output-format, the JSON
+// options, --jq, and --format-output on cmd
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&outputFormat, "output", "text", "result format: text, or json for one machine-readable document per request with its URL, headers, body, status, timings, captures, and assertions")
+	cmd.Flags().BoolVarP(&include, "include", "i", false, "print the gRPC status and response headers before each body and the trailers after it, like curl -i")
+	cmd.Flags().StringVar(&messageFormat, "output-format", "json", "format of the response messages: json, or text for the protobuf text format")
+	cmd.Flags().BoolVar(&jsonOptions.EmitDefaults, "emit-defaults", false, "include fields set to their default value in JSON messages, which proto3 JSON omits")
+	cmd.Flags().BoolVar(&jsonOptions.EnumsAsInts, "enums-as-ints", false, "write enum values in JSON messages as numbers instead of names")
+	cmd.Flags().BoolVar(&jsonOptions.UseProtoNames, "use-proto-names", false, "use the field names of the proto in JSON messages instead of lowerCamelCase")
+	cmd.Flags().BoolVar(&jsonOptions.Int64AsNumbers, "int64-as-numbers", false, "write 64-bit integers in JSON messages as numbers instead of strings, as JavaScript clients parse them (values beyond 2^53 lose precision there)")
+	cmd.Flags().StringVar(&jqPath, "jq", "", "print only the values this path selects in each response, e.g. '.user.name' or '$.users[*].id' (strings unquoted, one match per line)")
+	cmd.Flags().StringVar(&formatOutput, "format-output", "", `print each response through this Go template with the response as data, e.g. '{{.user.id}}\t{{.user.name}}' (\t and \n are expanded)`)
+	cmd.MarkFlagsMutuallyExclusive("jq", "format-output")
+}
+
+// warnPrecisionLoss warns that a 64-bit integer written as a number with
+// --int64-as-numbers is beyond what JavaScript numbers hold exactly
+func warnPrecisionLoss(value string) {
+	logger.Warnf("%s is beyond ±2^53 and loses precision when parsed as a JavaScript number", value)
+}
+
+// newLogger creates the logger of --log-level, masking secrets like all
+// output
+func newLogger() (*logging.Logger, error) {
+	level, err := logging.ParseLevel(logLevel)
+	if err != nil {
+		return nil, err
+	}
+	return logging.New(redactor.Writer(os.Stderr), level), nil
+}
+
+// newRedactor creates the redactor for the --redact-header names, which also
+// masks the values read by {{secret}}
+func newRedactor() *redact.Redactor {
+	r := redact.New(redactHeaders...)
+	r.Secrets = secret.Values
+	return r
+}
+
+// redactSecretVariables marks the values of the variables named like secrets
+// (see vars.IsSecretName) as secret, so they never appear in output
+func redactSecretVariables(variables map[string]interface{}) {
+	for name, value := range variables {
+		if vars.IsSecretName(name) {
+			redactor.AddSecret(fmt.Sprint(value))
+		}
+	}
+}
+
+// closeRenderer flushes the renderer, reporting its error only if the
+// command itself succeeded
+func closeRenderer(out render.Renderer, err *error) {
+	if cerr := out.Close(); cerr != nil && *err == nil {
+		*err = cerr
+	}
+}
+
+// writeOutput writes the response body of result to its output file (-o on
+// call, Output: in request files), replacing the file's content unless
+// --append is set. Failed calls have no body and write nothing.
+func writeOutput(result *render.Result) error {
+	if result.Output == "" || result.Error != "" {
+		return nil
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appendOutput {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(result.Output, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+	_, err = fmt.Fprintln(f, result.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+	return nil
+}
+
+// loadProtos loads the proto files selected with --proto-path and
+// --import-path. Only commands that need the definitions load them, so the
+// flag is checked here rather than marked required.
+func loadProtos() (*proto.Registry, error) {
+	if protoPath == "" {
+		return nil, errors.New(`required flag(s) "proto-path" not set`)
+	}
+	registry, err := proto.LoadProtos(protoPath, importPaths)
+	if err != nil {
+		return nil, withExitCode(exitProto, fmt.Errorf("failed to load protos: %w", err))
+	}
+	logger.Debugf("Loaded %d service(s) from %s", len(registry.ListServices()), protoPath)
+	return registry, nil
+}
+
+// setup applies the global flags before any command runs: it creates the
+// redactor and the logger, then loads the --profile. Subcommands must not
+// define their own PersistentPreRunE, which would replace this one.
+func setup(cmd *cobra.Command, args []string) error {
+	redactor = newRedactor()
+	l, err := newLogger()
+	if err != nil {
+		return err
+	}
+	logger = l
+	return loadProfile(cmd)
+}
+
+// Execute runs the root command, exiting with the code of the class of
+// failure (see exitCode)
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		logger.Errorf("%s", redactor.String(err.Error()))
+		os.Exit(exitCode(err))
 	}
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVarP(&protoPath, "proto-path", "p", "", "path to folder containing .proto files (required)")
+	rootCmd.PersistentFlags().StringVarP(&protoPath, "proto-path", "p", "", "path to folder containing .proto files (required by commands that load protos)")
 	rootCmd.PersistentFlags().StringArrayVarP(&importPaths, "import-path", "I", nil, "additional import paths for proto dependencies")
-	_ = rootCmd.MarkPersistentFlagRequired("proto-path")
+	rootCmd.PersistentFlags().StringVar(&renderFormat, "render", "text", "output renderer: "+strings.Join(render.Formats, ", "))
+	rootCmd.PersistentFlags().StringVar(&formatTemplate, "format-template", "", "Go template applied to each result (fields: .Name, .Service, .Method, .Status, .Duration, .Body; func: jsonpath)")
+	rootCmd.PersistentFlags().StringArrayVar(&redactHeaders, "redact-header", nil, "header whose values are masked in all output, in addition to "+strings.Join(redact.DefaultHeaders, ", ")+" (can be repeated)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "level of the diagnostics written to stderr: "+strings.Join(logging.Levels, ", "))
+	rootCmd.MarkFlagsMutuallyExclusive("render", "format-template")
+	rootCmd.PersistentPreRunE = setup
+	// Errors are printed by Execute, with secrets redacted
+	rootCmd.SilenceErrors = true
 }
@@ -1,23 +1,67 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 
-	"grpc_client/internal/assert"
-	"grpc_client/internal/client"
 	"grpc_client/internal/file"
-	"grpc_client/internal/proto"
-	"grpc_client/internal/template"
+	"grpc_client/internal/render"
+	"grpc_client/internal/runner"
+	"grpc_client/internal/vars"
+)
+
+var (
+	captureStore    string
+	updateGolden    bool
+	snapshots       bool
+	updateSnapshots bool
+	strict          bool
+	allowUnresolved bool
+	varFlags        []string
+	varFiles        []string
+	printVars       bool
+	noInput         bool
+	excludes        []string
+	jobs            int
+	continueOnError bool
+	failFast        bool
+	watch           bool
+	watchProtos     bool
+	filter          string
+	onlyNames       []string
+	repeat          int
+	repeatUntil     bool
+	skipNames       []string
+	normalizeFlags  []string
+
+	// requestFilter is the compiled --filter, if any
+	requestFilter *regexp.Regexp
+
+	// normalizations are the parsed --normalize rules
+	normalizations []file.Normalization
 )
 
 var runCmd = &cobra.Command{
-	Use:   "run <file>",
-	Short: "Execute a gRPC request from a .grpc file",
-	Long: `Execute a gRPC request defined in a .grpc file.
+	Use:   "run <path>...",
+	Short: "Execute the gRPC requests of .grpc files",
+	Long: `Execute the gRPC requests defined in .grpc files.
+
+Directories are searched recursively for .grpc files, and paths that do
+not exist are expanded as glob patterns ("**" matching any number of
+directories). Each file runs in turn as a suite with its own variables,
+and the results of all files are reported together. With --jobs, files
+run concurrently.
 
 The file format is inspired by Hurl and contains all request details:
 - Server address
@@ -37,154 +81,526 @@ Example file (get_user.grpc):
 
 Usage:
   grpc_client run -p ./protos ./get_user.grpc
+
+  # Run every .grpc file under tests/
+  grpc_client run -p ./protos ./tests
+
+  # Run the files a pattern selects, except the slow ones
+  grpc_client run -p ./protos "tests/users/*.grpc" --exclude "*_slow.grpc"
+
+  # Run only the requests named like "user", e.g. "# Create user"
+  grpc_client run -p ./protos --filter user ./tests
+
+  # Run two requests by name, or all but one
+  grpc_client run -p ./protos --only "Create user,Get user" ./users.grpc
+  grpc_client run -p ./protos --skip "Delete user" ./users.grpc
+
+  # Run the files up to 50 times, stopping at the first failure
+  grpc_client run -p ./protos --repeat 50 --repeat-until-failure ./tests
+
+  # Run every request even when some fail, then report all failures
+  grpc_client run -p ./protos --continue-on-error ./tests
+
+  # Run 8 files at a time
+  grpc_client run -p ./protos -j 8 ./tests
+
+  # Set variables used as {{token}} and {{user_id}} in the file
+  grpc_client run -p ./protos --var token=abc --var user_id=42 ./get_user.grpc
+
+  # Load variables from a file; nested values are used as {{auth.token}}
+  grpc_client run -p ./protos --var-file vars.yaml ./get_user.grpc
+
+  # Send the requests to the staging profile of grpc-client.yaml
+  grpc_client run -p ./protos --profile staging ./get_user.grpc
+
+  # Reuse captures (e.g. a login token) across invocations
+  grpc_client run -p ./protos --capture-store vars.json ./login.grpc
+  grpc_client run -p ./protos --capture-store vars.json ./get_user.grpc
+
+  # Log in once, then reuse its cookies and captured token in later commands
+  grpc_client run -p ./protos --session dev ./login.grpc
+  grpc_client run -p ./protos --session dev ./get_user.grpc
+
+  # Regenerate the golden files of body == file "..." assertions
+  grpc_client run -p ./protos --update-golden ./get_user.grpc
+
+  # Snapshot every response on the first run, compare on later runs, and
+  # accept intended changes
+  grpc_client run -p ./protos --snapshots ./tests
+  grpc_client run -p ./protos --snapshots --update-snapshots ./tests
+
+  # Compare bodies and snapshots without their creation times and trace IDs
+  grpc_client run -p ./protos --snapshots --normalize '$..create_time=timestamp' --normalize '$..trace_id=any' ./tests
+
+  # Save a run, then compare the responses of a later run with it
+  grpc_client run -p ./protos --output json ./tests > previous-run.json
+  grpc_client run -p ./protos --diff-against previous-run.json ./tests
+
+  # Report every syntax problem instead of skipping malformed lines
+  grpc_client run -p ./protos --strict ./get_user.grpc
+
+  # Show the effective variables and where each value comes from
+  grpc_client run --profile staging --var user_id=7 --print-vars ./get_user.grpc
+
+  # Variables that are not defined are asked for on a terminal; disable in CI
+  grpc_client run -p ./protos --no-input ./get_user.grpc
+
+  # Print only the failures and the summary, e.g. in CI logs
+  grpc_client run -p ./protos --quiet ./get_user.grpc
+
+  # Write a JUnit XML report for CI and an HTML report to share
+  grpc_client run -p ./protos --report junit=report.xml --report html=report.html ./get_user.grpc
+
+  # Export metrics of a scheduled run for trend dashboards
+  grpc_client run -p ./protos --metrics-out metrics.json --metrics-push http://pushgateway:9091 ./get_user.grpc
+
+  # Re-run the files that change while editing them, until Ctrl-C
+  grpc_client run -p ./protos --watch --watch-protos ./tests
+
+  # Print the requests that would be sent, without any network activity
+  grpc_client run -p ./protos --dry-run ./get_user.grpc
 `,
-	Args: cobra.ExactArgs(1),
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		filePath := args[0]
-
-		// Parse the request file (may contain multiple requests)
-		requests, err := file.ParseMultiple(filePath)
+		if cmd.Flags().Changed("repeat") && repeat < 1 {
+			return fmt.Errorf("invalid --repeat %d, must be at least 1", repeat)
+		}
+		if repeatUntil && !cmd.Flags().Changed("repeat") {
+			repeat = 0 // Until a failure, however long it takes
+		}
+		if watchProtos && !watch {
+			return errors.New("--watch-protos requires --watch")
+		}
+		if filter != "" {
+			var err error
+			if requestFilter, err = regexp.Compile(filter); err != nil {
+				return fmt.Errorf("invalid --filter: %w", err)
+			}
+		}
+		if err := parseNormalizeFlags(); err != nil {
+			return err
+		}
+		if diffAgainst != "" && messageFormat == "text" {
+			return errors.New("--diff-against compares JSON responses and cannot be combined with --output-format text")
+		}
+		if err := openPrevious(); err != nil {
+			return err
+		}
+		paths, err := grpcFiles(args, excludes)
 		if err != nil {
-			return fmt.Errorf("failed to parse request file: %w", err)
+			return err
+		}
+		if len(paths) == 0 {
+			return fmt.Errorf("no .grpc files found in %s", strings.Join(args, ", "))
 		}
+		if watch {
+			return watchFiles(args, paths)
+		}
+		return runFiles(paths)
+	},
+}
 
-		// Load proto definitions
-		registry, err := proto.LoadProtos(protoPath, importPaths)
+// parseNormalizeFlags parses the --normalize rules into normalizations
+func parseNormalizeFlags() error {
+	normalizations = nil
+	for _, flag := range normalizeFlags {
+		// Cut at the last =, as filters may contain ==
+		eq := strings.LastIndex(flag, "=")
+		if eq == -1 {
+			return fmt.Errorf("invalid --normalize %q, expected jsonpath=kind", flag)
+		}
+		n, err := file.ParseNormalization(flag[:eq], flag[eq+1:])
 		if err != nil {
-			return fmt.Errorf("failed to load protos: %w", err)
+			return fmt.Errorf("invalid --normalize %q: %w", flag, err)
 		}
+		normalizations = append(normalizations, n)
+	}
+	return nil
+}
 
-		// Variable store for captures
-		variables := make(map[string]interface{})
+// addNormalizeFlag registers --normalize, parsed by parseNormalizeFlags
+func addNormalizeFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&normalizeFlags, "normalize", nil, "normalize the values a jsonpath selects before responses are compared, as jsonpath=kind: "+strings.Join(file.NormalizeKinds, ", ")+" (can be repeated)")
+}
 
-		// Execute each request
-		for i, reqFile := range requests {
-			// Print separator between requests
-			if i > 0 {
-				fmt.Println("\n---")
-			}
+// runFiles runs the request files at paths, reporting their results together
+func runFiles(paths []string) (err error) {
+	out, err := newRenderer()
+	if err != nil {
+		return err
+	}
+	defer closeRenderer(out, &err)
 
-			// Substitute variables in Address, Headers, and Body
-			reqFile.Address = template.Substitute(reqFile.Address, variables)
-			reqFile.Body = template.Substitute(reqFile.Body, variables)
-			for k, v := range reqFile.Headers {
-				reqFile.Headers[k] = template.Substitute(v, variables)
-			}
+	report, err := openReports()
+	if err != nil {
+		return err
+	}
+	defer closeRenderer(report, &err)
+	results := render.Multi(out, report)
 
-			// Print request header
-			if reqFile.Name != "" {
-				fmt.Printf("# %s\n", reqFile.Name)
-			} else {
-				fmt.Printf("# Request %d\n", i+1)
+	if err := openSession(); err != nil {
+		return err
+	}
+	defer saveSession(&err)
+	if err := openOAuth(context.Background()); err != nil {
+		return err
+	}
+
+	if err := openTracer(); err != nil {
+		return err
+	}
+	defer flushTracer()
+	openHAR()
+	defer writeHAR(&err)
+	if err := openRetry(); err != nil {
+		return err
+	}
+
+	// Parse every file before sending anything (a file may contain
+	// multiple requests)
+	var suites []*suite
+	for _, path := range paths {
+		requests, err := parseSuite(out, path)
+		if err != nil {
+			return err
+		}
+		graph, err := runner.NewGraph(requests)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		suites = append(suites, &suite{path: path, requests: requests, graph: graph})
+	}
+	if err := checkRequestNames(suites); err != nil {
+		return err
+	}
+	if requestFilter != nil || len(onlyNames) > 0 {
+		suites = slices.DeleteFunc(suites, func(s *suite) bool { return !s.selectRequests(selectedByFlags) })
+		if len(suites) == 0 {
+			var flags []string
+			if requestFilter != nil {
+				flags = append(flags, fmt.Sprintf("--filter %q", filter))
+			}
+			if len(onlyNames) > 0 {
+				flags = append(flags, fmt.Sprintf("--only %q", strings.Join(onlyNames, ",")))
 			}
-			fmt.Printf("# %s/%s\n\n", reqFile.Service, reqFile.Method)
-
-			// Find the method descriptor
-			methodDesc, err := registry.FindMethod(reqFile.Service, reqFile.Method)
-			if err != nil {
-				// Provide helpful error with available services
-				services := registry.ListServices()
-				var available []string
-				for _, s := range services {
-					available = append(available, s.FullName)
+			return fmt.Errorf("no request matches %s", strings.Join(flags, " and "))
+		}
+	}
+	for _, s := range suites {
+		s.skipRequests(skipNames)
+	}
+
+	for _, s := range suites {
+		for _, req := range s.requests {
+			if profile != nil {
+				if err := profile.Apply(req); err != nil {
+					return fmt.Errorf("profile %s: %w", profileName, err)
 				}
-				return fmt.Errorf("%w\n\nAvailable services: %s", err, strings.Join(available, ", "))
 			}
+			applyRequestBearer(req)
+			applyRequestAuthority(req)
+		}
+	}
 
-			// Parse protocol
-			proto, err := client.ParseProtocol(reqFile.Protocol)
-			if err != nil {
+	scope, err := globalScope()
+	if err != nil {
+		return err
+	}
+	if printVars {
+		for _, s := range suites {
+			if len(suites) > 1 {
+				fmt.Printf("# %s\n", s.path)
+			}
+			if err := printVariables(scope.Explain(fileVariables(s.requests)), s.requests); err != nil {
 				return err
 			}
+		}
+		return nil
+	}
 
-			// Extract prefix from address if present
-			address, prefix := parseAddressAndPrefix(reqFile.Address)
-
-			// Create the client
-			c := client.NewClient(address, prefix, proto, reqFile.Headers)
+	// Load proto definitions
+	registry, err := loadProtos()
+	if err != nil {
+		return err
+	}
 
-			// Convert JSON input to proto message
-			inputMsg, err := client.JSONToProto(reqFile.Body, methodDesc.Input())
-			if err != nil {
-				return fmt.Errorf("failed to parse JSON input: %w", err)
+	// Each file runs with its own variables: those of the run, its
+	// [Variables] sections, and its captures
+	for _, s := range suites {
+		s.variables = scope.Resolve(fileVariables(s.requests))
+	}
+	var prompted []string // Secrets typed by the user are never stored
+	if captureStore != "" {
+		defer func() {
+			stored := map[string]interface{}{}
+			for _, s := range suites {
+				maps.Copy(stored, s.variables)
 			}
-
-			// Make the call
-			ctx, cancel := context.WithTimeout(context.Background(), reqFile.Timeout)
-			response, err := c.Call(ctx, methodDesc, inputMsg)
-			cancel()
-
-			if err != nil {
-				return fmt.Errorf("RPC call failed: %w", err)
+			for _, name := range prompted {
+				delete(stored, name)
 			}
-
-			// Convert response to JSON
-			jsonOutput, err := client.ProtoToJSON(response)
-			if err != nil {
-				return fmt.Errorf("failed to format response: %w", err)
+			if serr := vars.SaveStore(captureStore, stored); serr != nil && err == nil {
+				err = serr
 			}
+		}()
+	}
 
-			fmt.Println(jsonOutput)
-
-			// Handle Captures
-			if len(reqFile.Captures) > 0 {
-				fmt.Println("\n# Captures:")
-				for varName, path := range reqFile.Captures {
-					val, err := client.EvaluateJSONPath(jsonOutput, path)
-					if err != nil {
-						fmt.Printf("# Warning: failed to capture variable '%s' from path '%s': %v\n", varName, path, err)
-						continue
-					}
-					variables[varName] = val
-					fmt.Printf("# %s = %v\n", varName, val)
+	// Fail on placeholders that cannot be resolved before sending anything,
+	// asking for the missing variables first when a user is at the terminal
+	if !allowUnresolved {
+		missing, err := checkPlaceholders(suites)
+		if len(missing) > 0 && canPrompt() {
+			answers, perr := promptVariables(missing)
+			if perr != nil {
+				return perr
+			}
+			for name, value := range answers {
+				for _, s := range suites {
+					s.variables[name] = value
+				}
+				if vars.IsSecretName(name) {
+					prompted = append(prompted, name)
 				}
 			}
+			_, err = checkPlaceholders(suites)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, s := range suites {
+		redactSecretVariables(s.variables)
+	}
 
-			// Handle Asserts
-			if len(reqFile.Asserts) > 0 {
-				fmt.Println("\n# Asserts:")
-				allPassed := true
-				for _, a := range reqFile.Asserts {
-					result, err := assert.Check(a, jsonOutput)
-					if err != nil {
-						// Error executing check (e.g. invalid jsonpath)
-						fmt.Printf("# ERROR: %v\n", err)
-						allPassed = false
-						continue
-					}
-
-					fmt.Printf("# %s\n", result.Message)
-					if !result.Pass {
-						allPassed = false
-					}
+	// Execute each request
+	r := runner.New(registry)
+	r.UpdateGolden = updateGolden
+	r.Snapshots = snapshots
+	r.UpdateSnapshots = updateSnapshots
+	r.Normalize = normalizations
+	r.TLS = tlsFlags()
+	r.ShowCertificates = showCerts
+	r.TextFormat = messageFormat == "text"
+	r.JSON = jsonOptions
+	r.ClientOptions = clientOptions()
+	r.OnSecret = func(value string) { redactor.AddSecret(value) }
+	if activeSession != nil {
+		r.Jar = activeSession.Jar
+	}
+	// Captured values are unknown in a dry run, so they stay unresolved
+	r.Strict = !allowUnresolved && !dryRun
+	if dryRun {
+		for _, s := range suites {
+			for _, i := range s.sent() {
+				req, err := r.DryRun(context.Background(), i+1, s.requests[i], s.variables)
+				if err != nil {
+					return err
 				}
+				if err := out.Request(req); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
 
-				if !allPassed {
-					return fmt.Errorf("one or more assertions failed")
+	if jobs < 1 {
+		return fmt.Errorf("invalid --jobs %d, must be at least 1", jobs)
+	}
+	// With --repeat, every iteration starts from the variables of the
+	// run, and the results of all iterations are reported together
+	initial := make([]map[string]interface{}, len(suites))
+	for i, s := range suites {
+		initial[i] = maps.Clone(s.variables)
+	}
+	var runErr error
+	for iteration := 1; repeat == 0 || iteration <= repeat; iteration++ {
+		n := 0 // Iterations are numbered only when repeated
+		if repeat != 1 {
+			n = iteration
+			logger.Infof("Iteration %d%s", iteration, repeatTotal())
+			for i, s := range suites {
+				s.variables = maps.Clone(initial[i])
+			}
+		}
+		if err := runSuites(r, suites, n, results, report); err != nil {
+			return err
+		}
+		failed := false
+		for _, s := range suites {
+			if s.err != nil && !errors.Is(s.err, context.Canceled) {
+				failed = true
+				if runErr == nil {
+					runErr = s.err
 				}
 			}
 		}
+		if failed && (repeatUntil || !continueOnError) {
+			break
+		}
+	}
+	return runErr
+}
 
-		return nil
-	},
+// repeatTotal describes the number of iterations of --repeat, if limited
+func repeatTotal() string {
+	if repeat == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" of %d", repeat)
+}
+
+// selectedByFlags reports whether a request is selected by --filter and
+// --only
+func selectedByFlags(r *file.RequestFile) bool {
+	return (requestFilter == nil || requestFilter.MatchString(r.Name)) && (len(onlyNames) == 0 || slices.Contains(onlyNames, r.Name))
 }
 
-// parseAddressAndPrefix splits a URL into base address and path prefix
-// e.g., "http://localhost:8080/api/grpc" -> ("http://localhost:8080", "/api/grpc")
-func parseAddressAndPrefix(address string) (string, string) {
-	// Find the third slash (after http://)
-	count := 0
-	for i, c := range address {
-		if c == '/' {
-			count++
-			if count == 3 {
-				return address[:i], address[i:]
+// checkRequestNames fails on names of --only and --skip that no request of
+// the run has, which are most likely typos
+func checkRequestNames(suites []*suite) error {
+	for _, flag := range []struct {
+		name  string
+		names []string
+	}{{"--only", onlyNames}, {"--skip", skipNames}} {
+		for _, name := range flag.names {
+			named := slices.ContainsFunc(suites, func(s *suite) bool {
+				return slices.ContainsFunc(s.requests, func(r *file.RequestFile) bool { return r.Name == name })
+			})
+			if !named {
+				return fmt.Errorf("%s: no request is named %q", flag.name, name)
 			}
 		}
 	}
-	return address, ""
+	return nil
+}
+
+// globalScope collects the variables that apply to every file of a run, in
+// increasing precedence: the profile's, those stored by a previous run, then
+// --var-file and --var
+func globalScope() (*vars.Scope, error) {
+	scope := &vars.Scope{}
+	if profile != nil {
+		scope.Add("profile "+profileName, profile.Vars())
+	}
+	if captureStore != "" {
+		stored, err := vars.LoadStore(captureStore)
+		if err != nil {
+			return nil, err
+		}
+		scope.Add("capture store "+captureStore, stored)
+	}
+	for _, path := range varFiles {
+		fileVars, err := vars.LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		scope.Add("var file "+path, fileVars)
+	}
+	cliVars, err := vars.ParseAssignments(varFlags)
+	if err != nil {
+		return nil, err
+	}
+	scope.Add("--var", cliVars)
+	return scope, nil
+}
+
+// canPrompt reports whether missing variables can be asked for: stdin and
+// stderr are terminals and --no-input is not set
+func canPrompt() bool {
+	return !noInput && term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// promptVariables asks for the values of names on the terminal, without
+// echoing those named like secrets
+func promptVariables(names []string) (map[string]interface{}, error) {
+	fmt.Fprintln(os.Stderr, "Enter values for the undefined variables:")
+	p := &vars.Prompter{
+		In:  bufio.NewReader(os.Stdin),
+		Out: os.Stderr,
+		ReadSecret: func() (string, error) {
+			b, err := term.ReadPassword(int(os.Stdin.Fd()))
+			return string(b), err
+		},
+	}
+	return p.Ask(names)
+}
+
+// fileVariables returns the file-scoped variables defined by the [Variables]
+// sections of requests, which apply to every request of the file
+func fileVariables(requests []*file.RequestFile) vars.Layer {
+	layer := vars.Layer{Origin: "[Variables]", Vars: make(map[string]interface{})}
+	for _, req := range requests {
+		for name, value := range req.Vars {
+			layer.Vars[name] = value
+		}
+	}
+	return layer
+}
+
+// printVariables prints the effective variables of a file, then the
+// variables its requests capture, which override them once captured
+func printVariables(effective []vars.Variable, requests []*file.RequestFile) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVALUE\tORIGIN")
+	for _, v := range effective {
+		fmt.Fprintf(w, "%s\t%v\t%s\n", v.Name, v.Value, v.Origin)
+	}
+	for i, req := range requests {
+		for _, name := range slices.Sorted(maps.Keys(req.Captures)) {
+			fmt.Fprintf(w, "%s\t(captured at run time)\t[Captures] of request %d\n", name, i+1)
+		}
+	}
+	return w.Flush()
 }
 
 func init() {
 	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().StringArrayVar(&excludes, "exclude", nil, "skip the files matching this glob pattern: against each path element without a /, e.g. *_slow.grpc or legacy, otherwise against the path, e.g. tests/**/wip/* (can be repeated)")
+	runCmd.Flags().IntVarP(&jobs, "jobs", "j", 1, "number of files run concurrently, each with its own variables (results are still written in file order)")
+	runCmd.Flags().BoolVar(&continueOnError, "continue-on-error", false, "run every request even after a failed call or assertion, reporting all failures at the end (the command still fails)")
+	runCmd.Flags().BoolVar(&failFast, "fail-fast", false, "stop the run at the first failed call or assertion (the default)")
+	runCmd.MarkFlagsMutuallyExclusive("continue-on-error", "fail-fast")
+	runCmd.Flags().StringVar(&filter, "filter", "", "run only the requests whose name (the # comment) matches this regular expression, and the requests they need")
+	runCmd.Flags().StringSliceVar(&onlyNames, "only", nil, "run only the requests with these names, and the requests they need (comma-separated, can be repeated)")
+	runCmd.Flags().StringSliceVar(&skipNames, "skip", nil, "report the requests with these names as skipped instead of running them, like Skip: true (comma-separated, can be repeated)")
+	runCmd.Flags().IntVar(&repeat, "repeat", 1, "run the files this many times, e.g. to flush out flaky behavior, reporting the results of every iteration together")
+	runCmd.Flags().BoolVar(&repeatUntil, "repeat-until-failure", false, "repeat the run until an iteration fails, at most --repeat times if set")
+	runCmd.Flags().BoolVar(&watch, "watch", false, "keep running: re-run the files that change, and new files, until interrupted")
+	runCmd.Flags().BoolVar(&watchProtos, "watch-protos", false, "with --watch, also watch the .proto files, reloading them and re-running every file when one changes")
+	runCmd.Flags().StringArrayVar(&varFlags, "var", nil, "set a variable, overriding [Variables] sections (format: 'name=value', can be repeated)")
+	runCmd.Flags().StringArrayVar(&varFiles, "var-file", nil, "load variables from a JSON or YAML file, nested values addressed as {{auth.token}} (can be repeated, later files win)")
+	runCmd.Flags().StringVar(&bearer, "bearer", "", "bearer token sent in the Authorization header of every request (default: $"+bearerEnv+" for requests without one)")
+	runCmd.Flags().StringVar(&authority, "authority", "", "HTTP authority (Host header) of every request, instead of its address host")
+	addTLSFlags(runCmd)
+	runCmd.Flags().StringVar(&captureStore, "capture-store", "", "JSON file to load variables from and save captures to, shared across runs")
+	addSessionFlag(runCmd)
+	addOAuthFlags(runCmd)
+	addOTelFlag(runCmd)
+	addRetryFlags(runCmd)
+	addHARFlag(runCmd)
+	addProgressFlag(runCmd)
+	runCmd.MarkFlagsMutuallyExclusive("session", "capture-store")
+	runCmd.Flags().BoolVar(&showCerts, "show-certs", false, "print the server certificate chain with each response")
+	addOutputFlag(runCmd)
+	runCmd.Flags().BoolVar(&appendOutput, "append", false, "append the responses of requests with an Output: file to it instead of replacing its content")
+	runCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "print only the failed requests and the summary of the run, without banners or response bodies")
+	runCmd.MarkFlagsMutuallyExclusive("quiet", "include")
+	runCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "print the HTTP exchange of each request to stderr: URL, request and response headers, trailers, gRPC status, and message sizes")
+	runCmd.Flags().StringArrayVar(&reportSpecs, "report", nil, "also write a report of the run to a file, as kind=path: "+strings.Join(render.Reports, ", ")+" (can be repeated)")
+	runCmd.Flags().StringVar(&metricsOut, "metrics-out", "", "write counters and histograms of the run (request durations, statuses, and assertion outcomes) to this JSON file")
+	runCmd.Flags().StringVar(&metricsPush, "metrics-push", "", "push the metrics of the run to this Prometheus Pushgateway, e.g. http://localhost:9091 (job grpc_client unless the URL names a group)")
+	runCmd.Flags().BoolVar(&trace, "trace", false, "dump the wire framing of each request to stderr: each length-prefixed frame and the trailers frame, in hex and decoded")
+	runCmd.Flags().BoolVar(&printVars, "print-vars", false, "print the effective variables with the origin of each value, then exit without running")
+	runCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the URL, headers, and encoded payload of each request instead of sending it (captured variables stay unresolved)")
+	runCmd.Flags().BoolVar(&strict, "strict", false, "fail on every malformed line, reporting each with its line and column, instead of skipping it")
+	runCmd.Flags().BoolVar(&noInput, "no-input", false, "never prompt for undefined variables, even on a terminal (for CI)")
+	runCmd.Flags().BoolVar(&allowUnresolved, "allow-unresolved", false, "send placeholders that cannot be resolved literally instead of failing")
+	runCmd.Flags().BoolVar(&updateGolden, "update-golden", false, "write the responses to the golden files of body == file assertions instead of comparing")
+	runCmd.Flags().BoolVar(&snapshots, "snapshots", false, "compare every response with its snapshot in __snapshots__ next to its file, not only those of requests with Snapshot: true (missing snapshots are written)")
+	runCmd.Flags().BoolVar(&updateSnapshots, "update-snapshots", false, "rewrite the snapshots that differ from the responses, accepting the changes")
+	addNormalizeFlag(runCmd)
+	runCmd.Flags().StringVar(&diffAgainst, "diff-against", "", "compare the status and body of each response with those of the same request in a run saved with --output json, failing on differences")
 }
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"grpc_client/internal/session"
+)
+
+var (
+	sessionName string
+
+	// activeSession is the session selected with --session, or nil
+	activeSession *session.Session
+)
+
+// openSession opens the session selected with --session, if any. Its
+// variable store takes the place of --capture-store.
+func openSession() error {
+	if sessionName == "" {
+		return nil
+	}
+	s, err := session.Open(sessionName)
+	if err != nil {
+		return err
+	}
+	activeSession = s
+	captureStore = s.VarsPath()
+	return nil
+}
+
+// saveSession saves the cookies and OAuth tokens of the session, reporting its error only if
+// the command itself succeeded
+func saveSession(err *error) {
+	if activeSession == nil {
+		return
+	}
+	if serr := activeSession.Save(); serr != nil && *err == nil {
+		*err = serr
+	}
+}
+
+// addSessionFlag registers --session on cmd
+func addSessionFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&sessionName, "session", "", "keep cookies, captured variables, and OAuth tokens in a named session shared across invocations (stored under $XDG_DATA_HOME/grpc_client/sessions)")
+}
@@ -0,0 +1,378 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"grpc_client/internal/client"
+	"grpc_client/internal/file"
+	"grpc_client/internal/render"
+	"grpc_client/internal/runner"
+)
+
+// suite is a request file of a run
+type suite struct {
+	path      string
+	requests  []*file.RequestFile
+	graph     *runner.Graph          // Order of the requests when they declare Needs, or nil
+	selected  []bool                 // Requests selected by --filter and --only and those they need, or nil for all
+	skipped   []string               // Why each request is skipped, if it is
+	variables map[string]interface{} // Variables of the file, resolved
+
+	// Set by runSuite: the results of the requests, closed once the
+	// suite has ended with failed and err set
+	results chan *render.Result
+	failed  []*render.Result // Requests whose call failed, only for reports as the run stops
+	err     error            // The first failure of the suite, if any
+
+	mu sync.Mutex // Guards variables, failed, and err while requests run concurrently
+}
+
+// runSuites runs suites once, as iteration of --repeat (0 without it),
+// writing their
+// results to results and the calls that failed to report. Files run on up
+// to --jobs workers. Their results are written in the order of the files,
+// each file's as they come, so that the output of concurrent files never
+// interleaves. The failures of the files are left in their err.
+func runSuites(r *runner.Runner, suites []*suite, iteration int, results, report render.Renderer) error {
+	workers := min(jobs, len(suites))
+	total := 0
+	for _, s := range suites {
+		total += len(s.sent())
+		s.results = make(chan *render.Result, len(s.requests))
+		s.failed, s.err = nil, nil
+	}
+	var progress *render.Progress
+	if jobs == 1 { // A single line cannot show concurrent requests
+		progress = startProgress(total)
+	}
+	defer progress.Stop()
+
+	// A failure stops the run, unless --continue-on-error: requests not
+	// yet started are skipped
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+	queue := make(chan *suite, len(suites))
+	for _, s := range suites {
+		queue <- s
+	}
+	close(queue)
+	var started atomic.Int64
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range queue {
+				runSuite(ctx, r, s, progress, &started)
+				if s.err != nil && !continueOnError {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	for _, s := range suites {
+		for result := range s.results {
+			result.Iteration = iteration
+			if err := writeOutput(result); err != nil {
+				return err
+			}
+			if err := results.Result(result); err != nil {
+				return err
+			}
+		}
+		for _, failed := range s.failed {
+			failed.Iteration = iteration
+			// Reports record the failed call before the run stops
+			if err := report.Result(failed); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// runSuite executes the requests of s in order, or as its graph allows,
+// until one fails (unless --continue-on-error) or ctx is canceled. started
+// counts the requests started by the run.
+func runSuite(ctx context.Context, r *runner.Runner, s *suite, progress *render.Progress, started *atomic.Int64) {
+	defer close(s.results)
+	logger.Debugf("Running %s", s.path)
+	if s.graph != nil {
+		s.runGraph(ctx, r, progress, started)
+		return
+	}
+	for _, i := range s.order() {
+		if ctx.Err() != nil {
+			s.fail(ctx.Err())
+			return
+		}
+		if reason := s.skipped[i]; reason != "" {
+			s.skip(i, reason)
+			continue
+		}
+		if !s.runRequest(ctx, r, i, progress, started) && (!continueOnError || ctx.Err() != nil) {
+			return
+		}
+	}
+}
+
+// runGraph executes the requests of s once the requests they need have
+// passed, up to --jobs of the file at a time. Requests that need a failed
+// or skipped request are skipped, unless the suite stopped.
+func (s *suite) runGraph(ctx context.Context, r *runner.Runner, progress *render.Progress, started *atomic.Int64) {
+	const (
+		waiting = iota
+		running
+		passed
+		failed
+		skipped
+	)
+	type outcome struct {
+		i      int
+		passed bool
+	}
+	state := make([]int, len(s.requests))
+	order := s.order()
+	done := make(chan outcome)
+	active, stop := 0, false
+	for {
+		// In topological order, so that skips cascade in one pass
+		for _, i := range order {
+			if state[i] != waiting || stop {
+				continue
+			}
+			if reason := s.skipped[i]; reason != "" {
+				state[i] = skipped
+				s.skip(i, reason)
+				continue
+			}
+			needs := s.graph.Needs[i]
+			if n := slices.IndexFunc(needs, func(n int) bool { return state[n] >= failed }); n >= 0 {
+				state[i] = skipped
+				s.skip(i, s.needsReason(needs[n], state[needs[n]] == skipped))
+				continue
+			}
+			if active == max(jobs, 1) || slices.ContainsFunc(needs, func(n int) bool { return state[n] != passed }) {
+				continue
+			}
+			state[i] = running
+			active++
+			go func() {
+				done <- outcome{i, s.runRequest(ctx, r, i, progress, started)}
+			}()
+		}
+		if active == 0 {
+			return
+		}
+		o := <-done
+		active--
+		state[o.i] = failed
+		if o.passed {
+			state[o.i] = passed
+		} else if !continueOnError || ctx.Err() != nil {
+			stop = true
+		}
+	}
+}
+
+// runRequest executes request i of s and sends its result, reporting
+// whether it passed. The request runs with a copy of the variables of s,
+// into which its captures are then merged.
+func (s *suite) runRequest(ctx context.Context, r *runner.Runner, i int, progress *render.Progress, started *atomic.Int64) bool {
+	parsed := s.requests[i]
+	s.mu.Lock()
+	variables := maps.Clone(s.variables)
+	s.mu.Unlock()
+
+	logger.Debugf("Running request %d: %s/%s", i+1, parsed.Service, parsed.Method)
+	progress.Begin(int(started.Add(1)), progressLabel(parsed.Name, parsed.Service, parsed.Method))
+	result, err := r.Execute(ctx, i+1, parsed, variables)
+	progress.End()
+	redactSecretVariables(variables) // Captured tokens
+	if err != nil {
+		if ctx.Err() != nil {
+			// Canceled by the failure of another file
+			s.fail(ctx.Err())
+			return false
+		}
+		failed := &render.Result{File: s.path, Index: i + 1, Name: parsed.Name, Service: parsed.Service, Method: parsed.Method, Status: "error", Error: err.Error()}
+		var rpcErr *client.Error
+		if errors.As(err, &rpcErr) {
+			failed.Status = rpcErr.Status()
+		}
+		s.fail(err)
+		if continueOnError {
+			// Reported with the other results
+			s.results <- failed
+		} else {
+			s.mu.Lock()
+			s.failed = append(s.failed, failed)
+			s.mu.Unlock()
+		}
+		return false
+	}
+
+	s.mu.Lock()
+	for _, c := range result.Captures {
+		if c.Error == "" {
+			s.variables[c.Name] = variables[c.Name]
+		}
+	}
+	s.mu.Unlock()
+	result.File = s.path
+	checkPrevious(result, parsed)
+	s.results <- result
+	if result.Outcome() == "fail" {
+		s.fail(errAssertionsFailed)
+		return false
+	}
+	return true
+}
+
+// order returns the indexes of the requests of s that run, in the order
+// they run: that of its graph, if any, otherwise file order
+func (s *suite) order() []int {
+	var order []int
+	if s.graph != nil {
+		order = slices.Clone(s.graph.Order)
+	} else {
+		for i := range s.requests {
+			order = append(order, i)
+		}
+	}
+	return slices.DeleteFunc(order, func(i int) bool { return s.selected != nil && !s.selected[i] })
+}
+
+// sent returns the indexes of the requests of s that run and are not
+// skipped, in the order they run
+func (s *suite) sent() []int {
+	return slices.DeleteFunc(s.order(), func(i int) bool { return s.skipped[i] != "" })
+}
+
+// selectRequests selects the requests of s that match, and the requests
+// they need, reporting whether any matched
+func (s *suite) selectRequests(match func(*file.RequestFile) bool) bool {
+	s.selected = make([]bool, len(s.requests))
+	matched := false
+	for i, r := range s.requests {
+		if match(r) {
+			s.selected[i], matched = true, true
+		}
+	}
+	if s.graph != nil {
+		// Backwards, the requests a request needs come after it
+		for _, i := range slices.Backward(s.graph.Order) {
+			if s.selected[i] {
+				for _, n := range s.graph.Needs[i] {
+					s.selected[n] = true
+				}
+			}
+		}
+	}
+	return matched
+}
+
+// skipRequests sets why requests of s are skipped: they are marked Skip,
+// their name is in names, or they need a skipped request
+func (s *suite) skipRequests(names []string) {
+	s.skipped = make([]string, len(s.requests))
+	for i, r := range s.requests {
+		switch {
+		case r.Skip && r.SkipReason != "":
+			s.skipped[i] = r.SkipReason
+		case r.Skip:
+			s.skipped[i] = "marked Skip: true"
+		case r.Name != "" && slices.Contains(names, r.Name):
+			s.skipped[i] = "named in --skip"
+		}
+	}
+	if s.graph == nil {
+		return
+	}
+	for _, i := range s.graph.Order {
+		needs := s.graph.Needs[i]
+		if n := slices.IndexFunc(needs, func(n int) bool { return s.skipped[n] != "" }); n >= 0 && s.skipped[i] == "" {
+			s.skipped[i] = s.needsReason(needs[n], true)
+		}
+	}
+}
+
+// skip reports request i of s as skipped for reason
+func (s *suite) skip(i int, reason string) {
+	parsed := s.requests[i]
+	s.results <- &render.Result{File: s.path, Index: i + 1, Name: parsed.Name, Service: parsed.Service, Method: parsed.Method, Skipped: reason}
+}
+
+// needsReason is why a request is skipped when request need failed, or
+// was skipped itself
+func (s *suite) needsReason(need int, skipped bool) string {
+	reason := fmt.Sprintf("needs request %d", need+1)
+	if name := s.requests[need].Name; name != "" {
+		reason = fmt.Sprintf("needs %q", name)
+	}
+	if skipped {
+		return reason + ", which was skipped"
+	}
+	return reason + ", which failed"
+}
+
+// fail records err as the failure of s, unless it already failed
+func (s *suite) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// parseSuite parses a request file, writing its syntax errors to out
+func parseSuite(out render.Renderer, path string) ([]*file.RequestFile, error) {
+	parse := file.ParseMultiple
+	if strict {
+		parse = file.ParseStrict
+	}
+	requests, err := parse(path)
+	var syntaxErr *file.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		if err := out.Diagnostics(syntaxErr.Diagnostics); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to parse request file: %d syntax error(s)", len(syntaxErr.Diagnostics))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request file: %w", err)
+	}
+	return requests, nil
+}
+
+// checkPlaceholders checks the placeholders of every file of a run against
+// its variables, returning the first error and the variables missing from
+// any file
+func checkPlaceholders(suites []*suite) (missing []string, err error) {
+	for _, s := range suites {
+		serr := runner.CheckPlaceholders(s.requests, s.sent(), s.variables)
+		var unresolved *runner.UnresolvedError
+		if errors.As(serr, &unresolved) {
+			for _, name := range unresolved.Missing {
+				if !slices.Contains(missing, name) {
+					missing = append(missing, name)
+				}
+			}
+		}
+		if serr != nil && err == nil {
+			err = serr
+			if len(suites) > 1 {
+				err = fmt.Errorf("%s: %w", s.path, serr)
+			}
+		}
+	}
+	return missing, err
+}
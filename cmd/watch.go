@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"grpc_client/internal/file"
+)
+
+// watchInterval is how often watched files are polled for changes
+const watchInterval = 500 * time.Millisecond
+
+// watchFiles runs the files at paths, then polls them for changes until
+// interrupted. Changed files, and new files that args select, are run
+// again; with --watch-protos, a changed .proto file re-runs every file.
+// The failures of a run are logged instead of ending the watch.
+func watchFiles(args, paths []string) error {
+	grpc := file.TakeSnapshot(paths)
+	protos := file.TakeSnapshot(protoFiles())
+	run := func(paths []string) {
+		if err := runFiles(paths); err != nil {
+			logger.Errorf("%s", redactor.String(err.Error()))
+		}
+		logger.Infof("Watching %d files for changes, press Ctrl-C to stop", len(grpc)+len(protos))
+	}
+	run(paths)
+
+	for {
+		time.Sleep(watchInterval)
+		current, err := grpcFiles(args, excludes)
+		if err != nil {
+			// E.g. a directory being renamed
+			logger.Warnf("%v", err)
+			continue
+		}
+		nextGRPC, nextProtos := settle(current, grpc, protos)
+		changed, protosChanged := grpc.Changed(nextGRPC), len(protos.Changed(nextProtos)) > 0
+		grpc, protos = nextGRPC, nextProtos
+		if protosChanged {
+			logger.Infof("Protos changed, running every file")
+			run(current)
+			continue
+		}
+		if len(changed) > 0 {
+			logger.Infof("Changed: %s", strings.Join(changed, ", "))
+			run(changed)
+		}
+	}
+}
+
+// settle snapshots the .grpc files at paths and the .proto files, again
+// until they stop changing if they differ from grpc and protos, as editors
+// may save a file in several writes
+func settle(paths []string, grpc, protos file.Snapshot) (file.Snapshot, file.Snapshot) {
+	nextGRPC, nextProtos := file.TakeSnapshot(paths), file.TakeSnapshot(protoFiles())
+	if grpc.Changed(nextGRPC) == nil && protos.Changed(nextProtos) == nil {
+		return nextGRPC, nextProtos
+	}
+	for {
+		time.Sleep(watchInterval)
+		settledGRPC, settledProtos := file.TakeSnapshot(paths), file.TakeSnapshot(protoFiles())
+		if nextGRPC.Changed(settledGRPC) == nil && nextProtos.Changed(settledProtos) == nil {
+			return settledGRPC, settledProtos
+		}
+		nextGRPC, nextProtos = settledGRPC, settledProtos
+	}
+}
+
+// protoFiles returns the .proto files under --proto-path and the import
+// paths when --watch-protos is set. Directories that cannot be read are
+// left out: loading the protos reports them.
+func protoFiles() []string {
+	if !watchProtos {
+		return nil
+	}
+	var files []string
+	for _, root := range slices.Concat([]string{protoPath}, importPaths) {
+		if root == "" {
+			continue
+		}
+		_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() && strings.HasPrefix(d.Name(), ".") && p != root {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() && filepath.Ext(p) == ".proto" {
+				files = append(files, p)
+			}
+			return nil
+		})
+	}
+	return files
+}
@@ -1,35 +1,555 @@
 package assert
 
 import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"grpc_client/internal/client"
 	"grpc_client/internal/file"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Result represents the outcome of an assertion
 type Result struct {
 	Pass    bool
 	Message string
+	Diff    string // Unified diff of the expected and actual documents, when they differ
 }
 
-// Check evaluates a single assertion against the JSON output
-func Check(assert file.Assertion, jsonOutput string) (Result, error) {
-	if assert.Type != "jsonpath" {
+// Response is the part of an RPC outcome that assertions are evaluated against
+type Response struct {
+	Body    string      // JSON response body (empty when the call failed)
+	Header  http.Header // Response headers
+	Trailer http.Header // Response trailers
+	Status  string      // gRPC status name (e.g. "ok", "not_found")
+	Size    int         // Encoded response size in bytes (0 when the call failed)
+
+	// Certificates is the server's certificate chain, leaf first (empty
+	// for plain HTTP)
+	Certificates []*x509.Certificate
+
+	// Normalize rewrites dynamic values of the body, and of the document
+	// it is compared with, before body == and != and snapshots compare
+	// them
+	Normalize []file.Normalization
+}
+
+// ExpectsStatus reports whether the assertions declare an expected gRPC
+// status, in which case a failed call is evaluated rather than treated as
+// a fatal error
+func ExpectsStatus(asserts []file.Assertion) bool {
+	for _, a := range asserts {
+		if a.Type == "status" {
+			return true
+		}
+	}
+	return false
+}
+
+// filterableTypes are the assertion types that accept a filter
+var filterableTypes = map[string]bool{
+	"jsonpath": true,
+	"header":   true,
+	"trailer":  true,
+}
+
+// Check evaluates a single assertion against the response
+func Check(assert file.Assertion, resp *Response) (Result, error) {
+	if assert.Filter != "" && !filterableTypes[assert.Type] {
+		return Result{
+			Pass:    false,
+			Message: fmt.Sprintf("filter '%s' is not supported for %s assertions", assert.Filter, assert.Type),
+		}, nil
+	}
+
+	// The expected value may be read from the response too
+	var ref string
+	if assert.JSONPath {
+		v, err := client.EvaluateJSONPath(resp.Body, assert.Value)
+		if err != nil {
+			return Result{
+				Pass:    false,
+				Message: fmt.Sprintf("failed to evaluate jsonpath '%s': %v", assert.Value, err),
+			}, nil
+		}
+		ref, assert.Value = assert.Value, v
+	}
+
+	var val string
+	switch assert.Type {
+	case "jsonpath":
+		if assert.Filter == "count" {
+			return checkCount(assert, resp.Body), nil
+		}
+		if jsonType, ok := typeOperators[assert.Operator]; ok {
+			return checkType(assert, resp.Body, jsonType), nil
+		}
+		if assert.Operator == "contains" {
+			// contains tests membership of the values a wildcard, filter, or
+			// recursive descent selects
+			matches, definite, err := client.EvaluateJSONPathMatches(resp.Body, assert.Key)
+			if err == nil && !definite {
+				return checkMember(assert, matches, ref), nil
+			}
+		}
+		v, err := client.EvaluateJSONPath(resp.Body, assert.Key)
+		if assert.Operator == "exists" {
+			// A missing key answers the assertion rather than failing it
+			if err != nil && !errors.Is(err, client.ErrPathNotFound) {
+				return Result{
+					Pass:    false,
+					Message: fmt.Sprintf("failed to evaluate jsonpath '%s': %v", assert.Key, err),
+				}, nil
+			}
+			return existsResult(assert, err == nil), nil
+		}
+		if err != nil {
+			return Result{
+				Pass:    false,
+				Message: fmt.Sprintf("failed to evaluate jsonpath '%s': %v", assert.Key, err),
+			}, nil
+		}
+		val = v
+	case "header", "trailer":
+		source := resp.Header
+		if assert.Type == "trailer" {
+			source = resp.Trailer
+		}
+		values := source.Values(assert.Key)
+		if assert.Filter == "count" {
+			return compareNumber(assert, len(values)), nil
+		}
+		if assert.Operator == "exists" {
+			return existsResult(assert, len(values) > 0), nil
+		}
+		if len(values) == 0 {
+			return Result{
+				Pass:    false,
+				Message: fmt.Sprintf("FAIL: %s \"%s\" %s \"%s\" (%s not found)", assert.Type, assert.Key, operator(assert), assert.Value, assert.Type),
+			}, nil
+		}
+		val = strings.Join(values, ", ")
+	case "status":
+		val = resp.Status
+		if assert.Operator != "in" {
+			assert.Value = client.NormalizeStatus(assert.Value)
+		}
+	case "bytes", "responsesize":
+		return compareNumber(assert, resp.Size), nil
+	case "count":
+		return checkCount(assert, resp.Body), nil
+	case "body":
+		return checkBody(assert, resp.Body, resp.Normalize), nil
+	case "certificate":
+		if len(resp.Certificates) == 0 {
+			return Result{
+				Pass:    false,
+				Message: fmt.Sprintf("FAIL: certificate \"%s\" %s \"%s\" (no server certificate, the connection does not use TLS)", assert.Key, operator(assert), assert.Value),
+			}, nil
+		}
+		v, err := CertificateField(resp.Certificates[0], assert.Key, time.Now())
+		if err != nil {
+			return Result{Pass: false, Message: err.Error()}, nil
+		}
+		val = v
+	default:
 		return Result{
 			Pass:    true,
 			Message: fmt.Sprintf("Warning: skipping unknown assertion type '%s'", assert.Type),
 		}, nil
 	}
 
-	val, err := client.EvaluateJSONPath(jsonOutput, assert.Key)
+	return compare(assert, val, ref), nil
+}
+
+// CertificateFields are the keys of certificate assertions
+var CertificateFields = []string{"subject", "issuer", "serial", "not_before", "not_after", "expire_days", "dns_names"}
+
+// CertificateField returns a field of a server certificate as compared by
+// certificate assertions, e.g. certificate "expire_days" > 30. Dates are
+// RFC 3339 in UTC; expire_days counts the whole days left at now.
+func CertificateField(cert *x509.Certificate, field string, now time.Time) (string, error) {
+	switch field {
+	case "subject":
+		return cert.Subject.String(), nil
+	case "issuer":
+		return cert.Issuer.String(), nil
+	case "serial":
+		return cert.SerialNumber.String(), nil
+	case "not_before":
+		return cert.NotBefore.UTC().Format(time.RFC3339), nil
+	case "not_after":
+		return cert.NotAfter.UTC().Format(time.RFC3339), nil
+	case "expire_days":
+		return strconv.Itoa(int(math.Floor(cert.NotAfter.Sub(now).Hours() / 24))), nil
+	case "dns_names":
+		return strings.Join(cert.DNSNames, ", "), nil
+	default:
+		return "", fmt.Errorf("unknown certificate field '%s', expected one of: %s", field, strings.Join(CertificateFields, ", "))
+	}
+}
+
+// checkBody compares the whole response body. == and != compare JSON
+// semantically (formatting and key order are ignored); other operators see
+// the body as returned. rules are applied to both documents first.
+func checkBody(assert file.Assertion, body string, rules []file.Normalization) Result {
+	expected := assert.Value
+	if assert.File {
+		data, err := os.ReadFile(assert.Value)
+		if errors.Is(err, os.ErrNotExist) {
+			return Result{
+				Pass:    false,
+				Message: fmt.Sprintf("golden file '%s' does not exist (run with --update-golden to create it)", assert.Value),
+			}
+		}
+		if err != nil {
+			return Result{
+				Pass:    false,
+				Message: fmt.Sprintf("failed to read golden file: %v", err),
+			}
+		}
+		expected = string(data)
+	}
+
+	if assert.Operator != "==" && assert.Operator != "!=" {
+		if assert.File {
+			return Result{
+				Pass:    false,
+				Message: fmt.Sprintf("operator '%s' is not supported with a golden file", assert.Operator),
+			}
+		}
+		return compare(assert, body, "")
+	}
+
+	want, err := normalizeJSON(expected, rules...)
 	if err != nil {
 		return Result{
 			Pass:    false,
-			Message: fmt.Sprintf("failed to evaluate jsonpath '%s': %v", assert.Key, err),
+			Message: fmt.Sprintf("invalid expected JSON %s: %v", bodyExpected(assert), err),
+		}
+	}
+	got, err := normalizeJSON(body, rules...)
+	if err != nil {
+		got = body
+	}
+
+	pass := ((got == want) == (assert.Operator == "==")) != assert.Negate
+	status := "FAIL"
+	if pass {
+		status = "PASS"
+	}
+
+	// Format: PASS: body == file "golden/get_user.json"
+	// Format: FAIL: body == file "golden/get_user.json" (line 3: expected "...", actual "...")
+	msg := fmt.Sprintf("%s: body %s %s", status, operator(assert), bodyExpected(assert))
+	var diff string
+	if !pass && got != want {
+		msg += " (" + firstDifference(want, got) + ")"
+		diff = Diff(want, got)
+	}
+	return Result{
+		Pass:    pass,
+		Message: msg,
+		Diff:    diff,
+	}
+}
+
+// bodyExpected formats the expected value of a body assertion
+func bodyExpected(assert file.Assertion) string {
+	if assert.File {
+		return fmt.Sprintf("file \"%s\"", assert.Value)
+	}
+	return fmt.Sprintf("\"%s\"", assert.Value)
+}
+
+// normalizeJSON reformats a JSON document with sorted keys and two-space
+// indentation, so documents compare equal regardless of formatting, after
+// applying rules to it
+func normalizeJSON(s string, rules ...file.Normalization) (string, error) {
+	v, err := decodeJSON(s, rules)
+	if err != nil {
+		return "", err
+	}
+	// Without HTML escaping, so that e.g. <uuid> reads as written
+	var out strings.Builder
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out.String(), "\n"), nil
+}
+
+// decodeJSON decodes a JSON document, keeping numbers as written, and
+// applies rules to it
+func decodeJSON(s string, rules []file.Normalization) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after the JSON document")
+	}
+	return applyNormalizations(v, rules)
+}
+
+// firstDifference describes the first line at which two documents differ
+func firstDifference(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := 0; i < max(len(wantLines), len(gotLines)); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d: expected %q, actual %q", i+1, strings.TrimSpace(w), strings.TrimSpace(g))
+		}
+	}
+	return "documents differ"
+}
+
+// UpdateGolden writes the response body to the golden file of a body
+// assertion (see file.Assertion.File) instead of comparing against it
+func UpdateGolden(assert file.Assertion, resp *Response) (Result, error) {
+	if resp.Body == "" {
+		return Result{}, fmt.Errorf("no response body to write to %s", assert.Value)
+	}
+	body, err := normalizeJSON(resp.Body)
+	if err != nil {
+		return Result{}, fmt.Errorf("response is not valid JSON: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(assert.Value), 0755); err != nil {
+		return Result{}, fmt.Errorf("failed to create golden file directory: %w", err)
+	}
+	if err := os.WriteFile(assert.Value, []byte(body+"\n"), 0644); err != nil {
+		return Result{}, fmt.Errorf("failed to write golden file: %w", err)
+	}
+	return Result{
+		Pass:    true,
+		Message: fmt.Sprintf("UPDATED: body %s %s", assert.Operator, bodyExpected(assert)),
+	}, nil
+}
+
+// CheckSnapshot compares the response body with the snapshot at path, as
+// JSON, after applying the response's normalizations to both. A missing
+// snapshot is written instead, and so is every snapshot with update, e.g.
+// once a change of the response is intended; snapshots are written as
+// returned, and kept when only normalized values changed.
+func CheckSnapshot(path string, resp *Response, update bool) (Result, error) {
+	body, err := normalizeJSON(resp.Body)
+	if err != nil {
+		return Result{}, fmt.Errorf("response is not valid JSON: %w", err)
+	}
+	got, err := normalizeJSON(resp.Body, resp.Normalize...)
+	if err != nil {
+		return Result{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Result{}, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if err == nil && !update {
+		want, err := normalizeJSON(string(data), resp.Normalize...)
+		if err != nil {
+			return Result{}, fmt.Errorf("invalid snapshot %s: %w", path, err)
+		}
+		if got == want {
+			return Result{Pass: true, Message: fmt.Sprintf("PASS: body matches snapshot %q", path)}, nil
+		}
+		return Result{
+			Message: fmt.Sprintf("FAIL: body differs from snapshot %q (run with --update-snapshots to accept the change)", path),
+			Diff:    Diff(want, got),
 		}, nil
 	}
 
+	status := "WRITTEN"
+	if err == nil {
+		if want, _ := normalizeJSON(string(data), resp.Normalize...); want == got {
+			return Result{Pass: true, Message: fmt.Sprintf("PASS: body matches snapshot %q", path)}, nil
+		}
+		status = "UPDATED"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return Result{}, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(body+"\n"), 0644); err != nil {
+		return Result{}, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return Result{Pass: true, Message: fmt.Sprintf("%s: snapshot %q", status, path)}, nil
+}
+
+// checkCount compares the number of elements at the assertion's path
+func checkCount(assert file.Assertion, body string) Result {
+	n, err := countElements(body, assert.Key)
+	if err != nil {
+		return Result{
+			Pass:    false,
+			Message: fmt.Sprintf("failed to count '%s': %v", assert.Key, err),
+		}
+	}
+	return compareNumber(assert, n)
+}
+
+// countElements returns the number of elements in the array (or entries in
+// the object) at path, or the number of values a wildcard, filter, or
+// recursive descent selects. Empty repeated and map fields are omitted from
+// JSON responses, so a missing key counts as zero.
+func countElements(body, path string) (int, error) {
+	matches, definite, err := client.EvaluateJSONPathMatches(body, path)
+	if errors.Is(err, client.ErrPathNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !definite {
+		return len(matches), nil
+	}
+	v, err := client.FormatJSONValue(matches[0])
+	if err != nil {
+		return 0, err
+	}
+	var elems []json.RawMessage
+	if err := json.Unmarshal([]byte(v), &elems); err == nil {
+		return len(elems), nil
+	}
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(v), &entries); err == nil {
+		return len(entries), nil
+	}
+	return 0, fmt.Errorf("value at '%s' is not an array or object", path)
+}
+
+// typeOperators map the type check operators to the JSON type they expect
+var typeOperators = map[string]string{
+	"isString":  "string",
+	"isNumber":  "number",
+	"isBoolean": "boolean",
+	"isArray":   "array",
+	"isObject":  "object",
+	"isNull":    "null",
+}
+
+// checkType checks the JSON type of the value at the assertion's path, e.g.
+// to catch an int64 field that started serializing as a string
+func checkType(assert file.Assertion, body, want string) Result {
+	v, err := client.EvaluateJSONPathValue(body, assert.Key)
+	if err != nil {
+		return Result{
+			Pass:    false,
+			Message: fmt.Sprintf("failed to evaluate jsonpath '%s': %v", assert.Key, err),
+		}
+	}
+	got := jsonType(v)
+	pass := (got == want) != assert.Negate
+	status := "FAIL"
+	if pass {
+		status = "PASS"
+	}
+
+	// Format: PASS: jsonpath "$.id" isString
+	// Format: FAIL: jsonpath "$.id" isNumber (actual: string)
+	msg := fmt.Sprintf("%s: %s %s", status, subject(assert), operator(assert))
+	if !pass {
+		msg += fmt.Sprintf(" (actual: %s)", got)
+	}
+	return Result{
+		Pass:    pass,
+		Message: msg,
+	}
+}
+
+// jsonType names the JSON type of a decoded value
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// checkMember reports whether one of the values a path selects equals the
+// expected value. ref is the JSONPath the expected value was read from ("" for
+// a literal value).
+func checkMember(assert file.Assertion, matches []interface{}, ref string) Result {
+	values := make([]string, 0, len(matches))
+	pass := false
+	for _, m := range matches {
+		v, err := client.FormatJSONValue(m)
+		if err != nil {
+			return Result{Pass: false, Message: err.Error()}
+		}
+		values = append(values, v)
+		pass = pass || v == assert.Value
+	}
+	pass = pass != assert.Negate
+
+	status := "FAIL"
+	if pass {
+		status = "PASS"
+	}
+	expected := fmt.Sprintf("\"%s\"", assert.Value)
+	if ref != "" {
+		expected = fmt.Sprintf("jsonpath \"%s\"", ref)
+	}
+
+	// Format: PASS: jsonpath "$.items[*].id" contains "2"
+	// Format: FAIL: jsonpath "$..name" contains "Alice" (actual: ["Bob", "Carol"])
+	msg := fmt.Sprintf("%s: %s %s %s", status, subject(assert), operator(assert), expected)
+	if !pass {
+		quoted := make([]string, len(values))
+		for i, v := range values {
+			quoted[i] = fmt.Sprintf("\"%s\"", v)
+		}
+		msg += fmt.Sprintf(" (actual: [%s])", strings.Join(quoted, ", "))
+	}
+	return Result{
+		Pass:    pass,
+		Message: msg,
+	}
+}
+
+// existsResult reports the outcome of an exists (or not exists) assertion
+func existsResult(assert file.Assertion, found bool) Result {
+	pass := found != assert.Negate
+	status := "FAIL"
+	if pass {
+		status = "PASS"
+	}
+	return Result{
+		Pass:    pass,
+		Message: fmt.Sprintf("%s: %s \"%s\" %s", status, assert.Type, assert.Key, operator(assert)),
+	}
+}
+
+// compare applies the assertion operator to the actual value. ref is the
+// JSONPath the expected value was read from ("" for a literal value).
+func compare(assert file.Assertion, val, ref string) Result {
 	pass := false
 	switch assert.Operator {
 	case "==":
@@ -38,12 +558,65 @@ func Check(assert file.Assertion, jsonOutput string) (Result, error) {
 		pass = val != assert.Value
 	case "contains":
 		pass = strings.Contains(val, assert.Value)
+	case "matches":
+		re, err := regexp.Compile(assert.Value)
+		if err != nil {
+			return Result{
+				Pass:    false,
+				Message: fmt.Sprintf("invalid regex '%s': %v", assert.Value, err),
+			}
+		}
+		pass = re.MatchString(val)
+	case "<", "<=", ">", ">=":
+		expected, err := strconv.ParseFloat(assert.Value, 64)
+		if err != nil {
+			return Result{
+				Pass:    false,
+				Message: fmt.Sprintf("invalid number '%s' for %s assertion", assert.Value, assert.Type),
+			}
+		}
+		actual, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil {
+			return Result{
+				Pass:    false,
+				Message: fmt.Sprintf("FAIL: %s %s \"%s\" (actual: \"%s\" is not a number)", subject(assert), operator(assert), assert.Value, val),
+			}
+		}
+		pass = order(assert.Operator, actual, expected)
+	case "approx":
+		expected, tolerance, err := approxBounds(assert)
+		if err != nil {
+			return Result{Pass: false, Message: err.Error()}
+		}
+		actual, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil {
+			return Result{
+				Pass:    false,
+				Message: fmt.Sprintf("FAIL: %s %s %s (actual: \"%s\" is not a number)", subject(assert), operator(assert), approxExpected(assert), val),
+			}
+		}
+		pass = math.Abs(actual-expected) <= tolerance
+	case "in":
+		options, err := parseList(assert.Value)
+		if err != nil {
+			return Result{
+				Pass:    false,
+				Message: fmt.Sprintf("invalid list '%s' for in operator: %v", assert.Value, err),
+			}
+		}
+		for _, option := range options {
+			if option.matches(val, assert.Type == "status") {
+				pass = true
+				break
+			}
+		}
 	default:
 		return Result{
 			Pass:    false,
 			Message: fmt.Sprintf("unknown operator '%s'", assert.Operator),
-		}, nil
+		}
 	}
+	pass = pass != assert.Negate
 
 	status := "FAIL"
 	if pass {
@@ -52,13 +625,184 @@ func Check(assert file.Assertion, jsonOutput string) (Result, error) {
 
 	// Format: PASS: jsonpath "$.id" == "123"
 	// Format: FAIL: jsonpath "$.id" == "123" (actual: "456")
-	msg := fmt.Sprintf("%s: jsonpath \"%s\" %s \"%s\"", status, assert.Key, assert.Operator, assert.Value)
-	if !pass {
+	// Format: PASS: status == "not_found" (keyless types)
+	// Format: PASS: jsonpath "$.status" in ["ACTIVE", "PENDING"]
+	// Format: FAIL: jsonpath "$.score" approx 0.95 tolerance 0.01 (actual: "0.9")
+	// Format: FAIL: jsonpath "$.id" == jsonpath "$.echo.id" (actual: "1", expected: "2")
+	// Format: FAIL: jsonpath "$.name" not contains "test" (actual: "test user")
+	expected := fmt.Sprintf("\"%s\"", assert.Value)
+	switch {
+	case ref != "":
+		expected = fmt.Sprintf("jsonpath \"%s\"", ref)
+	case assert.Operator == "in":
+		expected = assert.Value
+	case assert.Operator == "approx":
+		expected = approxExpected(assert)
+	}
+	msg := fmt.Sprintf("%s: %s %s %s", status, subject(assert), operator(assert), expected)
+	switch {
+	case pass:
+	case ref != "":
+		msg += fmt.Sprintf(" (actual: \"%s\", expected: \"%s\")", val, assert.Value)
+	default:
 		msg += fmt.Sprintf(" (actual: \"%s\")", val)
 	}
 
 	return Result{
 		Pass:    pass,
 		Message: msg,
-	}, nil
+	}
+}
+
+// defaultTolerance is the tolerance of an approx assertion without one;
+// it absorbs floating-point rounding only
+const defaultTolerance = 1e-9
+
+// approxBounds parses the expected value and tolerance of an approx assertion
+func approxBounds(assert file.Assertion) (expected, tolerance float64, err error) {
+	expected, err = strconv.ParseFloat(assert.Value, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid number '%s' for %s assertion", assert.Value, assert.Type)
+	}
+	tolerance = defaultTolerance
+	if assert.Tolerance != "" {
+		tolerance, err = strconv.ParseFloat(assert.Tolerance, 64)
+		if err != nil || tolerance < 0 {
+			return 0, 0, fmt.Errorf("invalid tolerance '%s' for approx operator", assert.Tolerance)
+		}
+	}
+	return expected, tolerance, nil
+}
+
+// approxExpected formats the expected value of an approx assertion,
+// e.g. "0.95 tolerance 0.01"
+func approxExpected(assert file.Assertion) string {
+	if assert.Tolerance == "" {
+		return assert.Value
+	}
+	return assert.Value + " tolerance " + assert.Tolerance
+}
+
+// listOption is an element of the list of an in assertion
+type listOption struct {
+	text   string  // String value, or the JSON text of other values
+	number float64 // Value of a JSON number
+	isNum  bool
+}
+
+// matches reports whether the actual value equals the option. Numbers are
+// compared numerically, so 1.0 matches 1; status names are normalized.
+func (o listOption) matches(val string, status bool) bool {
+	if o.isNum {
+		actual, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		return err == nil && actual == o.number
+	}
+	if status {
+		return client.NormalizeStatus(o.text) == val
+	}
+	return o.text == val
+}
+
+// parseList parses the JSON array of an in assertion, e.g.
+// ["ACTIVE", "PENDING"] or [1, 2, 3]
+func parseList(value string) ([]listOption, error) {
+	var elems []json.RawMessage
+	if err := json.Unmarshal([]byte(value), &elems); err != nil {
+		return nil, errors.New("expected a JSON array")
+	}
+	options := make([]listOption, 0, len(elems))
+	for _, elem := range elems {
+		var str string
+		if err := json.Unmarshal(elem, &str); err == nil {
+			options = append(options, listOption{text: str})
+			continue
+		}
+		var num float64
+		if err := json.Unmarshal(elem, &num); err == nil {
+			options = append(options, listOption{number: num, isNum: true})
+			continue
+		}
+		options = append(options, listOption{text: string(elem)})
+	}
+	return options, nil
+}
+
+// compareNumber applies a numeric assertion operator to an integer value
+func compareNumber(assert file.Assertion, actual int) Result {
+	expected, err := strconv.Atoi(assert.Value)
+	if err != nil {
+		return Result{
+			Pass:    false,
+			Message: fmt.Sprintf("invalid number '%s' for %s assertion", assert.Value, assert.Type),
+		}
+	}
+
+	pass := false
+	switch assert.Operator {
+	case "==":
+		pass = actual == expected
+	case "!=":
+		pass = actual != expected
+	case "<", "<=", ">", ">=":
+		pass = order(assert.Operator, float64(actual), float64(expected))
+	default:
+		return Result{
+			Pass:    false,
+			Message: fmt.Sprintf("unknown operator '%s'", assert.Operator),
+		}
+	}
+	pass = pass != assert.Negate
+
+	status := "FAIL"
+	if pass {
+		status = "PASS"
+	}
+
+	// Format: PASS: bytes < 10240
+	// Format: FAIL: jsonpath "$.users" count <= 50 (actual: 120)
+	msg := fmt.Sprintf("%s: %s %s %d", status, subject(assert), operator(assert), expected)
+	if !pass {
+		msg += fmt.Sprintf(" (actual: %d)", actual)
+	}
+
+	return Result{
+		Pass:    pass,
+		Message: msg,
+	}
+}
+
+// order applies an ordering operator (<, <=, >, >=) to two numbers
+func order(op string, actual, expected float64) bool {
+	switch op {
+	case "<":
+		return actual < expected
+	case "<=":
+		return actual <= expected
+	case ">":
+		return actual > expected
+	default:
+		return actual >= expected
+	}
+}
+
+// operator formats the operator of an assertion, including its "not"
+// modifier, e.g. "not contains"
+func operator(assert file.Assertion) string {
+	if assert.Negate {
+		return "not " + assert.Operator
+	}
+	return assert.Operator
+}
+
+// subject formats what an assertion checks, e.g. `jsonpath "$.items" count`
+// or `status` for keyless types
+func subject(assert file.Assertion) string {
+	s := assert.Type
+	if assert.Key != "" {
+		s += fmt.Sprintf(" \"%s\"", assert.Key)
+	}
+	if assert.Filter != "" {
+		s += " " + assert.Filter
+	}
+	return s
 }
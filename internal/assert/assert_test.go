@@ -1,8 +1,16 @@
 package assert
 
 import (
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"grpc_client/internal/file"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCheck(t *testing.T) {
@@ -80,6 +88,39 @@ func TestCheck(t *testing.T) {
 			wantPass: false,
 			wantMsg:  `FAIL: jsonpath "$.items[0]" contains "xyz" (actual: "item1")`,
 		},
+		{
+			name: "Matches",
+			assertion: file.Assertion{
+				Type:     "jsonpath",
+				Key:      "$.items[0]",
+				Operator: "matches",
+				Value:    `^item\d$`,
+			},
+			wantPass: true,
+			wantMsg:  `PASS: jsonpath "$.items[0]" matches "^item\d$"`,
+		},
+		{
+			name: "Matches mismatch",
+			assertion: file.Assertion{
+				Type:     "jsonpath",
+				Key:      "$.items[0]",
+				Operator: "matches",
+				Value:    `^[0-9a-f-]{36}$`,
+			},
+			wantPass: false,
+			wantMsg:  `FAIL: jsonpath "$.items[0]" matches "^[0-9a-f-]{36}$" (actual: "item1")`,
+		},
+		{
+			name: "Matches invalid regex",
+			assertion: file.Assertion{
+				Type:     "jsonpath",
+				Key:      "$.items[0]",
+				Operator: "matches",
+				Value:    `(`,
+			},
+			wantPass: false,
+			wantMsg:  "invalid regex '(': error parsing regexp: missing closing ): `(`",
+		},
 		{
 			name: "Unknown operator",
 			assertion: file.Assertion{
@@ -105,19 +146,392 @@ func TestCheck(t *testing.T) {
 		{
 			name: "Unknown assertion type",
 			assertion: file.Assertion{
-				Type:     "header",
-				Key:      "Content-Type",
+				Type:     "xpath",
+				Key:      "//id",
 				Operator: "==",
-				Value:    "application/json",
+				Value:    "123",
 			},
 			wantPass: true, // Treated as warning
-			wantMsg:  "Warning: skipping unknown assertion type 'header'",
+			wantMsg:  "Warning: skipping unknown assertion type 'xpath'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := Check(tt.assertion, &Response{Body: jsonOutput})
+			if result.Pass != tt.wantPass {
+				t.Errorf("Check() pass = %v, want %v", result.Pass, tt.wantPass)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Check() message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCheck_Header(t *testing.T) {
+	resp := &Response{
+		Body: `{}`,
+		Header: http.Header{
+			"Content-Type": []string{"application/grpc-web+proto"},
+			"X-Multi":      []string{"a", "b"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		assertion file.Assertion
+		wantPass  bool
+		wantMsg   string
+	}{
+		{
+			name:      "Equals match (case-insensitive name)",
+			assertion: file.Assertion{Type: "header", Key: "content-type", Operator: "==", Value: "application/grpc-web+proto"},
+			wantPass:  true,
+			wantMsg:   `PASS: header "content-type" == "application/grpc-web+proto"`,
+		},
+		{
+			name:      "Contains match",
+			assertion: file.Assertion{Type: "header", Key: "Content-Type", Operator: "contains", Value: "grpc-web"},
+			wantPass:  true,
+			wantMsg:   `PASS: header "Content-Type" contains "grpc-web"`,
+		},
+		{
+			name:      "Equals mismatch",
+			assertion: file.Assertion{Type: "header", Key: "Content-Type", Operator: "==", Value: "application/json"},
+			wantPass:  false,
+			wantMsg:   `FAIL: header "Content-Type" == "application/json" (actual: "application/grpc-web+proto")`,
+		},
+		{
+			name:      "Multiple values",
+			assertion: file.Assertion{Type: "header", Key: "X-Multi", Operator: "==", Value: "a, b"},
+			wantPass:  true,
+			wantMsg:   `PASS: header "X-Multi" == "a, b"`,
+		},
+		{
+			name:      "Missing header",
+			assertion: file.Assertion{Type: "header", Key: "X-Missing", Operator: "==", Value: "x"},
+			wantPass:  false,
+			wantMsg:   `FAIL: header "X-Missing" == "x" (header not found)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := Check(tt.assertion, resp)
+			if result.Pass != tt.wantPass {
+				t.Errorf("Check() pass = %v, want %v", result.Pass, tt.wantPass)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Check() message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCheck_Trailer(t *testing.T) {
+	resp := &Response{
+		Body:   `{}`,
+		Header: http.Header{"X-Header-Only": []string{"h"}},
+		Trailer: http.Header{
+			"Grpc-Status":             []string{"0"},
+			"Grpc-Status-Details-Bin": []string{"CAU"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		assertion file.Assertion
+		wantPass  bool
+		wantMsg   string
+	}{
+		{
+			name:      "Exists",
+			assertion: file.Assertion{Type: "trailer", Key: "grpc-status-details-bin", Operator: "exists"},
+			wantPass:  true,
+			wantMsg:   `PASS: trailer "grpc-status-details-bin" exists`,
+		},
+		{
+			name:      "Exists missing",
+			assertion: file.Assertion{Type: "trailer", Key: "x-header-only", Operator: "exists"},
+			wantPass:  false,
+			wantMsg:   `FAIL: trailer "x-header-only" exists`,
+		},
+		{
+			name:      "Equals match",
+			assertion: file.Assertion{Type: "trailer", Key: "grpc-status", Operator: "==", Value: "0"},
+			wantPass:  true,
+			wantMsg:   `PASS: trailer "grpc-status" == "0"`,
+		},
+		{
+			name:      "Missing trailer",
+			assertion: file.Assertion{Type: "trailer", Key: "x-missing", Operator: "==", Value: "1"},
+			wantPass:  false,
+			wantMsg:   `FAIL: trailer "x-missing" == "1" (trailer not found)`,
+		},
+		{
+			name:      "Header exists",
+			assertion: file.Assertion{Type: "header", Key: "x-header-only", Operator: "exists"},
+			wantPass:  true,
+			wantMsg:   `PASS: header "x-header-only" exists`,
+		},
+		{
+			name:      "Not exists",
+			assertion: file.Assertion{Type: "trailer", Key: "x-missing", Operator: "exists", Negate: true},
+			wantPass:  true,
+			wantMsg:   `PASS: trailer "x-missing" not exists`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := Check(tt.assertion, resp)
+			if result.Pass != tt.wantPass {
+				t.Errorf("Check() pass = %v, want %v", result.Pass, tt.wantPass)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Check() message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCheck_JSONPathExists(t *testing.T) {
+	resp := &Response{Body: `{"user": {"id": "1", "tags": []}, "items": ["a"], "error": null}`}
+
+	tests := []struct {
+		name      string
+		assertion file.Assertion
+		wantPass  bool
+		wantMsg   string
+	}{
+		{
+			name:      "Exists",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.user.id", Operator: "exists"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.user.id" exists`,
+		},
+		{
+			name:      "Exists missing",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.user.name", Operator: "exists"},
+			wantPass:  false,
+			wantMsg:   `FAIL: jsonpath "$.user.name" exists`,
+		},
+		{
+			name:      "Null value exists",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.error", Operator: "exists"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.error" exists`,
+		},
+		{
+			name:      "Not exists",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.status", Operator: "exists", Negate: true},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.status" not exists`,
+		},
+		{
+			name:      "Not exists present",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.user", Operator: "exists", Negate: true},
+			wantPass:  false,
+			wantMsg:   `FAIL: jsonpath "$.user" not exists`,
+		},
+		{
+			name:      "Index out of bounds",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.items[1]", Operator: "exists", Negate: true},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.items[1]" not exists`,
+		},
+		{
+			name:      "Evaluation error",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.items.name", Operator: "exists"},
+			wantPass:  false,
+			wantMsg:   `failed to evaluate jsonpath '$.items.name': expected object for key 'name' but got []interface {}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := Check(tt.assertion, resp)
+			if result.Pass != tt.wantPass {
+				t.Errorf("Check() pass = %v, want %v", result.Pass, tt.wantPass)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Check() message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCheck_Approx(t *testing.T) {
+	resp := &Response{Body: `{"score": 0.9549, "ratio": 0.30000000000000004, "name": "x"}`}
+
+	tests := []struct {
+		name      string
+		assertion file.Assertion
+		wantPass  bool
+		wantMsg   string
+	}{
+		{
+			name:      "Within tolerance",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.score", Operator: "approx", Value: "0.95", Tolerance: "0.01"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.score" approx 0.95 tolerance 0.01`,
+		},
+		{
+			name:      "Outside tolerance",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.score", Operator: "approx", Value: "0.95", Tolerance: "0.001"},
+			wantPass:  false,
+			wantMsg:   `FAIL: jsonpath "$.score" approx 0.95 tolerance 0.001 (actual: "0.9549")`,
+		},
+		{
+			name:      "Default tolerance absorbs rounding",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.ratio", Operator: "approx", Value: "0.3"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.ratio" approx 0.3`,
+		},
+		{
+			name:      "Not a number",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.name", Operator: "approx", Value: "1", Tolerance: "0.1"},
+			wantPass:  false,
+			wantMsg:   `FAIL: jsonpath "$.name" approx 1 tolerance 0.1 (actual: "x" is not a number)`,
+		},
+		{
+			name:      "Invalid tolerance",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.score", Operator: "approx", Value: "0.95", Tolerance: "-1"},
+			wantPass:  false,
+			wantMsg:   `invalid tolerance '-1' for approx operator`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := Check(tt.assertion, resp)
+			if result.Pass != tt.wantPass {
+				t.Errorf("Check() pass = %v, want %v", result.Pass, tt.wantPass)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Check() message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCheck_JSONPathValue(t *testing.T) {
+	resp := &Response{Body: `{"request_id": "abc", "echo": {"request_id": "abc", "other": "xyz"}, "total": 2, "items": [1, 2]}`}
+
+	tests := []struct {
+		name      string
+		assertion file.Assertion
+		wantPass  bool
+		wantMsg   string
+	}{
+		{
+			name:      "Equal values",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.request_id", Operator: "==", Value: "$.echo.request_id", JSONPath: true},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.request_id" == jsonpath "$.echo.request_id"`,
+		},
+		{
+			name:      "Different values",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.request_id", Operator: "==", Value: "$.echo.other", JSONPath: true},
+			wantPass:  false,
+			wantMsg:   `FAIL: jsonpath "$.request_id" == jsonpath "$.echo.other" (actual: "abc", expected: "xyz")`,
+		},
+		{
+			name:      "Missing expected path",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.request_id", Operator: "==", Value: "$.missing", JSONPath: true},
+			wantPass:  false,
+			wantMsg:   `failed to evaluate jsonpath '$.missing': key 'missing' not found`,
+		},
+		{
+			name:      "Count against a field",
+			assertion: file.Assertion{Type: "count", Key: "$.items", Operator: "==", Value: "$.total", JSONPath: true},
+			wantPass:  true,
+			wantMsg:   `PASS: count "$.items" == 2`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := Check(tt.assertion, resp)
+			if result.Pass != tt.wantPass {
+				t.Errorf("Check() pass = %v, want %v", result.Pass, tt.wantPass)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Check() message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCheck_Type(t *testing.T) {
+	resp := &Response{Body: `{"id": "123", "count": 5, "active": true, "tags": [], "user": {}, "deleted": null}`}
+
+	tests := []struct {
+		name      string
+		assertion file.Assertion
+		wantPass  bool
+		wantMsg   string
+	}{
+		{
+			name:      "String",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.id", Operator: "isString"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.id" isString`,
+		},
+		{
+			name:      "Int64 serialized as string",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.id", Operator: "isNumber"},
+			wantPass:  false,
+			wantMsg:   `FAIL: jsonpath "$.id" isNumber (actual: string)`,
+		},
+		{
+			name:      "Number",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.count", Operator: "isNumber"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.count" isNumber`,
+		},
+		{
+			name:      "Boolean",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.active", Operator: "isBoolean"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.active" isBoolean`,
+		},
+		{
+			name:      "Array",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.tags", Operator: "isArray"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.tags" isArray`,
+		},
+		{
+			name:      "Object",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.user", Operator: "isObject"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.user" isObject`,
+		},
+		{
+			name:      "Null",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.deleted", Operator: "isNull"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.deleted" isNull`,
+		},
+		{
+			name:      "Wrong type",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.user", Operator: "isArray"},
+			wantPass:  false,
+			wantMsg:   `FAIL: jsonpath "$.user" isArray (actual: object)`,
+		},
+		{
+			name:      "Missing key",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.missing", Operator: "isNull"},
+			wantPass:  false,
+			wantMsg:   `failed to evaluate jsonpath '$.missing': key 'missing' not found`,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, _ := Check(tt.assertion, jsonOutput)
+			result, _ := Check(tt.assertion, resp)
 			if result.Pass != tt.wantPass {
 				t.Errorf("Check() pass = %v, want %v", result.Pass, tt.wantPass)
 			}
@@ -127,3 +541,774 @@ func TestCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestCheck_Negate(t *testing.T) {
+	resp := &Response{
+		Body:   `{"name": "test user", "id": "123", "items": [1, 2]}`,
+		Header: http.Header{"X-Id": []string{"1"}},
+		Status: "ok",
+		Size:   100,
+	}
+
+	tests := []struct {
+		name      string
+		assertion file.Assertion
+		wantPass  bool
+		wantMsg   string
+	}{
+		{
+			name:      "Not contains",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.name", Operator: "contains", Negate: true, Value: "admin"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.name" not contains "admin"`,
+		},
+		{
+			name:      "Not contains fails",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.name", Operator: "contains", Negate: true, Value: "test"},
+			wantPass:  false,
+			wantMsg:   `FAIL: jsonpath "$.name" not contains "test" (actual: "test user")`,
+		},
+		{
+			name:      "Not matches",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.id", Operator: "matches", Negate: true, Value: "^[a-z]+$"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.id" not matches "^[a-z]+$"`,
+		},
+		{
+			name:      "Not in",
+			assertion: file.Assertion{Type: "status", Operator: "in", Negate: true, Value: `["not_found", "internal"]`},
+			wantPass:  true,
+			wantMsg:   `PASS: status not in ["not_found", "internal"]`,
+		},
+		{
+			name:      "Not numeric",
+			assertion: file.Assertion{Type: "bytes", Operator: ">", Negate: true, Value: "50"},
+			wantPass:  false,
+			wantMsg:   `FAIL: bytes not > 50 (actual: 100)`,
+		},
+		{
+			name:      "Not a type",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.id", Operator: "isNumber", Negate: true},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.id" not isNumber`,
+		},
+		{
+			name:      "Not a number still fails",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.name", Operator: "<", Negate: true, Value: "5"},
+			wantPass:  false,
+			wantMsg:   `FAIL: jsonpath "$.name" not < "5" (actual: "test user" is not a number)`,
+		},
+		{
+			name:      "Missing header still fails",
+			assertion: file.Assertion{Type: "header", Key: "x-missing", Operator: "contains", Negate: true, Value: "a"},
+			wantPass:  false,
+			wantMsg:   `FAIL: header "x-missing" not contains "a" (header not found)`,
+		},
+		{
+			name:      "Body",
+			assertion: file.Assertion{Type: "body", Operator: "contains", Negate: true, Value: "password"},
+			wantPass:  true,
+			wantMsg:   `PASS: body not contains "password"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := Check(tt.assertion, resp)
+			if result.Pass != tt.wantPass {
+				t.Errorf("Check() pass = %v, want %v", result.Pass, tt.wantPass)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Check() message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCheck_MatchSet(t *testing.T) {
+	resp := &Response{Body: `{"items": [{"id": "1", "name": "Alice"}, {"id": "2", "name": "Bob"}], "owner": {"name": "Carol"}, "empty": []}`}
+
+	tests := []struct {
+		name      string
+		assertion file.Assertion
+		wantPass  bool
+		wantMsg   string
+	}{
+		{
+			name:      "Contains match",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.items[*].id", Operator: "contains", Value: "2"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.items[*].id" contains "2"`,
+		},
+		{
+			name:      "Contains is membership, not substring",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$..name", Operator: "contains", Value: "Ali"},
+			wantPass:  false,
+			wantMsg:   `FAIL: jsonpath "$..name" contains "Ali" (actual: ["Alice", "Bob", "Carol"])`,
+		},
+		{
+			name:      "Not contains",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$..name", Operator: "contains", Negate: true, Value: "Dave"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$..name" not contains "Dave"`,
+		},
+		{
+			name:      "Contains on an empty match set",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.empty[*]", Operator: "contains", Value: "x"},
+			wantPass:  false,
+			wantMsg:   `FAIL: jsonpath "$.empty[*]" contains "x" (actual: [])`,
+		},
+		{
+			name:      "Count of matches",
+			assertion: file.Assertion{Type: "count", Key: "$..name", Operator: "==", Value: "3"},
+			wantPass:  true,
+			wantMsg:   `PASS: count "$..name" == 3`,
+		},
+		{
+			name:      "Count filter of a single match",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.items[?(@.name=='Bob')]", Filter: "count", Operator: "==", Value: "1"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.items[?(@.name=='Bob')]" count == 1`,
+		},
+		{
+			name:      "Count of no matches",
+			assertion: file.Assertion{Type: "count", Key: "$.empty[*].id", Operator: "==", Value: "0"},
+			wantPass:  true,
+			wantMsg:   `PASS: count "$.empty[*].id" == 0`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := Check(tt.assertion, resp)
+			if result.Pass != tt.wantPass {
+				t.Errorf("Check() pass = %v, want %v", result.Pass, tt.wantPass)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Check() message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCheck_In(t *testing.T) {
+	tests := []struct {
+		name      string
+		assertion file.Assertion
+		resp      *Response
+		wantPass  bool
+		wantMsg   string
+	}{
+		{
+			name:      "String member",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.status", Operator: "in", Value: `["ACTIVE", "PENDING"]`},
+			resp:      &Response{Body: `{"status": "PENDING"}`},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.status" in ["ACTIVE", "PENDING"]`,
+		},
+		{
+			name:      "Not a member",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.status", Operator: "in", Value: `["ACTIVE", "PENDING"]`},
+			resp:      &Response{Body: `{"status": "DELETED"}`},
+			wantPass:  false,
+			wantMsg:   `FAIL: jsonpath "$.status" in ["ACTIVE", "PENDING"] (actual: "DELETED")`,
+		},
+		{
+			name:      "Numeric member",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.code", Operator: "in", Value: `[200, 2.5e2]`},
+			resp:      &Response{Body: `{"code": 250}`},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.code" in [200, 2.5e2]`,
+		},
+		{
+			name:      "Boolean member",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.active", Operator: "in", Value: `[true]`},
+			resp:      &Response{Body: `{"active": true}`},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.active" in [true]`,
+		},
+		{
+			name:      "Status member",
+			assertion: file.Assertion{Type: "status", Operator: "in", Value: `["NOT_FOUND", "7"]`},
+			resp:      &Response{Status: "permission_denied"},
+			wantPass:  true,
+			wantMsg:   `PASS: status in ["NOT_FOUND", "7"]`,
+		},
+		{
+			name:      "Invalid list",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.status", Operator: "in", Value: `ACTIVE, PENDING`},
+			resp:      &Response{Body: `{"status": "ACTIVE"}`},
+			wantPass:  false,
+			wantMsg:   `invalid list 'ACTIVE, PENDING' for in operator: expected a JSON array`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := Check(tt.assertion, tt.resp)
+			if result.Pass != tt.wantPass {
+				t.Errorf("Check() pass = %v, want %v", result.Pass, tt.wantPass)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Check() message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCheck_Status(t *testing.T) {
+	resp := &Response{Status: "not_found"}
+
+	tests := []struct {
+		name      string
+		assertion file.Assertion
+		wantPass  bool
+		wantMsg   string
+	}{
+		{
+			name:      "Equals match",
+			assertion: file.Assertion{Type: "status", Operator: "==", Value: "not_found"},
+			wantPass:  true,
+			wantMsg:   `PASS: status == "not_found"`,
+		},
+		{
+			name:      "Upper case and numeric codes",
+			assertion: file.Assertion{Type: "status", Operator: "==", Value: "5"},
+			wantPass:  true,
+			wantMsg:   `PASS: status == "not_found"`,
+		},
+		{
+			name:      "Equals mismatch",
+			assertion: file.Assertion{Type: "status", Operator: "==", Value: "OK"},
+			wantPass:  false,
+			wantMsg:   `FAIL: status == "ok" (actual: "not_found")`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := Check(tt.assertion, resp)
+			if result.Pass != tt.wantPass {
+				t.Errorf("Check() pass = %v, want %v", result.Pass, tt.wantPass)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Check() message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestExpectsStatus(t *testing.T) {
+	if ExpectsStatus([]file.Assertion{{Type: "jsonpath", Key: "$.id", Operator: "==", Value: "1"}}) {
+		t.Error("ExpectsStatus() = true without a status assertion")
+	}
+	if !ExpectsStatus([]file.Assertion{{Type: "status", Operator: "==", Value: "not_found"}}) {
+		t.Error("ExpectsStatus() = false with a status assertion")
+	}
+}
+
+func TestCheck_Bytes(t *testing.T) {
+	resp := &Response{Size: 2048}
+
+	tests := []struct {
+		name      string
+		assertion file.Assertion
+		wantPass  bool
+		wantMsg   string
+	}{
+		{
+			name:      "Less than",
+			assertion: file.Assertion{Type: "bytes", Operator: "<", Value: "10240"},
+			wantPass:  true,
+			wantMsg:   `PASS: bytes < 10240`,
+		},
+		{
+			name:      "Less than exceeded",
+			assertion: file.Assertion{Type: "bytes", Operator: "<", Value: "1024"},
+			wantPass:  false,
+			wantMsg:   `FAIL: bytes < 1024 (actual: 2048)`,
+		},
+		{
+			name:      "Greater or equal",
+			assertion: file.Assertion{Type: "bytes", Operator: ">=", Value: "2048"},
+			wantPass:  true,
+			wantMsg:   `PASS: bytes >= 2048`,
+		},
+		{
+			name:      "Response size",
+			assertion: file.Assertion{Type: "responsesize", Operator: ">", Value: "1024"},
+			wantPass:  true,
+			wantMsg:   `PASS: responsesize > 1024`,
+		},
+		{
+			name:      "Response size exceeded",
+			assertion: file.Assertion{Type: "responsesize", Operator: "<=", Value: "2047"},
+			wantPass:  false,
+			wantMsg:   `FAIL: responsesize <= 2047 (actual: 2048)`,
+		},
+		{
+			name:      "Not a number",
+			assertion: file.Assertion{Type: "bytes", Operator: "<", Value: "10k"},
+			wantPass:  false,
+			wantMsg:   `invalid number '10k' for bytes assertion`,
+		},
+		{
+			name:      "Unknown operator",
+			assertion: file.Assertion{Type: "bytes", Operator: "contains", Value: "1"},
+			wantPass:  false,
+			wantMsg:   `unknown operator 'contains'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := Check(tt.assertion, resp)
+			if result.Pass != tt.wantPass {
+				t.Errorf("Check() pass = %v, want %v", result.Pass, tt.wantPass)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Check() message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCheck_Certificate(t *testing.T) {
+	cert := &x509.Certificate{
+		Subject:      pkix.Name{CommonName: "api.example.com"},
+		Issuer:       pkix.Name{CommonName: "Example CA"},
+		SerialNumber: big.NewInt(42),
+		NotAfter:     time.Now().Add(45*24*time.Hour + time.Hour),
+		DNSNames:     []string{"api.example.com", "*.example.com"},
+	}
+	resp := &Response{Certificates: []*x509.Certificate{cert}}
+
+	tests := []struct {
+		name      string
+		assertion file.Assertion
+		wantPass  bool
+		wantMsg   string
+	}{
+		{
+			name:      "Expire days",
+			assertion: file.Assertion{Type: "certificate", Key: "expire_days", Operator: ">", Value: "30"},
+			wantPass:  true,
+			wantMsg:   `PASS: certificate "expire_days" > "30"`,
+		},
+		{
+			name:      "Expiring soon",
+			assertion: file.Assertion{Type: "certificate", Key: "expire_days", Operator: ">", Value: "60"},
+			wantPass:  false,
+			wantMsg:   `FAIL: certificate "expire_days" > "60" (actual: "45")`,
+		},
+		{
+			name:      "Subject",
+			assertion: file.Assertion{Type: "certificate", Key: "subject", Operator: "==", Value: "CN=api.example.com"},
+			wantPass:  true,
+			wantMsg:   `PASS: certificate "subject" == "CN=api.example.com"`,
+		},
+		{
+			name:      "DNS names",
+			assertion: file.Assertion{Type: "certificate", Key: "dns_names", Operator: "contains", Value: "*.example.com"},
+			wantPass:  true,
+			wantMsg:   `PASS: certificate "dns_names" contains "*.example.com"`,
+		},
+		{
+			name:      "Unknown field",
+			assertion: file.Assertion{Type: "certificate", Key: "owner", Operator: "==", Value: "x"},
+			wantPass:  false,
+			wantMsg:   `unknown certificate field 'owner', expected one of: subject, issuer, serial, not_before, not_after, expire_days, dns_names`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := Check(tt.assertion, resp)
+			if result.Pass != tt.wantPass {
+				t.Errorf("Check() pass = %v, want %v", result.Pass, tt.wantPass)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Check() message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+
+	// Plain HTTP has no certificate to check
+	result, _ := Check(tests[0].assertion, &Response{})
+	if result.Pass || !strings.Contains(result.Message, "does not use TLS") {
+		t.Errorf("Check() without TLS = %+v", result)
+	}
+}
+
+func TestCheck_Count(t *testing.T) {
+	resp := &Response{Body: `{"users": [{"id": "1"}, {"id": "2"}, {"id": "3"}], "name": "x"}`}
+
+	tests := []struct {
+		name      string
+		assertion file.Assertion
+		wantPass  bool
+		wantMsg   string
+	}{
+		{
+			name:      "Equals",
+			assertion: file.Assertion{Type: "count", Key: "$.users", Operator: "==", Value: "3"},
+			wantPass:  true,
+			wantMsg:   `PASS: count "$.users" == 3`,
+		},
+		{
+			name:      "Upper bound exceeded",
+			assertion: file.Assertion{Type: "count", Key: "$.users", Operator: "<=", Value: "2"},
+			wantPass:  false,
+			wantMsg:   `FAIL: count "$.users" <= 2 (actual: 3)`,
+		},
+		{
+			name:      "Omitted repeated field counts as zero",
+			assertion: file.Assertion{Type: "count", Key: "$.items", Operator: "==", Value: "0"},
+			wantPass:  true,
+			wantMsg:   `PASS: count "$.items" == 0`,
+		},
+		{
+			name:      "Not an array",
+			assertion: file.Assertion{Type: "count", Key: "$.name", Operator: "==", Value: "1"},
+			wantPass:  false,
+			wantMsg:   `failed to count '$.name': value at '$.name' is not an array or object`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := Check(tt.assertion, resp)
+			if result.Pass != tt.wantPass {
+				t.Errorf("Check() pass = %v, want %v", result.Pass, tt.wantPass)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Check() message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCheck_CountFilter(t *testing.T) {
+	resp := &Response{
+		Body:   `{"items": [1, 2, 3], "labels": {"a": "1", "b": "2"}, "name": "x"}`,
+		Header: http.Header{"X-Tag": []string{"a", "b"}},
+	}
+
+	tests := []struct {
+		name      string
+		assertion file.Assertion
+		wantPass  bool
+		wantMsg   string
+	}{
+		{
+			name:      "Array length",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.items", Filter: "count", Operator: "==", Value: "3"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.items" count == 3`,
+		},
+		{
+			name:      "Map size",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.labels", Filter: "count", Operator: ">", Value: "2"},
+			wantPass:  false,
+			wantMsg:   `FAIL: jsonpath "$.labels" count > 2 (actual: 2)`,
+		},
+		{
+			name:      "Omitted field",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.tags", Filter: "count", Operator: "==", Value: "0"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.tags" count == 0`,
+		},
+		{
+			name:      "Scalar",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.name", Filter: "count", Operator: "==", Value: "1"},
+			wantPass:  false,
+			wantMsg:   `failed to count '$.name': value at '$.name' is not an array or object`,
+		},
+		{
+			name:      "Header values",
+			assertion: file.Assertion{Type: "header", Key: "x-tag", Filter: "count", Operator: "==", Value: "2"},
+			wantPass:  true,
+			wantMsg:   `PASS: header "x-tag" count == 2`,
+		},
+		{
+			name:      "Unsupported type",
+			assertion: file.Assertion{Type: "status", Filter: "count", Operator: "==", Value: "1"},
+			wantPass:  false,
+			wantMsg:   `filter 'count' is not supported for status assertions`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := Check(tt.assertion, resp)
+			if result.Pass != tt.wantPass {
+				t.Errorf("Check() pass = %v, want %v", result.Pass, tt.wantPass)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Check() message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCheck_Numeric(t *testing.T) {
+	resp := &Response{
+		Body:   `{"age": 30, "score": 9.5, "total": "9007199254740993", "name": "Jane"}`,
+		Header: http.Header{"X-Remaining": []string{"42"}},
+	}
+
+	tests := []struct {
+		name      string
+		assertion file.Assertion
+		wantPass  bool
+		wantMsg   string
+	}{
+		{
+			name:      "Greater than",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.age", Operator: ">", Value: "18"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.age" > "18"`,
+		},
+		{
+			name:      "Numeric rather than string order",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.score", Operator: "<", Value: "10"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.score" < "10"`,
+		},
+		{
+			name:      "Less or equal mismatch",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.age", Operator: "<=", Value: "29.5"},
+			wantPass:  false,
+			wantMsg:   `FAIL: jsonpath "$.age" <= "29.5" (actual: "30")`,
+		},
+		{
+			name:      "int64 encoded as string",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.total", Operator: ">=", Value: "1e15"},
+			wantPass:  true,
+			wantMsg:   `PASS: jsonpath "$.total" >= "1e15"`,
+		},
+		{
+			name:      "Header",
+			assertion: file.Assertion{Type: "header", Key: "x-remaining", Operator: ">", Value: "0"},
+			wantPass:  true,
+			wantMsg:   `PASS: header "x-remaining" > "0"`,
+		},
+		{
+			name:      "Actual not numeric",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.name", Operator: ">", Value: "1"},
+			wantPass:  false,
+			wantMsg:   `FAIL: jsonpath "$.name" > "1" (actual: "Jane" is not a number)`,
+		},
+		{
+			name:      "Expected not numeric",
+			assertion: file.Assertion{Type: "jsonpath", Key: "$.age", Operator: ">", Value: "old"},
+			wantPass:  false,
+			wantMsg:   `invalid number 'old' for jsonpath assertion`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := Check(tt.assertion, resp)
+			if result.Pass != tt.wantPass {
+				t.Errorf("Check() pass = %v, want %v", result.Pass, tt.wantPass)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Check() message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCheck_Body(t *testing.T) {
+	dir := t.TempDir()
+	golden := filepath.Join(dir, "user.json")
+	if err := os.WriteFile(golden, []byte("{\n  \"name\": \"Alice\",\n  \"id\": \"1\"\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	resp := &Response{Body: `{"id":"1","name":"Alice"}`}
+
+	tests := []struct {
+		name      string
+		assertion file.Assertion
+		resp      *Response
+		wantPass  bool
+		wantMsg   string
+	}{
+		{
+			name:      "Golden file ignores formatting and key order",
+			assertion: file.Assertion{Type: "body", Operator: "==", Value: golden, File: true},
+			resp:      resp,
+			wantPass:  true,
+			wantMsg:   `PASS: body == file "` + golden + `"`,
+		},
+		{
+			name:      "Golden file mismatch",
+			assertion: file.Assertion{Type: "body", Operator: "==", Value: golden, File: true},
+			resp:      &Response{Body: `{"id":"1","name":"Bob"}`},
+			wantPass:  false,
+			wantMsg:   `FAIL: body == file "` + golden + `" (line 3: expected "\"name\": \"Alice\"", actual "\"name\": \"Bob\"")`,
+		},
+		{
+			name:      "Golden file not equal",
+			assertion: file.Assertion{Type: "body", Operator: "!=", Value: golden, File: true},
+			resp:      resp,
+			wantPass:  false,
+			wantMsg:   `FAIL: body != file "` + golden + `"`,
+		},
+		{
+			name:      "Missing golden file",
+			assertion: file.Assertion{Type: "body", Operator: "==", Value: filepath.Join(dir, "missing.json"), File: true},
+			resp:      resp,
+			wantPass:  false,
+			wantMsg:   "golden file '" + filepath.Join(dir, "missing.json") + "' does not exist (run with --update-golden to create it)",
+		},
+		{
+			name:      "Inline JSON",
+			assertion: file.Assertion{Type: "body", Operator: "==", Value: `{"name": "Alice", "id": "1"}`},
+			resp:      resp,
+			wantPass:  true,
+			wantMsg:   `PASS: body == "{"name": "Alice", "id": "1"}"`,
+		},
+		{
+			name:      "Invalid inline JSON",
+			assertion: file.Assertion{Type: "body", Operator: "==", Value: `{"name"`},
+			resp:      resp,
+			wantPass:  false,
+			wantMsg:   `invalid expected JSON "{"name"": unexpected EOF`,
+		},
+		{
+			name:      "Contains",
+			assertion: file.Assertion{Type: "body", Operator: "contains", Value: "Alice"},
+			resp:      resp,
+			wantPass:  true,
+			wantMsg:   `PASS: body contains "Alice"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := Check(tt.assertion, tt.resp)
+			if result.Pass != tt.wantPass {
+				t.Errorf("Check() pass = %v, want %v", result.Pass, tt.wantPass)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Check() message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestUpdateGolden(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "golden", "user.json")
+	a := file.Assertion{Type: "body", Operator: "==", Value: golden, File: true}
+
+	result, err := UpdateGolden(a, &Response{Body: `{"name":"Alice","id":"1"}`})
+	if err != nil {
+		t.Fatalf("UpdateGolden failed: %v", err)
+	}
+	if !result.Pass || result.Message != `UPDATED: body == file "`+golden+`"` {
+		t.Errorf("result = %+v", result)
+	}
+	data, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("golden file not written: %v", err)
+	}
+	if want := "{\n  \"id\": \"1\",\n  \"name\": \"Alice\"\n}\n"; string(data) != want {
+		t.Errorf("golden file = %q, want %q", data, want)
+	}
+
+	// The updated file passes the assertion
+	if result, _ := Check(a, &Response{Body: `{"id": "1", "name": "Alice"}`}); !result.Pass {
+		t.Errorf("Check after update = %+v", result)
+	}
+
+	if _, err := UpdateGolden(a, &Response{}); err == nil {
+		t.Error("expected an error without a response body")
+	}
+}
+
+func TestCheckSnapshot(t *testing.T) {
+	snapshot := filepath.Join(t.TempDir(), "__snapshots__", "users", "get-user.json")
+	alice := &Response{Body: `{"name":"Alice","id":"1"}`}
+
+	// The first run writes the snapshot
+	result, err := CheckSnapshot(snapshot, alice, false)
+	if err != nil {
+		t.Fatalf("CheckSnapshot failed: %v", err)
+	}
+	if !result.Pass || result.Message != `WRITTEN: snapshot "`+snapshot+`"` {
+		t.Errorf("result = %+v", result)
+	}
+	if data, _ := os.ReadFile(snapshot); string(data) != "{\n  \"id\": \"1\",\n  \"name\": \"Alice\"\n}\n" {
+		t.Errorf("snapshot = %q", data)
+	}
+
+	// Later runs compare, ignoring formatting
+	if result, _ := CheckSnapshot(snapshot, &Response{Body: `{"id": "1", "name": "Alice"}`}, false); !result.Pass || result.Diff != "" {
+		t.Errorf("result = %+v", result)
+	}
+	bob := &Response{Body: `{"id": "1", "name": "Bob"}`}
+	result, _ = CheckSnapshot(snapshot, bob, false)
+	if result.Pass || !strings.Contains(result.Diff, "-  \"name\": \"Alice\"\n+  \"name\": \"Bob\"") {
+		t.Errorf("expected a failure with a diff, got %+v", result)
+	}
+
+	// Updates are explicit
+	if result, _ := CheckSnapshot(snapshot, bob, true); !result.Pass || result.Message != `UPDATED: snapshot "`+snapshot+`"` {
+		t.Errorf("result = %+v", result)
+	}
+	if result, _ := CheckSnapshot(snapshot, bob, false); !result.Pass {
+		t.Errorf("expected the updated snapshot to match, got %+v", result)
+	}
+
+	if _, err := CheckSnapshot(snapshot, &Response{Body: "not json"}, false); err == nil {
+		t.Error("expected an error for a response that is not JSON")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	rules := []file.Normalization{
+		{Path: "$.id", Kind: "uuid"},
+		{Path: "$..create_time", Kind: "timestamp"},
+		{Path: "$.trace_id", Kind: "any"},
+		{Path: "$.debug", Kind: "ignore"},
+	}
+	golden := filepath.Join(t.TempDir(), "user.json")
+	if err := os.WriteFile(golden, []byte(`{"id": "0b8f5f8e-4c3a-4e4b-9a51-2f7d3c1e9a00", "user": {"create_time": "2024-01-01T00:00:00Z"}, "trace_id": "abc"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a := file.Assertion{Type: "body", Operator: "==", Value: golden, File: true}
+
+	same := &Response{Body: `{"id": "6f1c2d3e-0000-4000-8000-123456789abc", "user": {"create_time": "2025-06-30T12:34:56.789Z"}, "trace_id": "def", "debug": {"host": "a"}}`, Normalize: rules}
+	if result, err := Check(a, same); err != nil || !result.Pass {
+		t.Errorf("expected normalized values to compare equal, got %+v, %v", result, err)
+	}
+	if result, _ := Check(a, &Response{Body: same.Body}); result.Pass {
+		t.Error("expected the values to differ without normalization")
+	}
+
+	// Values of another form are still reported
+	malformed := &Response{Body: `{"id": "42", "user": {"create_time": "yesterday"}, "trace_id": "def"}`, Normalize: rules}
+	result, _ := Check(a, malformed)
+	if result.Pass || !strings.Contains(result.Diff, `-  "id": "<uuid>",`+"\n"+`+  "id": "42",`) || !strings.Contains(result.Diff, `+    "create_time": "yesterday"`) {
+		t.Errorf("expected the malformed values in the diff, got %+v", result)
+	}
+
+	// Snapshots are written as returned, and kept when only normalized
+	// values change
+	snapshot := filepath.Join(t.TempDir(), "user.json")
+	first := &Response{Body: `{"id": "1", "trace_id": "abc"}`, Normalize: rules[2:]}
+	if _, err := CheckSnapshot(snapshot, first, false); err != nil {
+		t.Fatalf("CheckSnapshot failed: %v", err)
+	}
+	second := &Response{Body: `{"id": "1", "trace_id": "def"}`, Normalize: rules[2:]}
+	for _, update := range []bool{false, true} {
+		if result, _ := CheckSnapshot(snapshot, second, update); !result.Pass || !strings.HasPrefix(result.Message, "PASS") {
+			t.Errorf("CheckSnapshot(update %v) = %+v", update, result)
+		}
+	}
+	if data, _ := os.ReadFile(snapshot); !strings.Contains(string(data), `"abc"`) {
+		t.Errorf("snapshot = %s", data)
+	}
+}
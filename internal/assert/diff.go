@@ -0,0 +1,275 @@
+package assert
+
+import (
+	"encoding/json"
+	"fmt"
+	"grpc_client/internal/file"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around changes
+const diffContext = 3
+
+// maxDiffCells bounds the work of a diff: changed regions larger than this
+// many line pairs are shown as removed and added whole
+const maxDiffCells = 4_000_000
+
+// Diff returns a unified diff of two documents, e.g. normalized JSON, with
+// lines of want prefixed by - and lines of got by +, or "" when they are
+// equal
+func Diff(want, got string) string {
+	if want == got {
+		return ""
+	}
+	a, b := strings.Split(want, "\n"), strings.Split(got, "\n")
+	ops := diffLines(a, b)
+
+	// Group the changes into hunks with their context
+	var out strings.Builder
+	for start := 0; start < len(ops); {
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		from := max(start-diffContext, 0)
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i + 1
+			} else if i-end >= 2*diffContext {
+				break
+			}
+		}
+		to := min(end+diffContext, len(ops))
+
+		hunk := ops[from:to]
+		aStart, bStart := hunk[0].a+1, hunk[0].b+1
+		aLen, bLen := 0, 0
+		for _, op := range hunk {
+			if op.kind != '+' {
+				aLen++
+			}
+			if op.kind != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, op := range hunk {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			out.WriteByte('\n')
+		}
+		start = to
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// diffOp is a line of a diff: ' ' when in both documents, - when only in
+// the first, + when only in the second. a and b are the positions the line
+// is at, or would be at, in each document.
+type diffOp struct {
+	kind byte
+	line string
+	a, b int
+}
+
+// diffLines computes the edits from a to b through their longest common
+// subsequence, after trimming their common prefix and suffix
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{' ', a[i], i, i})
+	}
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(ma)*len(mb) > maxDiffCells {
+		for i, line := range ma {
+			ops = append(ops, diffOp{'-', line, prefix + i, prefix})
+		}
+		for j, line := range mb {
+			ops = append(ops, diffOp{'+', line, prefix + len(ma), prefix + j})
+		}
+	} else {
+		// lcs[i][j] is the length of the longest common subsequence of
+		// ma[i:] and mb[j:]
+		lcs := make([][]int32, len(ma)+1)
+		for i := range lcs {
+			lcs[i] = make([]int32, len(mb)+1)
+		}
+		for i := len(ma) - 1; i >= 0; i-- {
+			for j := len(mb) - 1; j >= 0; j-- {
+				if ma[i] == mb[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(ma) || j < len(mb) {
+			switch {
+			case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+				ops = append(ops, diffOp{' ', ma[i], prefix + i, prefix + j})
+				i++
+				j++
+			case j == len(mb) || (i < len(ma) && lcs[i+1][j] >= lcs[i][j+1]):
+				ops = append(ops, diffOp{'-', ma[i], prefix + i, prefix + j})
+				i++
+			default:
+				ops = append(ops, diffOp{'+', mb[j], prefix + i, prefix + j})
+				j++
+			}
+		}
+	}
+	for k := len(a) - suffix; k < len(a); k++ {
+		ops = append(ops, diffOp{' ', a[k], k, k - len(a) + len(b)})
+	}
+	return ops
+}
+
+// Change is a difference between two JSON documents
+type Change struct {
+	Path string // jsonpath of the value, e.g. $.users[0].name
+	Kind byte   // - when the value is only in the first document, + when only in the second, ~ when it differs
+	Old  any    // Value in the first document (nil when added)
+	New  any    // Value in the second document (nil when removed)
+}
+
+// String formats the change as a line, e.g. ~ $.name: "Alice" -> "Bob"
+func (c Change) String() string {
+	switch c.Kind {
+	case '-':
+		return fmt.Sprintf("- %s: %s", c.Path, compactJSON(c.Old))
+	case '+':
+		return fmt.Sprintf("+ %s: %s", c.Path, compactJSON(c.New))
+	}
+	return fmt.Sprintf("~ %s: %s -> %s", c.Path, compactJSON(c.Old), compactJSON(c.New))
+}
+
+// DiffJSON compares two JSON documents structurally, after applying rules
+// to both, and returns their differences: the members of objects by key,
+// in key order, and the elements of arrays by position
+func DiffJSON(a, b string, rules ...file.Normalization) ([]Change, error) {
+	docA, err := decodeJSON(a, rules)
+	if err != nil {
+		return nil, fmt.Errorf("first document: %w", err)
+	}
+	docB, err := decodeJSON(b, rules)
+	if err != nil {
+		return nil, fmt.Errorf("second document: %w", err)
+	}
+	return diffValues("$", docA, docB, nil), nil
+}
+
+// FormatChanges formats changes one per line
+func FormatChanges(changes []Change) string {
+	lines := make([]string, len(changes))
+	for i, c := range changes {
+		lines[i] = c.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// diffValues appends the differences of a and b, found at path, to changes
+func diffValues(path string, a, b any, changes []Change) []Change {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := slices.Sorted(maps.Keys(a))
+		for key := range b {
+			if _, ok := a[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			va, inA := a[key]
+			vb, inB := b[key]
+			switch {
+			case !inB:
+				changes = append(changes, Change{Path: memberPath(path, key), Kind: '-', Old: va})
+			case !inA:
+				changes = append(changes, Change{Path: memberPath(path, key), Kind: '+', New: vb})
+			default:
+				changes = diffValues(memberPath(path, key), va, vb, changes)
+			}
+		}
+		return changes
+	case []any:
+		b, ok := b.([]any)
+		if !ok {
+			break
+		}
+		for i := range max(len(a), len(b)) {
+			elem := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(b):
+				changes = append(changes, Change{Path: elem, Kind: '-', Old: a[i]})
+			case i >= len(a):
+				changes = append(changes, Change{Path: elem, Kind: '+', New: b[i]})
+			default:
+				changes = diffValues(elem, a[i], b[i], changes)
+			}
+		}
+		return changes
+	}
+	if !equalValues(a, b) {
+		changes = append(changes, Change{Path: path, Kind: '~', Old: a, New: b})
+	}
+	return changes
+}
+
+// equalValues reports whether two scalars, or an object or array and
+// another value, are equal. Numbers are compared by value, so 1 equals 1.0.
+func equalValues(a, b any) bool {
+	na, okA := a.(json.Number)
+	nb, okB := b.(json.Number)
+	if okA && okB {
+		if na == nb {
+			return true
+		}
+		fa, errA := na.Float64()
+		fb, errB := nb.Float64()
+		return errA == nil && errB == nil && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// memberPathKey matches the keys that a jsonpath can name after a dot
+var memberPathKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// memberPath returns the path of the member key of the object at path
+func memberPath(path, key string) string {
+	if memberPathKey.MatchString(key) {
+		return path + "." + key
+	}
+	return path + "['" + strings.ReplaceAll(key, "'", `\'`) + "']"
+}
+
+// compactJSON formats a decoded value as compact JSON
+func compactJSON(v any) string {
+	var out strings.Builder
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
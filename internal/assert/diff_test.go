@@ -0,0 +1,80 @@
+package assert
+
+import (
+	"grpc_client/internal/file"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	want := strings.Join([]string{"{", `  "a": 1,`, `  "b": 2,`, `  "c": 3,`, `  "d": 4,`, `  "e": 5,`, `  "f": 6,`, `  "g": 7,`, `  "h": 8,`, `  "i": 9`, "}"}, "\n")
+	got := strings.Replace(strings.Replace(want, `"b": 2`, `"b": 20`, 1), `  "h": 8,`+"\n", "", 1)
+
+	expected := strings.Join([]string{
+		"@@ -1,11 +1,10 @@",
+		" {",
+		`   "a": 1,`,
+		`-  "b": 2,`,
+		`+  "b": 20,`,
+		`   "c": 3,`,
+		`   "d": 4,`,
+		`   "e": 5,`,
+		`   "f": 6,`,
+		`   "g": 7,`,
+		`-  "h": 8,`,
+		`   "i": 9`,
+		" }",
+	}, "\n")
+	if d := Diff(want, got); d != expected {
+		t.Errorf("Diff =\n%s\nwant\n%s", d, expected)
+	}
+
+	if d := Diff(want, want); d != "" {
+		t.Errorf("expected no diff of equal documents, got\n%s", d)
+	}
+}
+
+func TestDiff_Hunks(t *testing.T) {
+	var a []string
+	for i := 0; i < 20; i++ {
+		a = append(a, strings.Repeat("x", i))
+	}
+	b := append([]string(nil), a...)
+	b[1], b[18] = "first", "last"
+
+	d := Diff(strings.Join(a, "\n"), strings.Join(b, "\n"))
+	if strings.Count(d, "@@ -") != 2 || !strings.HasPrefix(d, "@@ -1,5 +1,5 @@\n") || !strings.Contains(d, "@@ -16,5 +16,5 @@\n") {
+		t.Errorf("expected two hunks with their context, got\n%s", d)
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	a := `{"id": "1", "name": "Alice", "age": 30, "score": 1.0, "tags": ["a", "b", "c"], "address": {"city": "Paris"}, "first-name": "A", "seen": "2024-01-01T00:00:00Z"}`
+	b := `{"id": "1", "name": "Alice Smith", "score": 1, "tags": ["a", "x"], "address": "unknown", "email": "alice@example.com", "first-name": "A", "seen": "2025-01-01T00:00:00Z"}`
+
+	changes, err := DiffJSON(a, b, file.Normalization{Path: "$.seen", Kind: "timestamp"})
+	if err != nil {
+		t.Fatalf("DiffJSON failed: %v", err)
+	}
+	want := strings.Join([]string{
+		`~ $.address: {"city":"Paris"} -> "unknown"`,
+		`- $.age: 30`,
+		`+ $.email: "alice@example.com"`,
+		`~ $.name: "Alice" -> "Alice Smith"`,
+		`~ $.tags[1]: "b" -> "x"`,
+		`- $.tags[2]: "c"`,
+	}, "\n")
+	if got := FormatChanges(changes); got != want {
+		t.Errorf("DiffJSON =\n%s\nwant\n%s", got, want)
+	}
+
+	if changes, _ := DiffJSON(`{"a": [1, {"b": null}]}`, `{"a":[1,{"b":null}]}`); len(changes) != 0 {
+		t.Errorf("expected no changes between equal documents, got %v", changes)
+	}
+	if _, err := DiffJSON(`{}`, `not json`); err == nil || !strings.Contains(err.Error(), "second document") {
+		t.Errorf("expected an error for the invalid document, got %v", err)
+	}
+	if got := memberPath("$", "first-name"); got != "$['first-name']" {
+		t.Errorf("memberPath = %s", got)
+	}
+}
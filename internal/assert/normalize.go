@@ -0,0 +1,56 @@
+package assert
+
+import (
+	"fmt"
+	"grpc_client/internal/client"
+	"grpc_client/internal/file"
+	"regexp"
+	"time"
+)
+
+// uuidPattern matches a UUID in its canonical textual form
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// applyNormalizations rewrites the values of a decoded document that rules
+// select, so that dynamic values compare equal (see file.NormalizeKinds)
+func applyNormalizations(doc any, rules []file.Normalization) (any, error) {
+	for _, rule := range rules {
+		edit := normalizer(rule.Kind)
+		if edit == nil {
+			return nil, fmt.Errorf("unknown normalization %q of %s", rule.Kind, rule.Path)
+		}
+		var err error
+		if doc, err = client.EditJSONPath(doc, rule.Path, edit); err != nil {
+			return nil, fmt.Errorf("failed to normalize %s: %w", rule.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+// normalizer returns the edit a kind of normalization makes to a value, or
+// nil for an unknown kind
+func normalizer(kind string) func(v any) (any, bool) {
+	switch kind {
+	case "ignore":
+		return func(any) (any, bool) { return nil, false }
+	case "any":
+		return func(any) (any, bool) { return "<any>", true }
+	case "uuid":
+		return func(v any) (any, bool) {
+			if s, ok := v.(string); ok && uuidPattern.MatchString(s) {
+				return "<uuid>", true
+			}
+			return v, true
+		}
+	case "timestamp":
+		return func(v any) (any, bool) {
+			if s, ok := v.(string); ok {
+				if _, err := time.Parse(time.RFC3339Nano, s); err == nil {
+					return "<timestamp>", true
+				}
+			}
+			return v, true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package bench
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Arrival distributions for the open model
+const (
+	ArrivalConstant = "constant"
+	ArrivalPoisson  = "poisson"
+)
+
+// Options configures a benchmark run
+type Options struct {
+	Concurrency int           // Number of concurrent workers (closed model)
+	Total       int           // Number of calls to make (0 = until Duration elapses)
+	Duration    time.Duration // Maximum run time (0 = until Total calls are made)
+	QPS         float64       // Global rate limit across all workers (0 = unlimited)
+	Burst       int           // Token bucket burst size when QPS is set
+
+	// ArrivalRate switches to the open model: calls are started at this
+	// rate per second regardless of how many are still in flight
+	ArrivalRate float64
+	Arrival     string     // Inter-arrival distribution: constant or poisson
+	Rand        *rand.Rand `json:"-"` // Random source for poisson arrivals (nil = random seed)
+
+	// Observe, if set, is called with every sample as it is recorded
+	Observe func(latency time.Duration, out Outcome) `json:"-"`
+}
+
+// Outcome is the result of a single call
+type Outcome struct {
+	Status       string // gRPC status name
+	RequestSize  int    // Encoded size of the request message(s) in bytes
+	ResponseSize int    // Encoded size of the response message(s) in bytes (0 when the call failed)
+}
+
+// CallFunc performs a single call and returns its outcome
+type CallFunc func(ctx context.Context) Outcome
+
+// Run executes call until Total calls have been made or Duration has
+// elapsed, and summarizes the samples. With an ArrivalRate it uses the open
+// model (see runOpen); otherwise opts.Concurrency workers each issue calls
+// back to back (the closed model).
+func Run(ctx context.Context, opts Options, call CallFunc) *Summary {
+	if opts.ArrivalRate > 0 {
+		return runOpen(ctx, opts, call)
+	}
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	if opts.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+
+	var limiter *Limiter
+	if opts.QPS > 0 {
+		limiter = NewLimiter(opts.QPS, opts.Burst)
+	}
+
+	recorder := NewRecorder()
+	var issued atomic.Int64
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				if opts.Total > 0 && issued.Add(1) > int64(opts.Total) {
+					return
+				}
+				if limiter != nil {
+					if err := limiter.Wait(ctx); err != nil {
+						return
+					}
+				}
+
+				callStart := time.Now()
+				out := call(ctx)
+				// Calls cut short by the end of the run are not samples
+				if ctx.Err() != nil {
+					return
+				}
+				record(recorder, opts, time.Since(callStart), out)
+			}
+		}()
+	}
+	wg.Wait()
+
+	summary := recorder.Summarize(time.Since(start))
+	summary.describe(opts, start)
+	return summary
+}
+
+// runOpen starts calls on a fixed schedule (constant or Poisson
+// inter-arrival times) without waiting for earlier calls to complete.
+// Latency is measured from each call's intended start time, so a slow
+// server cannot hide queueing delay by slowing the load generator down
+// (coordinated omission).
+func runOpen(ctx context.Context, opts Options, call CallFunc) *Summary {
+	schedCtx := ctx
+	if opts.Duration > 0 {
+		var cancel context.CancelFunc
+		schedCtx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+
+	rng := opts.Rand
+	if rng == nil {
+		rng = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	mean := time.Duration(float64(time.Second) / opts.ArrivalRate)
+
+	recorder := NewRecorder()
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	intended := start
+	for n := 0; opts.Total <= 0 || n < opts.Total; n++ {
+		if n > 0 {
+			if opts.Arrival == ArrivalPoisson {
+				intended = intended.Add(time.Duration(rng.ExpFloat64() * float64(mean)))
+			} else {
+				intended = intended.Add(mean)
+			}
+		}
+
+		if wait := time.Until(intended); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-schedCtx.Done():
+				timer.Stop()
+			}
+		}
+		if schedCtx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(intended time.Time) {
+			defer wg.Done()
+			// In-flight calls are allowed to finish after scheduling stops
+			out := call(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			record(recorder, opts, time.Since(intended), out)
+		}(intended)
+	}
+	wg.Wait()
+
+	summary := recorder.Summarize(time.Since(start))
+	summary.describe(opts, start)
+	return summary
+}
+
+// record adds a sample to recorder and passes it to the Observe hook
+func record(recorder *Recorder, opts Options, latency time.Duration, out Outcome) {
+	recorder.Record(latency, out)
+	if opts.Observe != nil {
+		opts.Observe(latency, out)
+	}
+}
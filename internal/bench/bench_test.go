@@ -0,0 +1,280 @@
+package bench
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Reserve(t *testing.T) {
+	start := time.Now()
+	l := NewLimiter(10, 2)
+	l.last = start
+
+	// The bucket starts full: burst calls go through immediately
+	for i := 0; i < 2; i++ {
+		if d := l.reserve(start); d != 0 {
+			t.Fatalf("call %d: expected no delay within burst, got %v", i, d)
+		}
+	}
+
+	// Further calls are spaced 100ms apart, even when reserved at the same instant
+	if d := l.reserve(start); d != 100*time.Millisecond {
+		t.Errorf("expected 100ms delay, got %v", d)
+	}
+	if d := l.reserve(start); d != 200*time.Millisecond {
+		t.Errorf("expected 200ms delay, got %v", d)
+	}
+
+	// Tokens refill over time, but never beyond the burst
+	if d := l.reserve(start.Add(10 * time.Second)); d != 0 {
+		t.Errorf("expected no delay after refill, got %v", d)
+	}
+	if l.tokens > l.burst {
+		t.Errorf("tokens %v exceed burst %v", l.tokens, l.burst)
+	}
+}
+
+func TestLimiter_SharedAcrossGoroutines(t *testing.T) {
+	l := NewLimiter(200, 1)
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 3; j++ {
+				_ = l.Wait(context.Background())
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 60 calls at 200 qps with burst 1 need at least 59 intervals of 5ms
+	if elapsed := time.Since(start); elapsed < 280*time.Millisecond {
+		t.Errorf("limiter not enforced globally: 60 calls took %v", elapsed)
+	}
+}
+
+func TestLimiter_WaitCanceled(t *testing.T) {
+	l := NewLimiter(1, 1)
+	_ = l.Wait(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx); err == nil {
+		t.Error("expected error from canceled context")
+	}
+}
+
+func TestRecorder_Summarize(t *testing.T) {
+	r := NewRecorder()
+	for i := 1; i <= 100; i++ {
+		status := "ok"
+		if i%10 == 0 {
+			status = "unavailable"
+		}
+		r.Record(time.Duration(i)*time.Millisecond, Outcome{Status: status})
+	}
+
+	s := r.Summarize(2 * time.Second)
+	if s.Count != 100 || s.Errors != 10 {
+		t.Errorf("count/errors = %d/%d, want 100/10", s.Count, s.Errors)
+	}
+	if s.AchievedQPS != 50 {
+		t.Errorf("achieved qps = %v, want 50", s.AchievedQPS)
+	}
+	if s.Min != time.Millisecond || s.Max != 100*time.Millisecond {
+		t.Errorf("min/max = %v/%v", s.Min, s.Max)
+	}
+	if s.P50 != 50*time.Millisecond || s.P90 != 90*time.Millisecond || s.P99 != 99*time.Millisecond {
+		t.Errorf("p50/p90/p99 = %v/%v/%v", s.P50, s.P90, s.P99)
+	}
+	if s.Statuses["ok"] != 90 || s.Statuses["unavailable"] != 10 {
+		t.Errorf("statuses = %v", s.Statuses)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	ten := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	hundred := make([]int, 100)
+	for i := range hundred {
+		hundred[i] = i + 1
+	}
+	tests := []struct {
+		sorted []int
+		p      float64
+		want   int
+	}{
+		{[]int{7}, 50, 7},
+		{[]int{7}, 0, 7},
+		{[]int{1, 2}, 50, 1},
+		{[]int{1, 2}, 51, 2},
+		{[]int{1, 2, 3}, 50, 2},
+		{[]int{1, 2, 3}, 34, 2},
+		{[]int{1, 2, 3}, 33, 1},
+		{[]int{1, 2, 3, 4}, 50, 2},
+		{[]int{1, 2, 3, 4}, 25, 1},
+		{[]int{1, 2, 3, 4}, 26, 2},
+		{ten, 0, 1},
+		{ten, 5, 1},
+		{ten, 10, 1},
+		{ten, 11, 2},
+		{ten, 50, 5},
+		{ten, 90, 9},
+		{ten, 91, 10},
+		{ten, 95, 10},
+		{ten, 99, 10},
+		{ten, 100, 10},
+		{hundred, 7, 7},
+		{hundred, 99, 99},
+		{hundred, 99.9, 100},
+	}
+	for _, tt := range tests {
+		if got := percentile(tt.sorted, tt.p); got != tt.want {
+			t.Errorf("p%v of %d samples = %d, want %d", tt.p, len(tt.sorted), got, tt.want)
+		}
+	}
+}
+
+func TestRecorder_Sizes(t *testing.T) {
+	r := NewRecorder()
+	for i := 1; i <= 10; i++ {
+		out := Outcome{Status: "ok", RequestSize: 10, ResponseSize: i * 100}
+		if i == 10 {
+			out = Outcome{Status: "unavailable", RequestSize: 10}
+		}
+		r.Record(time.Millisecond, out)
+	}
+
+	s := r.Summarize(time.Second)
+	if s.RequestSize.Count != 10 || s.RequestSize.Min != 10 || s.RequestSize.Mean != 10 || s.RequestSize.Max != 10 {
+		t.Errorf("request sizes = %+v", s.RequestSize)
+	}
+	// The failed call has no response and is left out; p90 of 9 samples is the 9th
+	want := SizeStats{Count: 9, Min: 100, Mean: 500, P50: 500, P90: 900, P99: 900, Max: 900}
+	if s.ResponseSize != want {
+		t.Errorf("response sizes = %+v, want %+v", s.ResponseSize, want)
+	}
+
+	if empty := NewRecorder().Summarize(time.Second); empty.ResponseSize != (SizeStats{}) {
+		t.Errorf("empty response sizes = %+v", empty.ResponseSize)
+	}
+}
+
+func TestRun_Total(t *testing.T) {
+	var calls atomic.Int64
+	s := Run(context.Background(), Options{Concurrency: 8, Total: 50}, func(ctx context.Context) Outcome {
+		calls.Add(1)
+		return Outcome{Status: "ok"}
+	})
+
+	if calls.Load() != 50 || s.Count != 50 {
+		t.Errorf("calls = %d, count = %d, want 50", calls.Load(), s.Count)
+	}
+}
+
+func TestRun_DurationOnly(t *testing.T) {
+	for _, opts := range []Options{
+		{Concurrency: 4, Duration: 200 * time.Millisecond},
+		{ArrivalRate: 5000, Arrival: ArrivalConstant, Duration: 200 * time.Millisecond},
+	} {
+		var calls atomic.Int64
+		s := Run(context.Background(), opts, func(ctx context.Context) Outcome {
+			calls.Add(1)
+			return Outcome{Status: "ok"}
+		})
+
+		// Without a total the run lasts the whole duration instead of stopping at 200 calls
+		if s.Count <= 200 {
+			t.Errorf("%+v: count = %d, want more than 200", opts, s.Count)
+		}
+		if s.Elapsed < 150*time.Millisecond {
+			t.Errorf("%+v: run took %v, want about 200ms", opts, s.Elapsed)
+		}
+	}
+}
+
+func TestRun_QPS(t *testing.T) {
+	s := Run(context.Background(), Options{Concurrency: 10, Total: 21, QPS: 100, Burst: 1}, func(ctx context.Context) Outcome {
+		return Outcome{Status: "ok"}
+	})
+
+	if s.RequestedQPS != 100 || s.Burst != 1 {
+		t.Errorf("requested qps/burst = %v/%d", s.RequestedQPS, s.Burst)
+	}
+	// 21 calls at 100 qps take at least 200ms, so the achieved rate stays near the limit
+	if s.AchievedQPS > 110 {
+		t.Errorf("achieved qps %v exceeds requested 100", s.AchievedQPS)
+	}
+}
+
+func TestRun_OpenModelDoesNotWaitForInFlight(t *testing.T) {
+	var inFlight, peak atomic.Int64
+	s := Run(context.Background(), Options{Total: 10, ArrivalRate: 100, Arrival: ArrivalConstant}, func(ctx context.Context) Outcome {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(200 * time.Millisecond)
+		inFlight.Add(-1)
+		return Outcome{Status: "ok"}
+	})
+
+	if s.Count != 10 {
+		t.Fatalf("count = %d, want 10", s.Count)
+	}
+	// A closed loop of one worker would take 2s; arrivals keep coming instead
+	if s.Elapsed > time.Second {
+		t.Errorf("elapsed %v, arrivals waited for completions", s.Elapsed)
+	}
+	if peak.Load() < 2 {
+		t.Errorf("peak in-flight = %d, want overlapping calls", peak.Load())
+	}
+	if s.Min < 200*time.Millisecond {
+		t.Errorf("min latency %v shorter than the call itself", s.Min)
+	}
+	if s.ArrivalRate != 100 || s.Arrival != ArrivalConstant {
+		t.Errorf("arrival = %v/%q", s.ArrivalRate, s.Arrival)
+	}
+}
+
+func TestRun_OpenModelLatencyFromSchedule(t *testing.T) {
+	// Each call blocks the next from starting on time; latency must include
+	// the delay since the intended start, not just the time spent in call
+	var mu sync.Mutex
+	s := Run(context.Background(), Options{Total: 5, ArrivalRate: 100, Arrival: ArrivalConstant}, func(ctx context.Context) Outcome {
+		mu.Lock()
+		defer mu.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		return Outcome{Status: "ok"}
+	})
+
+	// The fifth call is scheduled at 40ms but can only finish at ~250ms
+	if s.Max < 200*time.Millisecond {
+		t.Errorf("max latency %v does not include queueing delay", s.Max)
+	}
+}
+
+func TestRun_OpenModelPoissonDuration(t *testing.T) {
+	s := Run(context.Background(), Options{
+		Duration:    200 * time.Millisecond,
+		ArrivalRate: 200,
+		Arrival:     ArrivalPoisson,
+		Rand:        rand.New(rand.NewPCG(1, 2)),
+	}, func(ctx context.Context) Outcome {
+		return Outcome{Status: "ok"}
+	})
+
+	// About 40 arrivals are expected; allow for the randomness of the schedule
+	if s.Count < 10 || s.Count > 100 {
+		t.Errorf("count = %d, want roughly 40", s.Count)
+	}
+}
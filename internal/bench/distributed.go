@@ -0,0 +1,319 @@
+package bench
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// A distributed benchmark has one controller and a fixed number of workers.
+// Workers connect to the controller over TCP and receive a job: their share
+// of the load and a spec describing what to call. They stream their samples
+// back as newline-delimited JSON, and the controller aggregates them into a
+// single Summary, so percentiles are computed over every sample rather than
+// averaged across workers. The spec carries the credentials of the call, so
+// a worker must present the controller's shared token before it is sent.
+
+const (
+	sampleBatchSize     = 500                    // Samples per report message
+	sampleFlushInterval = 100 * time.Millisecond // Maximum delay before a partial batch is sent
+	helloTimeout        = 10 * time.Second       // Maximum time a connection may take to present its token
+	stopTimeout         = 5 * time.Second        // Maximum time workers may take to report after being stopped
+)
+
+// hello is sent by a worker when it connects to the controller
+type hello struct {
+	Token string
+}
+
+// job is sent by the controller to each worker
+type job struct {
+	Options Options
+	Spec    json.RawMessage // Interpreted by the worker's NewCallFunc
+	Error   string          `json:",omitempty"` // Set instead when the worker was rejected
+}
+
+// stop is sent by the controller to end a worker's run early, e.g. on Ctrl-C
+type stop struct {
+	Stop bool
+}
+
+// report is streamed by a worker to the controller
+type report struct {
+	Samples []sample `json:"samples,omitempty"`
+	Done    bool     `json:"done,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+type sample struct {
+	Latency      time.Duration `json:"l"`
+	Status       string        `json:"s"`
+	RequestSize  int           `json:"q,omitempty"`
+	ResponseSize int           `json:"r,omitempty"`
+}
+
+// outcome returns the outcome of the call the sample was taken from
+func (s sample) outcome() Outcome {
+	return Outcome{Status: s.Status, RequestSize: s.RequestSize, ResponseSize: s.ResponseSize}
+}
+
+// NewCallFunc builds the function a worker benchmarks from the spec sent
+// by the controller
+type NewCallFunc func(spec json.RawMessage) (CallFunc, error)
+
+// Split returns the share of o run by worker i of n. Totals, concurrency,
+// and rates are divided evenly, with remainders going to the first workers;
+// the duration and burst apply to every worker as is.
+func (o Options) Split(n, i int) Options {
+	share := func(total int) int {
+		s := total / n
+		if i < total%n {
+			s++
+		}
+		return s
+	}
+	part := o
+	part.Total = share(o.Total)
+	part.Concurrency = max(share(o.Concurrency), 1)
+	part.QPS = o.QPS / float64(n)
+	part.ArrivalRate = o.ArrivalRate / float64(n)
+	return part
+}
+
+// Coordinate runs a distributed benchmark as the controller. It waits for
+// workers presenting token to connect on ln, starts them all at once with
+// their share of opts and the given spec, and summarizes the samples they
+// report. Connections with another token are rejected without the spec.
+// Canceling ctx once the workers have started stops them early, and the
+// samples reported so far are still summarized.
+func Coordinate(ctx context.Context, ln net.Listener, workers int, token string, opts Options, spec any) (*Summary, error) {
+	if workers < 1 {
+		return nil, fmt.Errorf("at least one worker is required")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("a worker token is required")
+	}
+	if opts.Total > 0 && opts.Total < workers {
+		return nil, fmt.Errorf("a total of %d calls cannot be split across %d workers", opts.Total, workers)
+	}
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	// Closing the listener unblocks Accept on cancel
+	var conns []net.Conn
+	stopAccept := context.AfterFunc(ctx, func() {
+		_ = ln.Close()
+	})
+	defer stopAccept()
+	defer func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	}()
+
+	for len(conns) < workers {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("failed to accept worker: %w", err)
+		}
+		if err := authenticate(conn, token); err != nil {
+			_ = conn.Close()
+			continue
+		}
+		conns = append(conns, conn)
+	}
+	stopAccept()
+
+	recorder := NewRecorder()
+	errs := make(chan error, workers)
+	start := time.Now()
+	for i, conn := range conns {
+		go func() {
+			errs <- serveWorker(ctx, conn, job{Options: opts.Split(workers, i), Spec: specJSON}, recorder, opts.Observe)
+		}()
+	}
+	var firstErr error
+	for range conns {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	summary := recorder.Summarize(time.Since(start))
+	summary.describe(opts, start)
+	return summary, nil
+}
+
+// authenticate reads the hello of a worker and checks its token, telling the
+// worker why it was rejected
+func authenticate(conn net.Conn, token string) error {
+	_ = conn.SetReadDeadline(time.Now().Add(helloTimeout))
+	defer func() {
+		_ = conn.SetReadDeadline(time.Time{})
+	}()
+
+	var h hello
+	if err := json.NewDecoder(conn).Decode(&h); err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(h.Token), []byte(token)) != 1 {
+		_ = json.NewEncoder(conn).Encode(job{Error: "invalid worker token"})
+		return errors.New("invalid worker token")
+	}
+	return nil
+}
+
+// serveWorker sends j to a worker and records its samples until it is done,
+// passing them to observe (if set) as well. When ctx is canceled, the worker
+// is told to stop, and its run ends normally once it has reported, or after
+// stopTimeout.
+func serveWorker(ctx context.Context, conn net.Conn, j job, recorder *Recorder, observe func(time.Duration, Outcome)) error {
+	addr := conn.RemoteAddr()
+	enc := json.NewEncoder(conn)
+	if err := enc.Encode(j); err != nil {
+		return fmt.Errorf("worker %s: failed to send job: %w", addr, err)
+	}
+	stopWorker := context.AfterFunc(ctx, func() {
+		_ = enc.Encode(stop{Stop: true})
+		_ = conn.SetReadDeadline(time.Now().Add(stopTimeout))
+	})
+	defer stopWorker()
+
+	dec := json.NewDecoder(bufio.NewReader(conn))
+	for {
+		var r report
+		if err := dec.Decode(&r); err != nil {
+			if ctx.Err() != nil {
+				return nil // Stopped: keep the samples reported so far
+			}
+			return fmt.Errorf("worker %s: %w", addr, err)
+		}
+		for _, s := range r.Samples {
+			recorder.Record(s.Latency, s.outcome())
+			if observe != nil {
+				observe(s.Latency, s.outcome())
+			}
+		}
+		if r.Error != "" {
+			return fmt.Errorf("worker %s: %s", addr, r.Error)
+		}
+		if r.Done {
+			return nil
+		}
+	}
+}
+
+// Work joins the controller at addr as a worker presenting token: it waits
+// for a job, runs it with the CallFunc built by newCall, and streams the
+// samples back
+func Work(ctx context.Context, addr, token string, newCall NewCallFunc) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to controller: %w", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	enc := json.NewEncoder(conn)
+	if err := enc.Encode(hello{Token: token}); err != nil {
+		return fmt.Errorf("failed to join controller: %w", err)
+	}
+
+	// The controller sends the job once every worker has joined
+	var j job
+	dec := json.NewDecoder(conn)
+	stopWait := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	err = dec.Decode(&j)
+	if !stopWait() {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to receive job: %w", err)
+	}
+	if j.Error != "" {
+		return fmt.Errorf("rejected by controller: %s", j.Error)
+	}
+
+	call, err := newCall(j.Spec)
+	if err != nil {
+		_ = enc.Encode(report{Error: err.Error()})
+		return err
+	}
+
+	samples := make(chan sample, sampleBatchSize)
+	streamed := make(chan error, 1)
+	go func() {
+		streamed <- streamSamples(enc, samples)
+	}()
+
+	// The controller may stop the run early; losing it does too
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		var s stop
+		_ = dec.Decode(&s)
+		cancel()
+	}()
+
+	opts := j.Options
+	opts.Observe = func(latency time.Duration, out Outcome) {
+		samples <- sample{Latency: latency, Status: out.Status, RequestSize: out.RequestSize, ResponseSize: out.ResponseSize}
+	}
+	Run(runCtx, opts, call)
+	close(samples)
+
+	if err := <-streamed; err != nil {
+		return fmt.Errorf("failed to send samples: %w", err)
+	}
+	return enc.Encode(report{Done: true})
+}
+
+// streamSamples sends samples to the controller in batches until the
+// channel is closed. After a write error it keeps draining the channel so
+// the benchmark is never blocked, and returns the error at the end.
+func streamSamples(enc *json.Encoder, samples <-chan sample) error {
+	ticker := time.NewTicker(sampleFlushInterval)
+	defer ticker.Stop()
+
+	var batch []sample
+	var err error
+	flush := func() {
+		if len(batch) > 0 && err == nil {
+			err = enc.Encode(report{Samples: batch})
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case s, ok := <-samples:
+			if !ok {
+				flush()
+				return err
+			}
+			batch = append(batch, s)
+			if len(batch) >= sampleBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOptions_Split(t *testing.T) {
+	opts := Options{Concurrency: 5, Total: 10, QPS: 90, Duration: time.Second, Burst: 3}
+
+	var total, concurrency int
+	for i := 0; i < 3; i++ {
+		part := opts.Split(3, i)
+		total += part.Total
+		concurrency += part.Concurrency
+		if part.QPS != 30 || part.Duration != time.Second || part.Burst != 3 {
+			t.Errorf("worker %d: qps/duration/burst = %v/%v/%d", i, part.QPS, part.Duration, part.Burst)
+		}
+	}
+	if total != 10 || concurrency != 5 {
+		t.Errorf("split total/concurrency = %d/%d, want 10/5", total, concurrency)
+	}
+	if got := opts.Split(3, 0).Total; got != 4 {
+		t.Errorf("first worker total = %d, want the remainder (4)", got)
+	}
+	if got := (Options{Concurrency: 1}).Split(4, 3).Concurrency; got != 1 {
+		t.Errorf("concurrency = %d, want at least 1", got)
+	}
+}
+
+// testToken is the worker token of the tests
+const testToken = "s3cret"
+
+// startWorkers joins n workers to the controller listening on ln
+func startWorkers(t *testing.T, ln net.Listener, n int, newCall NewCallFunc) <-chan error {
+	t.Helper()
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			errs <- Work(context.Background(), ln.Addr().String(), testToken, newCall)
+		}()
+	}
+	return errs
+}
+
+func TestCoordinate(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	errs := startWorkers(t, ln, 3, func(spec json.RawMessage) (CallFunc, error) {
+		var status string
+		if err := json.Unmarshal(spec, &status); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) Outcome {
+			time.Sleep(time.Millisecond)
+			return Outcome{Status: status, RequestSize: 7, ResponseSize: 42}
+		}, nil
+	})
+
+	s, err := Coordinate(context.Background(), ln, 3, testToken, Options{Concurrency: 6, Total: 1000}, "ok")
+	if err != nil {
+		t.Fatalf("Coordinate failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("worker failed: %v", err)
+		}
+	}
+
+	// Every sample of every worker is aggregated
+	if s.Count != 1000 || s.Statuses["ok"] != 1000 {
+		t.Errorf("count = %d, statuses = %v", s.Count, s.Statuses)
+	}
+	if s.Concurrency != 6 || s.Total != 1000 || len(s.Latencies) != 1000 {
+		t.Errorf("summary = %+v", s)
+	}
+	if s.RequestSize.Mean != 7 || s.ResponseSize.Max != 42 {
+		t.Errorf("sizes = %+v/%+v, want the workers' sizes", s.RequestSize, s.ResponseSize)
+	}
+	if s.Min < time.Millisecond {
+		t.Errorf("min latency %v shorter than the call", s.Min)
+	}
+}
+
+func TestCoordinate_WorkerError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	errs := startWorkers(t, ln, 1, func(spec json.RawMessage) (CallFunc, error) {
+		return nil, errors.New("method not found")
+	})
+
+	_, err = Coordinate(context.Background(), ln, 1, testToken, Options{Total: 10}, nil)
+	if err == nil || !strings.Contains(err.Error(), "method not found") {
+		t.Errorf("Coordinate error = %v, want the worker's error", err)
+	}
+	if err := <-errs; err == nil {
+		t.Error("expected the worker to fail")
+	}
+}
+
+func TestCoordinate_Canceled(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Coordinate(ctx, ln, 2, testToken, Options{Total: 10}, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Coordinate error = %v, want context.Canceled", err)
+	}
+}
+
+func TestCoordinate_CanceledWhileRunning(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	started := make(chan struct{}, 100)
+	errs := startWorkers(t, ln, 2, func(spec json.RawMessage) (CallFunc, error) {
+		return func(ctx context.Context) Outcome {
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			time.Sleep(time.Millisecond)
+			return Outcome{Status: "ok"}
+		}, nil
+	})
+
+	// Without a total or duration, only the cancellation ends the run
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	s, err := Coordinate(ctx, ln, 2, testToken, Options{Concurrency: 4}, nil)
+	if err != nil {
+		t.Fatalf("Coordinate error = %v, want the summary of the samples so far", err)
+	}
+	if s.Count == 0 || s.Statuses["ok"] != s.Count {
+		t.Errorf("count = %d, statuses = %v", s.Count, s.Statuses)
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("worker failed: %v", err)
+		}
+	}
+}
+
+func TestCoordinate_TotalTooSmall(t *testing.T) {
+	if _, err := Coordinate(context.Background(), nil, 3, testToken, Options{Total: 2}, nil); err == nil {
+		t.Error("expected an error when total < workers")
+	}
+}
+
+func TestCoordinate_RejectsWrongToken(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	type result struct {
+		summary *Summary
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		s, err := Coordinate(context.Background(), ln, 1, testToken, Options{Total: 10}, "secret spec")
+		done <- result{s, err}
+	}()
+
+	// The intruder is turned away without the spec, and the run still waits
+	// for a worker with the token
+	err = Work(context.Background(), ln.Addr().String(), "guess", func(spec json.RawMessage) (CallFunc, error) {
+		t.Errorf("intruder received the spec %s", spec)
+		return nil, errors.New("unexpected spec")
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid worker token") {
+		t.Errorf("intruder error = %v, want a rejection", err)
+	}
+
+	errs := startWorkers(t, ln, 1, func(spec json.RawMessage) (CallFunc, error) {
+		return func(ctx context.Context) Outcome { return Outcome{Status: "ok"} }, nil
+	})
+	r := <-done
+	if r.err != nil {
+		t.Fatalf("Coordinate failed: %v", r.err)
+	}
+	if err := <-errs; err != nil {
+		t.Errorf("worker failed: %v", err)
+	}
+	if r.summary.Count != 10 {
+		t.Errorf("count = %d, want 10", r.summary.Count)
+	}
+}
+
+func TestCoordinate_TokenRequired(t *testing.T) {
+	if _, err := Coordinate(context.Background(), nil, 1, "", Options{Total: 10}, nil); err == nil {
+		t.Error("expected an error without a token")
+	}
+}
@@ -0,0 +1,151 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Monitor tracks live statistics of a running benchmark: totals since the
+// start, rates and p99 over a rolling window, and the calls in flight
+type Monitor struct {
+	window   time.Duration
+	start    time.Time
+	inFlight atomic.Int64
+
+	mu     sync.Mutex
+	count  int
+	errors int
+	recent []timedSample // Samples within the window, oldest first
+}
+
+type timedSample struct {
+	at      time.Time
+	latency time.Duration
+	failed  bool
+}
+
+// Snapshot is the state of a Monitor at one point in time
+type Snapshot struct {
+	Elapsed   time.Duration
+	Count     int           // Calls completed since the start
+	Errors    int           // Failed calls since the start
+	InFlight  int           // Calls currently in flight (0 when unknown)
+	QPS       float64       // Completed calls per second over the window
+	ErrorRate float64       // Fraction of failed calls over the window
+	P99       time.Duration // p99 latency over the window
+}
+
+// NewMonitor creates a Monitor with the given rolling window
+func NewMonitor(window time.Duration) *Monitor {
+	return &Monitor{window: window, start: time.Now()}
+}
+
+// Wrap returns call instrumented to count the calls in flight
+func (m *Monitor) Wrap(call CallFunc) CallFunc {
+	return func(ctx context.Context) Outcome {
+		m.inFlight.Add(1)
+		defer m.inFlight.Add(-1)
+		return call(ctx)
+	}
+}
+
+// Observe records a completed call; it matches Options.Observe
+func (m *Monitor) Observe(latency time.Duration, out Outcome) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	failed := out.Status != "ok"
+	m.count++
+	if failed {
+		m.errors++
+	}
+	m.recent = append(m.recent, timedSample{at: time.Now(), latency: latency, failed: failed})
+}
+
+// Snapshot returns the current statistics
+func (m *Monitor) Snapshot() Snapshot {
+	now := time.Now()
+
+	m.mu.Lock()
+	cutoff := now.Add(-m.window)
+	drop := sort.Search(len(m.recent), func(i int) bool { return m.recent[i].at.After(cutoff) })
+	m.recent = m.recent[drop:]
+	s := Snapshot{
+		Elapsed:  now.Sub(m.start),
+		Count:    m.count,
+		Errors:   m.errors,
+		InFlight: int(m.inFlight.Load()),
+	}
+	latencies := make([]time.Duration, 0, len(m.recent))
+	failed := 0
+	for _, sample := range m.recent {
+		latencies = append(latencies, sample.latency)
+		if sample.failed {
+			failed++
+		}
+	}
+	m.mu.Unlock()
+
+	if len(latencies) == 0 {
+		return s
+	}
+	span := min(m.window, s.Elapsed)
+	if span > 0 {
+		s.QPS = float64(len(latencies)) / span.Seconds()
+	}
+	s.ErrorRate = float64(failed) / float64(len(latencies))
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	s.P99 = percentile(latencies, 99)
+	return s
+}
+
+// Lines formats the snapshot for a dashboard. total is the requested number
+// of calls (0 when the run is bound by duration) and window the span of the
+// rolling statistics.
+func (s Snapshot) Lines(total int, window time.Duration) []string {
+	progress := fmt.Sprintf("%d calls", s.Count)
+	if total > 0 {
+		progress = fmt.Sprintf("%d/%d calls (%.0f%%)", s.Count, total, 100*float64(s.Count)/float64(total))
+	}
+	return []string{
+		fmt.Sprintf("Elapsed:    %s, %s", s.Elapsed.Round(100*time.Millisecond), progress),
+		fmt.Sprintf("QPS:        %.1f (last %s)", s.QPS, window),
+		fmt.Sprintf("Error rate: %.1f%% (last %s), %d total", 100*s.ErrorRate, window, s.Errors),
+		fmt.Sprintf("p99:        %s (last %s)", s.P99.Round(time.Microsecond), window),
+		fmt.Sprintf("In flight:  %d", s.InFlight),
+	}
+}
+
+// ShowDashboard redraws the monitor's statistics on w every interval until
+// ctx is done, then erases the dashboard so the final summary replaces it.
+// w should be a terminal that understands ANSI escape sequences.
+func ShowDashboard(ctx context.Context, w io.Writer, m *Monitor, total int, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	drawn := 0
+	erase := func() {
+		if drawn > 0 {
+			// Move to the first line of the previous frame and clear to the end
+			fmt.Fprintf(w, "\x1b[%dF\x1b[J", drawn)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			erase()
+			return
+		case <-ticker.C:
+			lines := m.Snapshot().Lines(total, m.window)
+			erase()
+			fmt.Fprintln(w, strings.Join(lines, "\n"))
+			drawn = len(lines)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMonitor_Snapshot(t *testing.T) {
+	m := NewMonitor(time.Hour)
+	for i := 1; i <= 100; i++ {
+		status := "ok"
+		if i%10 == 0 {
+			status = "unavailable"
+		}
+		m.Observe(time.Duration(i)*time.Millisecond, Outcome{Status: status})
+	}
+
+	s := m.Snapshot()
+	if s.Count != 100 || s.Errors != 10 {
+		t.Errorf("count/errors = %d/%d, want 100/10", s.Count, s.Errors)
+	}
+	if s.ErrorRate != 0.1 {
+		t.Errorf("error rate = %v, want 0.1", s.ErrorRate)
+	}
+	if s.P99 != 99*time.Millisecond {
+		t.Errorf("p99 = %v, want 99ms", s.P99)
+	}
+	if s.QPS <= 0 {
+		t.Errorf("qps = %v, want > 0", s.QPS)
+	}
+}
+
+func TestMonitor_WindowExpires(t *testing.T) {
+	m := NewMonitor(20 * time.Millisecond)
+	m.Observe(time.Second, Outcome{Status: "unavailable"})
+	time.Sleep(30 * time.Millisecond)
+	m.Observe(time.Millisecond, Outcome{Status: "ok"})
+
+	s := m.Snapshot()
+	// Totals keep every call, rolling statistics only the recent ones
+	if s.Count != 2 || s.Errors != 1 {
+		t.Errorf("count/errors = %d/%d, want 2/1", s.Count, s.Errors)
+	}
+	if s.ErrorRate != 0 || s.P99 != time.Millisecond {
+		t.Errorf("rolling error rate/p99 = %v/%v, want 0/1ms", s.ErrorRate, s.P99)
+	}
+}
+
+func TestMonitor_WrapCountsInFlight(t *testing.T) {
+	m := NewMonitor(time.Second)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	call := m.Wrap(func(ctx context.Context) Outcome {
+		started <- struct{}{}
+		<-release
+		return Outcome{Status: "ok"}
+	})
+
+	for i := 0; i < 3; i++ {
+		go call(context.Background())
+		<-started
+	}
+	if got := m.Snapshot().InFlight; got != 3 {
+		t.Errorf("in flight = %d, want 3", got)
+	}
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for m.Snapshot().InFlight != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := m.Snapshot().InFlight; got != 0 {
+		t.Errorf("in flight = %d after completion, want 0", got)
+	}
+}
+
+func TestSnapshot_Lines(t *testing.T) {
+	s := Snapshot{Elapsed: 2 * time.Second, Count: 50, Errors: 2, InFlight: 4, QPS: 25, ErrorRate: 0.04, P99: 12 * time.Millisecond}
+
+	lines := s.Lines(200, 5*time.Second)
+	want := []string{
+		"Elapsed:    2s, 50/200 calls (25%)",
+		"QPS:        25.0 (last 5s)",
+		"Error rate: 4.0% (last 5s), 2 total",
+		"p99:        12ms (last 5s)",
+		"In flight:  4",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+	if got := s.Lines(0, 5*time.Second)[0]; got != "Elapsed:    2s, 50 calls" {
+		t.Errorf("duration-bound progress = %q", got)
+	}
+}
+
+func TestShowDashboard(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, cancel := context.WithTimeout(context.Background(), 35*time.Millisecond)
+	defer cancel()
+
+	ShowDashboard(ctx, &buf, NewMonitor(time.Second), 10, 10*time.Millisecond)
+
+	out := buf.String()
+	if !strings.Contains(out, "0/10 calls") {
+		t.Errorf("dashboard not drawn: %q", out)
+	}
+	// The last frame is erased on exit
+	if !strings.HasSuffix(out, "\x1b[5F\x1b[J") {
+		t.Errorf("dashboard not erased: %q", out)
+	}
+}
@@ -0,0 +1,174 @@
+package bench
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Recorder collects per-call samples from concurrent workers
+type Recorder struct {
+	mu            sync.Mutex
+	latencies     []time.Duration
+	statuses      map[string]int
+	requestSizes  []int
+	responseSizes []int // Successful calls only
+}
+
+// NewRecorder creates an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{statuses: make(map[string]int)}
+}
+
+// Record adds a sample with its latency and outcome. The response size of a
+// failed call is not recorded, as it would skew the sizes towards zero.
+func (r *Recorder) Record(latency time.Duration, out Outcome) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies = append(r.latencies, latency)
+	r.statuses[out.Status]++
+	r.requestSizes = append(r.requestSizes, out.RequestSize)
+	if out.Status == "ok" {
+		r.responseSizes = append(r.responseSizes, out.ResponseSize)
+	}
+}
+
+// Summary is the aggregated result of a benchmark
+type Summary struct {
+	Target      string        // Server address (set by the caller)
+	Call        string        // Fully qualified method, e.g. example.UserService.GetUser (set by the caller)
+	Start       time.Time     // When the run started
+	Concurrency int           // Number of workers (0 for the open model)
+	Total       int           // Requested number of calls (0 = duration bound)
+	Duration    time.Duration // Requested run time (0 = total bound)
+
+	Count        int
+	Errors       int
+	Elapsed      time.Duration
+	RequestedQPS float64 // 0 when unlimited
+	Burst        int
+	ArrivalRate  float64 // Open model arrival rate (0 for the closed model)
+	Arrival      string  // Open model inter-arrival distribution
+	AchievedQPS  float64
+	Min          time.Duration
+	Mean         time.Duration
+	P50          time.Duration `json:"p50"`
+	P90          time.Duration `json:"p90"`
+	P99          time.Duration `json:"p99"`
+	Max          time.Duration
+	Statuses     map[string]int
+	Latencies    []time.Duration // All samples, sorted ascending
+	RequestSize  SizeStats       // Encoded request sizes of all calls
+	ResponseSize SizeStats       // Encoded response sizes of successful calls
+}
+
+// SizeStats summarizes message sizes in bytes
+type SizeStats struct {
+	Count int // Number of sizes summarized
+	Min   int
+	Mean  float64
+	P50   int `json:"p50"`
+	P90   int `json:"p90"`
+	P99   int `json:"p99"`
+	Max   int
+}
+
+// Summarize aggregates the recorded samples
+func (r *Recorder) Summarize(elapsed time.Duration) *Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := &Summary{
+		Count:    len(r.latencies),
+		Elapsed:  elapsed,
+		Statuses: make(map[string]int, len(r.statuses)),
+	}
+	for status, n := range r.statuses {
+		s.Statuses[status] = n
+		if status != "ok" {
+			s.Errors += n
+		}
+	}
+	if s.Count == 0 {
+		return s
+	}
+	if elapsed > 0 {
+		s.AchievedQPS = float64(s.Count) / elapsed.Seconds()
+	}
+
+	sorted := append([]time.Duration(nil), r.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+	s.Min = sorted[0]
+	s.Max = sorted[len(sorted)-1]
+	s.Mean = total / time.Duration(len(sorted))
+	s.P50 = percentile(sorted, 50)
+	s.P90 = percentile(sorted, 90)
+	s.P99 = percentile(sorted, 99)
+	s.Latencies = sorted
+	s.RequestSize = summarizeSizes(r.requestSizes)
+	s.ResponseSize = summarizeSizes(r.responseSizes)
+	return s
+}
+
+// summarizeSizes computes the statistics of a set of sizes
+func summarizeSizes(sizes []int) SizeStats {
+	if len(sizes) == 0 {
+		return SizeStats{}
+	}
+	sorted := append([]int(nil), sizes...)
+	sort.Ints(sorted)
+
+	total := 0
+	for _, size := range sorted {
+		total += size
+	}
+	return SizeStats{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Mean:  float64(total) / float64(len(sorted)),
+		P50:   percentile(sorted, 50),
+		P90:   percentile(sorted, 90),
+		P99:   percentile(sorted, 99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// describe records the requested load of a run started at start
+func (s *Summary) describe(opts Options, start time.Time) {
+	s.Start = start
+	s.Total = opts.Total
+	s.Duration = opts.Duration
+	if opts.ArrivalRate > 0 {
+		s.ArrivalRate = opts.ArrivalRate
+		s.Arrival = opts.Arrival
+		return
+	}
+	s.Concurrency = max(opts.Concurrency, 1)
+	s.RequestedQPS = opts.QPS
+	if opts.QPS > 0 {
+		s.Burst = max(opts.Burst, 1)
+	}
+}
+
+// Percentile returns the nearest-rank percentile of the samples
+// (0 when there are none)
+func (s *Summary) Percentile(p float64) time.Duration {
+	if len(s.Latencies) == 0 {
+		return 0
+	}
+	return percentile(s.Latencies, p)
+}
+
+// percentile returns the nearest-rank percentile of sorted samples: the
+// smallest sample that is greater than or equal to p percent of them
+func percentile[T time.Duration | int](sorted []T, p float64) T {
+	// p*n/100 rather than p/100*n, which is inexact for e.g. p = 7, n = 100
+	rank := int(math.Ceil(p*float64(len(sorted))/100)) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
@@ -0,0 +1,37 @@
+package capture
+
+import (
+	"fmt"
+	"regexp"
+
+	"grpc_client/internal/client"
+	"grpc_client/internal/file"
+)
+
+// Extract evaluates a capture against the JSON output and returns the captured value
+func Extract(c file.Capture, jsonOutput string) (string, error) {
+	val, err := client.EvaluateJSONPath(jsonOutput, c.Path)
+	if err != nil {
+		return "", err
+	}
+
+	if c.Regex == "" {
+		return val, nil
+	}
+
+	re, err := regexp.Compile(c.Regex)
+	if err != nil {
+		return "", fmt.Errorf("invalid regex %q: %w", c.Regex, err)
+	}
+
+	match := re.FindStringSubmatch(val)
+	if match == nil {
+		return "", fmt.Errorf("regex %q did not match %q", c.Regex, val)
+	}
+
+	// Prefer the first capture group, falling back to the whole match
+	if len(match) > 1 {
+		return match[1], nil
+	}
+	return match[0], nil
+}
@@ -0,0 +1,36 @@
+package capture
+
+import (
+	"grpc_client/internal/file"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	jsonOutput := `{"id": "123", "message": "created order id=4711 for user"}`
+
+	tests := []struct {
+		name    string
+		capture file.Capture
+		want    string
+		wantErr bool
+	}{
+		{"Plain path", file.Capture{Path: "id"}, "123", false},
+		{"Regex group", file.Capture{Path: "$.message", Regex: `id=(\d+)`}, "4711", false},
+		{"Regex without group", file.Capture{Path: "$.message", Regex: `\d+`}, "4711", false},
+		{"Regex no match", file.Capture{Path: "$.message", Regex: `uuid=(\w+)`}, "", true},
+		{"Invalid regex", file.Capture{Path: "$.message", Regex: `id=(`}, "", true},
+		{"Missing path", file.Capture{Path: "$.missing", Regex: `(.*)`}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Extract(tt.capture, jsonOutput)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Extract() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Extract() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// BasicAuth returns the Authorization header value for user:password
+// credentials. The password may contain colons and be empty.
+func BasicAuth(credentials string) (string, error) {
+	user, _, ok := strings.Cut(credentials, ":")
+	if !ok || user == "" {
+		return "", fmt.Errorf("invalid basic auth credentials, expected 'user:password'")
+	}
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials)), nil
+}
+
+// HasHeader reports whether headers sets name, ignoring case
+func HasHeader(headers map[string]string, name string) bool {
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetHeader returns the value of name in headers, ignoring case, or "" if
+// it is not set
+func GetHeader(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// SetHeader sets name in headers, replacing any header of the same name in
+// different casing
+func SetHeader(headers map[string]string, name, value string) {
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			delete(headers, k)
+		}
+	}
+	headers[name] = value
+}
+
+// HTTPHeader converts headers to an http.Header with canonical names
+func HTTPHeader(headers map[string]string) http.Header {
+	h := make(http.Header, len(headers))
+	for k, v := range headers {
+		h.Set(k, v)
+	}
+	return h
+}
@@ -0,0 +1,39 @@
+package client
+
+import "testing"
+
+func TestBasicAuth(t *testing.T) {
+	tests := []struct {
+		credentials string
+		want        string
+		wantErr     bool
+	}{
+		{"alice:s3cret", "Basic YWxpY2U6czNjcmV0", false},
+		{"alice:pa:ss", "Basic YWxpY2U6cGE6c3M=", false},
+		{"alice:", "Basic YWxpY2U6", false},
+		{"alice", "", true},
+		{":s3cret", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.credentials, func(t *testing.T) {
+			got, err := BasicAuth(tt.credentials)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BasicAuth error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("BasicAuth = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetHeader(t *testing.T) {
+	headers := map[string]string{"authorization": "Bearer x", "X-Env": "dev"}
+	SetHeader(headers, "Authorization", "Basic y")
+	if len(headers) != 2 || headers["Authorization"] != "Basic y" {
+		t.Errorf("unexpected headers %v", headers)
+	}
+	if !HasHeader(headers, "x-env") || HasHeader(headers, "X-Other") {
+		t.Errorf("HasHeader mismatch for %v", headers)
+	}
+}
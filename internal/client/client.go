@@ -2,13 +2,19 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"connectrpc.com/connect"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/dynamicpb"
@@ -39,37 +45,106 @@ func ParseProtocol(s string) (Protocol, error) {
 
 // Client is a dynamic gRPC client
 type Client struct {
-	address  string
-	prefix   string
-	protocol Protocol
-	headers  map[string]string
-	client   *http.Client
+	address        string
+	prefix         string
+	protocol       Protocol
+	headers        map[string]string
+	client         connect.HTTPClient
+	interceptors   []connect.Interceptor
+	headerProvider HeaderProvider
+	sendGzip       bool
+	verbose        *verboseLog
+	trace          *traceLog
+	tracer         *Tracer
+	har            *HAR
+	retry          *RetryPolicy
 }
 
-// NewClient creates a new dynamic gRPC client
-func NewClient(address, prefix string, protocol Protocol, headers map[string]string) *Client {
-	return &Client{
+// HeaderProvider supplies base headers for each call, e.g. freshly minted
+// auth tokens. Static headers passed to NewClient take precedence.
+type HeaderProvider func(ctx context.Context) (http.Header, error)
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for calls (default: http.DefaultClient),
+// allowing embedders to supply instrumented or custom-configured transports
+func WithHTTPClient(httpClient connect.HTTPClient) Option {
+	return func(c *Client) {
+		c.client = httpClient
+	}
+}
+
+// WithInterceptors adds connect interceptors that wrap every call
+func WithInterceptors(interceptors ...connect.Interceptor) Option {
+	return func(c *Client) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// WithHeaderProvider sets a provider for base headers resolved on every call
+func WithHeaderProvider(provider HeaderProvider) Option {
+	return func(c *Client) {
+		c.headerProvider = provider
+	}
+}
+
+// WithSendGzip compresses request messages with gzip
+func WithSendGzip() Option {
+	return func(c *Client) {
+		c.sendGzip = true
+	}
+}
+
+// NewClient creates a new dynamic gRPC client.
+// The prefix is appended to the path of the address (if any).
+func NewClient(address, prefix string, protocol Protocol, headers map[string]string, opts ...Option) *Client {
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	c := &Client{
 		address:  strings.TrimSuffix(address, "/"),
 		prefix:   strings.TrimSuffix(prefix, "/"),
 		protocol: protocol,
 		headers:  headers,
 		client:   http.DefaultClient,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
-// Call invokes a gRPC method
-func (c *Client) Call(ctx context.Context, method protoreflect.MethodDescriptor, input proto.Message) (proto.Message, error) {
-	// Build the full URL path
-	// gRPC path format: /{package}.{service}/{method}
-	svc := method.Parent().(protoreflect.ServiceDescriptor)
-	path := fmt.Sprintf("/%s/%s", svc.FullName(), method.Name())
+// Response is the outcome of a successful call
+type Response struct {
+	Msg     proto.Message // Decoded response message
+	Header  http.Header   // Response headers
+	Trailer http.Header   // Response trailers
+	Size    int           // Encoded size of the response message in bytes
+
+	// TLS describes the connection the response came over, including the
+	// server's certificate chain (nil for plain HTTP)
+	TLS *tls.ConnectionState
+}
+
+// Call invokes a gRPC method, retrying it as the retry policy of the
+// client allows
+func (c *Client) Call(ctx context.Context, method protoreflect.MethodDescriptor, input proto.Message) (*Response, error) {
+	if c.retry == nil {
+		return c.call(ctx, method, input)
+	}
+	return c.retry.do(ctx, func() (*Response, error) {
+		return c.call(ctx, method, input)
+	})
+}
 
-	// Add prefix if specified
-	fullURL := c.address
-	if c.prefix != "" {
-		fullURL += c.prefix
+// call makes a single attempt of a call
+func (c *Client) call(ctx context.Context, method protoreflect.MethodDescriptor, input proto.Message) (*Response, error) {
+	// Build the full URL
+	fullURL, err := c.MethodURL(method)
+	if err != nil {
+		return nil, err
 	}
-	fullURL += path
 
 	// Create client options based on protocol
 	var opts []connect.ClientOption
@@ -82,40 +157,239 @@ func (c *Client) Call(ctx context.Context, method protoreflect.MethodDescriptor,
 		// Connect is the default, no option needed
 	}
 
+	if len(c.interceptors) > 0 {
+		opts = append(opts, connect.WithInterceptors(c.interceptors...))
+	}
+	if c.sendGzip {
+		opts = append(opts, connect.WithSendGzip())
+	}
+
 	// Create output message factory for dynamic messages
 	outputDesc := method.Output()
 
+	// A Host header overrides the authority, e.g. to reach a virtual host
+	// through an IP address or port-forward
+	httpClient := c.client
+	if c.trace != nil {
+		httpClient = traceClient{HTTPClient: httpClient, log: c.trace, input: method.Input(), output: outputDesc}
+	}
+	if c.har != nil {
+		httpClient = harClient{HTTPClient: httpClient, har: c.har}
+	}
+	if c.verbose != nil {
+		httpClient = verboseClient{HTTPClient: httpClient, log: c.verbose}
+		c.verbose.request(fullURL, proto.Size(input))
+	}
+	if host := GetHeader(c.headers, "Host"); host != "" {
+		httpClient = hostClient{HTTPClient: httpClient, host: host}
+	}
+	recorder := &responseRecorder{HTTPClient: httpClient}
+
 	// Create a dynamic client for this method with a codec that handles dynamic messages
+	codec := &dynamicCodec{outputDesc: outputDesc}
 	client := connect.NewClient[dynamicpb.Message, dynamicpb.Message](
-		c.client,
+		recorder,
 		fullURL,
-		append(opts, connect.WithCodec(&dynamicCodec{outputDesc: outputDesc}))...,
+		append(opts, connect.WithCodec(codec))...,
 	)
 
 	// Create the request
 	req := connect.NewRequest(input.(*dynamicpb.Message))
 
-	// Add headers
+	// Add base headers from the provider, then static headers
+	if c.headerProvider != nil {
+		base, err := c.headerProvider(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve headers: %w", err)
+		}
+		for k, values := range base {
+			for _, v := range values {
+				req.Header().Add(k, v)
+			}
+		}
+	}
 	for k, v := range c.headers {
 		req.Header().Set(k, v)
 	}
 
+	// Record a client span, continuing the trace of a traceparent header
+	var sp *span
+	if c.tracer != nil {
+		var traceparent string
+		sp, traceparent = c.tracer.start(method, fullURL, c.protocol, req.Header().Get("Traceparent"))
+		req.Header().Set("Traceparent", traceparent)
+	}
+
 	// Make the call
 	resp, err := client.CallUnary(ctx, req)
+	if c.verbose != nil {
+		var trailer http.Header
+		if err == nil {
+			trailer = resp.Trailer()
+		}
+		c.verbose.result(trailer, codec.size, err)
+	}
 	if err != nil {
 		var connectErr *connect.Error
 		if errors.As(err, &connectErr) {
-			return nil, fmt.Errorf("gRPC error [%s]: %s", connectErr.Code(), connectErr.Message())
+			header, trailer := splitMetadata(connectErr.Meta(), recorder.header)
+			err = &Error{
+				Code:      connectErr.Code(),
+				Message:   connectErr.Message(),
+				Header:    header,
+				Trailer:   trailer,
+				Transport: isTransportError(connectErr),
+			}
+		}
+	}
+	if sp != nil {
+		sp.end(c.protocol, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		Msg:     resp.Msg,
+		Header:  resp.Header(),
+		Trailer: resp.Trailer(),
+		Size:    codec.size,
+		TLS:     recorder.state,
+	}, nil
+}
+
+// isTransportError reports whether a call failed because the server could
+// not be reached, e.g. the connection was refused or the TLS handshake
+// failed. Statuses sent by the server, and deadlines and cancellations on
+// the client side, are not transport errors.
+func isTransportError(err *connect.Error) bool {
+	if connect.IsWireError(err) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var (
+		netErr       net.Error
+		urlErr       *url.Error
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	return errors.As(err, &netErr) || errors.As(err, &urlErr) ||
+		errors.As(err, &recordErr) || errors.As(err, &alertErr) || errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr)
+}
+
+// hostClient sends requests with a fixed Host header (the HTTP/2
+// :authority), which net/http takes from the request rather than its headers
+type hostClient struct {
+	connect.HTTPClient
+	host string
+}
+
+func (c hostClient) Do(req *http.Request) (*http.Response, error) {
+	req.Host = c.host
+	return c.HTTPClient.Do(req)
+}
+
+// responseRecorder records the TLS state and headers of the last response
+type responseRecorder struct {
+	connect.HTTPClient
+	state  *tls.ConnectionState
+	header http.Header
+}
+
+func (r *responseRecorder) Do(req *http.Request) (*http.Response, error) {
+	resp, err := r.HTTPClient.Do(req)
+	if err == nil {
+		r.state = resp.TLS
+		r.header = resp.Header
+	}
+	return resp, err
+}
+
+// splitMetadata separates the metadata of an error, in which connect merges
+// the response headers and trailers, into the values sent in header (the
+// HTTP response headers) and the trailers that followed
+func splitMetadata(meta, header http.Header) (http.Header, http.Header) {
+	headers, trailers := http.Header{}, http.Header{}
+	for name, values := range meta {
+		sent := header.Values(name)
+		n := 0
+		for n < len(sent) && n < len(values) && sent[n] == values[n] {
+			n++
+		}
+		if n > 0 {
+			headers[name] = values[:n]
 		}
+		if n < len(values) {
+			trailers[name] = values[n:]
+		}
+	}
+	return headers, trailers
+}
+
+// Request is the HTTP request a call sends
+type Request struct {
+	URL    string
+	Header http.Header
+	Body   []byte // Encoded payload, framed as the protocol requires
+}
+
+// errDryRun stops a dry run once its request is recorded
+var errDryRun = errors.New("dry run")
+
+// dryRunTransport records a request instead of sending it
+type dryRunTransport struct {
+	req *Request
+}
+
+func (t *dryRunTransport) Do(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	t.req = &Request{URL: req.URL.String(), Header: req.Header.Clone(), Body: body}
+	return nil, errDryRun
+}
+
+// DryRun builds the request Call would send for method and input, with the
+// same URL, headers, and encoding, without any network activity
+func (c *Client) DryRun(ctx context.Context, method protoreflect.MethodDescriptor, input proto.Message) (*Request, error) {
+	transport := &dryRunTransport{}
+	dry := *c
+	dry.client = transport
+	dry.verbose, dry.trace, dry.tracer, dry.har, dry.retry = nil, nil, nil, nil, nil
+	if _, err := dry.Call(ctx, method, input); transport.req == nil {
 		return nil, err
 	}
+	return transport.req, nil
+}
 
-	return resp.Msg, nil
+// MethodURL returns the URL the client calls method at
+func (c *Client) MethodURL(method protoreflect.MethodDescriptor) (string, error) {
+	svc := method.Parent().(protoreflect.ServiceDescriptor)
+	return c.methodURL(string(svc.FullName()), string(method.Name()))
+}
+
+// methodURL builds the URL for a method by appending the prefix and the gRPC
+// path (/{package}.{service}/{method}) to the address, keeping any query string
+func (c *Client) methodURL(service, method string) (string, error) {
+	u, err := url.Parse(c.address)
+	if err != nil {
+		return "", fmt.Errorf("invalid server address %q: %w", c.address, err)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + c.prefix + "/" + service + "/" + method
+	u.RawPath = ""
+	return u.String(), nil
 }
 
-// dynamicCodec is a custom codec that properly handles dynamic protobuf messages
+// dynamicCodec is a custom codec that properly handles dynamic protobuf messages.
+// It records the size of the last message it decoded (after decompression).
 type dynamicCodec struct {
 	outputDesc protoreflect.MessageDescriptor
+	size       int
 }
 
 func (c *dynamicCodec) Name() string {
@@ -135,6 +409,7 @@ func (c *dynamicCodec) Unmarshal(data []byte, msg any) error {
 	if !ok {
 		return fmt.Errorf("cannot unmarshal: expected *dynamicpb.Message, got %T", msg)
 	}
+	c.size = len(data)
 
 	// Create a new message with the correct descriptor and unmarshal into it
 	newMsg := dynamicpb.NewMessage(c.outputDesc)
@@ -180,16 +455,49 @@ func JSONToProto(jsonData string, msgDesc protoreflect.MessageDescriptor) (proto
 
 // ProtoToJSON converts a protobuf message to pretty-printed JSON
 func ProtoToJSON(msg proto.Message) (string, error) {
+	return JSONOptions{}.Format(msg)
+}
+
+// JSONOptions select how messages are converted to JSON, e.g. to match the
+// JSON another client of the service produces
+type JSONOptions struct {
+	EmitDefaults  bool // Include fields set to their default value, which proto3 JSON omits
+	EnumsAsInts   bool // Write enum values as numbers instead of names
+	UseProtoNames bool // Use the field names of the proto instead of lowerCamelCase
+
+	// Int64AsNumbers writes 64-bit integers as numbers instead of the
+	// strings of proto3 JSON, as many JavaScript clients parse them.
+	// Values beyond ±2^53 - 1 lose precision in such clients; they are
+	// passed to OnPrecisionLoss, if set.
+	Int64AsNumbers  bool
+	OnPrecisionLoss func(value string)
+}
+
+// Format converts a protobuf message to pretty-printed JSON, with its fields
+// in declaration order and map entries sorted by key (see stableJSON)
+func (o JSONOptions) Format(msg proto.Message) (string, error) {
 	marshaler := protojson.MarshalOptions{
-		Multiline:       true,
-		Indent:          "  ",
-		EmitUnpopulated: false,
+		EmitUnpopulated: o.EmitDefaults,
+		UseEnumNumbers:  o.EnumsAsInts,
+		UseProtoNames:   o.UseProtoNames,
 	}
 
 	data, err := marshaler.Marshal(msg)
 	if err != nil {
 		return "", err
 	}
-
+	data, err = stableJSON(data, msg.ProtoReflect().Descriptor(), o)
+	if err != nil {
+		return "", err
+	}
 	return string(data), nil
 }
+
+// ProtoToText converts a protobuf message to multi-line protobuf text format
+func ProtoToText(msg proto.Message) (string, error) {
+	data, err := prototext.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
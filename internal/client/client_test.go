@@ -0,0 +1,417 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const testProto = `syntax = "proto3";
+package test;
+service EchoService {
+  rpc Echo(EchoRequest) returns (EchoResponse);
+}
+message EchoRequest { string text = 1; }
+message EchoResponse { string text = 1; }
+`
+
+// testMethod compiles testProto and returns the Echo method descriptor
+func testMethod(t *testing.T) protoreflect.MethodDescriptor {
+	t.Helper()
+	compiler := protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{"test.proto": testProto}),
+		},
+	}
+	files, err := compiler.Compile(context.Background(), "test.proto")
+	if err != nil {
+		t.Fatalf("failed to compile test proto: %v", err)
+	}
+	return files[0].Services().Get(0).Methods().Get(0)
+}
+
+// recordingTransport records the requests passing through it
+type recordingTransport struct {
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	r.requests = append(r.requests, req)
+	r.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newEchoServer serves an empty Connect unary response and records request headers
+func newEchoServer(t *testing.T) (*httptest.Server, *http.Header) {
+	t.Helper()
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/proto")
+		w.Header().Set("X-Server", "echo")
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+func TestClient_Options(t *testing.T) {
+	method := testMethod(t)
+	srv, gotHeader := newEchoServer(t)
+
+	transport := &recordingTransport{}
+	intercepted := false
+	interceptor := connect.UnaryInterceptorFunc(func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			intercepted = true
+			req.Header().Set("X-Intercepted", "yes")
+			return next(ctx, req)
+		}
+	})
+	provider := func(ctx context.Context) (http.Header, error) {
+		return http.Header{
+			"Authorization": []string{"Bearer provided"},
+			"X-Base":        []string{"base"},
+		}, nil
+	}
+
+	c := NewClient(srv.URL, "/api", ProtocolConnect,
+		map[string]string{"Authorization": "Bearer static"},
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithInterceptors(interceptor),
+		WithHeaderProvider(provider),
+	)
+
+	input, err := JSONToProto(`{"text": "hi"}`, method.Input())
+	if err != nil {
+		t.Fatalf("JSONToProto failed: %v", err)
+	}
+	resp, err := c.Call(context.Background(), method, input)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	if len(transport.requests) != 1 {
+		t.Fatalf("expected custom HTTP client to send 1 request, got %d", len(transport.requests))
+	}
+	if path := transport.requests[0].URL.Path; path != "/api/test.EchoService/Echo" {
+		t.Errorf("request path = %q", path)
+	}
+	if !intercepted || gotHeader.Get("X-Intercepted") != "yes" {
+		t.Errorf("interceptor was not applied")
+	}
+	if gotHeader.Get("X-Base") != "base" {
+		t.Errorf("provider header missing: %v", *gotHeader)
+	}
+	if auth := gotHeader.Get("Authorization"); auth != "Bearer static" {
+		t.Errorf("static headers should override provider headers, got Authorization %q", auth)
+	}
+	if resp.Header.Get("X-Server") != "echo" {
+		t.Errorf("response headers missing: %v", resp.Header)
+	}
+}
+
+func TestClient_HeaderProviderError(t *testing.T) {
+	method := testMethod(t)
+	srv, _ := newEchoServer(t)
+
+	c := NewClient(srv.URL, "", ProtocolConnect, nil,
+		WithHeaderProvider(func(ctx context.Context) (http.Header, error) {
+			return nil, context.DeadlineExceeded
+		}),
+	)
+
+	input, _ := JSONToProto(`{}`, method.Input())
+	if _, err := c.Call(context.Background(), method, input); err == nil {
+		t.Error("expected error from header provider")
+	}
+}
+
+func TestClient_ErrorTransport(t *testing.T) {
+	method := testMethod(t)
+	input, _ := JSONToProto(`{}`, method.Input())
+
+	// The server answers with an error status
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"code": "not_found", "message": "no user"}`))
+	}))
+	t.Cleanup(srv.Close)
+	_, err := NewClient(srv.URL, "", ProtocolConnect, nil).Call(context.Background(), method, input)
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != connect.CodeNotFound || rpcErr.Transport {
+		t.Errorf("server error = %#v, want a not_found status from the server", err)
+	}
+
+	// Nothing answers
+	srv.Close()
+	_, err = NewClient(srv.URL, "", ProtocolConnect, nil).Call(context.Background(), method, input)
+	if !errors.As(err, &rpcErr) || !rpcErr.Transport {
+		t.Errorf("connection error = %#v, want a transport error", err)
+	}
+}
+
+func TestClient_ErrorTrailers(t *testing.T) {
+	method := testMethod(t)
+	input, _ := JSONToProto(`{}`, method.Input())
+
+	// Connect unary responses send trailers as Trailer- prefixed headers
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Header", "h")
+		w.Header().Set("X-Both", "h")
+		w.Header().Set("Trailer-X-Trailer", "t")
+		w.Header().Set("Trailer-X-Both", "t")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"code": "not_found", "message": "no user"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	_, err := NewClient(srv.URL, "", ProtocolConnect, nil).Call(context.Background(), method, input)
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("err = %#v, want an RPC error", err)
+	}
+	if got := rpcErr.Header; got.Get("X-Header") != "h" || got.Get("X-Trailer") != "" || len(got.Values("X-Both")) != 1 || got.Get("X-Both") != "h" {
+		t.Errorf("Header = %v, want only the response headers", got)
+	}
+	if got := rpcErr.Trailer; got.Get("X-Trailer") != "t" || got.Get("X-Header") != "" || len(got.Values("X-Both")) != 1 || got.Get("X-Both") != "t" {
+		t.Errorf("Trailer = %v, want only the response trailers", got)
+	}
+}
+
+func TestClient_LocalDeadlineIsNotTransport(t *testing.T) {
+	method := testMethod(t)
+	input, _ := JSONToProto(`{}`, method.Input())
+
+	// The server is reached but answers too late
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := NewClient(srv.URL, "", ProtocolConnect, nil).Call(ctx, method, input)
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != connect.CodeDeadlineExceeded || rpcErr.Transport {
+		t.Errorf("deadline error = %#v, want a deadline_exceeded status that is not a transport error", err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewClient(srv.URL, "", ProtocolConnect, nil).Call(canceled, method, input)
+	if !errors.As(err, &rpcErr) || rpcErr.Code != connect.CodeCanceled || rpcErr.Transport {
+		t.Errorf("cancel error = %#v, want a canceled status that is not a transport error", err)
+	}
+}
+
+func TestClient_ResponseSize(t *testing.T) {
+	method := testMethod(t)
+	body, err := JSONToProto(`{"text": "hello"}`, method.Output())
+	if err != nil {
+		t.Fatalf("JSONToProto failed: %v", err)
+	}
+	encoded, err := proto.Marshal(body)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/proto")
+		w.Write(encoded)
+	}))
+	t.Cleanup(srv.Close)
+
+	input, _ := JSONToProto(`{}`, method.Input())
+	resp, err := NewClient(srv.URL, "", ProtocolConnect, nil).Call(context.Background(), method, input)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if resp.Size != len(encoded) {
+		t.Errorf("Size = %d, want %d", resp.Size, len(encoded))
+	}
+}
+
+func TestClient_HostHeader(t *testing.T) {
+	method := testMethod(t)
+	var host string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		w.Header().Set("Content-Type", "application/proto")
+	}))
+	t.Cleanup(srv.Close)
+
+	input, _ := JSONToProto(`{}`, method.Input())
+	c := NewClient(srv.URL, "", ProtocolConnect, map[string]string{"host": "api.example.com"})
+	if _, err := c.Call(context.Background(), method, input); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if host != "api.example.com" {
+		t.Errorf("Host = %q, want api.example.com", host)
+	}
+}
+
+func TestClient_TLSState(t *testing.T) {
+	method := testMethod(t)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/proto")
+	}))
+	t.Cleanup(srv.Close)
+
+	input, _ := JSONToProto(`{}`, method.Input())
+	resp, err := NewClient(srv.URL, "", ProtocolConnect, nil, WithHTTPClient(srv.Client())).Call(context.Background(), method, input)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 || !resp.TLS.PeerCertificates[0].Equal(srv.Certificate()) {
+		t.Errorf("expected the server certificate in the TLS state, got %+v", resp.TLS)
+	}
+}
+
+func TestClient_DryRun(t *testing.T) {
+	method := testMethod(t)
+	input, err := JSONToProto(`{"text": "hello"}`, method.Input())
+	if err != nil {
+		t.Fatalf("JSONToProto failed: %v", err)
+	}
+	encoded, err := proto.Marshal(input)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	// The address is unroutable, so any network activity would fail the call
+	c := NewClient("http://192.0.2.1:1/base", "/api", ProtocolGRPCWeb, map[string]string{"Authorization": "Bearer t"})
+	req, err := c.DryRun(context.Background(), method, input)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+
+	if want := "http://192.0.2.1:1/base/api/test.EchoService/Echo"; req.URL != want {
+		t.Errorf("URL = %q, want %q", req.URL, want)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer t" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer t")
+	}
+	if got := req.Header.Get("Content-Type"); got != "application/grpc-web+proto" {
+		t.Errorf("Content-Type = %q, want application/grpc-web+proto", got)
+	}
+	// gRPC-Web frames the message with a flag byte and a 4-byte length
+	if len(req.Body) != 5+len(encoded) || string(req.Body[5:]) != string(encoded) {
+		t.Errorf("Body = %x, want framed %x", req.Body, encoded)
+	}
+}
+
+func TestJSONOptions(t *testing.T) {
+	compiler := protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{"user.proto": `syntax = "proto3";
+package test;
+enum Role { ROLE_UNSPECIFIED = 0; ROLE_ADMIN = 1; }
+message User { string user_id = 1; Role role = 2; int32 login_count = 3; }
+`}),
+		},
+	}
+	files, err := compiler.Compile(context.Background(), "user.proto")
+	if err != nil {
+		t.Fatalf("failed to compile test proto: %v", err)
+	}
+	desc := files[0].Messages().Get(0)
+	msg := dynamicpb.NewMessage(desc)
+	msg.Set(desc.Fields().ByName("user_id"), protoreflect.ValueOfString("42"))
+	msg.Set(desc.Fields().ByName("role"), protoreflect.ValueOfEnum(1))
+
+	tests := []struct {
+		opts JSONOptions
+		want map[string]interface{}
+	}{
+		{JSONOptions{}, map[string]interface{}{"userId": "42", "role": "ROLE_ADMIN"}},
+		{JSONOptions{EmitDefaults: true}, map[string]interface{}{"userId": "42", "role": "ROLE_ADMIN", "loginCount": 0.0}},
+		{JSONOptions{EnumsAsInts: true}, map[string]interface{}{"userId": "42", "role": 1.0}},
+		{JSONOptions{UseProtoNames: true}, map[string]interface{}{"user_id": "42", "role": "ROLE_ADMIN"}},
+	}
+	for _, tt := range tests {
+		out, err := tt.opts.Format(msg)
+		if err != nil {
+			t.Fatalf("%+v: %v", tt.opts, err)
+		}
+		var got map[string]interface{}
+		if err := json.Unmarshal([]byte(out), &got); err != nil {
+			t.Fatalf("%+v: invalid JSON %q: %v", tt.opts, out, err)
+		}
+		if !maps.Equal(got, tt.want) {
+			t.Errorf("%+v: JSON = %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestJSONOptions_Int64AsNumbers(t *testing.T) {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{"account.proto": `syntax = "proto3";
+package test;
+import "google/protobuf/wrappers.proto";
+message Account {
+  int64 id = 1;
+  string name = 2;
+  repeated uint64 ids = 3;
+  map<string, sint64> balances = 4;
+  Account parent = 5;
+  google.protobuf.Int64Value limit = 6;
+}
+`}),
+		}),
+	}
+	files, err := compiler.Compile(context.Background(), "account.proto")
+	if err != nil {
+		t.Fatalf("failed to compile test proto: %v", err)
+	}
+	msg := dynamicpb.NewMessageType(files[0].Messages().Get(0)).New().Interface()
+	err = protojson.Unmarshal([]byte(`{"id": "9007199254740993", "name": "12", "ids": ["1", "2"],
+		"balances": {"usd": "-5"}, "parent": {"id": "7"}, "limit": "100"}`), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lossy []string
+	out, err := JSONOptions{Int64AsNumbers: true, OnPrecisionLoss: func(v string) { lossy = append(lossy, v) }}.Format(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "id": 9007199254740993,
+  "name": "12",
+  "ids": [
+    1,
+    2
+  ],
+  "balances": {
+    "usd": -5
+  },
+  "parent": {
+    "id": 7
+  },
+  "limit": 100
+}`
+	if out != want {
+		t.Errorf("JSON = %s, want %s", out, want)
+	}
+	if len(lossy) != 1 || lossy[0] != "9007199254740993" {
+		t.Errorf("precision loss reported for %v", lossy)
+	}
+}
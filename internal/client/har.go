@@ -0,0 +1,288 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"maps"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"connectrpc.com/connect"
+)
+
+// WithHAR records the HTTP exchange of every call in h
+func WithHAR(h *HAR) Option {
+	return func(c *Client) {
+		c.har = h
+	}
+}
+
+// HAR records HTTP exchanges for export in the HTTP Archive format, which
+// browser devtools and HTTP tooling import. It is safe for concurrent use.
+type HAR struct {
+	maskHeader func(http.Header) http.Header
+	maskBody   func([]byte) []byte
+
+	mu      sync.Mutex
+	entries []harEntry
+}
+
+// NewHAR creates an empty HAR. When it is written, headers are passed
+// through maskHeader and bodies through maskBody, if not nil, e.g. to
+// redact credentials and the secrets learnt during a run.
+func NewHAR(maskHeader func(http.Header) http.Header, maskBody func([]byte) []byte) *HAR {
+	return &HAR{maskHeader: maskHeader, maskBody: maskBody}
+}
+
+// Len returns the number of exchanges recorded
+func (h *HAR) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries)
+}
+
+// Write writes the recorded exchanges to w as a HAR 1.2 document, in the
+// order they started
+func (h *HAR) Write(w io.Writer) error {
+	h.mu.Lock()
+	entries := slices.Clone(h.entries)
+	h.mu.Unlock()
+	slices.SortStableFunc(entries, func(a, b harEntry) int { return a.Started.Compare(b.Started) })
+	if entries == nil {
+		entries = []harEntry{}
+	}
+	for i := range entries {
+		h.mask(&entries[i])
+	}
+
+	version := "(devel)"
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		version = info.Main.Version
+	}
+	doc := harDocument{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "grpc_client", Version: version},
+		Entries: entries,
+	}}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+func (h *HAR) add(e harEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, e)
+}
+
+// mask fills in the headers and bodies of an entry from the recorded
+// exchange, masked
+func (h *HAR) mask(e *harEntry) {
+	e.Request.Headers = h.headers(e.raw.reqHeader)
+	if e.raw.reqBody != nil {
+		text, encoding := harText(h.body(e.raw.reqBody))
+		e.Request.PostData = &harPostData{MimeType: e.raw.reqHeader.Get("Content-Type"), Text: text, Encoding: encoding}
+	}
+	if e.raw.respHeader == nil {
+		return // The call failed before a response
+	}
+	e.Response.Headers = h.headers(e.raw.respHeader)
+	e.Response.Content.Text, e.Response.Content.Encoding = harText(h.body(e.raw.respBody))
+	// gRPC sends its status in HTTP trailers, which HAR has no place for
+	if len(e.raw.respTrailer) > 0 {
+		e.Response.Trailers = h.headers(e.raw.respTrailer)
+	}
+}
+
+// body masks a body
+func (h *HAR) body(b []byte) []byte {
+	if h.maskBody == nil {
+		return b
+	}
+	return h.maskBody(slices.Clone(b))
+}
+
+// headers converts headers to HAR name/value pairs sorted by name
+func (h *HAR) headers(header http.Header) []harPair {
+	if h.maskHeader != nil {
+		header = h.maskHeader(header)
+	}
+	pairs := []harPair{}
+	for _, name := range slices.Sorted(maps.Keys(header)) {
+		for _, v := range header[name] {
+			pairs = append(pairs, harPair{Name: name, Value: v})
+		}
+	}
+	return pairs
+}
+
+// harClient records the HTTP request and response of a call
+type harClient struct {
+	connect.HTTPClient
+	har *HAR
+}
+
+func (c harClient) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	header := req.Header.Clone()
+	if req.Host != "" && req.Host != req.URL.Host {
+		header.Set("Host", req.Host)
+	}
+	entry := harEntry{
+		Started: time.Now(),
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     []harPair{},
+			QueryString: []harPair{},
+			HeadersSize: -1,
+			BodySize:    len(body),
+		},
+		Cache: struct{}{},
+	}
+	entry.raw.reqHeader, entry.raw.reqBody = header, body
+	for name, values := range req.URL.Query() {
+		for _, v := range values {
+			entry.Request.QueryString = append(entry.Request.QueryString, harPair{Name: name, Value: v})
+		}
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	wait := time.Since(entry.Started)
+	if err != nil {
+		// The exchange is recorded without a response, as browsers do
+		entry.Response = harResponse{StatusText: err.Error(), Cookies: []harPair{}, Headers: []harPair{}, HeadersSize: -1, BodySize: -1}
+		entry.Time = milliseconds(wait)
+		entry.Timings = harTimings{Wait: milliseconds(wait)}
+		c.har.add(entry)
+		return resp, err
+	}
+	resp.Body = &tracedBody{ReadCloser: resp.Body, done: func(body []byte) {
+		total := time.Since(entry.Started)
+		entry.Response = harResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Cookies:     []harPair{},
+			Content:     harContent{Size: len(body), MimeType: resp.Header.Get("Content-Type")},
+			HeadersSize: -1,
+			BodySize:    len(body),
+		}
+		entry.raw.respHeader, entry.raw.respTrailer = resp.Header.Clone(), resp.Trailer.Clone()
+		entry.raw.respBody = slices.Clone(body)
+		entry.Time = milliseconds(total)
+		entry.Timings = harTimings{Wait: milliseconds(wait), Receive: milliseconds(total - wait)}
+		c.har.add(entry)
+	}}
+	return resp, nil
+}
+
+// harText returns a body as HAR text: as is when it is UTF-8 text, and
+// base64-encoded otherwise, e.g. protobuf messages
+func harText(body []byte) (text, encoding string) {
+	binary := !utf8.Valid(body) || bytes.ContainsFunc(body, func(r rune) bool {
+		return unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r'
+	})
+	if binary {
+		return base64.StdEncoding.EncodeToString(body), "base64"
+	}
+	return string(body), ""
+}
+
+// milliseconds converts a duration to HAR's fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// The HAR 1.2 format, see http://www.softwareishard.com/blog/har-12-spec/.
+// Fields that do not apply to gRPC calls are written empty, as required.
+type (
+	harDocument struct {
+		Log harLog `json:"log"`
+	}
+	harLog struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	}
+	harCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	harEntry struct {
+		Started  time.Time   `json:"startedDateTime"`
+		Time     float64     `json:"time"`
+		Request  harRequest  `json:"request"`
+		Response harResponse `json:"response"`
+		Cache    struct{}    `json:"cache"`
+		Timings  harTimings  `json:"timings"`
+
+		// raw is the exchange as recorded, masked into the fields above
+		// when written
+		raw struct {
+			reqHeader, respHeader, respTrailer http.Header
+			reqBody, respBody                  []byte
+		}
+	}
+	harRequest struct {
+		Method      string       `json:"method"`
+		URL         string       `json:"url"`
+		HTTPVersion string       `json:"httpVersion"`
+		Cookies     []harPair    `json:"cookies"`
+		Headers     []harPair    `json:"headers"`
+		QueryString []harPair    `json:"queryString"`
+		PostData    *harPostData `json:"postData,omitempty"`
+		HeadersSize int          `json:"headersSize"`
+		BodySize    int          `json:"bodySize"`
+	}
+	harPostData struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+		Encoding string `json:"encoding,omitempty"` // Not in HAR 1.2, but widely read
+	}
+	harResponse struct {
+		Status      int        `json:"status"`
+		StatusText  string     `json:"statusText"`
+		HTTPVersion string     `json:"httpVersion"`
+		Cookies     []harPair  `json:"cookies"`
+		Headers     []harPair  `json:"headers"`
+		Trailers    []harPair  `json:"_trailers,omitempty"` // Custom fields start with _
+		Content     harContent `json:"content"`
+		RedirectURL string     `json:"redirectURL"`
+		HeadersSize int        `json:"headersSize"`
+		BodySize    int        `json:"bodySize"`
+	}
+	harContent struct {
+		Size     int    `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text,omitempty"`
+		Encoding string `json:"encoding,omitempty"`
+	}
+	harPair struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	harTimings struct {
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+	}
+)
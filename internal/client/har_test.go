@@ -0,0 +1,84 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestClient_HAR(t *testing.T) {
+	method := testMethod(t)
+	srv, _ := newEchoServer(t)
+
+	mask := func(h http.Header) http.Header {
+		h = h.Clone()
+		if h.Get("Authorization") != "" {
+			h.Set("Authorization", "***")
+		}
+		return h
+	}
+	har := NewHAR(mask, func(b []byte) []byte { return bytes.ReplaceAll(b, []byte("hello"), []byte("*****")) })
+	headers := map[string]string{"Authorization": "Bearer secret", "Host": "api.internal"}
+	c := NewClient(srv.URL, "", ProtocolConnect, headers, WithHAR(har))
+	input := dynamicpb.NewMessage(method.Input())
+	input.Set(method.Input().Fields().ByName("text"), protoreflect.ValueOfString("hello"))
+	if _, err := c.Call(context.Background(), method, input); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	// A call that never reaches a server is recorded without a response
+	_, _ = NewClient("http://127.0.0.1:1", "", ProtocolConnect, nil, WithHAR(har)).Call(context.Background(), method, input)
+
+	var buf bytes.Buffer
+	if err := har.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var doc harDocument
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid HAR: %v\n%s", err, buf.String())
+	}
+	if doc.Log.Version != "1.2" || doc.Log.Creator.Name != "grpc_client" || len(doc.Log.Entries) != 2 {
+		t.Fatalf("unexpected log %+v", doc.Log)
+	}
+
+	e := doc.Log.Entries[0]
+	if e.Request.Method != "POST" || e.Request.URL != srv.URL+"/test.EchoService/Echo" {
+		t.Errorf("unexpected request %s %s", e.Request.Method, e.Request.URL)
+	}
+	got := map[string]string{}
+	for _, h := range e.Request.Headers {
+		got[h.Name] = h.Value
+	}
+	if got["Authorization"] != "***" || got["Host"] != "api.internal" {
+		t.Errorf("expected masked credentials and the Host header, got %v", got)
+	}
+	// Binary protobuf bodies are base64-encoded, and masked first
+	if pd := e.Request.PostData; pd == nil || pd.Encoding != "base64" || pd.Text != base64.StdEncoding.EncodeToString([]byte("\n\x05*****")) || e.Request.BodySize != 7 {
+		t.Errorf("unexpected post data %+v", pd)
+	}
+	if e.Response.Status != 200 || e.Response.StatusText != "OK" || e.Response.Content.Size != 0 {
+		t.Errorf("unexpected response %+v", e.Response)
+	}
+	if e.Time <= 0 || e.Time < e.Timings.Wait {
+		t.Errorf("unexpected timings %v: %+v", e.Time, e.Timings)
+	}
+
+	failed := doc.Log.Entries[1]
+	if failed.Response.Status != 0 || failed.Response.StatusText == "" {
+		t.Errorf("expected the failure as the status text, got %+v", failed.Response)
+	}
+}
+
+func TestHARText(t *testing.T) {
+	if text, encoding := harText([]byte(`{"text":"hello"}`)); text != `{"text":"hello"}` || encoding != "" {
+		t.Errorf("expected JSON as text, got %q (%s)", text, encoding)
+	}
+	if text, encoding := harText([]byte{0, 0, 0, 0, 2, 8, 1}); text != "AAAAAAIIAQ==" || encoding != "base64" {
+		t.Errorf("expected a framed message as base64, got %q (%s)", text, encoding)
+	}
+}
@@ -0,0 +1,233 @@
+package client
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// maxSafeInteger is the largest integer a JavaScript number (a float64)
+// represents exactly, 2^53 - 1
+const maxSafeInteger = 1<<53 - 1
+
+// stableJSON rewrites data, the proto3 JSON form of a message of type md,
+// in a stable form: the fields of messages in the order of their
+// declaration, map entries sorted by key, and two-space indentation.
+// protojson makes no ordering promises and varies its whitespace between
+// builds on purpose, which would make runs and response files hard to diff.
+func stableJSON(data []byte, md protoreflect.MessageDescriptor, o JSONOptions) ([]byte, error) {
+	r := &jsonRewriter{int64s: o.Int64AsNumbers, lossy: o.OnPrecisionLoss}
+	compact, err := r.message(data, md)
+	if err != nil {
+		return nil, err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, compact, "", "  "); err != nil {
+		return nil, err
+	}
+	return indented.Bytes(), nil
+}
+
+// jsonRewriter rewrites the JSON of a message: see stableJSON. With int64s,
+// 64-bit integers, which proto3 JSON writes as strings, are written as
+// numbers, and lossy is called with every value beyond ±2^53 - 1.
+type jsonRewriter struct {
+	int64s bool
+	lossy  func(string)
+}
+
+// member is a member of a JSON object
+type member struct {
+	key   string
+	value json.RawMessage
+	rank  int // Position of a message member: the index of its field
+}
+
+// message rewrites an object encoding a message of type md
+func (r *jsonRewriter) message(raw json.RawMessage, md protoreflect.MessageDescriptor) ([]byte, error) {
+	switch md.FullName() {
+	case "google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		// Wrappers are written as their value
+		return r.integer(raw), nil
+	}
+	if md.FullName().Parent() == "google.protobuf" {
+		// Other well-known types have their own JSON forms
+		return raw, nil
+	}
+	members, err := objectMembers(raw)
+	if err != nil {
+		return nil, err
+	}
+	fields := md.Fields()
+	for i, m := range members {
+		fd := fields.ByJSONName(m.key)
+		if fd == nil {
+			fd = fields.ByName(protoreflect.Name(m.key))
+		}
+		if fd == nil {
+			// Extensions and unknown keys follow the fields, as written
+			members[i].rank = fields.Len() + i
+			continue
+		}
+		members[i].rank = fd.Index()
+		if members[i].value, err = r.field(m.value, fd); err != nil {
+			return nil, err
+		}
+	}
+	slices.SortStableFunc(members, func(a, b member) int { return a.rank - b.rank })
+	return writeObject(members), nil
+}
+
+// field rewrites the value of a field
+func (r *jsonRewriter) field(raw json.RawMessage, fd protoreflect.FieldDescriptor) ([]byte, error) {
+	switch {
+	case isNull(raw):
+		return raw, nil
+	case fd.IsMap():
+		members, err := objectMembers(raw)
+		if err != nil {
+			return nil, err
+		}
+		for i, m := range members {
+			if members[i].value, err = r.single(m.value, fd.MapValue()); err != nil {
+				return nil, err
+			}
+		}
+		slices.SortStableFunc(members, func(a, b member) int { return compareMapKeys(a.key, b.key, fd.MapKey()) })
+		return writeObject(members), nil
+	case fd.IsList():
+		var elems []json.RawMessage
+		if err := json.Unmarshal(raw, &elems); err != nil {
+			return nil, fmt.Errorf("invalid JSON for field %s: %w", fd.Name(), err)
+		}
+		var buf bytes.Buffer
+		buf.WriteByte('[')
+		for i, elem := range elems {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			rewritten, err := r.single(elem, fd)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(rewritten)
+		}
+		buf.WriteByte(']')
+		return buf.Bytes(), nil
+	}
+	return r.single(raw, fd)
+}
+
+// single rewrites one value of a field, an element of a list, or a value of
+// a map
+func (r *jsonRewriter) single(raw json.RawMessage, fd protoreflect.FieldDescriptor) ([]byte, error) {
+	switch fd.Kind() {
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if r.int64s {
+			return r.integer(raw), nil
+		}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if isNull(raw) {
+			return raw, nil
+		}
+		return r.message(raw, fd.Message())
+	}
+	return raw, nil
+}
+
+// integer returns the number of a JSON string holding an integer, or raw
+// when it holds anything else (or 64-bit integers are left as strings)
+func (r *jsonRewriter) integer(raw json.RawMessage) []byte {
+	var s string
+	if !r.int64s || json.Unmarshal(raw, &s) != nil {
+		return raw
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if (n > maxSafeInteger || n < -maxSafeInteger) && r.lossy != nil {
+			r.lossy(s)
+		}
+		return []byte(s)
+	}
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+		if u > maxSafeInteger && r.lossy != nil {
+			r.lossy(s)
+		}
+		return []byte(s)
+	}
+	return raw
+}
+
+// compareMapKeys orders the keys of a map: numerically for integer keys,
+// false before true for bool keys, and by bytes otherwise
+func compareMapKeys(a, b string, fd protoreflect.FieldDescriptor) int {
+	switch fd.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		x, xerr := strconv.ParseInt(a, 10, 64)
+		y, yerr := strconv.ParseInt(b, 10, 64)
+		if xerr == nil && yerr == nil {
+			return cmp.Compare(x, y)
+		}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		x, xerr := strconv.ParseUint(a, 10, 64)
+		y, yerr := strconv.ParseUint(b, 10, 64)
+		if xerr == nil && yerr == nil {
+			return cmp.Compare(x, y)
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// objectMembers returns the members of a JSON object, in order
+func objectMembers(raw json.RawMessage) ([]member, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("invalid JSON object: %s", raw)
+	}
+	var members []member
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON object: %w", err)
+		}
+		m := member{key: tok.(string), rank: len(members)}
+		if err := dec.Decode(&m.value); err != nil {
+			return nil, fmt.Errorf("invalid JSON object: %w", err)
+		}
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+// writeObject encodes members as a JSON object
+func writeObject(members []member) []byte {
+	var buf bytes.Buffer
+	// Keys are written like protojson writes them, without escaping HTML
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		_ = enc.Encode(m.key)
+		buf.Truncate(buf.Len() - 1) // Encode ends with a newline
+		buf.WriteByte(':')
+		buf.Write(m.value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+// isNull reports whether raw is the JSON null
+func isNull(raw json.RawMessage) bool {
+	return string(bytes.TrimSpace(raw)) == "null"
+}
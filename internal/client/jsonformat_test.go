@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+)
+
+func TestStableJSON(t *testing.T) {
+	compiler := protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{"order.proto": `syntax = "proto3";
+package test;
+message Item { string sku = 1; int32 qty = 2; }
+message Order {
+  string id = 1;
+  repeated Item items = 2;
+  map<int32, string> notes = 3;
+  map<string, Item> by_sku = 4;
+}
+`}),
+		},
+	}
+	files, err := compiler.Compile(context.Background(), "order.proto")
+	if err != nil {
+		t.Fatalf("failed to compile test proto: %v", err)
+	}
+	md := files[0].Messages().ByName("Order")
+
+	// Members out of declaration order, map keys unsorted, and uneven spacing
+	data := `{"bySku":  {"b<c": {"qty": 1, "sku": "b"}, "a": {"sku": "a"}},
+		"notes": {"10": "ten", "9": "nine"}, "items": [{"qty": 2,"sku": "x"}], "id": "o-1"}`
+	got, err := stableJSON([]byte(data), md, JSONOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "id": "o-1",
+  "items": [
+    {
+      "sku": "x",
+      "qty": 2
+    }
+  ],
+  "notes": {
+    "9": "nine",
+    "10": "ten"
+  },
+  "bySku": {
+    "a": {
+      "sku": "a"
+    },
+    "b<c": {
+      "sku": "b",
+      "qty": 1
+    }
+  }
+}`
+	if string(got) != want {
+		t.Errorf("JSON = %s, want %s", got, want)
+	}
+}
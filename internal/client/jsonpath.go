@@ -2,117 +2,463 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 )
 
+// ErrPathNotFound is returned (wrapped) when a key in the path does not exist
+var ErrPathNotFound = errors.New("not found")
+
 // EvaluateJSONPath extracts a value from a JSON string using a simple path syntax.
 // Supported syntax:
 // - Dot notation: user.details.name
 // - Array indexing: users[0].id
+// - Bracket keys: user['first-name']
+// - Filters: users[?(@.role=='admin')].id (see parseFilter)
+// - Wildcards: items[*].id, user.*
+// - Recursive descent: $..name (every name key at any depth)
+// - Root selector: $ (the whole document)
+//
+// Objects and arrays are returned as compact JSON so they can be substituted
+// verbatim into a subsequent request body. A path with a filter, wildcard, or
+// recursive descent returns its single match, or a JSON array when it matches
+// several values.
 func EvaluateJSONPath(jsonStr string, path string) (string, error) {
-	var data interface{}
-	if err := json.Unmarshal([]byte(jsonStr), &data); err != nil {
-		return "", fmt.Errorf("invalid JSON response: %w", err)
+	result, err := EvaluateJSONPathValue(jsonStr, path)
+	if err != nil {
+		return "", err
+	}
+	return FormatJSONValue(result)
+}
+
+// FormatJSONValue converts a decoded JSON value to the string form
+// EvaluateJSONPath returns
+func FormatJSONValue(v interface{}) (string, error) {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode value: %w", err)
+		}
+		return string(encoded), nil
 	}
+	return fmt.Sprintf("%v", v), nil
+}
 
-	result, err := evaluatePath(data, path)
+// EvaluateJSONPathValue is like EvaluateJSONPath but returns the decoded
+// value (map[string]interface{}, []interface{}, string, float64, bool, or
+// nil), so callers can tell a string "1" from the number 1
+func EvaluateJSONPathValue(jsonStr string, path string) (interface{}, error) {
+	matches, definite, err := EvaluateJSONPathMatches(jsonStr, path)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	switch {
+	case definite:
+		return matches[0], nil
+	case len(matches) == 0:
+		return nil, fmt.Errorf("no match for '%s': %w", path, ErrPathNotFound)
+	case len(matches) == 1:
+		return matches[0], nil
+	}
+	return matches, nil
+}
 
-	// Convert result to string
-	return fmt.Sprintf("%v", result), nil
+// EvaluateJSONPathMatches returns every value the path selects, and whether
+// the path is definite. A definite path (one without filters, wildcards, or
+// recursive descent) selects exactly one value or fails; other paths select
+// any number of values, including none.
+func EvaluateJSONPathMatches(jsonStr string, path string) ([]interface{}, bool, error) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(jsonStr), &data); err != nil {
+		return nil, false, fmt.Errorf("invalid JSON response: %w", err)
+	}
+	return evaluatePath(data, path)
 }
 
-func evaluatePath(data interface{}, path string) (interface{}, error) {
-	// Strip optional root selector
-	if strings.HasPrefix(path, "$.") {
-		path = strings.TrimPrefix(path, "$.")
-	} else if strings.HasPrefix(path, "$") {
-		path = strings.TrimPrefix(path, "$")
+// EditJSONPath replaces every value the path selects in data, a document
+// decoded from JSON, with the value edit returns for it, and returns the
+// edited document. When edit returns false the value is removed instead,
+// from its object or its array. Values the path does not reach, e.g. under
+// a missing key, are left as they are.
+func EditJSONPath(data interface{}, path string, edit func(v interface{}) (interface{}, bool)) (interface{}, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
 	}
 
-	if path == "" {
-		return data, nil
+	root := &location{get: func() interface{} { return data }, set: func(v interface{}) { data = v }}
+	locations := []*location{root}
+	for _, seg := range segments {
+		var next []*location
+		for _, loc := range locations {
+			next = append(next, seg.locate(loc)...)
+		}
+		locations = next
 	}
 
-	// Handle array indexing at the start of the path, e.g. [0].name
-	if strings.HasPrefix(path, "[") {
-		endIdx := strings.Index(path, "]")
-		if endIdx == -1 {
-			return nil, fmt.Errorf("unclosed array index in path: %s", path)
+	removals := false
+	for _, loc := range locations {
+		v, keep := edit(loc.get())
+		if !keep {
+			if loc == root {
+				return nil, fmt.Errorf("cannot remove the root of the document")
+			}
+			v, removals = removed{}, true
 		}
+		loc.set(v)
+	}
+	if removals {
+		data = compact(data)
+	}
+	return data, nil
+}
 
-		idxStr := path[1:endIdx]
-		idx, err := strconv.Atoi(idxStr)
-		if err != nil {
-			return nil, fmt.Errorf("invalid array index '%s': %w", idxStr, err)
+// removed marks the values EditJSONPath removes until compact drops them
+type removed struct{}
+
+// location is where a value is held in a document
+type location struct {
+	get func() interface{}
+	set func(v interface{})
+}
+
+// locate returns the locations of the values one segment selects under loc,
+// skipping those that do not exist
+func (s segment) locate(loc *location) []*location {
+	if s.descend {
+		sel := s
+		sel.descend = false
+		var matches []*location
+		for _, l := range descendantLocations(loc) {
+			matches = append(matches, sel.locate(l)...)
 		}
+		return matches
+	}
 
-		slice, ok := data.([]interface{})
-		if !ok {
-			return nil, fmt.Errorf("expected array but got %T", data)
+	switch {
+	case s.wildcard:
+		return childLocations(loc.get())
+	case s.filter != nil:
+		var matches []*location
+		for _, l := range childLocations(loc.get()) {
+			if s.filter.matches(l.get()) {
+				matches = append(matches, l)
+			}
+		}
+		return matches
+	case s.index >= 0:
+		if slice, ok := loc.get().([]interface{}); ok && s.index < len(slice) {
+			return []*location{elementLocation(slice, s.index)}
+		}
+		return nil
+	}
+	if obj, ok := loc.get().(map[string]interface{}); ok {
+		if _, ok := obj[s.key]; ok {
+			return []*location{memberLocation(obj, s.key)}
 		}
+	}
+	return nil
+}
+
+// descendantLocations returns loc and the locations of every value nested
+// in it, depth first
+func descendantLocations(loc *location) []*location {
+	locations := []*location{loc}
+	for _, child := range childLocations(loc.get()) {
+		locations = append(locations, descendantLocations(child)...)
+	}
+	return locations
+}
 
-		if idx < 0 || idx >= len(slice) {
-			return nil, fmt.Errorf("array index out of bounds: %d", idx)
+// childLocations returns the locations of the elements of an array or the
+// values of an object, in the order children returns them
+func childLocations(node interface{}) []*location {
+	var locations []*location
+	switch v := node.(type) {
+	case []interface{}:
+		for i := range v {
+			locations = append(locations, elementLocation(v, i))
 		}
+	case map[string]interface{}:
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			locations = append(locations, memberLocation(v, key))
+		}
+	}
+	return locations
+}
 
-		remainingPath := path[endIdx+1:]
-		remainingPath = strings.TrimPrefix(remainingPath, ".")
+func elementLocation(slice []interface{}, i int) *location {
+	return &location{get: func() interface{} { return slice[i] }, set: func(v interface{}) { slice[i] = v }}
+}
+
+func memberLocation(obj map[string]interface{}, key string) *location {
+	return &location{get: func() interface{} { return obj[key] }, set: func(v interface{}) { obj[key] = v }}
+}
 
-		return evaluatePath(slice[idx], remainingPath)
+// compact drops the values marked removed from the objects and arrays of a
+// document
+func compact(node interface{}) interface{} {
+	switch v := node.(type) {
+	case []interface{}:
+		kept := v[:0]
+		for _, elem := range v {
+			if _, ok := elem.(removed); !ok {
+				kept = append(kept, compact(elem))
+			}
+		}
+		return kept
+	case map[string]interface{}:
+		for key, value := range v {
+			if _, ok := value.(removed); ok {
+				delete(v, key)
+			} else {
+				v[key] = compact(value)
+			}
+		}
 	}
+	return node
+}
 
-	// Handle dot notation
-	parts := strings.SplitN(path, ".", 2)
-	key := parts[0]
+// segment is one step of a parsed path
+type segment struct {
+	key      string  // Object key (when no other selector is set and index is -1)
+	index    int     // Array index, or -1
+	filter   *filter // Filter selecting array elements
+	wildcard bool    // Selects every element or value
+	descend  bool    // Applies the selector to the node and all its descendants (..)
+}
 
-	// Check if key has array index like users[0]
-	bracketIdx := strings.Index(key, "[")
-	if bracketIdx != -1 {
-		// This handle cases like users[0] where [0] is part of the first segment
-		// Logic needs to be careful.
-		// Actually, simplest is to treat "users[0]" as property "users" then index [0]
-		// So if we find [, we split there.
-		realKey := key[:bracketIdx]
-		arrayPart := key[bracketIdx:]
+// indefinite reports whether the segment can select any number of values
+func (s segment) indefinite() bool {
+	return s.filter != nil || s.wildcard || s.descend
+}
 
-		// Update path to process array part next
-		remainingPath := arrayPart
-		if len(parts) > 1 {
-			remainingPath += "." + parts[1]
+// evaluatePath returns the values the path selects in data. A definite path
+// selects exactly one value or fails; other paths select any number of
+// values and skip elements that do not match.
+func evaluatePath(data interface{}, path string) ([]interface{}, bool, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	nodes := []interface{}{data}
+	definite := true
+	for _, seg := range segments {
+		var next []interface{}
+		for _, node := range nodes {
+			values, err := seg.apply(node)
+			if err != nil {
+				if definite {
+					return nil, false, err
+				}
+				continue
+			}
+			next = append(next, values...)
+		}
+		nodes = next
+		if seg.indefinite() {
+			definite = false
 		}
+	}
+	return nodes, definite, nil
+}
 
-		// Process key access first
-		obj, ok := data.(map[string]interface{})
-		if !ok {
-			return nil, fmt.Errorf("expected object for key '%s' but got %T", realKey, data)
+// apply selects the values of one segment in node
+func (s segment) apply(node interface{}) ([]interface{}, error) {
+	if s.descend {
+		sel := s
+		sel.descend = false
+		var matches []interface{}
+		for _, n := range descendants(node) {
+			// Nodes without the key (or index) are skipped
+			values, _ := sel.apply(n)
+			matches = append(matches, values...)
 		}
+		return matches, nil
+	}
 
-		val, ok := obj[realKey]
+	switch {
+	case s.wildcard:
+		return children(node), nil
+	case s.filter != nil:
+		var matches []interface{}
+		for _, elem := range children(node) {
+			if s.filter.matches(elem) {
+				matches = append(matches, elem)
+			}
+		}
+		return matches, nil
+	case s.index >= 0:
+		slice, ok := node.([]interface{})
 		if !ok {
-			return nil, fmt.Errorf("key '%s' not found", realKey)
+			return nil, fmt.Errorf("expected array but got %T", node)
 		}
-
-		return evaluatePath(val, remainingPath)
+		if s.index >= len(slice) {
+			return nil, fmt.Errorf("array index %d out of bounds: %w", s.index, ErrPathNotFound)
+		}
+		return []interface{}{slice[s.index]}, nil
 	}
 
-	obj, ok := data.(map[string]interface{})
+	obj, ok := node.(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("expected object for key '%s' but got %T", key, data)
+		return nil, fmt.Errorf("expected object for key '%s' but got %T", s.key, node)
 	}
-
-	val, ok := obj[key]
+	val, ok := obj[s.key]
 	if !ok {
-		return nil, fmt.Errorf("key '%s' not found", key)
+		return nil, fmt.Errorf("key '%s' %w", s.key, ErrPathNotFound)
+	}
+	return []interface{}{val}, nil
+}
+
+// descendants returns node and every value nested in it, depth first
+func descendants(node interface{}) []interface{} {
+	nodes := []interface{}{node}
+	for _, child := range children(node) {
+		nodes = append(nodes, descendants(child)...)
+	}
+	return nodes
+}
+
+// children returns the elements of an array or the values of an object (in
+// key order), which wildcards and filters select from
+func children(node interface{}) []interface{} {
+	switch v := node.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		values := make([]interface{}, 0, len(v))
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			values = append(values, v[key])
+		}
+		return values
 	}
+	return nil
+}
 
-	if len(parts) > 1 {
-		return evaluatePath(val, parts[1])
+// parsePath splits a path into segments. The root selector is optional, so
+// "user.name", "$.user.name", and "$['user'].name" are equivalent.
+func parsePath(path string) ([]segment, error) {
+	rest := strings.TrimPrefix(path, "$")
+	if rest == "." {
+		rest = ""
+	}
+	if rest != "" && rest[0] != '.' && rest[0] != '[' {
+		rest = "." + rest
 	}
 
-	return val, nil
+	var segments []segment
+	for rest != "" {
+		descend := strings.HasPrefix(rest, "..")
+		if descend {
+			rest = rest[2:]
+		} else if rest[0] == '.' {
+			rest = rest[1:]
+		}
+		if rest == "" {
+			return nil, fmt.Errorf("empty key in path: %s", path)
+		}
+
+		if rest[0] != '[' {
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in path: %s", path)
+			}
+			seg := segment{key: rest[:end], index: -1, descend: descend}
+			if seg.key == "*" {
+				seg = segment{index: -1, wildcard: true, descend: descend}
+			}
+			segments = append(segments, seg)
+			rest = rest[end:]
+			continue
+		}
+
+		end := closingBracket(rest)
+		if end == -1 {
+			return nil, fmt.Errorf("unclosed array index in path: %s", rest)
+		}
+		seg, err := parseBracket(rest[1:end])
+		if err != nil {
+			return nil, err
+		}
+		seg.descend = descend
+		segments = append(segments, seg)
+		rest = rest[end+1:]
+		if rest != "" && rest[0] != '.' && rest[0] != '[' {
+			return nil, fmt.Errorf("unexpected %q after ']' in path: %s", rest, path)
+		}
+	}
+	return segments, nil
+}
+
+// parseBracket parses the contents of a bracket segment: an index, a quoted
+// key, a wildcard, or a filter
+func parseBracket(s string) (segment, error) {
+	s = strings.TrimSpace(s)
+	if s == "*" {
+		return segment{index: -1, wildcard: true}, nil
+	}
+	if strings.HasPrefix(s, "?") {
+		f, err := parseFilter(s[1:])
+		if err != nil {
+			return segment{}, fmt.Errorf("invalid filter '%s': %w", s, err)
+		}
+		return segment{index: -1, filter: f}, nil
+	}
+	if key, ok := unquote(s); ok {
+		return segment{key: key, index: -1}, nil
+	}
+	idx, err := strconv.Atoi(s)
+	if err != nil {
+		return segment{}, fmt.Errorf("invalid array index '%s': %w", s, err)
+	}
+	if idx < 0 {
+		return segment{}, fmt.Errorf("array index %d out of bounds: %w", idx, ErrPathNotFound)
+	}
+	return segment{index: idx}, nil
+}
+
+// closingBracket returns the index of the ']' closing the bracket that s
+// starts with, skipping quoted strings and nested brackets, or -1
+func closingBracket(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// unquote returns the contents of a single- or double-quoted string
+func unquote(s string) (string, bool) {
+	if len(s) < 2 || (s[0] != '\'' && s[0] != '"') || s[len(s)-1] != s[0] {
+		return "", false
+	}
+	inner := s[1 : len(s)-1]
+	return strings.ReplaceAll(inner, `\`+string(s[0]), string(s[0])), true
 }
@@ -0,0 +1,296 @@
+package client
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// filter is a parsed filter expression such as @.role=='admin' && @.age>=18.
+// Exactly one of its fields is set.
+type filter struct {
+	any  []*filter  // Matches if any operand matches (||)
+	all  []*filter  // Matches if all operands match (&&)
+	cond *condition // Comparison or existence test
+}
+
+// condition compares two operands, or tests that a relative path exists
+// when op is empty
+type condition struct {
+	left, right operand
+	op          string
+}
+
+// operand is a relative path (@, @.name) or a JSON literal
+type operand struct {
+	path   string // Path relative to the current element (isPath only)
+	isPath bool
+	value  interface{}
+}
+
+// comparisonOperators are the filter operators, longest first so that <=
+// is not read as <
+var comparisonOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// parseFilter parses a filter expression, optionally wrapped in parentheses:
+// - Comparisons: @.role=='admin', @.age >= 18, @ == "read"
+// - Existence tests: @.email
+// - Combinations: @.active==true && (@.role=='admin' || @.role=='owner')
+//
+// Literals are single- or double-quoted strings, numbers, true, false, and
+// null; @ is the element being filtered.
+func parseFilter(s string) (*filter, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, errors.New("empty expression")
+	}
+	for _, sep := range []string{"||", "&&"} {
+		parts := splitTopLevel(s, sep)
+		if len(parts) == 1 {
+			continue
+		}
+		operands := make([]*filter, 0, len(parts))
+		for _, part := range parts {
+			f, err := parseFilter(part)
+			if err != nil {
+				return nil, err
+			}
+			operands = append(operands, f)
+		}
+		if sep == "||" {
+			return &filter{any: operands}, nil
+		}
+		return &filter{all: operands}, nil
+	}
+	if s[0] == '(' && closingParen(s) == len(s)-1 {
+		return parseFilter(s[1 : len(s)-1])
+	}
+
+	cond, err := parseCondition(s)
+	if err != nil {
+		return nil, err
+	}
+	return &filter{cond: cond}, nil
+}
+
+// parseCondition parses a single comparison or existence test
+func parseCondition(s string) (*condition, error) {
+	i, op := findOperator(s)
+	if op == "" {
+		left, err := parseOperand(s)
+		if err != nil {
+			return nil, err
+		}
+		if !left.isPath {
+			return nil, fmt.Errorf("expected a comparison or @ path, got %q", s)
+		}
+		return &condition{left: left}, nil
+	}
+
+	left, err := parseOperand(s[:i])
+	if err != nil {
+		return nil, err
+	}
+	right, err := parseOperand(s[i+len(op):])
+	if err != nil {
+		return nil, err
+	}
+	return &condition{left: left, right: right, op: op}, nil
+}
+
+// parseOperand parses a relative path or a literal
+func parseOperand(s string) (operand, error) {
+	s = strings.TrimSpace(s)
+	if path, ok := strings.CutPrefix(s, "@"); ok {
+		if _, err := parsePath(path); err != nil {
+			return operand{}, err
+		}
+		return operand{path: path, isPath: true}, nil
+	}
+	if str, ok := unquote(s); ok {
+		return operand{value: str}, nil
+	}
+	switch s {
+	case "true":
+		return operand{value: true}, nil
+	case "false":
+		return operand{value: false}, nil
+	case "null":
+		return operand{value: nil}, nil
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return operand{value: n}, nil
+	}
+	return operand{}, fmt.Errorf("unsupported operand %q", s)
+}
+
+// matches reports whether elem satisfies the filter
+func (f *filter) matches(elem interface{}) bool {
+	switch {
+	case f.any != nil:
+		for _, operand := range f.any {
+			if operand.matches(elem) {
+				return true
+			}
+		}
+		return false
+	case f.all != nil:
+		for _, operand := range f.all {
+			if !operand.matches(elem) {
+				return false
+			}
+		}
+		return true
+	}
+	return f.cond.matches(elem)
+}
+
+// matches reports whether elem satisfies the condition. A comparison with a
+// path that does not exist in elem is false, whatever the operator.
+func (c *condition) matches(elem interface{}) bool {
+	left, ok := c.left.resolve(elem)
+	if !ok || c.op == "" {
+		return ok
+	}
+	right, ok := c.right.resolve(elem)
+	if !ok {
+		return false
+	}
+
+	switch c.op {
+	case "==":
+		return reflect.DeepEqual(left, right)
+	case "!=":
+		return !reflect.DeepEqual(left, right)
+	}
+	switch l := left.(type) {
+	case float64:
+		if r, ok := right.(float64); ok {
+			return ordered(c.op, cmp.Compare(l, r))
+		}
+	case string:
+		if r, ok := right.(string); ok {
+			return ordered(c.op, strings.Compare(l, r))
+		}
+	}
+	return false
+}
+
+// ordered applies an ordering operator to the result of comparing two values
+func ordered(op string, c int) bool {
+	switch op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+// resolve returns the value of the operand for elem
+func (o operand) resolve(elem interface{}) (interface{}, bool) {
+	if !o.isPath {
+		return o.value, true
+	}
+	values, definite, err := evaluatePath(elem, o.path)
+	if err != nil || !definite {
+		return nil, false
+	}
+	// Documents decoded with UseNumber hold json.Number
+	if n, ok := values[0].(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			return f, true
+		}
+	}
+	return values[0], true
+}
+
+// splitTopLevel splits s at each sep outside quotes and parentheses
+func splitTopLevel(s, sep string) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(s[i:], sep):
+			parts = append(parts, s[start:i])
+			start = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// closingParen returns the index of the ')' closing the parenthesis that s
+// starts with, skipping quoted strings, or -1
+func closingParen(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// findOperator returns the position of the first comparison operator in s
+// outside quotes, and the operator ("" if there is none)
+func findOperator(s string) (int, string) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		default:
+			for _, op := range comparisonOperators {
+				if strings.HasPrefix(s[i:], op) {
+					return i, op
+				}
+			}
+		}
+	}
+	return -1, ""
+}
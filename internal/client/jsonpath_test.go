@@ -1,6 +1,8 @@
 package client
 
 import (
+	"encoding/json"
+	"errors"
 	"testing"
 )
 
@@ -40,6 +42,8 @@ func TestEvaluateJSONPath(t *testing.T) {
 		{"Invalid array index", "permissions[5]", "", true},
 		{"Invalid json", "{", "", true},
 		{"Empty path", "", jsonStr, false}, // Should return full json... wait, EvaluateJSONPath returns interface as string.
+		{"Nested object as JSON", "user.details", `{"active":true}`, false},
+		{"Array as JSON", "permissions", `["read","write"]`, false},
 	}
 
 	for _, tt := range tests {
@@ -58,3 +62,182 @@ func TestEvaluateJSONPath(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluateJSONPath_WholeDocument(t *testing.T) {
+	jsonStr := `{"id": "1", "tags": ["a", "b"], "nested": {"n": 2}}`
+
+	got, err := EvaluateJSONPath(jsonStr, "$")
+	if err != nil {
+		t.Fatalf("EvaluateJSONPath() error = %v", err)
+	}
+
+	want := `{"id":"1","nested":{"n":2},"tags":["a","b"]}`
+	if got != want {
+		t.Errorf("EvaluateJSONPath() = %v, want %v", got, want)
+	}
+}
+
+func TestEvaluateJSONPath_Filter(t *testing.T) {
+	jsonStr := `{
+		"users": [
+			{"id": "1", "role": "admin", "age": 40, "active": true},
+			{"id": "2", "role": "member", "age": 17, "active": true, "email": "b@example.com"},
+			{"id": "3", "role": "admin", "age": 25, "active": false, "tags": ["ops"]}
+		],
+		"permissions": ["read", "write"],
+		"first-name": "Alice"
+	}`
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"Single match", "$.users[?(@.role=='member')].id", "2", false},
+		{"Several matches", "$.users[?(@.role=='admin')].id", `["1","3"]`, false},
+		{"Without parentheses", "$.users[?@.role == 'member'].id", "2", false},
+		{"Double quotes", `$.users[?(@.role=="member")].age`, "17", false},
+		{"Numeric comparison", "$.users[?(@.age >= 18)].id", `["1","3"]`, false},
+		{"Boolean", "$.users[?(@.active == false)].id", "3", false},
+		{"And", "$.users[?(@.role=='admin' && @.active==true)].id", "1", false},
+		{"Or with parentheses", "$.users[?((@.age < 18 || @.age > 30) && @.active)].id", `["1","2"]`, false},
+		{"Existence", "$.users[?(@.email)].id", "2", false},
+		{"Missing key never matches", "$.users[?(@.email != 'x')].id", "2", false},
+		{"Array value", "$.users[?(@.tags == ['ops'])].id", "", true},
+		{"Scalar elements", "$.permissions[?(@ == 'write')]", "write", false},
+		{"Escaped quote in literal", `$.users[?(@.role == 'it\'s')].id`, "", true},
+		{"Filter then index", "$.users[?(@.role=='admin')].tags[0]", "ops", false},
+		{"No match", "$.users[?(@.role=='owner')].id", "", true},
+		{"Bracket key", "$['first-name']", "Alice", false},
+		{"Invalid operand", "$.users[?(@.role == admin)].id", "", true},
+		{"Empty filter", "$.users[?()].id", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateJSONPath(jsonStr, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EvaluateJSONPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluateJSONPath(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateJSONPath_FilterNoMatch(t *testing.T) {
+	_, err := EvaluateJSONPath(`{"users": []}`, "$.users[?(@.role=='admin')]")
+	if !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("error = %v, want ErrPathNotFound", err)
+	}
+}
+
+func TestEvaluateJSONPath_Wildcard(t *testing.T) {
+	jsonStr := `{
+		"items": [{"id": 1, "name": "a"}, {"id": 2, "name": "b"}],
+		"owner": {"name": "Alice", "team": {"name": "core"}},
+		"empty": [],
+		"single": [{"id": 7}]
+	}`
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"Array wildcard", "$.items[*].id", "[1,2]", false},
+		{"Dot wildcard", "$.owner.*", `["Alice",{"name":"core"}]`, false},
+		{"Wildcard on array with dot", "$.items.*.name", `["a","b"]`, false},
+		{"Single match", "$.single[*].id", "7", false},
+		{"Recursive descent", "$..name", `["a","b","Alice","core"]`, false},
+		{"Recursive descent below a key", "$.owner..name", `["Alice","core"]`, false},
+		{"Recursive descent with index", "$..items[1].id", "2", false},
+		{"Recursive wildcard", "$.owner..*", `["Alice",{"name":"core"},"core"]`, false},
+		{"Recursive descent with filter", "$..[?(@.id > 1)].id", `[2,7]`, false},
+		{"No match", "$.empty[*].id", "", true},
+		{"Missing key after descent", "$..", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateJSONPath(jsonStr, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EvaluateJSONPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluateJSONPath(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateJSONPathMatches(t *testing.T) {
+	jsonStr := `{"items": [{"id": 1}], "empty": []}`
+
+	tests := []struct {
+		path         string
+		wantCount    int
+		wantDefinite bool
+	}{
+		{"$.items", 1, true},
+		{"$.items[*].id", 1, false},
+		{"$.empty[*]", 0, false},
+		{"$..id", 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			matches, definite, err := EvaluateJSONPathMatches(jsonStr, tt.path)
+			if err != nil {
+				t.Fatalf("EvaluateJSONPathMatches() error = %v", err)
+			}
+			if len(matches) != tt.wantCount || definite != tt.wantDefinite {
+				t.Errorf("EvaluateJSONPathMatches() = %v, %v, want %d matches, definite %v", matches, definite, tt.wantCount, tt.wantDefinite)
+			}
+		})
+	}
+}
+
+func TestEditJSONPath(t *testing.T) {
+	decode := func(s string) interface{} {
+		var v interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	doc := `{"id": "a", "items": [{"id": "b", "debug": true}, {"id": "c"}, {"id": "d", "debug": true}], "meta": {"id": "e"}}`
+
+	tests := []struct {
+		path string
+		edit func(interface{}) (interface{}, bool)
+		want string
+	}{
+		{"$.id", func(interface{}) (interface{}, bool) { return "x", true }, `{"id":"x","items":[{"debug":true,"id":"b"},{"id":"c"},{"debug":true,"id":"d"}],"meta":{"id":"e"}}`},
+		{"$..id", func(v interface{}) (interface{}, bool) { return v.(string) + "!", true }, `{"id":"a!","items":[{"debug":true,"id":"b!"},{"id":"c!"},{"debug":true,"id":"d!"}],"meta":{"id":"e!"}}`},
+		{"$.items[*].debug", func(interface{}) (interface{}, bool) { return nil, false }, `{"id":"a","items":[{"id":"b"},{"id":"c"},{"id":"d"}],"meta":{"id":"e"}}`},
+		{"$.items[?(@.debug==true)]", func(interface{}) (interface{}, bool) { return nil, false }, `{"id":"a","items":[{"id":"c"}],"meta":{"id":"e"}}`},
+		{"$.missing.id", func(interface{}) (interface{}, bool) { return "x", true }, `{"id":"a","items":[{"debug":true,"id":"b"},{"id":"c"},{"debug":true,"id":"d"}],"meta":{"id":"e"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := EditJSONPath(decode(doc), tt.path, tt.edit)
+			if err != nil {
+				t.Fatalf("EditJSONPath(%q) error = %v", tt.path, err)
+			}
+			if encoded, _ := json.Marshal(got); string(encoded) != tt.want {
+				t.Errorf("EditJSONPath(%q) = %s, want %s", tt.path, encoded, tt.want)
+			}
+		})
+	}
+
+	if _, err := EditJSONPath(decode(doc), "$", func(interface{}) (interface{}, bool) { return nil, false }); err == nil {
+		t.Error("expected an error removing the root")
+	}
+	if _, err := EditJSONPath(decode(doc), "$.items[", func(v interface{}) (interface{}, bool) { return v, true }); err == nil {
+		t.Error("expected an error for an invalid path")
+	}
+}
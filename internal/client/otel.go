@@ -0,0 +1,271 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// WithTracer records a client span for every call with t and propagates it
+// to the server in a W3C traceparent header
+func WithTracer(t *Tracer) Option {
+	return func(c *Client) {
+		c.tracer = t
+	}
+}
+
+// Tracer collects OpenTelemetry client spans and exports them to a
+// collector over OTLP/HTTP with JSON encoding on Flush. It is safe for
+// concurrent use.
+type Tracer struct {
+	// Service is the service.name of the spans' resource
+	Service string
+
+	// Headers are sent with the export, e.g. the API key of a tracing
+	// backend
+	Headers http.Header
+
+	// Parent is a W3C traceparent the spans continue, e.g. that of the CI
+	// job (default: each call starts a trace, unless its request already
+	// has a traceparent header)
+	Parent string
+
+	endpoint string
+	client   *http.Client
+
+	mu    sync.Mutex
+	spans []otlpSpan
+}
+
+// NewTracer creates a Tracer exporting to the OTLP/HTTP endpoint of a
+// collector, e.g. http://localhost:4318; /v1/traces is appended to an
+// endpoint without a path
+func NewTracer(endpoint string) (*Tracer, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q, expected e.g. http://localhost:4318", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+	return &Tracer{Service: "grpc_client", endpoint: u.String(), client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+// ParseTraceparent returns the trace and span IDs of a W3C traceparent
+// header value, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceparent(v string) (traceID, spanID string, err error) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return "", "", fmt.Errorf("invalid traceparent %q", v)
+	}
+	for _, p := range parts[:4] {
+		if _, err := hex.DecodeString(p); err != nil {
+			return "", "", fmt.Errorf("invalid traceparent %q", v)
+		}
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return "", "", fmt.Errorf("invalid traceparent %q: all-zero ID", v)
+	}
+	return parts[1], parts[2], nil
+}
+
+// span is a client span in progress
+type span struct {
+	tracer *Tracer
+	data   otlpSpan
+}
+
+// start begins the span of a call of method to url, continuing the trace of
+// parent (a traceparent) when it is valid, and returns the traceparent to
+// send
+func (t *Tracer) start(method protoreflect.MethodDescriptor, rawURL string, protocol Protocol, parent string) (*span, string) {
+	if parent == "" {
+		parent = t.Parent
+	}
+	traceID, parentID, err := ParseTraceparent(parent)
+	if err != nil {
+		traceID, parentID = randomID(16), ""
+	}
+	svc := method.Parent().(protoreflect.ServiceDescriptor)
+	s := &span{tracer: t, data: otlpSpan{
+		TraceID:      traceID,
+		SpanID:       randomID(8),
+		ParentSpanID: parentID,
+		Name:         string(svc.FullName()) + "/" + string(method.Name()),
+		Kind:         otlpSpanKindClient,
+		Start:        unixNano(time.Now()),
+	}}
+
+	system := "grpc"
+	if protocol == ProtocolConnect {
+		system = "connect_rpc"
+	}
+	s.attr("rpc.system", otlpString(system))
+	s.attr("rpc.service", otlpString(string(svc.FullName())))
+	s.attr("rpc.method", otlpString(string(method.Name())))
+	s.attr("url.full", otlpString(rawURL))
+	if u, err := url.Parse(rawURL); err == nil {
+		s.attr("server.address", otlpString(u.Hostname()))
+		if port, err := strconv.Atoi(u.Port()); err == nil {
+			s.attr("server.port", otlpInt(port))
+		}
+	}
+	return s, "00-" + s.data.TraceID + "-" + s.data.SpanID + "-01"
+}
+
+// attr sets an attribute of the span
+func (s *span) attr(key string, value otlpValue) {
+	s.data.Attributes = append(s.data.Attributes, otlpAttribute{Key: key, Value: value})
+}
+
+// end completes the span with the outcome of the call
+func (s *span) end(protocol Protocol, err error) {
+	s.data.End = unixNano(time.Now())
+	code := connect.Code(0)
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		code = rpcErr.Code
+	} else if err != nil {
+		code = connect.CodeUnknown
+	}
+	if protocol == ProtocolConnect {
+		if code != 0 {
+			s.attr("rpc.connect_rpc.error_code", otlpString(code.String()))
+		}
+	} else {
+		s.attr("rpc.grpc.status_code", otlpInt(int(code)))
+	}
+	if err != nil {
+		s.data.Status = &otlpStatus{Code: otlpStatusError, Message: err.Error()}
+	}
+
+	s.tracer.mu.Lock()
+	s.tracer.spans = append(s.tracer.spans, s.data)
+	s.tracer.mu.Unlock()
+}
+
+// Flush exports the spans recorded since the last Flush
+func (t *Tracer) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	spans := t.spans
+	t.spans = nil
+	t.mu.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(otlpExport{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{{Key: "service.name", Value: otlpString(t.Service)}}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "grpc_client"},
+			Spans: spans,
+		}},
+	}}})
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+	for name, values := range t.Headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to export spans: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+// randomID returns n random bytes in hex
+func randomID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// unixNano formats t as the nanoseconds since the epoch, as a string as
+// OTLP JSON encodes 64-bit integers
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// The OTLP/JSON encoding of spans, see
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+type (
+	otlpExport struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID      string          `json:"traceId"`
+		SpanID       string          `json:"spanId"`
+		ParentSpanID string          `json:"parentSpanId,omitempty"`
+		Name         string          `json:"name"`
+		Kind         int             `json:"kind"`
+		Start        string          `json:"startTimeUnixNano"`
+		End          string          `json:"endTimeUnixNano"`
+		Attributes   []otlpAttribute `json:"attributes"`
+		Status       *otlpStatus     `json:"status,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		String *string `json:"stringValue,omitempty"`
+		Int    *string `json:"intValue,omitempty"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+)
+
+const (
+	otlpSpanKindClient = 3
+	otlpStatusError    = 2
+)
+
+func otlpString(s string) otlpValue {
+	return otlpValue{String: &s}
+}
+
+func otlpInt(n int) otlpValue {
+	s := strconv.Itoa(n)
+	return otlpValue{Int: &s}
+}
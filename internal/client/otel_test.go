@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// newCollector serves an OTLP/HTTP trace endpoint and records the exports
+func newCollector(t *testing.T) (*httptest.Server, *[]otlpExport, *http.Header) {
+	t.Helper()
+	var exports []otlpExport
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("expected an export to /v1/traces, got %s", r.URL.Path)
+		}
+		header = r.Header.Clone()
+		body, _ := io.ReadAll(r.Body)
+		var export otlpExport
+		if err := json.Unmarshal(body, &export); err != nil {
+			t.Errorf("invalid export %s: %v", body, err)
+		}
+		exports = append(exports, export)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &exports, &header
+}
+
+// attribute returns the value of an attribute of s as a string
+func attribute(s otlpSpan, key string) string {
+	for _, a := range s.Attributes {
+		if a.Key != key {
+			continue
+		}
+		if a.Value.String != nil {
+			return *a.Value.String
+		}
+		if a.Value.Int != nil {
+			return *a.Value.Int
+		}
+	}
+	return ""
+}
+
+func TestClient_Tracer(t *testing.T) {
+	method := testMethod(t)
+	srv, gotHeader := newEchoServer(t)
+	collector, exports, exportHeader := newCollector(t)
+
+	tracer, err := NewTracer(collector.URL)
+	if err != nil {
+		t.Fatalf("NewTracer failed: %v", err)
+	}
+	tracer.Service = "checkout-tests"
+	tracer.Headers = http.Header{"X-Api-Key": {"key"}}
+	c := NewClient(srv.URL, "", ProtocolGRPCWeb, nil, WithTracer(tracer))
+	if _, err := c.Call(context.Background(), method, dynamicpb.NewMessage(method.Input())); err == nil {
+		t.Fatal("expected the empty gRPC-Web response to fail")
+	}
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	if len(*exports) != 1 {
+		t.Fatalf("expected one export, got %d", len(*exports))
+	}
+	if got := exportHeader.Get("X-Api-Key"); got != "key" {
+		t.Errorf("expected the export headers to be sent, got %q", got)
+	}
+	rs := (*exports)[0].ResourceSpans[0]
+	if got := rs.Resource.Attributes[0]; got.Key != "service.name" || *got.Value.String != "checkout-tests" {
+		t.Errorf("unexpected resource attribute %+v", got)
+	}
+	spans := rs.ScopeSpans[0].Spans
+	if len(spans) != 1 {
+		t.Fatalf("expected one span, got %d", len(spans))
+	}
+	s := spans[0]
+	if s.Name != "test.EchoService/Echo" || s.Kind != otlpSpanKindClient {
+		t.Errorf("unexpected span %q of kind %d", s.Name, s.Kind)
+	}
+	for key, want := range map[string]string{
+		"rpc.system":           "grpc",
+		"rpc.service":          "test.EchoService",
+		"rpc.method":           "Echo",
+		"server.address":       "127.0.0.1",
+		"url.full":             srv.URL + "/test.EchoService/Echo",
+		"rpc.grpc.status_code": "2",
+	} {
+		if got := attribute(s, key); got != want {
+			t.Errorf("expected %s=%q, got %q", key, want, got)
+		}
+	}
+	if s.Status == nil || s.Status.Code != otlpStatusError {
+		t.Errorf("expected an error status, got %+v", s.Status)
+	}
+	if s.Start == "" || s.End < s.Start {
+		t.Errorf("unexpected timings %s to %s", s.Start, s.End)
+	}
+
+	want := "00-" + s.TraceID + "-" + s.SpanID + "-01"
+	if got := gotHeader.Get("Traceparent"); got != want {
+		t.Errorf("expected traceparent %q, got %q", want, got)
+	}
+
+	// Nothing is left to export
+	if err := tracer.Flush(context.Background()); err != nil || len(*exports) != 1 {
+		t.Errorf("expected an empty Flush to export nothing, got %d exports, %v", len(*exports), err)
+	}
+}
+
+func TestClient_TracerParent(t *testing.T) {
+	method := testMethod(t)
+	srv, gotHeader := newEchoServer(t)
+	collector, exports, _ := newCollector(t)
+
+	tracer, _ := NewTracer(collector.URL)
+	tracer.Parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	c := NewClient(srv.URL, "", ProtocolConnect, nil, WithTracer(tracer))
+	if _, err := c.Call(context.Background(), method, dynamicpb.NewMessage(method.Input())); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	// A traceparent header of the request takes precedence
+	headers := map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}
+	c = NewClient(srv.URL, "", ProtocolConnect, headers, WithTracer(tracer))
+	if _, err := c.Call(context.Background(), method, dynamicpb.NewMessage(method.Input())); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if got := gotHeader.Get("Traceparent"); !strings.HasPrefix(got, "00-0af7651916cd43dd8448eb211c80319c-") || strings.Contains(got, "b7ad6b7169203331") {
+		t.Errorf("expected the call to continue the trace of its header, got %q", got)
+	}
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	spans := (*exports)[0].ResourceSpans[0].ScopeSpans[0].Spans
+	for i, want := range []struct{ trace, parent string }{
+		{"4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"},
+		{"0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331"},
+	} {
+		if spans[i].TraceID != want.trace || spans[i].ParentSpanID != want.parent {
+			t.Errorf("span %d: expected trace %s with parent %s, got %s with %s", i, want.trace, want.parent, spans[i].TraceID, spans[i].ParentSpanID)
+		}
+		if got := attribute(spans[i], "rpc.system"); got != "connect_rpc" {
+			t.Errorf("span %d: expected rpc.system connect_rpc, got %q", i, got)
+		}
+		if spans[i].Status != nil {
+			t.Errorf("span %d: expected no error status, got %+v", i, spans[i].Status)
+		}
+	}
+}
+
+func TestNewTracer_InvalidEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"localhost:4318", "ftp://collector", "http://"} {
+		if _, err := NewTracer(endpoint); err == nil {
+			t.Errorf("expected %q to be rejected", endpoint)
+		}
+	}
+	tracer, err := NewTracer("https://collector.example.com/otlp/v1/traces")
+	if err != nil || tracer.endpoint != "https://collector.example.com/otlp/v1/traces" {
+		t.Errorf("expected an endpoint with a path to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// proxyWithUser wraps a proxy function so that the proxy URL it selects
+// carries user:password credentials, which net/http sends in the
+// Proxy-Authorization header (also for the CONNECT of https requests)
+func proxyWithUser(proxy func(*http.Request) (*url.URL, error), credentials string) (func(*http.Request) (*url.URL, error), error) {
+	user, password, ok := strings.Cut(credentials, ":")
+	if !ok || user == "" {
+		return nil, fmt.Errorf("invalid proxy credentials, expected 'user:password'")
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		u, err := proxy(req)
+		if err != nil || u == nil {
+			return u, err
+		}
+		withUser := *u
+		withUser.User = url.UserPassword(user, password)
+		return &withUser, nil
+	}, nil
+}
+
+// parseProxyHeaders parses headers given one "Name: value" per line
+func parseProxyHeaders(lines string) (http.Header, error) {
+	header := make(http.Header)
+	for _, line := range strings.Split(lines, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid proxy header %q, expected 'Key: Value'", line)
+		}
+		header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	return header, nil
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestProxyWithUser(t *testing.T) {
+	var auth, connectHeader string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Proxy-Authorization")
+		connectHeader = r.Header.Get("X-Proxy-Tenant")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	withUser, err := proxyWithUser(http.ProxyURL(proxyURL), "alice:p@ss:word")
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := parseProxyHeaders("X-Proxy-Tenant: team-a\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpClient := &http.Client{Transport: &http.Transport{Proxy: withUser, ProxyConnectHeader: header}}
+
+	want := "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:p@ss:word"))
+	for _, target := range []string{"http://upstream.invalid/", "https://upstream.invalid/"} {
+		auth, connectHeader = "", ""
+		resp, err := httpClient.Get(target)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if auth != want {
+			t.Errorf("%s: Proxy-Authorization = %q, want %q", target, auth, want)
+		}
+		// Connect headers are only sent with the CONNECT of https requests
+		if https := target[:5] == "https"; (connectHeader == "team-a") != https {
+			t.Errorf("%s: X-Proxy-Tenant = %q", target, connectHeader)
+		}
+	}
+
+	if _, err := proxyWithUser(http.ProxyURL(proxyURL), "alice"); err == nil {
+		t.Error("expected error for credentials without a password")
+	}
+	if _, err := parseProxyHeaders("no colon"); err == nil {
+		t.Error("expected error for a malformed header")
+	}
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+// Retry backoffs
+const (
+	BackoffExponential = "exp"   // Doubles the delay after each attempt
+	BackoffConstant    = "const" // Waits the same delay before each attempt
+)
+
+// Backoffs lists the accepted RetryPolicy backoffs
+var Backoffs = []string{BackoffExponential, BackoffConstant}
+
+// DefaultRetryOn are the statuses retried by default: those of transient
+// failures such as a restarting upstream or an overloaded proxy
+var DefaultRetryOn = []connect.Code{connect.CodeUnavailable}
+
+// WithRetry retries the calls that fail as p allows
+func WithRetry(p *RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
+// RetryPolicy retries calls that fail with a retryable status, waiting
+// between attempts. The context of the call bounds all its attempts.
+type RetryPolicy struct {
+	Retries  int            // Attempts after the first one
+	On       []connect.Code // Statuses retried (default: DefaultRetryOn)
+	Backoff  string         // BackoffExponential (default) or BackoffConstant
+	Delay    time.Duration  // Delay before the first retry
+	MaxDelay time.Duration  // Upper bound of exponential delays (0 = none)
+
+	// OnRetry, if set, is called before waiting delay to make attempt (from
+	// 2) after err
+	OnRetry func(attempt int, err error, delay time.Duration)
+
+	sleep func(ctx context.Context, d time.Duration) error // Replaced in tests
+}
+
+// Validate checks the backoff of the policy
+func (p *RetryPolicy) Validate() error {
+	if p.Retries < 0 {
+		return fmt.Errorf("invalid retry count %d, must not be negative", p.Retries)
+	}
+	if p.Backoff != "" && !slices.Contains(Backoffs, p.Backoff) {
+		return fmt.Errorf("invalid retry backoff %q, must be one of: exp, const", p.Backoff)
+	}
+	return nil
+}
+
+// do calls call until it succeeds, fails with a status that is not
+// retried, or the retries are exhausted, returning its last outcome
+func (p *RetryPolicy) do(ctx context.Context, call func() (*Response, error)) (*Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := call()
+		if err == nil || attempt > p.Retries || !p.retryable(err) || ctx.Err() != nil {
+			return resp, err
+		}
+		delay := p.delay(attempt)
+		if p.OnRetry != nil {
+			p.OnRetry(attempt+1, err, delay)
+		}
+		sleep := p.sleep
+		if sleep == nil {
+			sleep = sleepContext
+		}
+		if sleep(ctx, delay) != nil {
+			return resp, err // The deadline passed while waiting
+		}
+	}
+}
+
+// retryable reports whether err has a status the policy retries
+func (p *RetryPolicy) retryable(err error) bool {
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	on := p.On
+	if len(on) == 0 {
+		on = DefaultRetryOn
+	}
+	return slices.Contains(on, rpcErr.Code)
+}
+
+// delay returns the delay before the retry following attempt (from 1).
+// Exponential delays are jittered by up to a fifth, so that clients failing
+// together do not retry in lockstep.
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	if p.Backoff == BackoffConstant {
+		return p.Delay
+	}
+	d := p.Delay << min(attempt-1, 16)
+	if d > 0 {
+		d += rand.N(d/5 + 1)
+	}
+	if p.MaxDelay > 0 {
+		d = min(d, p.MaxDelay)
+	}
+	return d
+}
+
+// sleepContext waits d, or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestClient_Retry(t *testing.T) {
+	method := testMethod(t)
+	attempts, failures := 0, 2
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Content-Type", "application/json")
+		if attempts <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"code": "unavailable", "message": "upstream restarting"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/proto")
+	}))
+	t.Cleanup(srv.Close)
+
+	var retries []int
+	var delays []time.Duration
+	policy := &RetryPolicy{
+		Retries: 3,
+		Delay:   100 * time.Millisecond,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			retries = append(retries, attempt)
+		},
+		sleep: func(ctx context.Context, d time.Duration) error {
+			delays = append(delays, d)
+			return nil
+		},
+	}
+	c := NewClient(srv.URL, "", ProtocolConnect, nil, WithRetry(policy))
+	if _, err := c.Call(context.Background(), method, dynamicpb.NewMessage(method.Input())); err != nil {
+		t.Fatalf("expected the third attempt to succeed, got %v", err)
+	}
+	if attempts != 3 || len(retries) != 2 || retries[0] != 2 || retries[1] != 3 {
+		t.Errorf("attempts = %d, retries = %v", attempts, retries)
+	}
+	// Exponential delays, with up to a fifth of jitter
+	if len(delays) != 2 || delays[0] < 100*time.Millisecond || delays[0] > 120*time.Millisecond || delays[1] < 200*time.Millisecond || delays[1] > 240*time.Millisecond {
+		t.Errorf("delays = %v", delays)
+	}
+
+	// Statuses that are not retried fail at once
+	attempts, failures = 0, 10
+	policy.On = []connect.Code{connect.CodeDeadlineExceeded}
+	_, err := c.Call(context.Background(), method, dynamicpb.NewMessage(method.Input()))
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != connect.CodeUnavailable || attempts != 1 {
+		t.Errorf("expected a single failed attempt, got %d attempts, %v", attempts, err)
+	}
+}
+
+func TestRetryPolicy_Exhausted(t *testing.T) {
+	policy := &RetryPolicy{Retries: 2, Backoff: BackoffConstant, Delay: time.Second, sleep: func(context.Context, time.Duration) error { return nil }}
+	calls := 0
+	_, err := policy.do(context.Background(), func() (*Response, error) {
+		calls++
+		return nil, &Error{Code: connect.CodeUnavailable}
+	})
+	if err == nil || calls != 3 {
+		t.Errorf("expected 3 attempts and the last error, got %d, %v", calls, err)
+	}
+	if d := policy.delay(5); d != time.Second {
+		t.Errorf("expected a constant delay, got %v", d)
+	}
+
+	// The deadline of the call bounds the waits
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	policy.sleep = nil
+	calls = 0
+	if _, err := policy.do(ctx, func() (*Response, error) {
+		calls++
+		return nil, &Error{Code: connect.CodeUnavailable}
+	}); err == nil || calls != 1 {
+		t.Errorf("expected a canceled call not to be retried, got %d attempts", calls)
+	}
+}
+
+func TestRetryPolicy_MaxDelay(t *testing.T) {
+	policy := &RetryPolicy{Delay: time.Second, MaxDelay: 5 * time.Second}
+	if d := policy.delay(10); d != 5*time.Second {
+		t.Errorf("expected the delay to be capped, got %v", d)
+	}
+	if err := (&RetryPolicy{Backoff: "linear"}).Validate(); err == nil {
+		t.Error("expected an unknown backoff to be rejected")
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	for s, want := range map[string]connect.Code{"unavailable": connect.CodeUnavailable, "DEADLINE_EXCEEDED": connect.CodeDeadlineExceeded, "14": connect.CodeUnavailable} {
+		if got, err := ParseStatus(s); err != nil || got != want {
+			t.Errorf("ParseStatus(%q) = %v, %v", s, got, err)
+		}
+	}
+	if _, err := ParseStatus("flaky"); err == nil {
+		t.Error("expected an unknown status to be rejected")
+	}
+}
@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// SPIFFEEndpointEnv is the environment variable SPIFFE workloads find the
+// Workload API socket in
+const SPIFFEEndpointEnv = "SPIFFE_ENDPOINT_SOCKET"
+
+// spiffeTimeout bounds a fetch from the Workload API
+const spiffeTimeout = 10 * time.Second
+
+// SVID is an X.509 SPIFFE Verifiable Identity Document
+type SVID struct {
+	ID          string          // SPIFFE ID, e.g. spiffe://example.org/ns/dev/sa/cli
+	Certificate tls.Certificate // Certificate chain and private key
+}
+
+// FetchX509SVID fetches the default X.509 SVID of the workload from the
+// SPIFFE Workload API at addr, e.g. unix:///run/spire/agent.sock or
+// tcp://127.0.0.1:8081 (a bare path is taken as a unix socket)
+func FetchX509SVID(ctx context.Context, addr string) (*SVID, error) {
+	network, address, err := parseSocketAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// The Workload API is gRPC over cleartext HTTP/2
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	httpClient := &http.Client{Transport: &http.Transport{
+		Protocols: &protocols,
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+	}}
+	c := connect.NewClient[rawMessage, rawMessage](httpClient, "http://localhost/SpiffeWorkloadAPI/FetchX509SVID",
+		connect.WithGRPC(), connect.WithCodec(rawCodec{}))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	req := connect.NewRequest(&rawMessage{})
+	req.Header().Set("workload.spiffe.io", "true")
+	stream, err := c.CallServerStream(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the SPIFFE Workload API at %s: %w", addr, err)
+	}
+	defer stream.Close()
+	if !stream.Receive() {
+		err := stream.Err()
+		if err == nil {
+			err = errors.New("no response")
+		}
+		return nil, fmt.Errorf("failed to fetch an X.509 SVID from %s: %w", addr, err)
+	}
+	return parseX509SVIDResponse(*stream.Msg())
+}
+
+// parseSocketAddr splits a Workload API address into a network and address
+func parseSocketAddr(addr string) (network, address string, err error) {
+	switch {
+	case addr == "":
+		return "", "", fmt.Errorf("no SPIFFE Workload API socket given (see $%s)", SPIFFEEndpointEnv)
+	case strings.HasPrefix(addr, "unix://"):
+		return "unix", strings.TrimPrefix(addr, "unix://"), nil
+	case strings.HasPrefix(addr, "tcp://"):
+		return "tcp", strings.TrimPrefix(addr, "tcp://"), nil
+	case strings.Contains(addr, "://"):
+		return "", "", fmt.Errorf("invalid SPIFFE Workload API socket %q, expected unix:// or tcp://", addr)
+	default:
+		return "unix", addr, nil
+	}
+}
+
+// parseX509SVIDResponse decodes the first SVID of an X509SVIDResponse
+// message (field 1, repeated X509SVID svids)
+func parseX509SVIDResponse(b []byte) (*SVID, error) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, fmt.Errorf("invalid Workload API response: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+		if num == 1 && typ == protowire.BytesType {
+			svid, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return nil, fmt.Errorf("invalid Workload API response: %w", protowire.ParseError(n))
+			}
+			return parseX509SVID(svid)
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return nil, fmt.Errorf("invalid Workload API response: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+	return nil, errors.New("the Workload API returned no X.509 SVID")
+}
+
+// parseX509SVID decodes an X509SVID message: spiffe_id (1), x509_svid (2,
+// concatenated DER certificates, leaf first), and x509_svid_key (3, PKCS#8
+// DER private key)
+func parseX509SVID(b []byte) (*SVID, error) {
+	var (
+		svid       SVID
+		certs, key []byte
+	)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, fmt.Errorf("invalid X.509 SVID: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		} else {
+			var v []byte
+			v, n = protowire.ConsumeBytes(b)
+			switch num {
+			case 1:
+				svid.ID = string(v)
+			case 2:
+				certs = v
+			case 3:
+				key = v
+			}
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("invalid X.509 SVID: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+
+	chain, err := x509.ParseCertificates(certs)
+	if err != nil || len(chain) == 0 {
+		return nil, fmt.Errorf("invalid X.509 SVID certificates: %v", err)
+	}
+	privateKey, err := x509.ParsePKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid X.509 SVID key: %w", err)
+	}
+	for _, c := range chain {
+		svid.Certificate.Certificate = append(svid.Certificate.Certificate, c.Raw)
+	}
+	svid.Certificate.PrivateKey = privateKey
+	svid.Certificate.Leaf = chain[0]
+	return &svid, nil
+}
+
+// rawMessage is an encoded protobuf message
+type rawMessage []byte
+
+// rawCodec passes encoded messages through unchanged
+type rawCodec struct{}
+
+func (rawCodec) Name() string { return "proto" }
+
+func (rawCodec) Marshal(msg any) ([]byte, error) {
+	return *msg.(*rawMessage), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, msg any) error {
+	*msg.(*rawMessage) = append(rawMessage(nil), data...)
+	return nil
+}
+
+// svidSource supplies the client certificate from the Workload API,
+// fetching a new SVID once half the lifetime of the current one has passed,
+// since SVIDs are short-lived and rotated
+type svidSource struct {
+	addr string
+
+	mu      sync.Mutex
+	svid    *SVID
+	refresh time.Time // When to fetch a new SVID
+}
+
+func (s *svidSource) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.svid == nil || time.Now().After(s.refresh) {
+		if err := s.fetch(); err != nil {
+			return nil, err
+		}
+	}
+	return &s.svid.Certificate, nil
+}
+
+// fetch replaces the SVID with a fresh one
+func (s *svidSource) fetch() error {
+	ctx, cancel := context.WithTimeout(context.Background(), spiffeTimeout)
+	defer cancel()
+	svid, err := FetchX509SVID(ctx, s.addr)
+	if err != nil {
+		return err
+	}
+	leaf := svid.Certificate.Leaf
+	s.svid = svid
+	s.refresh = leaf.NotBefore.Add(leaf.NotAfter.Sub(leaf.NotBefore) / 2)
+	return nil
+}
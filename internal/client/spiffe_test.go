@@ -0,0 +1,203 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// newSVIDResponse returns an encoded X509SVIDResponse holding an SVID for id
+func newSVIDResponse(t *testing.T, id string, lifetime time.Duration) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uri, _ := url.Parse(id)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "svid"},
+		URIs:         []*url.URL{uri},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(lifetime),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var svid []byte
+	svid = protowire.AppendTag(svid, 1, protowire.BytesType)
+	svid = protowire.AppendString(svid, id)
+	svid = protowire.AppendTag(svid, 2, protowire.BytesType)
+	svid = protowire.AppendBytes(svid, der)
+	svid = protowire.AppendTag(svid, 3, protowire.BytesType)
+	svid = protowire.AppendBytes(svid, keyDER)
+	var resp []byte
+	resp = protowire.AppendTag(resp, 1, protowire.BytesType)
+	return protowire.AppendBytes(resp, svid)
+}
+
+// startWorkloadAPI serves a fake SPIFFE Workload API on a unix socket and
+// returns its address and a counter of fetches
+func startWorkloadAPI(t *testing.T, response func() []byte) (string, *atomic.Int32) {
+	t.Helper()
+	// Unix socket paths are short, so avoid the long t.TempDir
+	dir, err := os.MkdirTemp("", "spiffe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "agent.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetches := new(atomic.Int32)
+	mux := http.NewServeMux()
+	mux.Handle("/SpiffeWorkloadAPI/FetchX509SVID", connect.NewServerStreamHandler("/SpiffeWorkloadAPI/FetchX509SVID",
+		func(_ context.Context, req *connect.Request[rawMessage], stream *connect.ServerStream[rawMessage]) error {
+			if req.Header().Get("workload.spiffe.io") != "true" {
+				return connect.NewError(connect.CodeInvalidArgument, errors.New("security header missing"))
+			}
+			fetches.Add(1)
+			msg := rawMessage(response())
+			return stream.Send(&msg)
+		}, connect.WithCodec(rawCodec{})))
+
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	server := &http.Server{Handler: mux, Protocols: &protocols}
+	go server.Serve(ln)
+	t.Cleanup(func() { server.Close() })
+	return "unix://" + socket, fetches
+}
+
+func TestFetchX509SVID(t *testing.T) {
+	const id = "spiffe://example.org/ns/dev/sa/cli"
+	response := newSVIDResponse(t, id, time.Hour)
+	addr, _ := startWorkloadAPI(t, func() []byte { return response })
+
+	svid, err := FetchX509SVID(context.Background(), addr)
+	if err != nil {
+		t.Fatalf("FetchX509SVID failed: %v", err)
+	}
+	if svid.ID != id {
+		t.Errorf("got ID %q, want %q", svid.ID, id)
+	}
+	if len(svid.Certificate.Certificate) != 1 || svid.Certificate.PrivateKey == nil {
+		t.Errorf("expected a certificate and key, got %+v", svid.Certificate)
+	}
+	if uris := svid.Certificate.Leaf.URIs; len(uris) != 1 || uris[0].String() != id {
+		t.Errorf("got leaf URIs %v", uris)
+	}
+
+	if _, err := FetchX509SVID(context.Background(), "unix:///nonexistent/agent.sock"); err == nil {
+		t.Error("expected an error for a missing socket")
+	}
+	if _, err := FetchX509SVID(context.Background(), "http://localhost"); err == nil || !strings.Contains(err.Error(), "unix://") {
+		t.Errorf("expected an invalid address error, got %v", err)
+	}
+}
+
+func TestSVIDSource_Refresh(t *testing.T) {
+	lifetime := time.Hour
+	addr, fetches := startWorkloadAPI(t, func() []byte {
+		return newSVIDResponse(t, "spiffe://example.org/cli", lifetime)
+	})
+
+	cfg, err := TLSConfig{SPIFFESocket: addr}.Config()
+	if err != nil {
+		t.Fatalf("Config failed: %v", err)
+	}
+	for range 2 {
+		if _, err := cfg.GetClientCertificate(nil); err != nil {
+			t.Fatalf("GetClientCertificate failed: %v", err)
+		}
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("expected the SVID to be cached, got %d fetches", fetches.Load())
+	}
+
+	// An SVID past half its lifetime is refetched
+	source := &svidSource{addr: addr}
+	lifetime = time.Second
+	if _, err := source.GetClientCertificate(nil); err != nil {
+		t.Fatal(err)
+	}
+	source.refresh = time.Now().Add(-time.Second)
+	if _, err := source.GetClientCertificate(nil); err != nil {
+		t.Fatal(err)
+	}
+	if fetches.Load() != 3 {
+		t.Errorf("expected the SVID to be refetched, got %d fetches", fetches.Load())
+	}
+
+	if _, err := (TLSConfig{SPIFFESocket: addr, CertFile: "client.crt"}).Config(); err == nil {
+		t.Error("expected an error combining a certificate with a SPIFFE socket")
+	}
+}
+
+func TestFileCertSource_Reload(t *testing.T) {
+	certFile, keyFile := writeClientCert(t)
+	cfg, err := TLSConfig{CertFile: certFile, KeyFile: keyFile}.Config()
+	if err != nil {
+		t.Fatalf("Config failed: %v", err)
+	}
+	first, err := cfg.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rotate the files
+	newCert, newKey := writeClientCert(t)
+	for _, f := range [][2]string{{newCert, certFile}, {newKey, keyFile}} {
+		content, _ := os.ReadFile(f[0])
+		if err := os.WriteFile(f[1], content, 0600); err != nil {
+			t.Fatal(err)
+		}
+		later := time.Now().Add(time.Minute)
+		if err := os.Chtimes(f[1], later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	second, err := cfg.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Leaf.Equal(second.Leaf) {
+		t.Error("expected the rotated certificate to be loaded")
+	}
+
+	// A half-written rotation keeps the previous certificate
+	if err := os.WriteFile(keyFile, []byte("partial"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(2 * time.Minute)
+	os.Chtimes(keyFile, later, later)
+	if cert, err := cfg.GetClientCertificate(nil); err != nil || !cert.Leaf.Equal(second.Leaf) {
+		t.Errorf("expected the previous certificate, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"connectrpc.com/connect"
+)
+
+// StatusOK is the status name of a successful call
+const StatusOK = "ok"
+
+// Error is a gRPC error status returned for a call
+type Error struct {
+	Code    connect.Code
+	Message string
+	Header  http.Header // Response headers sent with the error
+	Trailer http.Header // Response trailers sent with the error
+
+	// Transport is set when the call failed before a response arrived, e.g.
+	// the connection was refused or the TLS handshake failed, rather than
+	// the server answering with an error status or the call timing out
+	Transport bool
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("gRPC error [%s]: %s", e.Code, e.Message)
+}
+
+// Status returns the canonical status name of the error (e.g. "not_found")
+func (e *Error) Status() string {
+	return StatusName(e.Code)
+}
+
+// StatusName returns the canonical name of a gRPC status code
+func StatusName(code connect.Code) string {
+	if code == 0 {
+		return StatusOK
+	}
+	return code.String()
+}
+
+// NormalizeStatus maps a status written as a name in any case (NOT_FOUND,
+// not_found) or as a numeric code (5) to its canonical name
+func NormalizeStatus(s string) string {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return StatusName(connect.Code(n))
+	}
+	return strings.ToLower(s)
+}
+
+// ParseStatus parses a status written as in NormalizeStatus into its code
+func ParseStatus(s string) (connect.Code, error) {
+	name := NormalizeStatus(s)
+	for code := connect.CodeCanceled; code <= connect.CodeUnauthenticated; code++ {
+		if StatusName(code) == name {
+			return code, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown status %q", s)
+}
@@ -0,0 +1,25 @@
+package client
+
+import (
+	"testing"
+)
+
+func TestNormalizeStatus(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"not_found", "not_found"},
+		{"NOT_FOUND", "not_found"},
+		{"5", "not_found"},
+		{"0", "ok"},
+		{"OK", "ok"},
+		{" unavailable ", "unavailable"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeStatus(tt.in); got != tt.want {
+			t.Errorf("NormalizeStatus(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TLSConfig holds the TLS and proxy settings of calls, e.g. from --cert and
+// --key. It is comparable, so that HTTP clients can be reused per setting.
+type TLSConfig struct {
+	CertFile string // PEM client certificate presented for mutual TLS
+	KeyFile  string // PEM private key of CertFile (default: read from CertFile)
+	CAFile   string // PEM CA certificates trusted instead of the system roots, a file or a directory of files
+	Insecure bool   // Skip verification of the server certificate chain and host name
+
+	// SPIFFESocket is the SPIFFE Workload API address the client identity
+	// (X.509 SVID) is fetched from instead of CertFile, e.g.
+	// unix:///run/spire/agent.sock. The SVID is refetched as it nears expiry.
+	SPIFFESocket string
+
+	ServerName   string // Host name sent for SNI and verified against the certificate (default: the address host)
+	MinVersion   string // Lowest TLS version offered: 1.0, 1.1, 1.2, or 1.3 (default: Go's default)
+	MaxVersion   string // Highest TLS version offered (default: 1.3)
+	CipherSuites string // Comma-separated names of the TLS 1.0-1.2 cipher suites offered (default: Go's default)
+	KeyLogFile   string // File the TLS session secrets are appended to in NSS key log format, e.g. for Wireshark
+
+	// The proxy itself is taken from HTTPS_PROXY, HTTP_PROXY, and NO_PROXY
+	ProxyUser    string // user:password sent to the proxy in Proxy-Authorization
+	ProxyHeaders string // Headers sent to the proxy with CONNECT, one "Name: value" per line
+}
+
+// tlsVersions maps the versions accepted by MinVersion and MaxVersion
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// parseVersion returns the TLS version named v, or 0 if v is empty
+func parseVersion(v string) (uint16, error) {
+	if v == "" {
+		return 0, nil
+	}
+	version, ok := tlsVersions[strings.TrimPrefix(strings.ToLower(v), "tls")]
+	if !ok {
+		return 0, fmt.Errorf("unknown TLS version %q, expected 1.0, 1.1, 1.2, or 1.3", v)
+	}
+	return version, nil
+}
+
+// parseCipherSuites returns the IDs of the comma-separated cipher suite
+// names, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, including those Go
+// considers insecure
+func parseCipherSuites(names string) ([]uint16, error) {
+	byName := make(map[string]uint16)
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		byName[suite.Name] = suite.ID
+	}
+	var ids []uint16
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		id, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// IsZero reports whether t makes no TLS settings
+func (t TLSConfig) IsZero() bool {
+	return t == TLSConfig{}
+}
+
+// Config builds the tls.Config described by t
+func (t TLSConfig) Config() (*tls.Config, error) {
+	cfg := &tls.Config{InsecureSkipVerify: t.Insecure, ServerName: t.ServerName}
+	if t.CertFile == "" && t.KeyFile != "" {
+		return nil, errors.New("a client key requires a client certificate")
+	}
+	if t.CertFile != "" && t.SPIFFESocket != "" {
+		return nil, errors.New("a client certificate cannot be combined with a SPIFFE Workload API socket")
+	}
+	if t.CertFile != "" {
+		keyFile := t.KeyFile
+		if keyFile == "" {
+			keyFile = t.CertFile
+		}
+		// Loaded now so that a bad certificate fails early, and reloaded by
+		// GetClientCertificate whenever the files are rotated
+		source := &fileCertSource{certFile: t.CertFile, keyFile: keyFile}
+		cert, err := source.load()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{*cert}
+		cfg.GetClientCertificate = source.GetClientCertificate
+	}
+	if t.SPIFFESocket != "" {
+		if _, _, err := parseSocketAddr(t.SPIFFESocket); err != nil {
+			return nil, err
+		}
+		cfg.GetClientCertificate = (&svidSource{addr: t.SPIFFESocket}).GetClientCertificate
+	}
+	var err error
+	if cfg.MinVersion, err = parseVersion(t.MinVersion); err != nil {
+		return nil, err
+	}
+	if cfg.MaxVersion, err = parseVersion(t.MaxVersion); err != nil {
+		return nil, err
+	}
+	if cfg.MinVersion != 0 && cfg.MaxVersion != 0 && cfg.MinVersion > cfg.MaxVersion {
+		return nil, fmt.Errorf("TLS min version %s is above max version %s", t.MinVersion, t.MaxVersion)
+	}
+	if t.CipherSuites != "" {
+		if cfg.CipherSuites, err = parseCipherSuites(t.CipherSuites); err != nil {
+			return nil, err
+		}
+	}
+	if t.KeyLogFile != "" {
+		// Left open for the life of the process, as connections may be
+		// established at any time
+		f, err := os.OpenFile(t.KeyLogFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open TLS key log: %w", err)
+		}
+		cfg.KeyLogWriter = f
+	}
+	if t.CAFile != "" {
+		pool, err := loadCertPool(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// fileCertSource supplies the client certificate from files, reloading them
+// when their modification time changes, so that long runs (e.g. bench or
+// --watch) pick up certificates rotated by an agent
+type fileCertSource struct {
+	certFile, keyFile string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	modTimes [2]time.Time
+}
+
+func (s *fileCertSource) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.modTimes != s.stat() {
+		// Keep the previous certificate when the files are mid-rotation
+		if cert, err := s.loadLocked(); err == nil || s.cert == nil {
+			return cert, err
+		}
+	}
+	return s.cert, nil
+}
+
+// load reads the certificate and key files
+func (s *fileCertSource) load() (*tls.Certificate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadLocked()
+}
+
+func (s *fileCertSource) loadLocked() (*tls.Certificate, error) {
+	modTimes := s.stat()
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	s.cert, s.modTimes = &cert, modTimes
+	return s.cert, nil
+}
+
+// stat returns the modification times of the certificate and key files
+func (s *fileCertSource) stat() [2]time.Time {
+	var modTimes [2]time.Time
+	for i, name := range []string{s.certFile, s.keyFile} {
+		if info, err := os.Stat(name); err == nil {
+			modTimes[i] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+// loadCertPool reads the PEM certificates of path, a file or a directory
+// whose files are all read (subdirectories are not searched)
+func loadCertPool(path string) (*x509.CertPool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+	}
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+
+	pool := x509.NewCertPool()
+	found := false
+	for _, file := range files {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+		}
+		if pool.AppendCertsFromPEM(pem) {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no PEM certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// WithJar returns a copy of c that stores and sends cookies with jar
+func WithJar(c *http.Client, jar http.CookieJar) *http.Client {
+	withJar := *c
+	withJar.Jar = jar
+	return &withJar
+}
+
+// NewHTTPClient returns an HTTP client that applies t, or http.DefaultClient
+// when t makes no settings
+func NewHTTPClient(t TLSConfig) (*http.Client, error) {
+	if t.IsZero() {
+		return http.DefaultClient, nil
+	}
+	cfg, err := t.Config()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = cfg
+	if t.ProxyUser != "" {
+		if transport.Proxy, err = proxyWithUser(transport.Proxy, t.ProxyUser); err != nil {
+			return nil, err
+		}
+	}
+	if t.ProxyHeaders != "" {
+		if transport.ProxyConnectHeader, err = parseProxyHeaders(t.ProxyHeaders); err != nil {
+			return nil, err
+		}
+	}
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,287 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeClientCert writes a self-signed client certificate and its key as PEM
+// files and returns their paths
+func writeClientCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestTLSConfig(t *testing.T) {
+	certFile, keyFile := writeClientCert(t)
+
+	// Certificate and key in one file
+	combined := filepath.Join(t.TempDir(), "client.pem")
+	certPEM, _ := os.ReadFile(certFile)
+	keyPEM, _ := os.ReadFile(keyFile)
+	if err := os.WriteFile(combined, append(certPEM, keyPEM...), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		tls     TLSConfig
+		certs   int
+		wantErr bool
+	}{
+		{"none", TLSConfig{}, 0, false},
+		{"cert and key", TLSConfig{CertFile: certFile, KeyFile: keyFile}, 1, false},
+		{"combined file", TLSConfig{CertFile: combined}, 1, false},
+		{"key only", TLSConfig{KeyFile: keyFile}, 0, true},
+		{"cert without key", TLSConfig{CertFile: certFile}, 0, true},
+		{"missing file", TLSConfig{CertFile: "missing.crt", KeyFile: keyFile}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := tt.tls.Config()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Config error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(cfg.Certificates) != tt.certs {
+				t.Errorf("got %d certificates, want %d", len(cfg.Certificates), tt.certs)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClient_MutualTLS(t *testing.T) {
+	certFile, keyFile := writeClientCert(t)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	// The test server's certificate is self-signed
+	if _, err := http.DefaultClient.Get(server.URL); err == nil {
+		t.Error("expected the self-signed certificate to be rejected")
+	}
+	if c, err := NewHTTPClient(TLSConfig{}); err != nil || c != http.DefaultClient {
+		t.Errorf("expected http.DefaultClient without settings, got %v, %v", c, err)
+	}
+
+	httpClient, err := NewHTTPClient(TLSConfig{CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("NewHTTPClient failed: %v", err)
+	}
+	// Trust the test server
+	httpClient.Transport.(*http.Transport).TLSClientConfig.RootCAs = server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+	resp, err := httpClient.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the client certificate to be presented, got %s", resp.Status)
+	}
+}
+
+func TestNewHTTPClient_Insecure(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	httpClient, err := NewHTTPClient(TLSConfig{Insecure: true})
+	if err != nil {
+		t.Fatalf("NewHTTPClient failed: %v", err)
+	}
+	resp, err := httpClient.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected the self-signed certificate to be accepted: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestNewHTTPClient_CAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{caFile, dir} {
+		httpClient, err := NewHTTPClient(TLSConfig{CAFile: path})
+		if err != nil {
+			t.Fatalf("NewHTTPClient(%s) failed: %v", path, err)
+		}
+		resp, err := httpClient.Get(server.URL)
+		if err != nil {
+			t.Fatalf("expected the server to be trusted with %s: %v", path, err)
+		}
+		resp.Body.Close()
+	}
+
+	// The test certificate is valid for example.com, which is then verified
+	// instead of the address host
+	for name, valid := range map[string]bool{"example.com": true, "other.test": false} {
+		httpClient, err := NewHTTPClient(TLSConfig{CAFile: caFile, ServerName: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := httpClient.Get(server.URL)
+		if (err == nil) != valid {
+			t.Errorf("server name %s: error = %v, want valid %v", name, err, valid)
+		}
+		if err == nil {
+			resp.Body.Close()
+		}
+	}
+
+	if _, err := NewHTTPClient(TLSConfig{CAFile: t.TempDir()}); err == nil {
+		t.Error("expected error for a directory without certificates")
+	}
+	if _, err := NewHTTPClient(TLSConfig{CAFile: "missing.pem"}); err == nil {
+		t.Error("expected error for a missing CA file")
+	}
+}
+
+func TestTLSConfig_Versions(t *testing.T) {
+	tests := []struct {
+		name     string
+		tls      TLSConfig
+		min, max uint16
+		ciphers  int
+		wantErr  bool
+	}{
+		{"defaults", TLSConfig{}, 0, 0, 0, false},
+		{"range", TLSConfig{MinVersion: "1.2", MaxVersion: "TLS1.3"}, tls.VersionTLS12, tls.VersionTLS13, 0, false},
+		{"unknown version", TLSConfig{MinVersion: "1.4"}, 0, 0, 0, true},
+		{"min above max", TLSConfig{MinVersion: "1.3", MaxVersion: "1.2"}, 0, 0, 0, true},
+		{"ciphers", TLSConfig{CipherSuites: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_RSA_WITH_AES_128_CBC_SHA"}, 0, 0, 2, false},
+		{"unknown cipher", TLSConfig{CipherSuites: "TLS_NULL"}, 0, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := tt.tls.Config()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Config error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if cfg.MinVersion != tt.min || cfg.MaxVersion != tt.max || len(cfg.CipherSuites) != tt.ciphers {
+				t.Errorf("got versions %x-%x and %d cipher suites, want %x-%x and %d", cfg.MinVersion, cfg.MaxVersion, len(cfg.CipherSuites), tt.min, tt.max, tt.ciphers)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClient_Versions(t *testing.T) {
+	var negotiated uint16
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		negotiated = r.TLS.CipherSuite
+	}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	get := func(settings TLSConfig) error {
+		settings.Insecure = true
+		httpClient, err := NewHTTPClient(settings)
+		if err != nil {
+			return err
+		}
+		resp, err := httpClient.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	if err := get(TLSConfig{MinVersion: "1.3"}); err == nil {
+		t.Error("expected the handshake to fail with a TLS 1.2 server")
+	}
+	if err := get(TLSConfig{MaxVersion: "1.2", CipherSuites: "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if negotiated != tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384 {
+		t.Errorf("negotiated %s, want TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", tls.CipherSuiteName(negotiated))
+	}
+}
+
+func TestNewHTTPClient_KeyLog(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	keyLog := filepath.Join(t.TempDir(), "keys.log")
+	httpClient, err := NewHTTPClient(TLSConfig{Insecure: true, KeyLogFile: keyLog})
+	if err != nil {
+		t.Fatalf("NewHTTPClient failed: %v", err)
+	}
+	resp, err := httpClient.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	content, err := os.ReadFile(keyLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// TLS 1.3 logs its traffic secrets
+	if !strings.Contains(string(content), "CLIENT_TRAFFIC_SECRET_0 ") {
+		t.Errorf("expected session secrets in the key log, got %q", content)
+	}
+
+	if _, err := (TLSConfig{KeyLogFile: filepath.Join(t.TempDir(), "missing", "keys.log")}).Config(); err == nil {
+		t.Error("expected an error for an unwritable key log")
+	}
+}
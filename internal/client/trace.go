@@ -0,0 +1,243 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Envelope flags of length-prefixed frames
+const (
+	flagCompressed = 0x01 // The payload is compressed
+	flagEndStream  = 0x02 // Connect streaming: the end-of-stream message
+	flagTrailers   = 0x80 // gRPC-Web: the payload is the trailers
+)
+
+// envelopeSize is the size of a frame's prefix: a flags byte and a 4-byte
+// big-endian payload length
+const envelopeSize = 5
+
+// WithTrace writes the wire framing of every call to w: each
+// length-prefixed frame with its flags and length, as a hex dump followed by
+// the decoded message or trailers. Unenveloped bodies (Connect unary) are
+// dumped whole.
+func WithTrace(w io.Writer) Option {
+	return func(c *Client) {
+		c.trace = &traceLog{w: w}
+	}
+}
+
+// traceLog writes the frames of WithTrace. Each body is written with a
+// single Write, so that concurrent calls do not interleave.
+type traceLog struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// traceClient dumps the request and response bodies of a call
+type traceClient struct {
+	connect.HTTPClient
+	log           *traceLog
+	input, output protoreflect.MessageDescriptor
+}
+
+func (c traceClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		c.log.body(">", req.Header, body, c.input)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return resp, err
+	}
+	// Dumped once read, so that it follows any --verbose response headers
+	resp.Body = &tracedBody{ReadCloser: resp.Body, done: func(body []byte) {
+		c.log.body("<", resp.Header, body, c.output)
+	}}
+	return resp, nil
+}
+
+// tracedBody records a response body as it is read and passes it to done
+// at the end of the body or when it is closed, whichever is first
+type tracedBody struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	once sync.Once
+	done func([]byte)
+}
+
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if err != nil {
+		b.once.Do(func() { b.done(b.buf.Bytes()) })
+	}
+	return n, err
+}
+
+func (b *tracedBody) Close() error {
+	b.once.Do(func() { b.done(b.buf.Bytes()) })
+	return b.ReadCloser.Close()
+}
+
+// body dumps an HTTP body sent (prefix ">") or received ("<") with headers
+// h, decoding messages as desc
+func (l *traceLog) body(prefix string, h http.Header, body []byte, desc protoreflect.MessageDescriptor) {
+	var b bytes.Buffer
+	contentType := h.Get("Content-Type")
+	encodingHeader := "Grpc-Encoding"
+	if strings.HasPrefix(contentType, "application/connect+") {
+		encodingHeader = "Connect-Content-Encoding"
+	} else if !strings.HasPrefix(contentType, "application/grpc") {
+		// Connect unary: the body is the message, compressed per Content-Encoding
+		fmt.Fprintf(&b, "%s body: %d bytes (%s, unframed)\n", prefix, len(body), contentType)
+		dump(&b, body)
+		if strings.Contains(contentType, "json") {
+			fmt.Fprintf(&b, "  %s\n", bytes.TrimSpace(body))
+		} else {
+			decodeMessage(&b, body, h.Get("Content-Encoding"), desc)
+		}
+		l.write(&b)
+		return
+	}
+
+	for n := 1; len(body) > 0; n++ {
+		if len(body) < envelopeSize {
+			fmt.Fprintf(&b, "%s frame %d: truncated prefix, %d of %d bytes\n", prefix, n, len(body), envelopeSize)
+			dump(&b, body)
+			break
+		}
+		flags, length := body[0], binary.BigEndian.Uint32(body[1:envelopeSize])
+		payload := body[envelopeSize:]
+		truncated := uint32(len(payload)) < length
+		if !truncated {
+			payload = payload[:length]
+		}
+		body = body[envelopeSize+len(payload):]
+
+		fmt.Fprintf(&b, "%s frame %d: %s, flags 0x%02x, %d bytes\n", prefix, n, frameKind(flags), flags, length)
+		dump(&b, payload)
+		if truncated {
+			fmt.Fprintf(&b, "  (truncated: %d of %d bytes received)\n", len(payload), length)
+			break
+		}
+		encoding := ""
+		if flags&flagCompressed != 0 {
+			encoding = h.Get(encodingHeader)
+			if encoding == "" {
+				encoding = "unknown"
+			}
+		}
+		if flags&(flagTrailers|flagEndStream) != 0 {
+			decodeText(&b, payload, encoding)
+		} else {
+			decodeMessage(&b, payload, encoding, desc)
+		}
+	}
+	l.write(&b)
+}
+
+// write writes a block of lines
+func (l *traceLog) write(b *bytes.Buffer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.w.Write(b.Bytes())
+}
+
+// frameKind describes the frame with flags
+func frameKind(flags byte) string {
+	kind := "message"
+	switch {
+	case flags&flagTrailers != 0:
+		kind = "trailers"
+	case flags&flagEndStream != 0:
+		kind = "end of stream"
+	}
+	if flags&flagCompressed != 0 {
+		kind += ", compressed"
+	}
+	return kind
+}
+
+// dump writes b as an indented hex dump
+func dump(b *bytes.Buffer, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	for _, line := range strings.SplitAfter(hex.Dump(data), "\n") {
+		if line != "" {
+			b.WriteString("  " + line)
+		}
+	}
+}
+
+// decompress returns data decoded with encoding, which may be empty
+func decompress(data []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case "", "identity":
+		return data, nil
+	case "gzip":
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(r)
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", encoding)
+	}
+}
+
+// decodeMessage writes data decoded as a desc message in JSON
+func decodeMessage(b *bytes.Buffer, data []byte, encoding string, desc protoreflect.MessageDescriptor) {
+	data, err := decompress(data, encoding)
+	if err != nil {
+		fmt.Fprintf(b, "  (not decoded: %v)\n", err)
+		return
+	}
+	msg := dynamicpb.NewMessage(desc)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		fmt.Fprintf(b, "  (not a valid %s: %v)\n", desc.FullName(), err)
+		return
+	}
+	text, err := protojson.Marshal(msg)
+	if err != nil {
+		fmt.Fprintf(b, "  (not decoded: %v)\n", err)
+		return
+	}
+	if encoding != "" && encoding != "identity" {
+		fmt.Fprintf(b, "  %s, %d bytes: ", encoding, len(data))
+	} else {
+		b.WriteString("  ")
+	}
+	fmt.Fprintf(b, "%s %s\n", desc.FullName(), text)
+}
+
+// decodeText writes data, e.g. trailers, as indented text
+func decodeText(b *bytes.Buffer, data []byte, encoding string) {
+	data, err := decompress(data, encoding)
+	if err != nil {
+		fmt.Fprintf(b, "  (not decoded: %v)\n", err)
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		fmt.Fprintf(b, "  %s\n", strings.TrimRight(line, "\r"))
+	}
+}
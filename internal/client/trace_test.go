@@ -0,0 +1,95 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// frame returns payload enveloped with flags
+func frame(flags byte, payload []byte) []byte {
+	prefix := make([]byte, envelopeSize)
+	prefix[0] = flags
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(payload)))
+	return append(prefix, payload...)
+}
+
+func TestTraceLog_Frames(t *testing.T) {
+	method := testMethod(t)
+	msg := dynamicpb.NewMessage(method.Output())
+	msg.Set(method.Output().Fields().ByName("text"), protoreflect.ValueOfString("hi"))
+	encoded, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write(encoded)
+	zw.Close()
+
+	var body []byte
+	body = append(body, frame(0, encoded)...)
+	body = append(body, frame(flagCompressed, compressed.Bytes())...)
+	body = append(body, frame(flagTrailers, []byte("grpc-status: 0\r\ngrpc-message: \r\n"))...)
+	body = append(body, frame(0, []byte("short"))[:7]...) // Cut off by a broken gateway
+
+	var out bytes.Buffer
+	log := &traceLog{w: &out}
+	h := http.Header{"Content-Type": {"application/grpc-web+proto"}, "Grpc-Encoding": {"gzip"}}
+	log.body("<", h, body, method.Output())
+
+	got := out.String()
+	for _, want := range []string{
+		"< frame 1: message, flags 0x00, 4 bytes\n",
+		"  00000000  0a 02 68 69",
+		`  test.EchoResponse {"text":"hi"}` + "\n",
+		"< frame 2: message, compressed, flags 0x01,",
+		`  gzip, 4 bytes: test.EchoResponse {"text":"hi"}` + "\n",
+		"< frame 3: trailers, flags 0x80, 32 bytes\n",
+		"  grpc-status: 0\n",
+		"< frame 4: message, flags 0x00, 5 bytes\n",
+		"  (truncated: 2 of 5 bytes received)\n",
+	} {
+		if !strings.Contains(strings.ReplaceAll(got, `"text": "hi"`, `"text":"hi"`), want) {
+			t.Errorf("expected %q in trace:\n%s", want, got)
+		}
+	}
+
+	out.Reset()
+	log.body(">", h, []byte{0, 0, 0}, method.Input())
+	if !strings.Contains(out.String(), "> frame 1: truncated prefix, 3 of 5 bytes\n") {
+		t.Errorf("expected a truncated prefix, got:\n%s", out.String())
+	}
+}
+
+func TestClient_Trace(t *testing.T) {
+	method := testMethod(t)
+	srv, _ := newEchoServer(t)
+
+	var out strings.Builder
+	c := NewClient(srv.URL, "", ProtocolConnect, nil, WithTrace(&out))
+	input := dynamicpb.NewMessage(method.Input())
+	input.Set(method.Input().Fields().ByName("text"), protoreflect.ValueOfString("hello"))
+	if _, err := c.Call(context.Background(), method, input); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"> body: 7 bytes (application/proto, unframed)\n",
+		"hello",
+		"< body: 0 bytes (application/proto, unframed)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in trace:\n%s", want, got)
+		}
+	}
+}
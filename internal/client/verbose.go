@@ -0,0 +1,115 @@
+package client
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+
+	"connectrpc.com/connect"
+)
+
+// WithVerbose writes the HTTP exchange of every call to w, like curl -v:
+// the URL, request line and headers, response status and headers,
+// trailers, gRPC status, and message sizes. Header values are passed
+// through mask first, if not nil, e.g. to redact credentials.
+func WithVerbose(w io.Writer, mask func(http.Header) http.Header) Option {
+	return func(c *Client) {
+		c.verbose = &verboseLog{w: w, mask: mask}
+	}
+}
+
+// verboseLog writes the exchanges of WithVerbose. Each block is written
+// with a single Write, so that concurrent calls do not interleave lines.
+type verboseLog struct {
+	w    io.Writer
+	mask func(http.Header) http.Header
+	mu   sync.Mutex
+}
+
+// write writes a block of lines
+func (l *verboseLog) write(b *bytes.Buffer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.w.Write(b.Bytes())
+}
+
+// writeHeader writes h sorted by name, one line per value with prefix
+func (l *verboseLog) writeHeader(b *bytes.Buffer, prefix string, h http.Header) {
+	if l.mask != nil {
+		h = l.mask(h)
+	}
+	for _, name := range slices.Sorted(maps.Keys(h)) {
+		for _, v := range h[name] {
+			fmt.Fprintf(b, "%s %s: %s\n", prefix, name, v)
+		}
+	}
+}
+
+// request logs the call about to be made
+func (l *verboseLog) request(url string, size int) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "* Calling %s\n", url)
+	fmt.Fprintf(&b, "* Request message: %d bytes\n", size)
+	l.write(&b)
+}
+
+// result logs the outcome of a call: its trailers, message size, and status
+func (l *verboseLog) result(trailer http.Header, size int, err error) {
+	var b bytes.Buffer
+	var connectErr *connect.Error
+	switch {
+	case err == nil:
+		if len(trailer) > 0 {
+			l.writeHeader(&b, "<", trailer)
+			b.WriteString("<\n")
+		}
+		fmt.Fprintf(&b, "* Response message: %d bytes\n", size)
+		fmt.Fprintf(&b, "* gRPC status: %s\n", StatusName(0))
+	case errors.As(err, &connectErr):
+		// Headers and trailers are merged into the error's metadata
+		fmt.Fprintf(&b, "* gRPC status: %s: %s\n", StatusName(connectErr.Code()), connectErr.Message())
+	default:
+		fmt.Fprintf(&b, "* Call failed: %v\n", err)
+	}
+	l.write(&b)
+}
+
+// verboseClient logs the HTTP requests and responses of a call
+type verboseClient struct {
+	connect.HTTPClient
+	log *verboseLog
+}
+
+func (c verboseClient) Do(req *http.Request) (*http.Response, error) {
+	var b bytes.Buffer
+	path := req.URL.RequestURI()
+	fmt.Fprintf(&b, "> %s %s %s\n", req.Method, path, req.Proto)
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	fmt.Fprintf(&b, "> Host: %s\n", host)
+	c.log.writeHeader(&b, ">", req.Header)
+	b.WriteString(">\n")
+	c.log.write(&b)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return resp, err
+	}
+	b.Reset()
+	if resp.TLS != nil {
+		fmt.Fprintf(&b, "* %s connection using %s\n", tls.VersionName(resp.TLS.Version), tls.CipherSuiteName(resp.TLS.CipherSuite))
+	}
+	fmt.Fprintf(&b, "< %s %s\n", resp.Proto, resp.Status)
+	c.log.writeHeader(&b, "<", resp.Header)
+	b.WriteString("<\n")
+	c.log.write(&b)
+	return resp, nil
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestClient_Verbose(t *testing.T) {
+	method := testMethod(t)
+	srv, _ := newEchoServer(t)
+
+	var out strings.Builder
+	mask := func(h http.Header) http.Header {
+		h = h.Clone()
+		if h.Get("Authorization") != "" {
+			h.Set("Authorization", "***")
+		}
+		return h
+	}
+	headers := map[string]string{"Authorization": "Bearer secret", "X-Custom": "value"}
+	c := NewClient(srv.URL, "", ProtocolConnect, headers, WithVerbose(&out, mask))
+	input := dynamicpb.NewMessage(method.Input())
+	input.Set(method.Input().Fields().ByName("text"), protoreflect.ValueOfString("hello"))
+	if _, err := c.Call(context.Background(), method, input); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"* Calling " + srv.URL + "/test.EchoService/Echo\n",
+		"* Request message: 7 bytes\n",
+		"> POST /test.EchoService/Echo HTTP/1.1\n",
+		"> Host: " + strings.TrimPrefix(srv.URL, "http://") + "\n",
+		"> Authorization: ***\n",
+		"> X-Custom: value\n",
+		"< HTTP/1.1 200 OK\n",
+		"< X-Server: echo\n",
+		"* Response message: 0 bytes\n",
+		"* gRPC status: ok\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in verbose output:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "secret") {
+		t.Errorf("expected the Authorization header to be masked:\n%s", got)
+	}
+}
+
+func TestClient_VerboseError(t *testing.T) {
+	method := testMethod(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code": "not_found", "message": "no such echo"}`))
+	}))
+	defer srv.Close()
+
+	var out strings.Builder
+	c := NewClient(srv.URL, "", ProtocolConnect, nil, WithVerbose(&out, nil))
+	if _, err := c.Call(context.Background(), method, dynamicpb.NewMessage(method.Input())); err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := out.String(); !strings.Contains(got, "< HTTP/1.1 404 Not Found\n") || !strings.Contains(got, "* gRPC status: not_found: no such echo\n") {
+		t.Errorf("unexpected verbose output:\n%s", got)
+	}
+
+	// Dry runs send nothing, so log nothing
+	out.Reset()
+	if _, err := c.DryRun(context.Background(), method, dynamicpb.NewMessage(method.Input())); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no verbose output for a dry run, got:\n%s", out.String())
+	}
+}
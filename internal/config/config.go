@@ -0,0 +1,165 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"grpc_client/internal/client"
+	"grpc_client/internal/file"
+	"grpc_client/internal/vars"
+)
+
+// FileName is the name of the project-level config file, looked up in the
+// working directory and its parents
+const FileName = "grpc-client.yaml"
+
+// Config is the content of a grpc-client.yaml file
+type Config struct {
+	Profiles map[string]*Profile `yaml:"profiles"`
+}
+
+// Profile holds the connection settings, headers, and variables of one
+// environment, e.g. dev, staging, or prod
+type Profile struct {
+	Address   string                 `yaml:"address"`   // Server address, in any form accepted by --address
+	Prefix    string                 `yaml:"prefix"`    // Route prefix
+	Protocol  string                 `yaml:"protocol"`  // grpc, grpc-web, or connect
+	TLS       TLS                    `yaml:"tls"`       // TLS settings
+	Headers   map[string]string      `yaml:"headers"`   // Headers sent with every request
+	Variables map[string]interface{} `yaml:"variables"` // Template variables, nested values flattened to auth.token
+}
+
+// TLS holds the TLS settings of a profile
+type TLS struct {
+	Enabled bool   `yaml:"enabled"` // Connect over https even if the address has no scheme
+	Cert    string `yaml:"cert"`    // PEM client certificate for mutual TLS, relative to the config file
+	Key     string `yaml:"key"`     // PEM private key of Cert, relative to the config file
+	CACert  string `yaml:"cacert"`  // PEM CA certificates (file or directory) trusted for the server, relative to the config file
+	// SPIFFE Workload API socket the client certificate is fetched from instead of Cert
+	SPIFFESocket string `yaml:"spiffe_socket"`
+	// Skip verification of the server certificate, e.g. for self-signed dev clusters
+	Insecure bool `yaml:"insecure"`
+}
+
+// Find returns the path of the config file in dir or its nearest parent
+// containing one, or "" if there is none
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to read config file: %w", err)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads a config file. Unknown keys are rejected so that typos do not
+// silently fall back to defaults.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var cfg Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	for name, profile := range cfg.Profiles {
+		if profile == nil {
+			profile = &Profile{}
+			cfg.Profiles[name] = profile
+		}
+		for _, p := range []*string{&profile.TLS.Cert, &profile.TLS.Key, &profile.TLS.CACert} {
+			if *p != "" && !filepath.IsAbs(*p) {
+				*p = filepath.Join(dir, *p)
+			}
+		}
+	}
+	return &cfg, nil
+}
+
+// Profile returns the profile with the given name
+func (c *Config) Profile(name string) (*Profile, error) {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		available := slices.Sorted(maps.Keys(c.Profiles))
+		if len(available) == 0 {
+			return nil, fmt.Errorf("unknown profile %q: no profiles are defined", name)
+		}
+		return nil, fmt.Errorf("unknown profile %q, available profiles: %s", name, strings.Join(available, ", "))
+	}
+	return profile, nil
+}
+
+// URL returns the normalized address of the profile, switched to https when
+// TLS is enabled, or "" if the profile has no address
+func (p *Profile) URL() (string, error) {
+	if p.Address == "" {
+		return "", nil
+	}
+	u, err := client.ParseAddress(p.Address)
+	if err != nil {
+		return "", err
+	}
+	if p.TLS.Enabled {
+		u.Scheme = "https"
+	}
+	return u.String(), nil
+}
+
+// Vars returns the variables of the profile, with nested values flattened
+// into dotted names
+func (p *Profile) Vars() map[string]interface{} {
+	return vars.Flatten(p.Variables)
+}
+
+// Apply points req at the profile's environment: the address, prefix, and
+// protocol of the profile replace those of the request, and its headers are
+// added unless the request sets them.
+func (p *Profile) Apply(req *file.RequestFile) error {
+	address, err := p.URL()
+	if err != nil {
+		return err
+	}
+	if address != "" {
+		req.Address = address
+	}
+	if p.Prefix != "" {
+		req.Prefix = p.Prefix
+	}
+	if p.Protocol != "" {
+		req.Protocol = p.Protocol
+	}
+	if req.Headers == nil && len(p.Headers) > 0 {
+		req.Headers = make(map[string]string)
+	}
+	for name, value := range p.Headers {
+		if !client.HasHeader(req.Headers, name) {
+			req.Headers[name] = value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"grpc_client/internal/file"
+)
+
+const sample = `profiles:
+  dev:
+    address: :8080
+  staging:
+    address: staging.example.com:443
+    prefix: /api/grpc
+    protocol: connect
+    tls:
+      enabled: true
+      cert: certs/client.crt
+      key: /etc/client.key
+      cacert: ca.pem
+      insecure: true
+    headers:
+      Authorization: Bearer {{token}}
+    variables:
+      token: abc
+      auth:
+        user: alice
+`
+
+func writeConfig(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, FileName)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := Find(nested); err != nil || got != "" {
+		t.Errorf("Find without config = %q, %v; want \"\"", got, err)
+	}
+
+	want := writeConfig(t, root, sample)
+	if got, err := Find(nested); err != nil || got != want {
+		t.Errorf("Find = %q, %v; want %q", got, err, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), sample)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	staging, err := cfg.Profile("staging")
+	if err != nil {
+		t.Fatalf("Profile failed: %v", err)
+	}
+	url, err := staging.URL()
+	if err != nil {
+		t.Fatalf("URL failed: %v", err)
+	}
+	if url != "https://staging.example.com:443" {
+		t.Errorf("URL = %q, want https://staging.example.com:443", url)
+	}
+	if dir := filepath.Dir(path); staging.TLS.Cert != filepath.Join(dir, "certs/client.crt") || staging.TLS.Key != "/etc/client.key" {
+		t.Errorf("TLS paths = %q, %q; want the cert relative to %s", staging.TLS.Cert, staging.TLS.Key, dir)
+	}
+	if dir := filepath.Dir(path); staging.TLS.CACert != filepath.Join(dir, "ca.pem") {
+		t.Errorf("TLS cacert = %q, want it relative to %s", staging.TLS.CACert, dir)
+	}
+	if !staging.TLS.Insecure {
+		t.Error("expected tls.insecure to be loaded")
+	}
+	vars := staging.Vars()
+	if vars["token"] != "abc" || vars["auth.user"] != "alice" {
+		t.Errorf("Vars = %v", vars)
+	}
+
+	dev, _ := cfg.Profile("dev")
+	if url, _ := dev.URL(); url != "http://localhost:8080" {
+		t.Errorf("dev URL = %q, want http://localhost:8080", url)
+	}
+
+	_, err = cfg.Profile("prod")
+	if err == nil || !strings.Contains(err.Error(), "available profiles: dev, staging") {
+		t.Errorf("expected unknown profile error listing profiles, got %v", err)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unknown key", "profiles:\n  dev:\n    adress: :8080\n"},
+		{"malformed", "profiles: [dev\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(writeConfig(t, t.TempDir(), tt.content)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	// An empty file defines no profiles
+	cfg, err := Load(writeConfig(t, t.TempDir(), ""))
+	if err != nil || len(cfg.Profiles) != 0 {
+		t.Errorf("Load of empty file = %v, %v", cfg, err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	profile := &Profile{
+		Address:  "staging.example.com:443",
+		Protocol: "connect",
+		TLS:      TLS{Enabled: true},
+		Headers:  map[string]string{"Authorization": "Bearer abc", "X-Env": "staging"},
+	}
+	req := &file.RequestFile{
+		Address:  "http://localhost:8080",
+		Prefix:   "/api",
+		Protocol: "grpc-web",
+		Headers:  map[string]string{"x-env": "local"},
+	}
+	if err := profile.Apply(req); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	if req.Address != "https://staging.example.com:443" {
+		t.Errorf("Address = %q", req.Address)
+	}
+	if req.Prefix != "/api" {
+		t.Errorf("Prefix = %q, want the request's /api", req.Prefix)
+	}
+	if req.Protocol != "connect" {
+		t.Errorf("Protocol = %q, want connect", req.Protocol)
+	}
+	want := map[string]string{"Authorization": "Bearer abc", "x-env": "local"}
+	if len(req.Headers) != len(want) {
+		t.Errorf("Headers = %v, want %v", req.Headers, want)
+	}
+	for k, v := range want {
+		if req.Headers[k] != v {
+			t.Errorf("header %s = %q, want %q", k, req.Headers[k], v)
+		}
+	}
+}
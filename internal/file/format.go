@@ -0,0 +1,301 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Format rewrites .grpc content in canonical form:
+// - the GRPC line first, then Service, Method, Prefix, Protocol, Timeout,
+// BasicAuth, ClientCert, ClientKey, CACert, Insecure, Output, ExpectFailure,
+// Needs, Skip, Snapshot, and the headers, with header names in canonical casing
+// - the JSON body indented with two spaces (bodies that are not valid JSON,
+// e.g. because of unquoted variables, are kept as written)
+// - [Variables], [Captures], [Secrets], [Normalize], then [Asserts], one
+// blank line between blocks
+// - assertions with quoted keys and values, except numbers compared
+// numerically, in lists, and in approx assertions
+//
+// Comments are kept with the line that follows them. Lines the parser does
+// not understand are kept as written, so formatting never loses content.
+func Format(r io.Reader) ([]byte, error) {
+	sections, err := splitSections(r)
+	if err != nil {
+		return nil, err
+	}
+	formatted := make([]string, len(sections))
+	for i, sec := range sections {
+		formatted[i] = formatSection(sec.lines)
+	}
+	return []byte(strings.Join(formatted, "\n---\n\n")), nil
+}
+
+// mainRanks orders the lines of the main block; headers come last
+var mainRanks = map[string]int{
+	"GRPC":       0,
+	"Service":    1,
+	"Method":     2,
+	"Prefix":     3,
+	"Protocol":   4,
+	"Timeout":    5,
+	"BasicAuth":  6,
+	"ClientCert": 7,
+	"ClientKey":  8,
+	"CACert":     9,
+	"Insecure":   10,
+	"Output":     11,
+
+	"ExpectFailure": 12,
+	"Needs":         13,
+	"Skip":          14,
+	"Snapshot":      15,
+}
+
+// headerRank is the rank of header lines in the main block
+var headerRank = len(mainRanks)
+
+// formatLine is a formatted line and the comments preceding it
+type formatLine struct {
+	comments []string
+	text     string
+	rank     int // Position in the main block
+}
+
+// blockOrder is the canonical order of the bracketed blocks of a request
+var blockOrder = []string{"Variables", "Captures", "Secrets", "Normalize", "Asserts"}
+
+// namedBlock is a bracketed block such as [Captures]
+type namedBlock struct {
+	header []string // Comments before the [Name] line
+	lines  []formatLine
+}
+
+// formatSection formats a single request. The comments the section starts
+// with are kept first, since the first of them names the request.
+func formatSection(lines []string) string {
+	preamble, lines := leadingComments(lines)
+	var (
+		main         []formatLine
+		body         []string
+		bodyComments []string
+		named        = make(map[string]*namedBlock) // Bracketed blocks by name
+		pending      []string                       // Comments not yet attached to a line
+		current      string                         // "", "Body", or a bracketed block name
+	)
+	take := func(text string, rank int) formatLine {
+		l := formatLine{comments: pending, text: text, rank: rank}
+		pending = nil
+		return l
+	}
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" {
+			if current == "Body" {
+				body = append(body, line)
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			pending = append(pending, trimmed)
+			continue
+		}
+		if name, ok := strings.CutPrefix(trimmed, "["); ok && slices.Contains(blockOrder, strings.TrimSuffix(name, "]")) {
+			current = strings.TrimSuffix(name, "]")
+			if named[current] == nil {
+				named[current] = &namedBlock{}
+			}
+			named[current].header = append(named[current].header, pending...)
+			pending = nil
+			continue
+		}
+
+		switch current {
+		case "Variables", "Captures":
+			named[current].lines = append(named[current].lines, take(formatPair(trimmed), 0))
+			continue
+		case "Secrets":
+			named[current].lines = append(named[current].lines, take(trimmed, 0))
+			continue
+		case "Normalize":
+			text := trimmed
+			if colon := strings.LastIndex(trimmed, ":"); colon != -1 {
+				text = strings.TrimSpace(trimmed[:colon]) + ": " + strings.TrimSpace(trimmed[colon+1:])
+			}
+			named[current].lines = append(named[current].lines, take(text, 0))
+			continue
+		case "Asserts":
+			text := trimmed
+			if a, err := parseAssertion(trimmed); err == nil {
+				text = formatAssertion(a)
+			}
+			named[current].lines = append(named[current].lines, take(text, 0))
+			continue
+		}
+
+		if current == "" && strings.HasPrefix(trimmed, "{") {
+			current = "Body"
+		}
+		if current == "Body" {
+			bodyComments = append(bodyComments, pending...)
+			pending = nil
+			body = append(body, line)
+			continue
+		}
+
+		main = append(main, take(formatMainLine(line)))
+	}
+
+	slices.SortStableFunc(main, func(a, b formatLine) int {
+		return a.rank - b.rank
+	})
+
+	var blocks [][]string
+	if len(main) > 0 || len(preamble) > 0 {
+		blocks = append(blocks, append(preamble, flatten(main)...))
+	}
+	if len(body) > 0 {
+		blocks = append(blocks, append(bodyComments, formatBody(body)))
+	}
+	for _, name := range blockOrder {
+		if b := named[name]; b != nil {
+			blocks = append(blocks, append(append(b.header, "["+name+"]"), flatten(b.lines)...))
+		}
+	}
+	if len(pending) > 0 {
+		blocks = append(blocks, pending)
+	}
+
+	joined := make([]string, len(blocks))
+	for i, block := range blocks {
+		joined[i] = strings.Join(block, "\n")
+	}
+	return strings.Join(joined, "\n\n") + "\n"
+}
+
+// leadingComments splits the comments and blank lines a section starts with
+// from the rest of its lines
+func leadingComments(lines []string) ([]string, []string) {
+	var comments []string
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "#"):
+			comments = append(comments, trimmed)
+		default:
+			return comments, lines[i:]
+		}
+	}
+	return comments, nil
+}
+
+// flatten returns formatted lines preceded by their comments
+func flatten(lines []formatLine) []string {
+	var out []string
+	for _, l := range lines {
+		out = append(out, l.comments...)
+		out = append(out, l.text)
+	}
+	return out
+}
+
+// formatMainLine formats a line of the main block and returns its rank
+func formatMainLine(line string) (string, int) {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(line, "GRPC ") {
+		return "GRPC " + strings.TrimSpace(strings.TrimPrefix(line, "GRPC")), mainRanks["GRPC"]
+	}
+	key, value, ok := strings.Cut(trimmed, ":")
+	if !ok {
+		// Unrecognized lines stay where headers go
+		return trimmed, headerRank
+	}
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+	if rank, ok := mainRanks[key]; ok {
+		return key + ": " + value, rank
+	}
+	return http.CanonicalHeaderKey(key) + ": " + value, headerRank
+}
+
+// formatPair formats a capture or variable line, e.g. "token:$.token" as
+// "token: $.token"
+func formatPair(line string) string {
+	name, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return line
+	}
+	return strings.TrimSpace(name) + ": " + strings.TrimSpace(value)
+}
+
+// formatBody indents a JSON body with two spaces, keeping its key order
+func formatBody(lines []string) string {
+	raw := strings.TrimSpace(strings.Join(lines, "\n"))
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(raw), "", "  "); err != nil {
+		return raw
+	}
+	return buf.String()
+}
+
+// numericTypes are the assertion types compared as numbers
+var numericTypes = map[string]bool{
+	"bytes":        true,
+	"responsesize": true,
+	"count":        true,
+}
+
+// formatAssertion formats an assertion in canonical form, e.g.
+// jsonpath "$.id" == "123" or bytes < 10240
+func formatAssertion(a Assertion) string {
+	parts := []string{a.Type}
+	if a.Key != "" {
+		parts = append(parts, quote(a.Key))
+	}
+	if a.Filter != "" {
+		parts = append(parts, a.Filter)
+	}
+	if a.Negate {
+		parts = append(parts, "not")
+	}
+	parts = append(parts, a.Operator)
+
+	switch {
+	case unaryOperators[a.Operator]:
+	case a.File:
+		parts = append(parts, "file", quote(a.Value))
+	case a.JSONPath:
+		parts = append(parts, "jsonpath", quote(a.Value))
+	case a.Operator == "in":
+		parts = append(parts, a.Value)
+	case a.Operator == "approx":
+		parts = append(parts, a.Value)
+		if a.Tolerance != "" {
+			parts = append(parts, "tolerance", a.Tolerance)
+		}
+	case isNumber(a.Value) && (numericTypes[a.Type] || a.Filter == "count" || strings.ContainsAny(a.Operator, "<>")):
+		parts = append(parts, a.Value)
+	default:
+		parts = append(parts, quote(a.Value))
+	}
+	return strings.Join(parts, " ")
+}
+
+// isNumber reports whether s is a decimal number
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// quote double-quotes s, escaping the quotes parseQuoted unescapes
+func quote(s string) string {
+	return fmt.Sprintf("\"%s\"", strings.ReplaceAll(s, "\"", "\\\""))
+}
@@ -0,0 +1,170 @@
+package file
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	content := `# Login
+Output:  login.json
+x-api-key:  secret
+Method:Login
+GRPC   http://localhost:8080
+Service: example.AuthService
+{"user": "alice",
+   "password": "s3cret"}
+
+[Asserts]
+# The token is returned
+jsonpath "$.token"   exists
+status == 0
+bytes <   10240
+jsonpath "$.count" == 2
+jsonpath "$.items" count > 1
+
+[Captures]
+token:$.token
+
+[Secrets]
+  token
+$.refreshToken
+
+[Normalize]
+$.issued_at:timestamp
+
+[Variables]
+user:alice
+---
+GRPC http://localhost:8080
+Service: example.UserService
+Method: GetUser
+Authorization: Bearer {{token}}
+{"id": {{id}}}
+`
+	want := `# Login
+GRPC http://localhost:8080
+Service: example.AuthService
+Method: Login
+Output: login.json
+X-Api-Key: secret
+
+{
+  "user": "alice",
+  "password": "s3cret"
+}
+
+[Variables]
+user: alice
+
+[Captures]
+token: $.token
+
+[Secrets]
+token
+$.refreshToken
+
+[Normalize]
+$.issued_at: timestamp
+
+[Asserts]
+# The token is returned
+jsonpath "$.token" exists
+status == "0"
+bytes < 10240
+jsonpath "$.count" == "2"
+jsonpath "$.items" count > 1
+
+---
+
+GRPC http://localhost:8080
+Service: example.UserService
+Method: GetUser
+Authorization: Bearer {{token}}
+
+{"id": {{id}}}
+`
+
+	got, err := Format(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("Format mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	again, err := Format(strings.NewReader(string(got)))
+	if err != nil {
+		t.Fatalf("Format of formatted content failed: %v", err)
+	}
+	if string(again) != string(got) {
+		t.Errorf("Format is not idempotent\nfirst:\n%s\nsecond:\n%s", got, again)
+	}
+}
+
+func TestFormat_PreservesRequests(t *testing.T) {
+	content := `# Create a user
+Content-Type: application/json
+GRPC http://localhost:8080
+Service: example.UserService
+Method: CreateUser
+{"name": "Ann"}
+[Asserts]
+jsonpath "$.name" not contains "Bob"
+jsonpath "$.age" approx 30 tolerance 0.5
+header "x-id" matches "^[0-9]+$"
+`
+	before, err := ParseReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseReader failed: %v", err)
+	}
+	formatted, err := Format(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	after, err := ParseReader(strings.NewReader(string(formatted)))
+	if err != nil {
+		t.Fatalf("ParseReader of formatted content failed: %v\n%s", err, formatted)
+	}
+
+	b, a := before[0], after[0]
+	if a.Name != b.Name || a.Service != b.Service || a.Method != b.Method || a.Address != b.Address {
+		t.Errorf("request changed: before %+v, after %+v", b, a)
+	}
+	if len(a.Asserts) != len(b.Asserts) {
+		t.Fatalf("expected %d assertions, got %d", len(b.Asserts), len(a.Asserts))
+	}
+	for i := range b.Asserts {
+		if a.Asserts[i] != b.Asserts[i] {
+			t.Errorf("assertion %d changed: before %+v, after %+v", i, b.Asserts[i], a.Asserts[i])
+		}
+	}
+}
+
+func TestFormatAssertion(t *testing.T) {
+	tests := []struct {
+		assertion string
+		want      string
+	}{
+		{`status == 0`, `status == "0"`},
+		{`jsonpath "$.id" == 123`, `jsonpath "$.id" == "123"`},
+		{`jsonpath "$.age" >= 18`, `jsonpath "$.age" >= 18`},
+		{`responsesize == 512`, `responsesize == 512`},
+		{`jsonpath "$.role" in ["a", "b"]`, `jsonpath "$.role" in ["a", "b"]`},
+		{`jsonpath "$.id" not exists`, `jsonpath "$.id" not exists`},
+		{`body == file "golden.json"`, `body == file "golden.json"`},
+		{`jsonpath "$.name" == "say \"hi\""`, `jsonpath "$.name" == "say \"hi\""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.assertion, func(t *testing.T) {
+			a, err := parseAssertion(tt.assertion)
+			if err != nil {
+				t.Fatalf("parseAssertion failed: %v", err)
+			}
+			if got := formatAssertion(a); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
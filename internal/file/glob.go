@@ -0,0 +1,128 @@
+package file
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// HasGlob reports whether pattern contains glob metacharacters
+func HasGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// Glob returns the files and directories matching pattern, in lexical
+// order. Patterns use the syntax of path.Match, with / as the separator on
+// every platform, and "**" matches any number of directories, e.g.
+// "tests/**/*.grpc". Matching directories are returned without their
+// contents.
+func Glob(pattern string) ([]string, error) {
+	segments := splitPath(pattern)
+	for _, s := range segments {
+		if _, err := path.Match(s, ""); err != nil {
+			return nil, err
+		}
+	}
+	// Walk from the directory the pattern starts with
+	static := 0
+	for static < len(segments)-1 && !HasGlob(segments[static]) {
+		static++
+	}
+	root := strings.Join(segments[:static], "/")
+	if strings.HasPrefix(filepath.ToSlash(pattern), "/") {
+		root = "/" + root
+	}
+	if root == "" {
+		root = "."
+	}
+	deep := strings.Contains(pattern, "**")
+
+	var matches []string
+	err := filepath.WalkDir(filepath.FromSlash(root), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == filepath.FromSlash(root) {
+				return fs.SkipAll // Nothing matches
+			}
+			return err
+		}
+		elems := splitPath(p)
+		if p != filepath.FromSlash(root) && matchSegments(segments, elems) {
+			matches = append(matches, p)
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		// Without "**" a pattern never matches below its depth
+		if d.IsDir() && !deep && len(elems) >= len(segments) {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// Excluded reports whether path matches one of patterns. A pattern with a
+// separator is matched against the path and its parent directories, one
+// without against each element of the path, so that "legacy" and
+// "*_slow.grpc" exclude files at any depth.
+func Excluded(p string, patterns []string) bool {
+	elems := splitPath(p)
+	for _, pattern := range patterns {
+		segments := splitPath(pattern)
+		if len(segments) == 0 {
+			continue
+		}
+		if len(segments) == 1 && !strings.Contains(pattern, "/") {
+			for _, e := range elems {
+				if ok, _ := path.Match(pattern, e); ok {
+					return true
+				}
+			}
+			continue
+		}
+		for n := len(elems); n > 0; n-- {
+			if matchSegments(segments, elems[:n]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitPath splits a cleaned path into its elements, ignoring "." and the
+// root
+func splitPath(p string) []string {
+	var elems []string
+	for _, e := range strings.Split(filepath.ToSlash(filepath.Clean(p)), "/") {
+		if e != "" && e != "." {
+			elems = append(elems, e)
+		}
+	}
+	return elems
+}
+
+// matchSegments matches path elements against pattern segments, where
+// "**" matches any number of elements
+func matchSegments(segments, elems []string) bool {
+	if len(segments) == 0 {
+		return len(elems) == 0
+	}
+	if segments[0] == "**" {
+		for i := 0; i <= len(elems); i++ {
+			if matchSegments(segments[1:], elems[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(elems) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(segments[0], elems[0]); !ok {
+		return false
+	}
+	return matchSegments(segments[1:], elems[1:])
+}
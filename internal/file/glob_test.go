@@ -0,0 +1,74 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"users/get.grpc", "users/list.grpc", "users/notes.txt", "orders/v1/get.grpc", "smoke.grpc"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		{"users/*.grpc", []string{"users/get.grpc", "users/list.grpc"}},
+		{"*.grpc", []string{"smoke.grpc"}},
+		{"**/get.grpc", []string{"orders/v1/get.grpc", "users/get.grpc"}},
+		{"**/*.grpc", []string{"orders/v1/get.grpc", "smoke.grpc", "users/get.grpc", "users/list.grpc"}},
+		{"./users/l?st.grpc", []string{"users/list.grpc"}},
+		{"o*", []string{"orders"}}, // Directories are not searched
+		{"missing/*.grpc", nil},
+		{filepath.ToSlash(dir) + "/*.grpc", []string{filepath.Join(dir, "smoke.grpc")}},
+	} {
+		got, err := Glob(tt.pattern)
+		if err != nil {
+			t.Fatalf("Glob(%q) failed: %v", tt.pattern, err)
+		}
+		for i := range got {
+			got[i] = filepath.ToSlash(got[i])
+		}
+		want := tt.want
+		for i := range want {
+			want[i] = filepath.ToSlash(want[i])
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("Glob(%q) = %q, want %q", tt.pattern, got, want)
+		}
+	}
+
+	if _, err := Glob("users/[.grpc"); err == nil {
+		t.Error("expected an invalid pattern to be rejected")
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	patterns := []string{"*_slow.grpc", "legacy", "tests/orders/**/v1/*.grpc"}
+	for path, want := range map[string]bool{
+		"tests/users/get.grpc":         false,
+		"tests/users/get_slow.grpc":    true,
+		"tests/legacy/users/get.grpc":  true,
+		"legacy.grpc":                  false,
+		"tests/orders/v1/get.grpc":     true,
+		"tests/orders/eu/v1/get.grpc":  true,
+		"tests/orders/v2/get.grpc":     false,
+		"./tests/orders/v1/get.grpc":   true,
+		"other/tests/orders/v1/x.grpc": false,
+	} {
+		if got := Excluded(path, patterns); got != want {
+			t.Errorf("Excluded(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
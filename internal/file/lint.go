@@ -0,0 +1,168 @@
+package file
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+)
+
+// Severities of a diagnostic
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is a problem found in a request file
+type Diagnostic struct {
+	File     string // Path of the file (empty when read from a reader)
+	Line     int    // 1-based line number
+	Column   int    // 1-based byte offset in the line (0 when the problem is not on a line)
+	Severity string // SeverityError or SeverityWarning
+	Message  string
+	Source   string // Text of the line (empty when the problem is not on a line)
+
+	err   error // Underlying error, wrapped by ParseReader
+	fatal bool  // Fails ParseReader; other problems are tolerated
+}
+
+func (d Diagnostic) String() string {
+	if d.Column > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", d.File, d.Line, d.Severity, d.Message)
+}
+
+// Snippet returns the source line with a caret under the column, e.g.
+//
+//	jsonpath "$.id" equals "1"
+//	                ^
+//
+// It is empty when the problem is not on a specific column.
+func (d Diagnostic) Snippet() string {
+	if d.Column == 0 || d.Source == "" {
+		return ""
+	}
+	// Keep tabs so the caret lines up with the source
+	prefix := []byte(d.Source[:min(d.Column-1, len(d.Source))])
+	for i, c := range prefix {
+		if c != '\t' {
+			prefix[i] = ' '
+		}
+	}
+	return d.Source + "\n" + string(prefix) + "^"
+}
+
+// SyntaxError is returned by ParseStrict with every error found in a file
+type SyntaxError struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *SyntaxError) Error() string {
+	msg := e.Diagnostics[0].String()
+	if more := len(e.Diagnostics) - 1; more > 0 {
+		msg += fmt.Sprintf(" (and %d more)", more)
+	}
+	return msg
+}
+
+// ParseStrict parses a request file like ParseMultiple, but fails on every
+// problem Lint reports as an error, e.g. a malformed assertion that
+// ParseMultiple skips, instead of the first fatal one. The error is a
+// *SyntaxError listing all of them; warnings are ignored.
+func ParseStrict(path string) ([]*RequestFile, error) {
+	diags, err := Lint(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	var errs []Diagnostic
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			errs = append(errs, d)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, &SyntaxError{Diagnostics: errs}
+	}
+	return ParseMultiple(path)
+}
+
+// Lint parses a request file strictly and reports every problem, including
+// lines that Parse silently skips. See LintReader.
+func Lint(path string, check func(req *RequestFile) []Diagnostic) ([]Diagnostic, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open request file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	diags, err := LintReader(file, check)
+	if err != nil {
+		return nil, err
+	}
+	for i := range diags {
+		diags[i].File = path
+	}
+	return diags, nil
+}
+
+// LintReader parses .grpc content strictly and reports, in line order:
+// - malformed capture lines and unparseable assertions, which Parse skips
+// - unknown assertion types and operators, which fail only when run
+// - unrecognized lines, invalid values, and missing required fields
+// - headers set more than once (warning)
+// - captured variables that no later request references (warning)
+//
+// check, if not nil, reports additional problems of each parsed request,
+// e.g. a service missing from the proto definitions.
+func LintReader(r io.Reader, check func(req *RequestFile) []Diagnostic) ([]Diagnostic, error) {
+	sections, err := splitSections(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var diags []Diagnostic
+	requests := make([]*RequestFile, len(sections))
+	for i, sec := range sections {
+		req, reqDiags := parseContent(sec)
+		requests[i] = req
+		diags = append(diags, reqDiags...)
+		if check != nil {
+			diags = append(diags, check(req)...)
+		}
+	}
+
+	for i, req := range requests {
+		for name := range req.Captures {
+			if !referenced(name, sections[i+1:]) {
+				diags = append(diags, Diagnostic{
+					Line:     req.captureLines[name],
+					Severity: SeverityWarning,
+					Message:  fmt.Sprintf("variable %q is captured but never used by a later request", name),
+				})
+			}
+		}
+	}
+
+	slices.SortStableFunc(diags, func(a, b Diagnostic) int {
+		return a.Line - b.Line
+	})
+	return diags, nil
+}
+
+// referenced reports whether any of the sections uses the variable, e.g.
+// as {{name}} or {{name | default "x"}}
+func referenced(name string, sections []section) bool {
+	placeholder := regexp.MustCompile(`\{\{\s*` + regexp.QuoteMeta(name) + `\s*(\}\}|\|)`)
+	for _, sec := range sections {
+		for _, line := range sec.lines {
+			if placeholder.MatchString(line) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintReader(t *testing.T) {
+	content := `# Login
+GRPC http://localhost:8080
+Service: example.AuthService
+Method: Login
+Authorization: Bearer a
+authorization: Bearer b
+this line is not valid
+{"user": "alice"}
+
+[Captures]
+token: $.token
+unused: $.id
+missing colon
+
+[Asserts]
+jsonpath "$.token" exists
+jsonpath "$.token" equals "x"
+cookie "session" == "1"
+jsonpath $.unquoted == "1"
+---
+GRPC http://localhost:8080
+Service: example.UserService
+Method: GetUser
+Timeout: soon
+Authorization: Bearer {{token | default "none"}}
+`
+
+	diags, err := LintReader(strings.NewReader(content), nil)
+	if err != nil {
+		t.Fatalf("LintReader failed: %v", err)
+	}
+
+	want := []struct {
+		line     int
+		severity string
+		message  string
+	}{
+		{6, SeverityWarning, `duplicate header "authorization" overrides line 5`},
+		{7, SeverityError, `unrecognized line "this line is not valid"`},
+		{12, SeverityWarning, `variable "unused" is captured but never used by a later request`},
+		{13, SeverityError, `malformed capture "missing colon": expected '<name>: <path>'`},
+		{17, SeverityError, `unknown operator "equals"`},
+		{18, SeverityError, `unknown assertion type "cookie"`},
+		{19, SeverityError, `invalid assertion "jsonpath $.unquoted == \"1\"": jsonpath assertion key: expected quoted string, got "$.unquoted == \"1\""`},
+		{24, SeverityError, `invalid timeout duration "soon": time: invalid duration "soon"`},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("got %d diagnostics, want %d: %v", len(diags), len(want), diags)
+	}
+	for i, w := range want {
+		d := diags[i]
+		if d.Line != w.line || d.Severity != w.severity || d.Message != w.message {
+			t.Errorf("diagnostic %d = %d %s %q, want %d %s %q", i, d.Line, d.Severity, d.Message, w.line, w.severity, w.message)
+		}
+	}
+}
+
+func TestLintReader_MissingFields(t *testing.T) {
+	diags, err := LintReader(strings.NewReader("GRPC http://localhost:8080\n---\nService: svc\nMethod: m\n"), nil)
+	if err != nil {
+		t.Fatalf("LintReader failed: %v", err)
+	}
+
+	var got []string
+	for _, d := range diags {
+		got = append(got, d.String())
+	}
+	want := []string{
+		":1: error: missing required 'Service:' field",
+		":1: error: missing required 'Method:' field",
+		":3: error: missing required 'GRPC <address>' line",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("diagnostics = %q, want %q", got, want)
+	}
+}
+
+func TestLint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ok.grpc")
+	content := "GRPC http://localhost:8080\nService: svc\nMethod: m\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	diags, err := Lint(path, func(req *RequestFile) []Diagnostic {
+		return []Diagnostic{{Line: req.Line, Severity: SeverityError, Message: "unknown service"}}
+	})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	if len(diags) != 1 || diags[0].String() != path+":1: error: unknown service" {
+		t.Errorf("diagnostics = %v", diags)
+	}
+}
+
+func TestParseReader_ToleratesLintProblems(t *testing.T) {
+	content := "GRPC http://localhost:8080\nService: svc\nMethod: m\n[Asserts]\njsonpath $.bad\nstatus equals ok\n"
+	requests, err := ParseReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseReader failed: %v", err)
+	}
+	// The unparseable assertion is skipped; the unknown operator fails when run
+	if len(requests[0].Asserts) != 1 || requests[0].Asserts[0].Operator != "equals" {
+		t.Errorf("asserts = %+v", requests[0].Asserts)
+	}
+}
+
+func TestLintReader_Columns(t *testing.T) {
+	content := "GRPC http://localhost:8080\nService: svc\nMethod: m\nTimeout:  soon\n[Asserts]\n  jsonpath \"$.id\" equals \"1\"\njsonpath $.id == \"1\"\nstatus ==\nheader \"x-id\" not\n"
+	diags, err := LintReader(strings.NewReader(content), nil)
+	if err != nil {
+		t.Fatalf("LintReader failed: %v", err)
+	}
+
+	want := []struct {
+		line, column int
+		snippet      string
+	}{
+		{4, 11, "Timeout:  soon\n          ^"},
+		{6, 19, "  jsonpath \"$.id\" equals \"1\"\n                  ^"},
+		{7, 10, "jsonpath $.id == \"1\"\n         ^"},
+		{8, 10, "status ==\n         ^"},
+		{9, 18, "header \"x-id\" not\n                 ^"},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("got %d diagnostics, want %d: %v", len(diags), len(want), diags)
+	}
+	for i, w := range want {
+		d := diags[i]
+		if d.Line != w.line || d.Column != w.column || d.Snippet() != w.snippet {
+			t.Errorf("diagnostic %d = %d:%d\n%s\nwant %d:%d\n%s", i, d.Line, d.Column, d.Snippet(), w.line, w.column, w.snippet)
+		}
+	}
+}
+
+func TestParseStrict(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.grpc")
+	content := "GRPC http://localhost:8080\nService: svc\nMethod: m\n[Captures]\nmissing colon\n[Asserts]\nstatus equals ok\n"
+	if err := os.WriteFile(bad, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ParseStrict(bad)
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected a SyntaxError, got %v", err)
+	}
+	if len(syntaxErr.Diagnostics) != 2 {
+		t.Errorf("expected 2 errors, got %v", syntaxErr.Diagnostics)
+	}
+	if want := bad + `:5:1: error: malformed capture "missing colon": expected '<name>: <path>' (and 1 more)`; err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+
+	good := filepath.Join(dir, "good.grpc")
+	if err := os.WriteFile(good, []byte("GRPC http://localhost:8080\nService: svc\nMethod: m\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	requests, err := ParseStrict(good)
+	if err != nil || len(requests) != 1 {
+		t.Errorf("ParseStrict = %v, %v", requests, err)
+	}
+}
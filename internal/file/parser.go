@@ -2,32 +2,130 @@ package file
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // RequestFile represents a parsed .grpc request file
 type RequestFile struct {
-	Name     string            // Optional request name (from comment)
-	Address  string            // Server address (from GRPC line)
-	Service  string            // Fully qualified service name
-	Method   string            // Method name
-	Protocol string            // grpc, grpc-web, or connect
-	Timeout  time.Duration     // Request timeout
-	Headers  map[string]string // HTTP headers
-	Body     string            // JSON request body
-	Captures map[string]string // Captured variables from response
-	Asserts  []Assertion       // List of assertions
+	Name            string             // Optional request name (from comment)
+	Address         string             // Server address (from GRPC line)
+	Prefix          string             // Optional route prefix, appended to the address path
+	Service         string             // Fully qualified service name
+	Method          string             // Method name
+	Protocol        string             // grpc, grpc-web, or connect
+	Timeout         time.Duration      // Request timeout
+	TimeoutTemplate string             // Timeout as written when it contains placeholders, parsed once they are resolved
+	Headers         map[string]string  // HTTP headers
+	BasicAuth       string             // Optional user:password, sent as a Basic Authorization header
+	ClientCert      string             // Optional PEM client certificate for mutual TLS, relative to the file
+	ClientKey       string             // Optional PEM private key of ClientCert, relative to the file
+	CACert          string             // Optional PEM CA certificates (file or directory) trusted for the server, relative to the file
+	Insecure        bool               // Skip verification of the server certificate
+	Body            string             // JSON request body
+	Output          string             // Optional file the response body is written to instead of printed, relative to the file
+	ExpectFailure   bool               // The request is expected to fail: its failure passes and its success fails
+	Needs           []string           // Names of the requests of the file that must pass first, one per Needs line
+	Skip            bool               // The request is not run, but reported as skipped
+	SkipReason      string             // Why the request is skipped, when Skip gives one instead of true
+	Snapshot        bool               // Compare the response with a snapshot, written on the first run
+	SnapshotPath    string             // Where the snapshot of the response is kept, when parsed from a file (see SnapshotPath)
+	Dir             string             // Directory of the file, which {{file}} paths are relative to (empty = the working directory)
+	Captures        map[string]Capture // Captured variables from response
+	Vars            map[string]string  // Variables defined in a [Variables] section
+	Secrets         []string           // Variables and jsonpaths whose values are masked in output, from a [Secrets] section
+	Normalize       []Normalization    // Dynamic values of the body, e.g. timestamps, that body comparisons ignore, from a [Normalize] section
+	Asserts         []Assertion        // List of assertions
+	Line            int                // Line number the request starts at in its file
+
+	captureLines map[string]int // Line each capture is defined on, for lint
+}
+
+// Capture describes how a variable is extracted from the response
+type Capture struct {
+	Path  string // jsonpath expression
+	Regex string // Optional regex applied to the extracted value (first group is captured)
+}
+
+// Normalization makes the values a jsonpath selects in the response body,
+// and in the document it is compared with, compare equal when they are
+// dynamic, e.g. timestamps or generated IDs
+type Normalization struct {
+	Path string // jsonpath expression
+	Kind string // One of NormalizeKinds
+}
+
+// NormalizeKinds are the kinds of normalization:
+// - ignore removes the values, so they may also be missing
+// - any replaces them with a placeholder, so they must be present
+// - uuid and timestamp replace UUIDs and RFC 3339 timestamps with a
+// placeholder, so values of another form still differ
+var NormalizeKinds = []string{"ignore", "any", "uuid", "timestamp"}
+
+// ParseNormalization checks the kind of a normalization of path
+func ParseNormalization(path, kind string) (Normalization, error) {
+	if path == "" {
+		return Normalization{}, fmt.Errorf("missing jsonpath")
+	}
+	if !slices.Contains(NormalizeKinds, kind) {
+		return Normalization{}, fmt.Errorf("unknown normalization %q, expected one of: %s", kind, strings.Join(NormalizeKinds, ", "))
+	}
+	return Normalization{Path: path, Kind: kind}, nil
 }
 
 // Assertion represents a check to be performed on the response
 type Assertion struct {
-	Type     string // "jsonpath", "header", "status"
-	Key      string // jsonpath expression or header name
-	Operator string // "==", "!=", "contains"
-	Value    string // Expected value (as string)
+	Type     string // "jsonpath", "header", "trailer", "status", "bytes" (or "responsesize"), "count", "body", "certificate"
+	Key      string // jsonpath expression or header/trailer name (empty for keyless types)
+	Filter   string // Optional filter applied to the value before comparing, e.g. "count"
+	Operator string // "==", "!=", "contains", "matches", "in", "exists", "isString" (and other type checks), or "<", "<=", ">", ">=", "approx" for numeric values
+	Negate   bool   // Set by a "not" before the operator, which inverts it, e.g. jsonpath "$.error" not exists
+	Value    string // Expected value (as string, empty for unary operators)
+
+	// Tolerance is the allowed difference for the approx operator, e.g.
+	// jsonpath "$.score" approx 0.95 tolerance 0.01 (empty = default)
+	Tolerance string
+
+	// File is set when Value is the path of a golden file holding the
+	// expected body, e.g. body == file "golden/get_user.json"
+	File bool
+
+	// JSONPath is set when Value is a JSONPath evaluated against the same
+	// response, e.g. jsonpath "$.request_id" == jsonpath "$.echo.request_id"
+	JSONPath bool
+}
+
+// Clone returns a deep copy of the request, so it can be resolved
+// (e.g. template-substituted) without mutating the parsed original
+func (r *RequestFile) Clone() *RequestFile {
+	c := *r
+	c.Headers = make(map[string]string, len(r.Headers))
+	for k, v := range r.Headers {
+		c.Headers[k] = v
+	}
+	c.Captures = make(map[string]Capture, len(r.Captures))
+	for k, v := range r.Captures {
+		c.Captures[k] = v
+	}
+	c.Vars = make(map[string]string, len(r.Vars))
+	for k, v := range r.Vars {
+		c.Vars[k] = v
+	}
+	c.Asserts = append([]Assertion(nil), r.Asserts...)
+	c.Secrets = append([]string(nil), r.Secrets...)
+	c.Normalize = append([]Normalization(nil), r.Normalize...)
+	c.Needs = append([]string(nil), r.Needs...)
+	return &c
 }
 
 // Parse reads and parses a .grpc request file (returns first request only)
@@ -53,21 +151,114 @@ func ParseMultiple(path string) ([]*RequestFile, error) {
 		_ = file.Close()
 	}()
 
-	scanner := bufio.NewScanner(file)
-	var sections [][]string
-	var currentSection []string
+	requests, err := ParseReader(file)
+	if err != nil {
+		return nil, err
+	}
+	ResolvePaths(path, requests)
+	return requests, nil
+}
+
+// ResolvePaths makes the paths of requests parsed from the file at path
+// relative to that file rather than the working directory: golden files,
+// certificates, outputs, snapshots, and those read by {{file}}
+func ResolvePaths(path string, requests []*RequestFile) {
+	dir := filepath.Dir(path)
+	for _, req := range requests {
+		req.Dir = dir
+		for _, p := range []*string{&req.ClientCert, &req.ClientKey, &req.CACert, &req.Output} {
+			if *p != "" && !filepath.IsAbs(*p) {
+				*p = filepath.Join(dir, *p)
+			}
+		}
+		for i, a := range req.Asserts {
+			if a.File && !filepath.IsAbs(a.Value) {
+				req.Asserts[i].Value = filepath.Join(dir, a.Value)
+			}
+		}
+	}
+	for i, req := range requests {
+		req.SnapshotPath = SnapshotPath(path, requests, i)
+	}
+}
+
+// SnapshotPath returns where the snapshot of the response to request i of
+// the file at path is kept: in a __snapshots__ directory next to the file,
+// named after the file and the request, e.g.
+// __snapshots__/users/get-user.json. Requests without a name, or whose
+// name is not unique, are named by their position, e.g. request-2.json.
+func SnapshotPath(path string, requests []*RequestFile, i int) string {
+	stem := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	name := fmt.Sprintf("request-%d", i+1)
+	if slug := slugify(requests[i].Name); slug != "" {
+		named := 0
+		for _, r := range requests {
+			if r.Name == requests[i].Name {
+				named++
+			}
+		}
+		if named == 1 {
+			name = slug
+		}
+	}
+	return filepath.Join(filepath.Dir(path), "__snapshots__", stem, name+".json")
+}
+
+// slugify lowercases s and joins its runs of letters and digits with -
+func slugify(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+// ParseReader parses .grpc content containing one or more requests from r,
+// e.g. a scenario received over the network rather than read from disk
+func ParseReader(r io.Reader) ([]*RequestFile, error) {
+	sections, err := splitSections(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var requests []*RequestFile
+	for i, sec := range sections {
+		req, diags := parseContent(sec)
+		for _, d := range diags {
+			if d.fatal {
+				return nil, fmt.Errorf("request %d: %w", i+1, d.err)
+			}
+		}
+		requests = append(requests, req)
+	}
+
+	return requests, nil
+}
+
+// section is the text of one request and the line number it starts at
+type section struct {
+	lines []string
+	start int // 1-based line number of lines[0]
+}
+
+// splitSections splits .grpc content into requests at "---" lines
+func splitSections(r io.Reader) ([]section, error) {
+	scanner := bufio.NewScanner(r)
+	var sections []section
+	current := section{start: 1}
+	lineNum := 0
 
 	for scanner.Scan() {
 		line := scanner.Text()
+		lineNum++
 		// Check for separator
 		if strings.TrimSpace(line) == "---" {
-			if len(currentSection) > 0 {
-				sections = append(sections, currentSection)
-				currentSection = nil
+			if len(current.lines) > 0 {
+				sections = append(sections, current)
 			}
+			current = section{start: lineNum + 1}
 			continue
 		}
-		currentSection = append(currentSection, line)
+		current.lines = append(current.lines, line)
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -75,48 +266,50 @@ func ParseMultiple(path string) ([]*RequestFile, error) {
 	}
 
 	// Don't forget the last section
-	if len(currentSection) > 0 {
-		sections = append(sections, currentSection)
+	if len(current.lines) > 0 {
+		sections = append(sections, current)
 	}
 
 	if len(sections) == 0 {
 		return nil, fmt.Errorf("no requests found in file")
 	}
-
-	var requests []*RequestFile
-	for i, section := range sections {
-		req, err := parseContent(section, i+1)
-		if err != nil {
-			return nil, fmt.Errorf("request %d: %w", i+1, err)
-		}
-		requests = append(requests, req)
-	}
-
-	return requests, nil
+	return sections, nil
 }
 
-// parseContent parses a single request from lines of text
-func parseContent(lines []string, requestNum int) (*RequestFile, error) {
-
-	// Move body lines processing earlier or handle logic flow:
-	// The previous loop was skipping lines inside `inBody`.
-	// We need to refactor the loop to correctly handle sections.
-	// However, looking at the previous code, it was mixing line-by-line parsing with a flag.
-
-	// Let's rewrite the parsing loop logic slightly to support sections.
-	// Re-parsing the lines is cleaner.
-
+// parseContent parses a single request. Problems are returned as
+// diagnostics rather than aborting the parse, so that lint can report all
+// of them; ParseReader fails on the first fatal one and tolerates the rest
+// (e.g. skips malformed capture and assertion lines).
+func parseContent(sec section) (*RequestFile, []Diagnostic) {
 	req := &RequestFile{
+		Line:     sec.start,
 		Protocol: "grpc-web",
 		Timeout:  30 * time.Second,
 		Headers:  make(map[string]string),
-		Captures: make(map[string]string),
+		Captures: make(map[string]Capture),
+		Vars:     make(map[string]string),
 	}
 
-	var currentSection string // "", "Body", "Captures", "Asserts"
+	var diags []Diagnostic
+	// report records a problem on a source line ("" for the whole request)
+	report := func(lineNum int, line, severity string, fatal bool, err error) {
+		diags = append(diags, Diagnostic{
+			Line:     lineNum,
+			Column:   column(line, err),
+			Severity: severity,
+			Message:  err.Error(),
+			Source:   line,
+			err:      err,
+			fatal:    fatal,
+		})
+	}
+
+	var currentSection string // "", "Body", "Variables", "Captures", "Secrets", "Normalize", "Asserts"
 	var bodyLines []string
+	headerLines := make(map[string]int) // Canonical header name -> line it was set on
 
-	for _, line := range lines {
+	for i, line := range sec.lines {
+		lineNum := sec.start + i
 		trimmed := strings.TrimSpace(line)
 
 		// Skip empty lines if not in a body/block
@@ -134,30 +327,96 @@ func parseContent(lines []string, requestNum int) (*RequestFile, error) {
 		}
 
 		// Detect section headers
+		if trimmed == "[Variables]" {
+			currentSection = "Variables"
+			continue
+		}
 		if trimmed == "[Captures]" {
 			currentSection = "Captures"
 			continue
 		}
+		if trimmed == "[Secrets]" {
+			currentSection = "Secrets"
+			continue
+		}
+		if trimmed == "[Normalize]" {
+			currentSection = "Normalize"
+			continue
+		}
 		if trimmed == "[Asserts]" {
 			currentSection = "Asserts"
 			continue
 		}
 
+		// If we are in Variables section
+		if currentSection == "Variables" {
+			if trimmed == "" {
+				continue
+			}
+			name, value, ok := strings.Cut(trimmed, ":")
+			if !ok || strings.TrimSpace(name) == "" {
+				report(lineNum, line, SeverityError, false, fmt.Errorf("malformed variable %q: expected '<name>: <value>'", trimmed))
+				continue
+			}
+			req.Vars[strings.TrimSpace(name)] = strings.TrimSpace(value)
+			continue
+		}
+
 		// If we are in Captures section
 		if currentSection == "Captures" {
 			if trimmed == "" {
 				continue
 			}
-			// Parse key: value
+			// Parse key: value; malformed lines are skipped
 			parts := strings.SplitN(line, ":", 2)
 			if len(parts) != 2 {
-				// invalid capture line, maybe log warning or error?
-				// content parser returning error is better
-				continue // strict parsing might fail on empty lines or comments
+				report(lineNum, line, SeverityError, false, fmt.Errorf("malformed capture %q: expected '<name>: <path>'", trimmed))
+				continue
 			}
 			key := strings.TrimSpace(parts[0])
-			val := strings.TrimSpace(parts[1])
-			req.Captures[key] = val
+			capture, err := parseCapture(strings.TrimSpace(parts[1]))
+			if err != nil {
+				report(lineNum, line, SeverityError, true, fmt.Errorf("invalid capture %q: %w", key, err))
+				continue
+			}
+			req.Captures[key] = capture
+			if req.captureLines == nil {
+				req.captureLines = make(map[string]int)
+			}
+			req.captureLines[key] = lineNum
+			continue
+		}
+
+		// If we are in Secrets section: variable names or jsonpaths
+		if currentSection == "Secrets" {
+			if trimmed == "" {
+				continue
+			}
+			if !strings.HasPrefix(trimmed, "$") && strings.ContainsAny(trimmed, " \t:{}") {
+				report(lineNum, line, SeverityError, false, fmt.Errorf("invalid secret %q: expected a variable name or a jsonpath starting with $", trimmed))
+				continue
+			}
+			req.Secrets = append(req.Secrets, trimmed)
+			continue
+		}
+
+		// If we are in Normalize section: jsonpath: kind. The path is cut
+		// at the last colon, as filters may contain colons.
+		if currentSection == "Normalize" {
+			if trimmed == "" {
+				continue
+			}
+			colon := strings.LastIndex(trimmed, ":")
+			if colon == -1 {
+				report(lineNum, line, SeverityError, false, fmt.Errorf("malformed normalization %q: expected '<jsonpath>: <kind>'", trimmed))
+				continue
+			}
+			n, err := ParseNormalization(strings.TrimSpace(trimmed[:colon]), strings.TrimSpace(trimmed[colon+1:]))
+			if err != nil {
+				report(lineNum, line, SeverityError, false, fmt.Errorf("invalid normalization %q: %w", trimmed, err))
+				continue
+			}
+			req.Normalize = append(req.Normalize, n)
 			continue
 		}
 
@@ -168,75 +427,18 @@ func parseContent(lines []string, requestNum int) (*RequestFile, error) {
 			}
 			// Parse assertion: type "key" op "value"
 			// Example: jsonpath "$.id" == "123"
-			// Simple parser avoiding regex for now to avoid dependency complexity if possible,
-			// but regex is clearer for quoted strings.
-			// Let's use specific logic for the expected format.
-			parts := strings.Fields(trimmed)
-			if len(parts) >= 4 {
-				// We need to handle quotes. strings.Fields splits by space, breaking quoted strings.
-				// Let's rely on a helper or regex.
-				// Given the constraints and simplicity, let's try a custom split function or just regex.
-				// A simple way for now: assumes standard formatting jsonpath "key" op "value"
-
-				// Re-parsing line to handle quotes properly
-				// Format: <type> <key_q> <op> <value_q>
-				// or: <type> <key_q> <op> <value_raw> (if value is number/bool)
-
-				// Let's use a robust approach: find first space, then parse first quoted string, etc.
-				// But to keep it simple and consistent with strict hurl-like syntax:
-
-				// 1. Type
-				firstSpace := strings.Index(trimmed, " ")
-				if firstSpace == -1 {
-					continue
-				}
-				aType := trimmed[:firstSpace]
-				rest := strings.TrimSpace(trimmed[firstSpace:])
-
-				// 2. Key (quoted)
-				if !strings.HasPrefix(rest, "\"") {
-					continue
-				}
-				rest = rest[1:] // skip open quote
-				endQuote := strings.Index(rest, "\"")
-				if endQuote == -1 {
-					continue
-				}
-				key := rest[:endQuote]
-				rest = strings.TrimSpace(rest[endQuote+1:])
-
-				// 3. Operator
-				firstSpace = strings.Index(rest, " ")
-				if firstSpace == -1 {
-					continue
-				}
-				op := rest[:firstSpace]
-				rest = strings.TrimSpace(rest[firstSpace:])
-
-				// 4. Value (quoted or raw)
-				var val string
-				if strings.HasPrefix(rest, "\"") {
-					// create valid string from quoted
-					rest = rest[1:]
-					endQuote = strings.LastIndex(rest, "\"") // Use LastIndex to handle simple cases? No, strict.
-					// Actually, value might contain quotes.
-					// For simple implementation, let's assume valid JSON string or simple string.
-					// Let's just take until the end quote?
-					endQuote = strings.Index(rest, "\"")
-					if endQuote != -1 {
-						val = rest[:endQuote]
-					}
-				} else {
-					val = rest
-				}
-
-				req.Asserts = append(req.Asserts, Assertion{
-					Type:     aType,
-					Key:      key,
-					Operator: op,
-					Value:    val,
-				})
+			// Malformed lines are skipped
+			a, opAt, err := scanAssertion(trimmed)
+			if err != nil {
+				report(lineNum, line, SeverityError, false, fmt.Errorf("invalid assertion %q: %w", trimmed, err))
+				continue
 			}
+			if !assertionTypes[a.Type] {
+				report(lineNum, line, SeverityError, false, fmt.Errorf("unknown assertion type %q", a.Type))
+			} else if !assertionOperators[a.Operator] {
+				report(lineNum, line, SeverityError, false, errorAt(opAt, "unknown operator %q", a.Operator))
+			}
+			req.Asserts = append(req.Asserts, a)
 			continue
 		}
 
@@ -258,28 +460,90 @@ func parseContent(lines []string, requestNum int) (*RequestFile, error) {
 
 		// Parse key: value pairs for main section
 		colonIdx := strings.Index(line, ":")
-		if colonIdx != -1 {
-			key := strings.TrimSpace(line[:colonIdx])
-			value := strings.TrimSpace(line[colonIdx+1:])
-
-			switch key {
-			case "Service":
-				req.Service = value
-			case "Method":
-				req.Method = value
-			case "Protocol":
-				req.Protocol = value
-			case "Timeout":
-				duration, err := time.ParseDuration(value)
-				if err != nil {
-					return nil, fmt.Errorf("invalid timeout duration %q: %w", value, err)
-				}
-				req.Timeout = duration
+		if colonIdx == -1 {
+			report(lineNum, line, SeverityError, false, fmt.Errorf("unrecognized line %q", trimmed))
+			continue
+		}
+		key := strings.TrimSpace(line[:colonIdx])
+		value := strings.TrimSpace(line[colonIdx+1:])
+
+		switch key {
+		case "Service":
+			req.Service = value
+		case "Method":
+			req.Method = value
+		case "Prefix":
+			req.Prefix = value
+		case "Protocol":
+			req.Protocol = value
+		case "BasicAuth":
+			req.BasicAuth = value
+		case "ClientCert":
+			req.ClientCert = value
+		case "ClientKey":
+			req.ClientKey = value
+		case "CACert":
+			req.CACert = value
+		case "Insecure":
+			insecure, err := strconv.ParseBool(value)
+			if err != nil {
+				report(lineNum, line, SeverityError, true, errorAt(value, "invalid Insecure value %q, expected true or false", value))
+				continue
+			}
+			req.Insecure = insecure
+		case "Output":
+			req.Output = value
+		case "ExpectFailure":
+			expect, err := strconv.ParseBool(value)
+			if err != nil {
+				report(lineNum, line, SeverityError, true, errorAt(value, "invalid ExpectFailure value %q, expected true or false", value))
+				continue
+			}
+			req.ExpectFailure = expect
+		case "Skip":
+			// Skip: true, or the reason to skip
+			skip, err := strconv.ParseBool(value)
+			switch {
+			case value == "":
+				report(lineNum, line, SeverityError, true, fmt.Errorf("missing value after Skip:, expected true, false, or a reason"))
+				continue
+			case err != nil:
+				req.Skip, req.SkipReason = true, value
 			default:
-				// Treat as HTTP header
-				req.Headers[key] = value
+				req.Skip, req.SkipReason = skip, ""
 			}
-			continue
+		case "Snapshot":
+			snapshot, err := strconv.ParseBool(value)
+			if err != nil {
+				report(lineNum, line, SeverityError, true, errorAt(value, "invalid Snapshot value %q, expected true or false", value))
+				continue
+			}
+			req.Snapshot = snapshot
+		case "Needs":
+			if value == "" {
+				report(lineNum, line, SeverityError, true, fmt.Errorf("missing request name after Needs:"))
+				continue
+			}
+			req.Needs = append(req.Needs, value)
+		case "Timeout":
+			if strings.Contains(value, "{{") {
+				req.TimeoutTemplate = value
+				continue
+			}
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				report(lineNum, line, SeverityError, true, errorAt(value, "invalid timeout duration %q: %w", value, err))
+				continue
+			}
+			req.Timeout = duration
+		default:
+			// Treat as HTTP header
+			name := http.CanonicalHeaderKey(key)
+			if prev, ok := headerLines[name]; ok {
+				report(lineNum, line, SeverityWarning, false, fmt.Errorf("duplicate header %q overrides line %d", key, prev))
+			}
+			headerLines[name] = lineNum
+			req.Headers[key] = value
 		}
 	}
 
@@ -291,14 +555,298 @@ func parseContent(lines []string, requestNum int) (*RequestFile, error) {
 
 	// Validate required fields
 	if req.Address == "" {
-		return nil, fmt.Errorf("missing required 'GRPC <address>' line")
+		report(sec.start, "", SeverityError, true, fmt.Errorf("missing required 'GRPC <address>' line"))
 	}
 	if req.Service == "" {
-		return nil, fmt.Errorf("missing required 'Service:' field")
+		report(sec.start, "", SeverityError, true, fmt.Errorf("missing required 'Service:' field"))
 	}
 	if req.Method == "" {
-		return nil, fmt.Errorf("missing required 'Method:' field")
+		report(sec.start, "", SeverityError, true, fmt.Errorf("missing required 'Method:' field"))
+	}
+
+	return req, diags
+}
+
+// assertionTypes are the assertion types the assert package evaluates
+var assertionTypes = map[string]bool{
+	"jsonpath":     true,
+	"header":       true,
+	"trailer":      true,
+	"status":       true,
+	"bytes":        true,
+	"responsesize": true,
+	"count":        true,
+	"body":         true,
+	"certificate":  true,
+}
+
+// assertionOperators are the operators the assert package evaluates
+var assertionOperators = map[string]bool{
+	"==":        true,
+	"!=":        true,
+	"contains":  true,
+	"matches":   true,
+	"in":        true,
+	"approx":    true,
+	"<":         true,
+	"<=":        true,
+	">":         true,
+	">=":        true,
+	"exists":    true,
+	"isString":  true,
+	"isNumber":  true,
+	"isBoolean": true,
+	"isArray":   true,
+	"isObject":  true,
+	"isNull":    true,
+}
+
+// keylessAssertions are assertion types that take no quoted key,
+// e.g. status == "not_found"
+var keylessAssertions = map[string]bool{
+	"status":       true,
+	"bytes":        true,
+	"responsesize": true,
+	"body":         true,
+}
+
+// assertionFilters are keywords that may follow the key to transform the
+// value before comparing, e.g. jsonpath "$.items" count == 3
+var assertionFilters = map[string]bool{
+	"count": true,
+}
+
+// unaryOperators are assertion operators that take no value,
+// e.g. trailer "grpc-status-details-bin" exists
+var unaryOperators = map[string]bool{
+	"exists":    true,
+	"isString":  true,
+	"isNumber":  true,
+	"isBoolean": true,
+	"isArray":   true,
+	"isObject":  true,
+	"isNull":    true,
+}
+
+// typeOperators check the JSON type of a jsonpath value,
+// e.g. jsonpath "$.user.id" isString
+var typeOperators = map[string]bool{
+	"isString":  true,
+	"isNumber":  true,
+	"isBoolean": true,
+	"isArray":   true,
+	"isObject":  true,
+	"isNull":    true,
+}
+
+// parseAssertion parses a single assertion line.
+// Format: <type> "<key>" [filter] [not] <op> <value>, where the value is
+// either quoted or taken verbatim up to the end of the line. Keyless types
+// omit the key and unary operators omit the value.
+func parseAssertion(line string) (Assertion, error) {
+	a, _, err := scanAssertion(line)
+	return a, err
+}
+
+// scanAssertion parses an assertion like parseAssertion and also returns
+// the text of line starting at the operator, to locate it in diagnostics
+func scanAssertion(line string) (Assertion, string, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return Assertion{}, "", fmt.Errorf("empty assertion")
+	}
+	a := Assertion{Type: fields[0]}
+	rest := strings.TrimSpace(strings.TrimPrefix(line, a.Type))
+
+	// Key (quoted)
+	if !keylessAssertions[a.Type] {
+		key, remaining, err := parseQuoted(rest)
+		if err != nil {
+			return Assertion{}, "", fmt.Errorf("%s assertion key: %w", a.Type, err)
+		}
+		a.Key = key
+		rest = strings.TrimSpace(remaining)
+	}
+
+	// Optional filter
+	if filter, remaining := cutField(rest); assertionFilters[filter] {
+		a.Filter = filter
+		rest = strings.TrimSpace(remaining)
+	}
+
+	// Operator
+	opAt := rest
+	op, remaining := cutField(rest)
+	if op == "" {
+		return Assertion{}, "", errorAt(rest, "missing operator")
+	}
+	if op == "not" {
+		opAt = strings.TrimSpace(remaining)
+		op, remaining = cutField(opAt)
+		if op == "" || op == "not" {
+			return Assertion{}, "", errorAt(opAt, "missing operator after \"not\"")
+		}
+		a.Negate = true
+	}
+	a.Operator = op
+	rest = strings.TrimSpace(remaining)
+
+	if typeOperators[op] && a.Type != "jsonpath" {
+		return Assertion{}, "", errorAt(opAt, "operator %q is only supported for jsonpath assertions", op)
+	}
+	if unaryOperators[op] {
+		if rest != "" {
+			return Assertion{}, "", errorAt(rest, "operator %q takes no value, got %q", op, rest)
+		}
+		return a, opAt, nil
+	}
+
+	// Value (quoted or raw)
+	if rest == "" {
+		return Assertion{}, "", errorAt(rest, "missing value for operator %q", op)
+	}
+	if op == "approx" {
+		a, err := parseApprox(a, rest)
+		return a, opAt, err
+	}
+	if path, ok := strings.CutPrefix(rest, "file "); ok {
+		if a.Type != "body" {
+			return Assertion{}, "", errorAt(rest, "file values are only supported for body assertions")
+		}
+		val, _, err := parseQuoted(strings.TrimSpace(path))
+		if err != nil {
+			return Assertion{}, "", fmt.Errorf("file path: %w", err)
+		}
+		a.Value = val
+		a.File = true
+		return a, opAt, nil
+	}
+	if path, ok := strings.CutPrefix(rest, "jsonpath "); ok {
+		val, _, err := parseQuoted(strings.TrimSpace(path))
+		if err != nil {
+			return Assertion{}, "", fmt.Errorf("value jsonpath: %w", err)
+		}
+		a.Value = val
+		a.JSONPath = true
+		return a, opAt, nil
 	}
+	if strings.HasPrefix(rest, "\"") {
+		val, _, err := parseQuoted(rest)
+		if err != nil {
+			return Assertion{}, "", fmt.Errorf("assertion value: %w", err)
+		}
+		a.Value = val
+	} else {
+		a.Value = rest
+	}
+
+	return a, opAt, nil
+}
+
+// parseApprox parses the value of an approx assertion: a number optionally
+// followed by "tolerance <number>"
+func parseApprox(a Assertion, rest string) (Assertion, error) {
+	fields := strings.Fields(rest)
+	switch {
+	case len(fields) == 1:
+	case len(fields) == 3 && fields[1] == "tolerance":
+		a.Tolerance = fields[2]
+	default:
+		return Assertion{}, errorAt(rest, "expected 'approx <number> [tolerance <number>]', got %q", "approx "+rest)
+	}
+	a.Value = fields[0]
+	return a, nil
+}
+
+// cutField splits s at the first whitespace, returning the first field and
+// the remaining text
+func cutField(s string) (field, rest string) {
+	if i := strings.IndexAny(s, " \t"); i != -1 {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
 
-	return req, nil
+// parseCapture parses the value side of a capture line.
+// Supported forms:
+// - path.to.value
+// - regex "$.message" "id=(\d+)"
+func parseCapture(value string) (Capture, error) {
+	rest, ok := strings.CutPrefix(value, "regex ")
+	if !ok {
+		return Capture{Path: value}, nil
+	}
+
+	path, rest, err := parseQuoted(strings.TrimSpace(rest))
+	if err != nil {
+		return Capture{}, fmt.Errorf("regex capture path: %w", err)
+	}
+	pattern, rest, err := parseQuoted(strings.TrimSpace(rest))
+	if err != nil {
+		return Capture{}, fmt.Errorf("regex capture pattern: %w", err)
+	}
+	if rest = strings.TrimSpace(rest); rest != "" {
+		return Capture{}, errorAt(rest, "unexpected trailing text %q", rest)
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		return Capture{}, fmt.Errorf("invalid regex: %w", err)
+	}
+
+	return Capture{Path: path, Regex: pattern}, nil
+}
+
+// parseQuoted reads a double-quoted string from the start of s and returns
+// its contents and the remaining text. A backslash-escaped quote (\") is
+// kept as a literal quote; all other backslashes are preserved so regex
+// escapes such as \d survive untouched.
+func parseQuoted(s string) (value, rest string, err error) {
+	if !strings.HasPrefix(s, "\"") {
+		return "", s, errorAt(s, "expected quoted string, got %q", s)
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '"':
+			b.WriteByte('"')
+			i++
+		case s[i] == '"':
+			return b.String(), s[i+1:], nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", s, errorAt(s, "unterminated quoted string %q", s)
+}
+
+// positionError is a syntax error located at the start of at, the text of
+// the line that remained to be parsed when the error was found
+type positionError struct {
+	at  string
+	err error
+}
+
+func (e *positionError) Error() string { return e.err.Error() }
+func (e *positionError) Unwrap() error { return e.err }
+
+// errorAt formats a syntax error located at the start of at, which must be
+// a suffix of the line being parsed ("" for its end)
+func errorAt(at, format string, args ...any) error {
+	return &positionError{at: at, err: fmt.Errorf(format, args...)}
+}
+
+// column returns the 1-based column of err in line: where its position
+// error points, or else the first non-blank character. It is 0 for errors
+// that are not about a line.
+func column(line string, err error) int {
+	if line == "" {
+		return 0
+	}
+	trimmed := strings.TrimRight(line, " \t")
+	col := len(line) - len(strings.TrimLeft(line, " \t")) + 1
+	var pe *positionError
+	if errors.As(err, &pe) && strings.HasSuffix(trimmed, pe.at) {
+		col = len(trimmed) - len(pe.at) + 1
+	}
+	return col
 }
@@ -1,6 +1,7 @@
 package file
 
 import (
+	"maps"
 	"strings"
 	"testing"
 )
@@ -16,20 +17,99 @@ Method: method
 var1: path.to.val
 var2: array[0]
 `
-	lines := strings.Split(strings.TrimSpace(content), "\n")
-	req, err := parseContent(lines, 1)
+	requests, err := ParseReader(strings.NewReader(content))
 	if err != nil {
-		t.Fatalf("parseContent failed: %v", err)
+		t.Fatalf("ParseReader failed: %v", err)
 	}
+	req := requests[0]
 
 	if len(req.Captures) != 2 {
 		t.Errorf("Expected 2 captures, got %d", len(req.Captures))
 	}
 
-	if req.Captures["var1"] != "path.to.val" {
-		t.Errorf("Expected var1=path.to.val, got %s", req.Captures["var1"])
+	if req.Captures["var1"].Path != "path.to.val" {
+		t.Errorf("Expected var1=path.to.val, got %s", req.Captures["var1"].Path)
 	}
-	if req.Captures["var2"] != "array[0]" {
-		t.Errorf("Expected var2=array[0], got %s", req.Captures["var2"])
+	if req.Captures["var2"].Path != "array[0]" {
+		t.Errorf("Expected var2=array[0], got %s", req.Captures["var2"].Path)
+	}
+}
+
+func TestParseRegexCaptures(t *testing.T) {
+	content := `
+GRPC http://localhost:8080
+Service: svc
+Method: method
+
+[Captures]
+order_id: regex "$.message" "id=(\d+)"
+quoted: regex "msg" "say \"(\w+)\""
+`
+	requests, err := ParseReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseReader failed: %v", err)
+	}
+	req := requests[0]
+
+	want := Capture{Path: "$.message", Regex: `id=(\d+)`}
+	if req.Captures["order_id"] != want {
+		t.Errorf("Expected %+v, got %+v", want, req.Captures["order_id"])
+	}
+	want = Capture{Path: "msg", Regex: `say "(\w+)"`}
+	if req.Captures["quoted"] != want {
+		t.Errorf("Expected %+v, got %+v", want, req.Captures["quoted"])
+	}
+}
+
+func TestParseRegexCaptures_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"Unquoted path", `regex $.message "id=(\d+)"`},
+		{"Missing pattern", `regex "$.message"`},
+		{"Bad pattern", `regex "$.message" "id=(\d+"`},
+		{"Trailing text", `regex "$.message" "id" extra`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := []string{
+				"GRPC http://localhost:8080",
+				"Service: svc",
+				"Method: method",
+				"[Captures]",
+				"id: " + tt.value,
+			}
+			if _, err := ParseReader(strings.NewReader(strings.Join(lines, "\n"))); err == nil {
+				t.Errorf("expected error for capture %q", tt.value)
+			}
+		})
+	}
+}
+
+func TestParseVariables(t *testing.T) {
+	content := `
+GRPC {{host}}
+Service: svc
+Method: method
+{ "user": "{{user}}" }
+
+[Variables]
+host: http://localhost:8080
+user:  alice
+`
+	requests, err := ParseReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseReader failed: %v", err)
+	}
+	req := requests[0]
+
+	want := map[string]string{"host": "http://localhost:8080", "user": "alice"}
+	if !maps.Equal(req.Vars, want) {
+		t.Errorf("Expected variables %v, got %v", want, req.Vars)
+	}
+	if strings.TrimSpace(req.Body) != `{ "user": "{{user}}" }` {
+		t.Errorf("Unexpected body %q", req.Body)
 	}
 }
@@ -7,7 +7,7 @@ import (
 
 func TestParseCapturesReproduction(t *testing.T) {
 	// Read the actual file causing issues
-	filePath := "../../test.grpc"
+	filePath := "../../testdata/capture_sample.grpc"
 	reqs, err := ParseMultiple(filePath)
 	if err != nil {
 		t.Fatalf("ParseMultiple failed: %v", err)
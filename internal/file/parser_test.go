@@ -3,6 +3,8 @@ package file
 import (
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
@@ -138,6 +140,23 @@ Timeout: 5s
 	}
 }
 
+func TestParseMultiple_TimeoutTemplate(t *testing.T) {
+	content := `GRPC http://localhost:8080
+Service: example.Service
+Method: DoSomething
+Timeout: {{timeout | default "5s"}}
+{}`
+
+	requests := parseTestContent(t, content)
+
+	if requests[0].TimeoutTemplate != `{{timeout | default "5s"}}` {
+		t.Errorf("expected the timeout to be kept for substitution, got %q", requests[0].TimeoutTemplate)
+	}
+	if requests[0].Timeout != 30*time.Second {
+		t.Errorf("expected the default timeout until resolved, got %v", requests[0].Timeout)
+	}
+}
+
 func TestParseMultiple_CustomProtocol(t *testing.T) {
 	content := `GRPC http://localhost:8080
 Service: example.Service
@@ -152,6 +171,40 @@ Protocol: connect
 	}
 }
 
+func TestParseMultiple_Prefix(t *testing.T) {
+	content := `GRPC http://localhost:8080
+Service: example.Service
+Method: DoSomething
+Prefix: /api/grpc
+{}`
+
+	requests := parseTestContent(t, content)
+
+	if requests[0].Prefix != "/api/grpc" {
+		t.Errorf("expected prefix '/api/grpc', got %q", requests[0].Prefix)
+	}
+	if _, ok := requests[0].Headers["Prefix"]; ok {
+		t.Errorf("Prefix should not be treated as a header")
+	}
+}
+
+func TestParseMultiple_BasicAuth(t *testing.T) {
+	content := `GRPC http://localhost:8080
+Service: example.Service
+Method: DoSomething
+BasicAuth: {{user}}:p@ss:word
+{}`
+
+	requests := parseTestContent(t, content)
+
+	if requests[0].BasicAuth != "{{user}}:p@ss:word" {
+		t.Errorf("expected BasicAuth '{{user}}:p@ss:word', got %q", requests[0].BasicAuth)
+	}
+	if _, ok := requests[0].Headers["BasicAuth"]; ok {
+		t.Errorf("BasicAuth should not be treated as a header")
+	}
+}
+
 func TestParseMultiple_MissingAddress(t *testing.T) {
 	content := `Service: example.Service
 Method: DoSomething
@@ -246,6 +299,63 @@ jsonpath "$.items[0]" contains "item1"`
 	}
 }
 
+func TestParseAssertion(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    Assertion
+		wantErr bool
+	}{
+		{"Quoted value", `jsonpath "$.id" == "123"`, Assertion{Type: "jsonpath", Key: "$.id", Operator: "==", Value: "123"}, false},
+		{"Raw value", `jsonpath "$.count" == 10`, Assertion{Type: "jsonpath", Key: "$.count", Operator: "==", Value: "10"}, false},
+		{"Escaped quote", `jsonpath "$.msg" == "say \"hi\""`, Assertion{Type: "jsonpath", Key: "$.msg", Operator: "==", Value: `say "hi"`}, false},
+		{"Tab separated", "header \"x-id\"\t==\t\"1\"", Assertion{Type: "header", Key: "x-id", Operator: "==", Value: "1"}, false},
+		{"Keyless status", `status == "not_found"`, Assertion{Type: "status", Operator: "==", Value: "not_found"}, false},
+		{"Keyless raw status", `status != ok`, Assertion{Type: "status", Operator: "!=", Value: "ok"}, false},
+		{"Keyless bytes", `bytes < 10240`, Assertion{Type: "bytes", Operator: "<", Value: "10240"}, false},
+		{"Keyless response size", `responsesize <= 2048`, Assertion{Type: "responsesize", Operator: "<=", Value: "2048"}, false},
+		{"Count", `count "$.users" <= 50`, Assertion{Type: "count", Key: "$.users", Operator: "<=", Value: "50"}, false},
+		{"Matches with escapes", `jsonpath "$.id" matches "^\d+-[0-9a-f]{4}$"`, Assertion{Type: "jsonpath", Key: "$.id", Operator: "matches", Value: `^\d+-[0-9a-f]{4}$`}, false},
+		{"In list", `jsonpath "$.status" in ["ACTIVE", "PENDING"]`, Assertion{Type: "jsonpath", Key: "$.status", Operator: "in", Value: `["ACTIVE", "PENDING"]`}, false},
+		{"Approx", `jsonpath "$.score" approx 0.95 tolerance 0.01`, Assertion{Type: "jsonpath", Key: "$.score", Operator: "approx", Value: "0.95", Tolerance: "0.01"}, false},
+		{"Approx default tolerance", `jsonpath "$.score" approx 0.95`, Assertion{Type: "jsonpath", Key: "$.score", Operator: "approx", Value: "0.95"}, false},
+		{"Approx missing tolerance", `jsonpath "$.score" approx 0.95 tolerance`, Assertion{}, true},
+		{"Golden file", `body == file "golden/user.json"`, Assertion{Type: "body", Operator: "==", Value: "golden/user.json", File: true}, false},
+		{"Inline body", `body contains "Alice"`, Assertion{Type: "body", Operator: "contains", Value: "Alice"}, false},
+		{"File value for other types", `jsonpath "$.id" == file "id.txt"`, Assertion{}, true},
+		{"JSONPath value", `jsonpath "$.request_id" == jsonpath "$.echo.request_id"`, Assertion{Type: "jsonpath", Key: "$.request_id", Operator: "==", Value: "$.echo.request_id", JSONPath: true}, false},
+		{"JSONPath value unquoted", `jsonpath "$.a" == jsonpath $.b`, Assertion{}, true},
+		{"Count filter", `jsonpath "$.items" count == 3`, Assertion{Type: "jsonpath", Key: "$.items", Filter: "count", Operator: "==", Value: "3"}, false},
+		{"Filter without operator", `jsonpath "$.items" count`, Assertion{}, true},
+		{"Unary operator", `trailer "grpc-status-details-bin" exists`, Assertion{Type: "trailer", Key: "grpc-status-details-bin", Operator: "exists"}, false},
+		{"Unary operator with value", `trailer "x" exists "y"`, Assertion{}, true},
+		{"Type operator", `jsonpath "$.id" isString`, Assertion{Type: "jsonpath", Key: "$.id", Operator: "isString"}, false},
+		{"Type operator with value", `jsonpath "$.id" isString "1"`, Assertion{}, true},
+		{"Type operator for other types", `header "x-id" isNumber`, Assertion{}, true},
+		{"Negated operator", `jsonpath "$.error" not exists`, Assertion{Type: "jsonpath", Key: "$.error", Operator: "exists", Negate: true}, false},
+		{"Negated operator with value", `jsonpath "$.error" not exists "y"`, Assertion{}, true},
+		{"Negated contains", `jsonpath "$.name" not contains "test"`, Assertion{Type: "jsonpath", Key: "$.name", Operator: "contains", Negate: true, Value: "test"}, false},
+		{"Negated type operator", `jsonpath "$.id" not isNull`, Assertion{Type: "jsonpath", Key: "$.id", Operator: "isNull", Negate: true}, false},
+		{"Double negation", `jsonpath "$.id" not not exists`, Assertion{}, true},
+		{"Not without operator", `jsonpath "$.id" not`, Assertion{}, true},
+		{"Unquoted key", `jsonpath $.id == "123"`, Assertion{}, true},
+		{"Missing value", `jsonpath "$.id" ==`, Assertion{}, true},
+		{"Missing operator", `status`, Assertion{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAssertion(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAssertion(%q) error = %v, wantErr %v", tt.line, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseAssertion(%q) = %+v, want %+v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestParse_BackwardCompatibility(t *testing.T) {
 	content := `# Single request
 GRPC http://localhost:8080
@@ -272,6 +382,315 @@ Method: DoSomething
 	}
 }
 
+func TestParseMultiple_GoldenFile(t *testing.T) {
+	content := `GRPC http://localhost:8080
+Service: example.Service
+Method: GetData
+{}
+
+[Asserts]
+body == file "golden/get_data.json"
+body == file "/abs/golden.json"`
+
+	req := parseTestContent(t, content)[0]
+	want := filepath.Join(os.TempDir(), "golden", "get_data.json")
+	if a := req.Asserts[0]; a.Type != "body" || !a.File || a.Value != want {
+		t.Errorf("assert = %+v, want a file relative to the request file (%s)", a, want)
+	}
+	if a := req.Asserts[1]; a.Value != "/abs/golden.json" {
+		t.Errorf("absolute path changed: %q", a.Value)
+	}
+}
+
+func TestParseMultiple_ClientCert(t *testing.T) {
+	content := `GRPC https://localhost:8443
+Service: example.Service
+Method: GetData
+ClientCert: certs/client.crt
+ClientKey: /abs/client.key
+CACert: ca
+{}`
+
+	req := parseTestContent(t, content)[0]
+	if want := filepath.Join(os.TempDir(), "certs", "client.crt"); req.ClientCert != want {
+		t.Errorf("ClientCert = %q, want %q (relative to the request file)", req.ClientCert, want)
+	}
+	if want := filepath.Join(os.TempDir(), "ca"); req.CACert != want {
+		t.Errorf("CACert = %q, want %q (relative to the request file)", req.CACert, want)
+	}
+	if req.ClientKey != "/abs/client.key" {
+		t.Errorf("absolute path changed: %q", req.ClientKey)
+	}
+	if len(req.Headers) != 0 {
+		t.Errorf("ClientCert and ClientKey should not be treated as headers: %v", req.Headers)
+	}
+}
+
+func TestResolvePaths(t *testing.T) {
+	content := `# Get data
+GRPC https://localhost:8443
+Service: example.Service
+Method: GetData
+ClientCert: certs/client.crt
+Output: out/data.json
+{}
+
+[Asserts]
+body == file "golden/get_data.json"`
+
+	// As for a scenario read elsewhere, e.g. by a bench controller
+	requests, err := ParseReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseReader failed: %v", err)
+	}
+	ResolvePaths(filepath.Join("scenarios", "data.grpc"), requests)
+
+	req := requests[0]
+	if req.Dir != "scenarios" {
+		t.Errorf("Dir = %q, want scenarios", req.Dir)
+	}
+	if want := filepath.Join("scenarios", "certs", "client.crt"); req.ClientCert != want {
+		t.Errorf("ClientCert = %q, want %q", req.ClientCert, want)
+	}
+	if want := filepath.Join("scenarios", "out", "data.json"); req.Output != want {
+		t.Errorf("Output = %q, want %q", req.Output, want)
+	}
+	if want := filepath.Join("scenarios", "golden", "get_data.json"); req.Asserts[0].Value != want {
+		t.Errorf("golden file = %q, want %q", req.Asserts[0].Value, want)
+	}
+	if want := filepath.Join("scenarios", "__snapshots__", "data", "get-data.json"); req.SnapshotPath != want {
+		t.Errorf("SnapshotPath = %q, want %q", req.SnapshotPath, want)
+	}
+}
+
+func TestParseMultiple_Output(t *testing.T) {
+	content := `GRPC http://localhost:8080
+Service: example.Service
+Method: GetData
+Output: responses/data.json
+{}`
+
+	req := parseTestContent(t, content)[0]
+	if want := filepath.Join(os.TempDir(), "responses", "data.json"); req.Output != want {
+		t.Errorf("Output = %q, want %q (relative to the request file)", req.Output, want)
+	}
+	if len(req.Headers) != 0 {
+		t.Errorf("Output should not be treated as a header: %v", req.Headers)
+	}
+}
+
+func TestParseMultiple_Secrets(t *testing.T) {
+	content := `GRPC http://localhost:8080
+Service: example.AuthService
+Method: Login
+
+[Captures]
+token: $.token
+
+[Secrets]
+token
+$.refreshToken
+api key: value
+`
+	requests, err := ParseMultiple(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("ParseMultiple failed: %v", err)
+	}
+	if got := requests[0].Secrets; !slices.Equal(got, []string{"token", "$.refreshToken"}) {
+		t.Errorf("Secrets = %q", got)
+	}
+
+	if _, err := ParseStrict(createTempFile(t, content)); err == nil || !strings.Contains(err.Error(), `invalid secret "api key: value"`) {
+		t.Errorf("expected the malformed secret to be reported, got %v", err)
+	}
+}
+
+func TestParseMultiple_Normalize(t *testing.T) {
+	content := `GRPC http://localhost:8080
+Service: example.UserService
+Method: GetUser
+
+[Normalize]
+$.user.create_time: timestamp
+$.events[?(@.type=='a:b')].id: uuid
+$..trace_id: sometimes
+`
+	requests, err := ParseMultiple(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("ParseMultiple failed: %v", err)
+	}
+	want := []Normalization{
+		{Path: "$.user.create_time", Kind: "timestamp"},
+		{Path: "$.events[?(@.type=='a:b')].id", Kind: "uuid"},
+	}
+	if got := requests[0].Normalize; !slices.Equal(got, want) {
+		t.Errorf("Normalize = %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseStrict(createTempFile(t, content)); err == nil || !strings.Contains(err.Error(), `unknown normalization "sometimes"`) {
+		t.Errorf("expected the unknown kind to be reported, got %v", err)
+	}
+}
+
+func TestParseMultiple_Insecure(t *testing.T) {
+	content := `GRPC https://localhost:8443
+Service: example.Service
+Method: GetData
+Insecure: true
+{}`
+
+	req := parseTestContent(t, content)[0]
+	if !req.Insecure {
+		t.Error("expected Insecure to be set")
+	}
+	if len(req.Headers) != 0 {
+		t.Errorf("Insecure should not be treated as a header: %v", req.Headers)
+	}
+
+	diags, err := LintReader(strings.NewReader("GRPC https://localhost:8443\nService: svc\nMethod: m\nInsecure: maybe\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 || diags[0].Message != `invalid Insecure value "maybe", expected true or false` {
+		t.Errorf("expected an invalid Insecure value to be reported, got %v", diags)
+	}
+}
+
+func TestParseMultiple_ExpectFailure(t *testing.T) {
+	content := `GRPC http://localhost:8080
+Service: example.Service
+Method: GetData
+ExpectFailure: true
+{}`
+
+	req := parseTestContent(t, content)[0]
+	if !req.ExpectFailure || len(req.Headers) != 0 {
+		t.Errorf("expected ExpectFailure to be set and not sent as a header, got %v, %v", req.ExpectFailure, req.Headers)
+	}
+
+	diags, err := LintReader(strings.NewReader("GRPC http://localhost:8080\nService: svc\nMethod: m\nExpectFailure: sometimes\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 || diags[0].Message != `invalid ExpectFailure value "sometimes", expected true or false` {
+		t.Errorf("expected an invalid ExpectFailure value to be reported, got %v", diags)
+	}
+}
+
+func TestParseMultiple_Needs(t *testing.T) {
+	content := `# Get user
+GRPC http://localhost:8080
+Service: example.Service
+Method: GetData
+Needs: Create user
+Needs: Log in
+{}`
+
+	req := parseTestContent(t, content)[0]
+	if !slices.Equal(req.Needs, []string{"Create user", "Log in"}) || len(req.Headers) != 0 {
+		t.Errorf("expected Needs not to be sent as headers, got %q, %v", req.Needs, req.Headers)
+	}
+
+	diags, err := LintReader(strings.NewReader("GRPC http://localhost:8080\nService: svc\nMethod: m\nNeeds: \n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 || diags[0].Message != "missing request name after Needs:" {
+		t.Errorf("expected a missing name to be reported, got %v", diags)
+	}
+}
+
+func TestSnapshotPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.grpc")
+	content := `# Get user!
+GRPC http://localhost:8080
+Service: svc
+Method: Get
+Snapshot: true
+{}
+---
+GRPC http://localhost:8080
+Service: svc
+Method: Get
+{}
+---
+# Twice
+GRPC http://localhost:8080
+Service: svc
+Method: Get
+{}
+---
+# Twice
+GRPC http://localhost:8080
+Service: svc
+Method: Get
+{}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	requests, err := ParseMultiple(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(filepath.Dir(path), "__snapshots__", "users")
+	for i, want := range []string{"get-user.json", "request-2.json", "request-3.json", "request-4.json"} {
+		if got := requests[i].SnapshotPath; got != filepath.Join(dir, want) {
+			t.Errorf("request %d: SnapshotPath = %q, want %s", i+1, got, want)
+		}
+	}
+	if !requests[0].Snapshot || requests[1].Snapshot {
+		t.Errorf("Snapshot = %v, %v", requests[0].Snapshot, requests[1].Snapshot)
+	}
+}
+
+func TestParseMultiple_Skip(t *testing.T) {
+	for _, tt := range []struct {
+		line   string
+		skip   bool
+		reason string
+	}{
+		{"Skip: true", true, ""},
+		{"Skip: false", false, ""},
+		{"Skip: flaky until the cache is fixed", true, "flaky until the cache is fixed"},
+	} {
+		req := parseTestContent(t, "GRPC http://localhost:8080\nService: svc\nMethod: m\n"+tt.line+"\n{}")[0]
+		if req.Skip != tt.skip || req.SkipReason != tt.reason || len(req.Headers) != 0 {
+			t.Errorf("%s: Skip = %v, SkipReason = %q, headers = %v", tt.line, req.Skip, req.SkipReason, req.Headers)
+		}
+	}
+}
+
+func TestRequestFile_Clone(t *testing.T) {
+	content := `GRPC http://localhost:8080
+Service: example.Service
+Method: GetData
+Authorization: Bearer {{token}}
+{}
+
+[Captures]
+id: $.id
+
+[Asserts]
+jsonpath "$.status" == "active"`
+
+	orig := parseTestContent(t, content)[0]
+	clone := orig.Clone()
+
+	clone.Headers["Authorization"] = "Bearer resolved"
+	clone.Captures["other"] = Capture{Path: "$.other"}
+	clone.Asserts[0].Value = "inactive"
+
+	if orig.Headers["Authorization"] != "Bearer {{token}}" {
+		t.Errorf("clone mutated original headers: %v", orig.Headers)
+	}
+	if _, ok := orig.Captures["other"]; ok {
+		t.Errorf("clone mutated original captures: %v", orig.Captures)
+	}
+	if orig.Asserts[0].Value != "active" {
+		t.Errorf("clone mutated original asserts: %+v", orig.Asserts)
+	}
+}
+
 // Helper functions
 
 func parseTestContent(t *testing.T, content string) []*RequestFile {
@@ -0,0 +1,44 @@
+package file
+
+import (
+	"os"
+	"slices"
+	"time"
+)
+
+// Snapshot is the state of files at one point in time, compared with a
+// later snapshot to poll for changes without platform-specific file
+// notifications
+type Snapshot map[string]stamp
+
+type stamp struct {
+	modified time.Time
+	size     int64
+}
+
+// TakeSnapshot records the modification time and size of paths. Files that
+// cannot be read, e.g. deleted ones, are left out.
+func TakeSnapshot(paths []string) Snapshot {
+	s := make(Snapshot, len(paths))
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		s[p] = stamp{modified: info.ModTime(), size: info.Size()}
+	}
+	return s
+}
+
+// Changed returns the files of next that are not in s or differ from it,
+// in lexical order. Files missing from next are not reported.
+func (s Snapshot) Changed(next Snapshot) []string {
+	var changed []string
+	for p, st := range next {
+		if prev, ok := s[p]; !ok || !prev.modified.Equal(st.modified) || prev.size != st.size {
+			changed = append(changed, p)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
@@ -0,0 +1,40 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSnapshot_Changed(t *testing.T) {
+	dir := t.TempDir()
+	a, b, c := filepath.Join(dir, "a.grpc"), filepath.Join(dir, "b.grpc"), filepath.Join(dir, "c.grpc")
+	for _, p := range []string{a, b} {
+		if err := os.WriteFile(p, []byte("GRPC"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := TakeSnapshot([]string{a, b, c})
+	if len(before) != 2 {
+		t.Fatalf("expected the missing file to be left out, got %v", before)
+	}
+	if changed := before.Changed(TakeSnapshot([]string{a, b})); changed != nil {
+		t.Errorf("expected no changes, got %v", changed)
+	}
+
+	// Same size, later modification time
+	if err := os.Chtimes(a, time.Time{}, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(c, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(b); err != nil {
+		t.Fatal(err)
+	}
+	if changed := before.Changed(TakeSnapshot([]string{a, b, c})); !slices.Equal(changed, []string{a, c}) {
+		t.Errorf("changed = %v, want the modified and the new file", changed)
+	}
+}
@@ -0,0 +1,310 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"grpc_client/internal/client"
+)
+
+// Outcomes of a check
+const (
+	Supported     = "yes"
+	Unsupported   = "no"
+	NotApplicable = "n/a"
+)
+
+// Protocols are the protocols every check is run with, in matrix order
+var Protocols = []string{"grpc-web", "grpc", "connect"}
+
+const (
+	largeMessageSize = 1 << 20        // Size of the padded field in the large message check
+	longDeadline     = 24 * time.Hour // Deadline sent by the long deadline check
+)
+
+// Target is the endpoint and method a gateway check exercises
+type Target struct {
+	Address    string                        // Normalized server address
+	Prefix     string                        // Route prefix
+	Headers    map[string]string             // Headers sent with every call
+	Method     protoreflect.MethodDescriptor // A unary method the gateway routes
+	Input      proto.Message                 // Request message the method accepts
+	Timeout    time.Duration                 // Timeout of each call (except the long deadline check)
+	HTTPClient connect.HTTPClient            // HTTP client for calls (nil = http.DefaultClient)
+}
+
+// Result is the outcome of one check with one protocol
+type Result struct {
+	Check    string
+	Protocol string
+	Outcome  string // Supported, Unsupported, or NotApplicable
+	Detail   string // Status or error the outcome is based on
+}
+
+// Matrix is the outcome of every check with every protocol
+type Matrix struct {
+	Target    string   // Server address
+	Call      string   // Fully qualified method, e.g. example.UserService.GetUser
+	Checks    []string // Check names, in order
+	Protocols []string
+	Results   []Result // One per check and protocol, grouped by check
+}
+
+// Result returns the outcome of check with protocol
+func (m *Matrix) Result(check, protocol string) Result {
+	for _, r := range m.Results {
+		if r.Check == check && r.Protocol == protocol {
+			return r
+		}
+	}
+	return Result{Check: check, Protocol: protocol, Outcome: NotApplicable}
+}
+
+// check probes one gateway feature with one protocol and returns its
+// outcome and detail
+type check struct {
+	name string
+	run  func(ctx context.Context, t *Target, protocol string) (outcome, detail string)
+}
+
+// checks are run in order for every protocol
+var checks = []check{
+	{"unary", checkUnary},
+	{"errors", checkErrors},
+	{"trailers", checkTrailers},
+	{"large-message", checkLargeMessage},
+	{"compression", checkCompression},
+	{"long-deadline", checkLongDeadline},
+	{"text-mode", checkTextMode},
+}
+
+// Run exercises the target with every check and protocol
+func Run(ctx context.Context, t *Target) *Matrix {
+	svc := t.Method.Parent().(protoreflect.ServiceDescriptor)
+	m := &Matrix{
+		Target:    t.Address,
+		Call:      fmt.Sprintf("%s.%s", svc.FullName(), t.Method.Name()),
+		Protocols: Protocols,
+	}
+	for _, c := range checks {
+		m.Checks = append(m.Checks, c.name)
+		for _, protocol := range Protocols {
+			outcome, detail := c.run(ctx, t, protocol)
+			m.Results = append(m.Results, Result{Check: c.name, Protocol: protocol, Outcome: outcome, Detail: detail})
+		}
+	}
+	return m
+}
+
+// call invokes method with input using protocol and returns the response
+// and the status name of the call
+func (t *Target) call(ctx context.Context, protocol string, method protoreflect.MethodDescriptor, input proto.Message, timeout time.Duration, opts ...client.Option) (*client.Response, string, error) {
+	p, err := client.ParseProtocol(protocol)
+	if err != nil {
+		return nil, "", err
+	}
+	if t.HTTPClient != nil {
+		opts = append(opts, client.WithHTTPClient(t.HTTPClient))
+	}
+	c := client.NewClient(t.Address, t.Prefix, p, t.Headers, opts...)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resp, err := c.Call(ctx, method, input)
+	if err == nil {
+		return resp, client.StatusOK, nil
+	}
+	var rpcErr *client.Error
+	if errors.As(err, &rpcErr) {
+		return nil, rpcErr.Status(), rpcErr
+	}
+	return nil, "", err
+}
+
+// answered reports whether a call reached the service: it succeeded or
+// failed with a status the service could have returned. Gateways that cannot
+// handle a request typically fail it as unknown, internal, unavailable, or
+// unimplemented instead.
+func answered(status string) bool {
+	switch status {
+	case "", "unknown", "internal", "unavailable", "unimplemented":
+		return false
+	}
+	return true
+}
+
+// answeredOutcome converts the result of a call that should reach the
+// service into an outcome
+func answeredOutcome(status string, err error) (string, string) {
+	if answered(status) {
+		return Supported, status
+	}
+	return Unsupported, errorDetail(err)
+}
+
+// errorDetail shortens an error for display in the matrix
+func errorDetail(err error) string {
+	if err == nil {
+		return ""
+	}
+	var rpcErr *client.Error
+	if errors.As(err, &rpcErr) {
+		return fmt.Sprintf("%s: %s", rpcErr.Status(), rpcErr.Message)
+	}
+	return err.Error()
+}
+
+// checkUnary makes the configured call
+func checkUnary(ctx context.Context, t *Target, protocol string) (string, string) {
+	_, status, err := t.call(ctx, protocol, t.Method, t.Input, t.Timeout)
+	return answeredOutcome(status, err)
+}
+
+// checkErrors calls a method the service does not implement, which must
+// come back as a well-formed unimplemented status
+func checkErrors(ctx context.Context, t *Target, protocol string) (string, string) {
+	method, err := unimplementedMethod(t.Method)
+	if err != nil {
+		return Unsupported, err.Error()
+	}
+	_, status, err := t.call(ctx, protocol, method, dynamicpb.NewMessage(method.Input()), t.Timeout)
+	if status == "unimplemented" {
+		return Supported, status
+	}
+	if status != "" {
+		return Unsupported, fmt.Sprintf("got %s, want unimplemented", status)
+	}
+	return Unsupported, errorDetail(err)
+}
+
+// checkTrailers makes the configured call and reports the trailers that
+// reached the client. The gRPC and gRPC-Web clients fail a call whose
+// grpc-status trailer is lost, so a successful call proves trailers work.
+// Connect unary calls have no trailers.
+func checkTrailers(ctx context.Context, t *Target, protocol string) (string, string) {
+	if protocol == "connect" {
+		return NotApplicable, "connect unary calls have no trailers"
+	}
+	resp, status, err := t.call(ctx, protocol, t.Method, t.Input, t.Timeout)
+	if !answered(status) {
+		return Unsupported, errorDetail(err)
+	}
+	if resp == nil {
+		// Trailers-only error response
+		return Supported, status
+	}
+	return Supported, fmt.Sprintf("%s, %d trailer(s)", status, len(resp.Trailer))
+}
+
+// checkLargeMessage sends the configured request with its first string or
+// bytes field padded to largeMessageSize
+func checkLargeMessage(ctx context.Context, t *Target, protocol string) (string, string) {
+	input, ok := padMessage(t.Input, largeMessageSize)
+	if !ok {
+		return NotApplicable, "request has no string or bytes field to pad"
+	}
+	_, status, err := t.call(ctx, protocol, t.Method, input, t.Timeout)
+	if status == "resource_exhausted" {
+		return Unsupported, errorDetail(err)
+	}
+	return answeredOutcome(status, err)
+}
+
+// checkCompression sends the configured request compressed with gzip
+func checkCompression(ctx context.Context, t *Target, protocol string) (string, string) {
+	_, status, err := t.call(ctx, protocol, t.Method, t.Input, t.Timeout, client.WithSendGzip())
+	return answeredOutcome(status, err)
+}
+
+// checkLongDeadline sends the configured request with a deadline of
+// longDeadline, which some proxies reject or fail to parse
+func checkLongDeadline(ctx context.Context, t *Target, protocol string) (string, string) {
+	_, status, err := t.call(ctx, protocol, t.Method, t.Input, longDeadline)
+	return answeredOutcome(status, err)
+}
+
+// checkTextMode sends the configured request as base64-encoded gRPC-Web
+// (application/grpc-web-text), which browsers use when they cannot stream
+// binary responses
+func checkTextMode(ctx context.Context, t *Target, protocol string) (string, string) {
+	if protocol != "grpc-web" {
+		return NotApplicable, "grpc-web only"
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+	status, err := callWebText(ctx, t)
+	if err != nil {
+		return Unsupported, err.Error()
+	}
+	if !answered(status) {
+		return Unsupported, status
+	}
+	return Supported, status
+}
+
+// padMessage returns a copy of msg with its first singular string or bytes
+// field set to size bytes
+func padMessage(msg proto.Message, size int) (proto.Message, bool) {
+	padded := proto.Clone(msg)
+	m := padded.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.Cardinality() == protoreflect.Repeated {
+			continue
+		}
+		switch fd.Kind() {
+		case protoreflect.StringKind:
+			m.Set(fd, protoreflect.ValueOfString(strings.Repeat("x", size)))
+			return padded, true
+		case protoreflect.BytesKind:
+			m.Set(fd, protoreflect.ValueOfBytes(make([]byte, size)))
+			return padded, true
+		}
+	}
+	return nil, false
+}
+
+// unimplementedMethodName is the method called by the errors check
+const unimplementedMethodName = "GatewayCheckUnimplemented"
+
+// unimplementedMethod returns a method of the same service as method that
+// the service does not implement, taking and returning an empty message
+func unimplementedMethod(method protoreflect.MethodDescriptor) (protoreflect.MethodDescriptor, error) {
+	svc := method.Parent().(protoreflect.ServiceDescriptor)
+	pkg := string(svc.ParentFile().Package())
+	empty := "." + unimplementedMethodName
+	if pkg != "" {
+		empty = "." + pkg + empty
+	}
+
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("gateway_check.proto"),
+		Package:     proto.String(pkg),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String(unimplementedMethodName)}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String(string(svc.Name())),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String(unimplementedMethodName),
+				InputType:  proto.String(empty),
+				OutputType: proto.String(empty),
+			}},
+		}},
+	}, new(protoregistry.Files))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build unimplemented method: %w", err)
+	}
+	return fd.Services().Get(0).Methods().Get(0), nil
+}
@@ -0,0 +1,233 @@
+package gateway
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const testProto = `syntax = "proto3";
+package test;
+service EchoService {
+  rpc Echo(EchoRequest) returns (EchoResponse);
+}
+message EchoRequest { string text = 1; }
+message EchoResponse { string text = 1; }
+message Number { int64 value = 1; repeated string labels = 2; }
+`
+
+// echoMethod compiles testProto and returns its Echo method
+func echoMethod(t *testing.T) protoreflect.MethodDescriptor {
+	t.Helper()
+	compiler := protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{"test.proto": testProto}),
+		},
+	}
+	files, err := compiler.Compile(context.Background(), "test.proto")
+	if err != nil {
+		t.Fatalf("failed to compile test proto: %v", err)
+	}
+	return files[0].Services().Get(0).Methods().Get(0)
+}
+
+// serverCodec decodes requests into dynamic messages of a fixed type
+type serverCodec struct {
+	desc protoreflect.MessageDescriptor
+}
+
+func (c serverCodec) Name() string { return "proto" }
+
+func (c serverCodec) Marshal(msg any) ([]byte, error) {
+	return proto.Marshal(msg.(proto.Message))
+}
+
+func (c serverCodec) Unmarshal(data []byte, msg any) error {
+	decoded := dynamicpb.NewMessage(c.desc)
+	if err := proto.Unmarshal(data, decoded); err != nil {
+		return err
+	}
+	*msg.(*dynamicpb.Message) = *decoded
+	return nil
+}
+
+// newTarget starts a Connect server implementing Echo over every protocol
+// and returns a Target for it
+func newTarget(t *testing.T) *Target {
+	t.Helper()
+	method := echoMethod(t)
+	handler := connect.NewUnaryHandler("/test.EchoService/Echo",
+		func(ctx context.Context, req *connect.Request[dynamicpb.Message]) (*connect.Response[dynamicpb.Message], error) {
+			out := dynamicpb.NewMessage(method.Output())
+			out.Set(method.Output().Fields().ByName("text"), req.Msg.Get(method.Input().Fields().ByName("text")))
+			return connect.NewResponse(out), nil
+		},
+		connect.WithCodec(serverCodec{desc: method.Input()}),
+	)
+	mux := http.NewServeMux()
+	mux.Handle("/test.EchoService/Echo", handler)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	input := dynamicpb.NewMessage(method.Input())
+	input.Set(method.Input().Fields().ByName("text"), protoreflect.ValueOfString("hello"))
+	return &Target{
+		Address: srv.URL,
+		Method:  method,
+		Input:   input,
+		Timeout: 5 * time.Second,
+	}
+}
+
+func TestRun(t *testing.T) {
+	m := Run(context.Background(), newTarget(t))
+
+	if m.Call != "test.EchoService.Echo" || len(m.Checks) != len(checks) {
+		t.Fatalf("matrix = %+v", m)
+	}
+	if len(m.Results) != len(checks)*len(Protocols) {
+		t.Fatalf("got %d results, want %d", len(m.Results), len(checks)*len(Protocols))
+	}
+
+	// connect-go serves every binary protocol but not gRPC-Web text mode
+	for _, r := range m.Results {
+		want := Supported
+		switch {
+		case r.Check == "trailers" && r.Protocol == "connect",
+			r.Check == "text-mode" && r.Protocol != "grpc-web":
+			want = NotApplicable
+		case r.Check == "text-mode":
+			want = Unsupported
+		}
+		if r.Outcome != want {
+			t.Errorf("%s (%s) = %s (%s), want %s", r.Check, r.Protocol, r.Outcome, r.Detail, want)
+		}
+	}
+	if got := m.Result("errors", "grpc-web").Detail; got != "unimplemented" {
+		t.Errorf("errors detail = %q, want unimplemented", got)
+	}
+}
+
+func TestRun_Unreachable(t *testing.T) {
+	target := newTarget(t)
+	target.Address = "http://127.0.0.1:1"
+
+	m := Run(context.Background(), target)
+	if r := m.Result("unary", "grpc-web"); r.Outcome != Unsupported || r.Detail == "" {
+		t.Errorf("unary = %+v, want unsupported with a detail", r)
+	}
+}
+
+func TestCallWebText(t *testing.T) {
+	target := newTarget(t)
+
+	tests := []struct {
+		name       string
+		body       string
+		header     string
+		wantStatus string
+		wantErr    string
+	}{
+		{
+			name: "Frames encoded separately",
+			body: base64.StdEncoding.EncodeToString(appendFrame(nil, frameData, []byte("\n\x02hi"))) +
+				base64.StdEncoding.EncodeToString(appendFrame(nil, frameTrailer, []byte("grpc-status: 0\r\ngrpc-message: \r\n"))),
+			wantStatus: "ok",
+		},
+		{
+			name:       "Error status",
+			body:       base64.StdEncoding.EncodeToString(appendFrame(nil, frameTrailer, []byte("grpc-status:5\r\n"))),
+			wantStatus: "not_found",
+		},
+		{
+			name:       "Trailers-only",
+			header:     "7",
+			wantStatus: "permission_denied",
+		},
+		{
+			name:    "Missing trailer",
+			body:    base64.StdEncoding.EncodeToString(appendFrame(nil, frameData, []byte("\n\x02hi"))),
+			wantErr: "no grpc-status trailer",
+		},
+		{
+			name:    "Not base64",
+			body:    "not base64!",
+			wantErr: "invalid base64 response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Content-Type") != "application/grpc-web-text" {
+					w.WriteHeader(http.StatusUnsupportedMediaType)
+					return
+				}
+				w.Header().Set("Content-Type", "application/grpc-web-text")
+				if tt.header != "" {
+					w.Header().Set("Grpc-Status", tt.header)
+				}
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			target.Address = srv.URL
+
+			status, err := callWebText(context.Background(), target)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("callWebText failed: %v", err)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestPadMessage(t *testing.T) {
+	method := echoMethod(t)
+	input := dynamicpb.NewMessage(method.Input())
+
+	padded, ok := padMessage(input, 1024)
+	if !ok {
+		t.Fatal("expected the text field to be padded")
+	}
+	if got := padded.ProtoReflect().Get(method.Input().Fields().ByName("text")).String(); len(got) != 1024 {
+		t.Errorf("padded length = %d, want 1024", len(got))
+	}
+	// The original is left untouched
+	if input.Has(method.Input().Fields().ByName("text")) {
+		t.Error("input was mutated")
+	}
+
+	// Repeated fields are not padded
+	number := dynamicpb.NewMessage(method.ParentFile().Messages().ByName("Number"))
+	if _, ok := padMessage(number, 1024); ok {
+		t.Error("expected a message without a singular string field not to be padded")
+	}
+}
+
+func TestUnimplementedMethod(t *testing.T) {
+	method, err := unimplementedMethod(echoMethod(t))
+	if err != nil {
+		t.Fatalf("unimplementedMethod failed: %v", err)
+	}
+	if got := string(method.FullName()); got != "test.EchoService.GatewayCheckUnimplemented" {
+		t.Errorf("full name = %q", got)
+	}
+}
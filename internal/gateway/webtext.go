@@ -0,0 +1,141 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	"grpc_client/internal/client"
+)
+
+// gRPC-Web frame flags
+const (
+	frameData    = 0x00
+	frameTrailer = 0x80
+)
+
+// callWebText makes the target's call in gRPC-Web text mode and returns its
+// status name. connect-go only speaks binary gRPC-Web, so the request is
+// framed and encoded by hand.
+func callWebText(ctx context.Context, t *Target) (string, error) {
+	c := client.NewClient(t.Address, t.Prefix, client.ProtocolGRPCWeb, nil)
+	url, err := c.MethodURL(t.Method)
+	if err != nil {
+		return "", err
+	}
+	msg, err := proto.Marshal(t.Input)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	body := base64.StdEncoding.EncodeToString(appendFrame(nil, frameData, msg))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	for k, v := range t.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/grpc-web-text")
+	req.Header.Set("Accept", "application/grpc-web-text")
+	req.Header.Set("X-Grpc-Web", "1")
+
+	httpClient := t.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	// A trailers-only response carries the status in the headers
+	if status := resp.Header.Get("Grpc-Status"); status != "" {
+		return client.NormalizeStatus(status), nil
+	}
+
+	encoded, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	decoded, err := decodeWebText(encoded)
+	if err != nil {
+		return "", err
+	}
+	status, ok, err := trailerStatus(decoded)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("response has no grpc-status trailer")
+	}
+	return status, nil
+}
+
+// appendFrame appends a gRPC-Web frame with the given flag and payload to b
+func appendFrame(b []byte, flag byte, payload []byte) []byte {
+	b = append(b, flag)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(payload)))
+	return append(b, payload...)
+}
+
+// decodeWebText decodes a gRPC-Web text body. Servers may encode each frame
+// separately, so the body can be several padded base64 strings in a row;
+// decoding it in 4-byte quanta handles the padding in between.
+func decodeWebText(encoded []byte) ([]byte, error) {
+	encoded = bytes.Join(bytes.Fields(encoded), nil)
+	if len(encoded)%4 != 0 {
+		return nil, fmt.Errorf("invalid base64 response: length %d is not a multiple of 4", len(encoded))
+	}
+	var decoded []byte
+	quantum := make([]byte, 3)
+	for i := 0; i < len(encoded); i += 4 {
+		n, err := base64.StdEncoding.Decode(quantum, encoded[i:i+4])
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 response: %w", err)
+		}
+		decoded = append(decoded, quantum[:n]...)
+	}
+	return decoded, nil
+}
+
+// trailerStatus finds the grpc-status in the trailer frame of a decoded
+// gRPC-Web body
+func trailerStatus(body []byte) (string, bool, error) {
+	for len(body) > 0 {
+		if len(body) < 5 {
+			return "", false, fmt.Errorf("truncated gRPC-Web frame header")
+		}
+		flag := body[0]
+		size := binary.BigEndian.Uint32(body[1:5])
+		if uint32(len(body)-5) < size {
+			return "", false, fmt.Errorf("truncated gRPC-Web frame")
+		}
+		payload := body[5 : 5+size]
+		body = body[5+size:]
+
+		if flag&frameTrailer == 0 {
+			continue
+		}
+		for _, line := range strings.Split(string(payload), "\r\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if ok && strings.EqualFold(strings.TrimSpace(key), "grpc-status") {
+				return client.NormalizeStatus(value), true, nil
+			}
+		}
+	}
+	return "", false, nil
+}
@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Level is the severity of a message
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+// Levels lists the names of the levels, as ParseLevel accepts them
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// ParseLevel parses a level name
+func ParseLevel(s string) (Level, error) {
+	for i, name := range Levels {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	if strings.EqualFold(s, "warning") {
+		return Warn, nil
+	}
+	return 0, fmt.Errorf("invalid log level %q, must be one of: %s", s, strings.Join(Levels, ", "))
+}
+
+func (l Level) String() string {
+	if l < Debug || l > Error {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return Levels[l]
+}
+
+// prefixes mark the level of each line: info messages are written as they
+// are, like the progress notes they are
+var prefixes = map[Level]string{
+	Debug: "Debug: ",
+	Warn:  "Warning: ",
+	Error: "Error: ",
+}
+
+// Logger writes diagnostics at or above a level, one line per message. It
+// is safe for concurrent use.
+type Logger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level Level
+}
+
+// New creates a Logger writing the messages at or above level to w
+func New(w io.Writer, level Level) *Logger {
+	return &Logger{w: w, level: level}
+}
+
+// Enabled reports whether messages at level are written
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.level
+}
+
+// Logf writes a message at level, formatted like fmt.Sprintf
+func (l *Logger) Logf(level Level, format string, args ...any) {
+	if !l.Enabled(level) {
+		return
+	}
+	msg := strings.TrimSuffix(fmt.Sprintf(format, args...), "\n")
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = io.WriteString(l.w, prefixes[level]+msg+"\n")
+}
+
+func (l *Logger) Debugf(format string, args ...any) { l.Logf(Debug, format, args...) }
+func (l *Logger) Infof(format string, args ...any)  { l.Logf(Info, format, args...) }
+func (l *Logger) Warnf(format string, args ...any)  { l.Logf(Warn, format, args...) }
+func (l *Logger) Errorf(format string, args ...any) { l.Logf(Error, format, args...) }
@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	var out strings.Builder
+	l := New(&out, Info)
+	l.Debugf("resolved %s", "address")
+	l.Infof("Waiting for %d worker(s)", 2)
+	l.Warnf("failed to export spans: %v\n", "refused")
+	l.Errorf("RPC call failed")
+
+	want := "Waiting for 2 worker(s)\nWarning: failed to export spans: refused\nError: RPC call failed\n"
+	if got := out.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if l.Enabled(Debug) || !l.Enabled(Error) {
+		t.Error("expected only messages at or above info to be enabled")
+	}
+
+	out.Reset()
+	New(&out, Debug).Debugf("loaded %d files", 3)
+	if got := out.String(); got != "Debug: loaded 3 files\n" {
+		t.Errorf("got %q", got)
+	}
+	out.Reset()
+	New(&out, Error).Warnf("dropped")
+	if got := out.String(); got != "" {
+		t.Errorf("expected warnings to be dropped at level error, got %q", got)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for in, want := range map[string]Level{"debug": Debug, "INFO": Info, "warn": Warn, "warning": Warn, "error": Error} {
+		if got, err := ParseLevel(in); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil || !strings.Contains(err.Error(), "debug, info, warn, error") {
+		t.Errorf("expected an error listing the levels, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+// Package oauth obtains access tokens with the OAuth 2.0 client credentials
+// grant (RFC 6749, section 4.4), so that calls can authenticate without a
+// separate login step.
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// expiryLeeway is how long before its expiry a token is no longer used, so
+// that it does not expire while a call is in flight
+const expiryLeeway = 30 * time.Second
+
+// Config describes the client and the token endpoint it authenticates with
+type Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// Token is an access token and the time it expires
+type Token struct {
+	AccessToken string    `json:"access_token"`
+	Expiry      time.Time `json:"expiry,omitzero"` // Zero when the server gave no lifetime
+}
+
+// Valid reports whether the token is set and does not expire within the
+// next 30 seconds
+func (t Token) Valid() bool {
+	return t.AccessToken != "" && (t.Expiry.IsZero() || time.Now().Add(expiryLeeway).Before(t.Expiry))
+}
+
+// Key identifies the tokens issued for c, e.g. in a cache: tokens of
+// another endpoint, client, or set of scopes are never reused
+func (c Config) Key() string {
+	return c.TokenURL + " " + c.ClientID + " " + strings.Join(c.Scopes, " ")
+}
+
+// tokenResponse is the successful response of a token endpoint
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// errorResponse is the error response of a token endpoint
+type errorResponse struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// Fetch requests a new token from the token endpoint of c, authenticating
+// the client with HTTP Basic credentials
+func (c Config) Fetch(ctx context.Context, httpClient *http.Client) (Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, fmt.Errorf("invalid token URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to request an OAuth token: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to read the OAuth token response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+		if json.Unmarshal(body, &e) == nil && e.Error != "" {
+			if e.Description != "" {
+				return Token{}, fmt.Errorf("OAuth token request failed: %s: %s", e.Error, e.Description)
+			}
+			return Token{}, fmt.Errorf("OAuth token request failed: %s", e.Error)
+		}
+		return Token{}, fmt.Errorf("OAuth token request failed: %s", resp.Status)
+	}
+	var r tokenResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return Token{}, fmt.Errorf("invalid OAuth token response: %w", err)
+	}
+	if r.AccessToken == "" {
+		return Token{}, fmt.Errorf("invalid OAuth token response: no access_token")
+	}
+	if r.TokenType != "" && !strings.EqualFold(r.TokenType, "bearer") {
+		return Token{}, fmt.Errorf("unsupported OAuth token type %q, expected Bearer", r.TokenType)
+	}
+
+	tok := Token{AccessToken: r.AccessToken}
+	// The lifetime counts from when the server issued the token
+	if r.ExpiresIn > 0 {
+		tok.Expiry = start.Add(time.Duration(r.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
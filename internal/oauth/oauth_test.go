@@ -0,0 +1,62 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if err := r.ParseForm(); err != nil || r.Method != http.MethodPost {
+			t.Errorf("unexpected request %s, %v", r.Method, err)
+		}
+		if user != "cli" || pass != "s3cret" || r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("scope") != "read write" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "invalid_client", "error_description": "unknown client"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token": "tok-1", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	defer srv.Close()
+
+	c := Config{TokenURL: srv.URL, ClientID: "cli", ClientSecret: "s3cret", Scopes: []string{"read", "write"}}
+	tok, err := c.Fetch(context.Background(), srv.Client())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if tok.AccessToken != "tok-1" || !tok.Valid() {
+		t.Errorf("token = %+v", tok)
+	}
+	if d := time.Until(tok.Expiry); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("expiry in %v, want about an hour", d)
+	}
+
+	c.ClientSecret = "wrong"
+	if _, err := c.Fetch(context.Background(), srv.Client()); err == nil || !strings.Contains(err.Error(), "invalid_client: unknown client") {
+		t.Errorf("Fetch error = %v, want the endpoint's error", err)
+	}
+}
+
+func TestToken_Valid(t *testing.T) {
+	tests := []struct {
+		name  string
+		token Token
+		want  bool
+	}{
+		{"No lifetime", Token{AccessToken: "t"}, true},
+		{"Expires later", Token{AccessToken: "t", Expiry: time.Now().Add(time.Hour)}, true},
+		{"Expires soon", Token{AccessToken: "t", Expiry: time.Now().Add(10 * time.Second)}, false},
+		{"Expired", Token{AccessToken: "t", Expiry: time.Now().Add(-time.Minute)}, false},
+		{"Empty", Token{}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.token.Valid(); got != tt.want {
+			t.Errorf("%s: Valid = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
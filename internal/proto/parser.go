@@ -81,15 +81,15 @@ func LoadProtos(protoPath string, importPaths []string) (*Registry, error) {
 
 // ServiceInfo contains information about a gRPC service
 type ServiceInfo struct {
-	FullName string
-	Methods  []MethodInfo
+	FullName string       `json:"name"`
+	Methods  []MethodInfo `json:"methods"`
 }
 
 // MethodInfo contains information about a gRPC method
 type MethodInfo struct {
-	Name       string
-	InputType  string
-	OutputType string
+	Name       string `json:"name"`
+	InputType  string `json:"input_type"`
+	OutputType string `json:"output_type"`
 }
 
 // Registry holds parsed proto file descriptors and provides lookup methods
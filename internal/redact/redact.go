@@ -0,0 +1,167 @@
+// Package redact masks sensitive header values and secrets in output, so
+// traces, reports, and error messages can be shared safely.
+package redact
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Mask replaces redacted values
+const Mask = "***"
+
+// DefaultHeaders are the headers whose values are always redacted
+var DefaultHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// minSecretLen is the length under which values are not redacted from
+// text, since masking every "1" or "on" would garble output
+const minSecretLen = 4
+
+// Redactor masks the values of sensitive headers and known secret values.
+// It is safe for concurrent use.
+type Redactor struct {
+	headers map[string]bool // Canonical names of redacted headers
+
+	mu      sync.Mutex
+	secrets []string // Secret values, longest first
+
+	// Secrets, if set, supplies further secret values each time text is
+	// redacted, e.g. those read by {{secret}}
+	Secrets func() []string
+}
+
+// New returns a Redactor for DefaultHeaders and the given header names
+func New(headers ...string) *Redactor {
+	r := &Redactor{headers: make(map[string]bool)}
+	for _, name := range append(slices.Clone(DefaultHeaders), headers...) {
+		r.headers[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+	}
+	return r
+}
+
+// AddSecret marks values as secret, so they are masked wherever they appear
+func (r *Redactor) AddSecret(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.secrets = addSecrets(r.secrets, values)
+}
+
+// addSecrets adds values to secrets, longest first so that a secret
+// containing another is masked whole
+func addSecrets(secrets, values []string) []string {
+	for _, v := range values {
+		if len(v) >= minSecretLen && !slices.Contains(secrets, v) {
+			secrets = append(secrets, v)
+		}
+	}
+	slices.SortStableFunc(secrets, func(a, b string) int {
+		return len(b) - len(a)
+	})
+	return secrets
+}
+
+// IsSensitive reports whether the values of the header name are redacted
+func (r *Redactor) IsSensitive(name string) bool {
+	return r.headers[http.CanonicalHeaderKey(name)]
+}
+
+// Header returns a copy of h with the values of sensitive headers masked
+// and secrets masked in the others
+func (r *Redactor) Header(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+	out := make(http.Header, len(h))
+	for name, values := range h {
+		masked := make([]string, len(values))
+		for i, v := range values {
+			if r.IsSensitive(name) {
+				masked[i] = Mask
+			} else {
+				masked[i] = r.String(v)
+			}
+		}
+		out[name] = masked
+	}
+	return out
+}
+
+// Headers returns a copy of headers with the values of sensitive headers
+// masked and secrets masked in the others
+func (r *Redactor) Headers(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	out := make(map[string]string, len(headers))
+	for name, v := range headers {
+		if r.IsSensitive(name) {
+			out[name] = Mask
+		} else {
+			out[name] = r.String(v)
+		}
+	}
+	return out
+}
+
+// String masks the secrets in s
+func (r *Redactor) String(s string) string {
+	for _, secret := range r.allSecrets() {
+		s = strings.ReplaceAll(s, secret, Mask)
+	}
+	return s
+}
+
+// Bytes masks the secrets in b, returning b itself if it contains none
+func (r *Redactor) Bytes(b []byte) []byte {
+	for _, secret := range r.allSecrets() {
+		if bytes.Contains(b, []byte(secret)) {
+			b = bytes.ReplaceAll(b, []byte(secret), []byte(Mask))
+		}
+	}
+	return b
+}
+
+// Binary masks the secrets in b with asterisks of the same length, which
+// keeps binary encodings such as length-prefixed protobuf messages valid.
+// It returns b itself if it contains none.
+func (r *Redactor) Binary(b []byte) []byte {
+	for _, secret := range r.allSecrets() {
+		if bytes.Contains(b, []byte(secret)) {
+			b = bytes.ReplaceAll(b, []byte(secret), bytes.Repeat([]byte("*"), len(secret)))
+		}
+	}
+	return b
+}
+
+// Writer returns a writer that masks the secrets in what is written to w.
+// Secrets split across writes are not masked, so each write should hold
+// whole lines.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	return writer{r: r, w: w}
+}
+
+type writer struct {
+	r *Redactor
+	w io.Writer
+}
+
+func (w writer) Write(p []byte) (int, error) {
+	if _, err := w.w.Write(w.r.Bytes(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// allSecrets returns the secrets added and those supplied by r.Secrets
+func (r *Redactor) allSecrets() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Secrets == nil {
+		return r.secrets
+	}
+	return addSecrets(slices.Clone(r.secrets), r.Secrets())
+}
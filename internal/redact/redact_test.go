@@ -0,0 +1,71 @@
+package redact
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedactor_Header(t *testing.T) {
+	r := New("x-api-key")
+	r.AddSecret("s3cr3t-token")
+
+	got := r.Header(http.Header{
+		"Authorization": {"Bearer abc"},
+		"Cookie":        {"session=1"},
+		"X-Api-Key":     {"k1", "k2"},
+		"X-Trace":       {"trace for s3cr3t-token"},
+		"Content-Type":  {"application/grpc"},
+	})
+	want := map[string][]string{
+		"Authorization": {Mask},
+		"Cookie":        {Mask},
+		"X-Api-Key":     {Mask, Mask},
+		"X-Trace":       {"trace for " + Mask},
+		"Content-Type":  {"application/grpc"},
+	}
+	for name, values := range want {
+		if got := got[name]; len(got) != len(values) || got[0] != values[0] {
+			t.Errorf("%s = %q, want %q", name, got, values)
+		}
+	}
+
+	headers := r.Headers(map[string]string{"authorization": "Basic xyz", "Accept": "*/*"})
+	if headers["authorization"] != Mask || headers["Accept"] != "*/*" {
+		t.Errorf("Headers = %v", headers)
+	}
+}
+
+func TestRedactor_String(t *testing.T) {
+	r := New()
+	r.AddSecret("token", "token-long", "on")
+	r.Secrets = func() []string { return []string{"from-keyring"} }
+
+	got := r.String(`{"a": "token-long", "b": "token", "c": "on", "d": "from-keyring"}`)
+	want := `{"a": "***", "b": "***", "c": "on", "d": "***"}`
+	if got != want {
+		t.Errorf("String = %s, want %s", got, want)
+	}
+	if got := string(r.Bytes([]byte("xtokenx"))); got != "x***x" {
+		t.Errorf("Bytes = %q", got)
+	}
+	// A protobuf string field keeps its length
+	if got := r.Binary([]byte("\x0a\x05token")); string(got) != "\x0a\x05*****" {
+		t.Errorf("Binary = %q", got)
+	}
+}
+
+func TestRedactor_Writer(t *testing.T) {
+	r := New()
+	r.AddSecret("hunter2")
+
+	var out strings.Builder
+	w := r.Writer(&out)
+	n, err := w.Write([]byte("> X-Password: hunter2\n"))
+	if err != nil || n != len("> X-Password: hunter2\n") {
+		t.Errorf("Write = %d, %v", n, err)
+	}
+	if got := out.String(); got != "> X-Password: ***\n" {
+		t.Errorf("wrote %q", got)
+	}
+}
@@ -0,0 +1,56 @@
+package render
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// csvHeader names the columns of a CSV report
+var csvHeader = []string{"file", "index", "name", "service", "method", "status", "outcome", "duration_ms", "assertions_passed", "assertions_failed", "error"}
+
+// csvRenderer writes the results of a run as CSV on Close, one row per
+// request, for spreadsheets
+type csvRenderer struct {
+	resultsOnly
+	w       io.Writer
+	results []*Result
+}
+
+func (c *csvRenderer) Result(r *Result) error {
+	c.results = append(c.results, r)
+	return nil
+}
+
+// csvRow converts a result to a row of a CSV report
+func csvRow(r *Result) []string {
+	var counts assertionCounts
+	counts.add(r)
+	return []string{
+		r.File,
+		strconv.Itoa(r.Index),
+		r.Name,
+		r.Service,
+		r.Method,
+		r.Status,
+		r.Outcome(),
+		strconv.FormatFloat(float64(r.Duration.Microseconds())/1000, 'f', 3, 64),
+		strconv.Itoa(counts.Passed),
+		strconv.Itoa(counts.Failed),
+		r.Error,
+	}
+}
+
+func (c *csvRenderer) Close() error {
+	w := csv.NewWriter(c.w)
+	if err := w.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range c.results {
+		if err := w.Write(csvRow(r)); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,43 @@
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCSVReport(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := NewReport("csv", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := []*Result{
+		{File: "users.grpc", Index: 1, Name: "Get user, by id", Service: "example.UserService", Method: "GetUser", Status: "ok", Duration: 12500 * time.Microsecond,
+			Asserts: []Assertion{{Pass: true}, {Pass: false}}},
+		{File: "users.grpc", Index: 2, Service: "example.UserService", Method: "GetUser", Status: "unavailable", Error: `RPC call failed: "connection refused"`},
+	}
+	for _, res := range results {
+		if err := r.Result(res); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	want := [][]string{
+		csvHeader,
+		{"users.grpc", "1", "Get user, by id", "example.UserService", "GetUser", "ok", "fail", "12.500", "1", "1", ""},
+		{"users.grpc", "2", "", "example.UserService", "GetUser", "unavailable", "error", "0.000", "0", "0", `RPC call failed: "connection refused"`},
+	}
+	if !slices.EqualFunc(rows, want, slices.Equal) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+}
@@ -0,0 +1,59 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"grpc_client/internal/client"
+)
+
+// Extract wraps r so that the body of each result is replaced by the values
+// path selects in it (see client.EvaluateJSONPath), like a jq filter:
+// strings are printed raw, objects and arrays as indented JSON, and each
+// match of a path with a wildcard, filter, or recursive descent on its own
+// line. A path that selects nothing fails the result.
+func Extract(r Renderer, path string) Renderer {
+	return &extractingRenderer{Renderer: r, path: path}
+}
+
+// extractingRenderer filters result bodies before rendering
+type extractingRenderer struct {
+	Renderer
+	path string
+}
+
+func (e *extractingRenderer) Result(res *Result) error {
+	// Failed calls have no body to filter
+	if res.Error != "" || res.Body == "" {
+		return e.Renderer.Result(res)
+	}
+	matches, definite, err := client.EvaluateJSONPathMatches(res.Body, e.path)
+	if err != nil {
+		return fmt.Errorf("extract %s: %w", e.path, err)
+	}
+	if !definite && len(matches) == 0 {
+		return fmt.Errorf("extract %s: no match: %w", e.path, client.ErrPathNotFound)
+	}
+	lines := make([]string, len(matches))
+	for i, m := range matches {
+		if lines[i], err = formatExtracted(m); err != nil {
+			return err
+		}
+	}
+	extracted := *res
+	extracted.Body = strings.Join(lines, "\n")
+	return e.Renderer.Result(&extracted)
+}
+
+// formatExtracted formats one extracted value for printing
+func formatExtracted(v interface{}) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode value: %w", err)
+	}
+	return string(encoded), nil
+}
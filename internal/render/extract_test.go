@@ -0,0 +1,44 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"grpc_client/internal/client"
+)
+
+func TestExtract(t *testing.T) {
+	body := `{"user": {"name": "Alice", "roles": ["admin", "dev"], "age": 30}}`
+	tests := []struct {
+		path string
+		want string
+	}{
+		{".user.name", "Alice\n"},
+		{"$.user.age", "30\n"},
+		{".user.roles[*]", "admin\ndev\n"},
+		{".user.roles", "[\n  \"admin\",\n  \"dev\"\n]\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		base, _ := New("text", &buf)
+		if err := Extract(base, tt.path).Result(&Result{Body: body}); err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s: output = %q, want %q", tt.path, buf.String(), tt.want)
+		}
+	}
+
+	base, _ := New("text", &bytes.Buffer{})
+	if err := Extract(base, ".user.email").Result(&Result{Body: body}); !errors.Is(err, client.ErrPathNotFound) {
+		t.Errorf("missing key: err = %v, want ErrPathNotFound", err)
+	}
+	if err := Extract(base, ".user.roles[?(@ == 'ops')]").Result(&Result{Body: body}); !errors.Is(err, client.ErrPathNotFound) {
+		t.Errorf("no match: err = %v, want ErrPathNotFound", err)
+	}
+	// Failed calls are rendered as they are
+	if err := Extract(base, ".user").Result(&Result{Error: "gRPC error [unavailable]"}); err != nil {
+		t.Errorf("failed call: %v", err)
+	}
+}
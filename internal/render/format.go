@@ -0,0 +1,68 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// formatFuncs are the functions available to --format-output templates, in
+// addition to templateFuncs
+var formatFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		encoded, err := json.Marshal(v)
+		return string(encoded), err
+	},
+}
+
+// unescaper expands the escapes a shell leaves alone in single quotes, so
+// that '{{.id}}\t{{.name}}' separates the values with a tab
+var unescaper = strings.NewReplacer(`\t`, "\t", `\n`, "\n", `\\`, `\`)
+
+// FormatBodies wraps r so that the body of each result is replaced by text,
+// a Go template executed with the decoded response as data, e.g.
+// {{.user.id}}\t{{.user.name}} or {{range .users}}{{.id}},{{.name}}\n{{end}}.
+// Numbers are kept as they were sent.
+func FormatBodies(r Renderer, text string) (Renderer, error) {
+	funcs := template.FuncMap{}
+	for name, f := range templateFuncs {
+		funcs[name] = f
+	}
+	for name, f := range formatFuncs {
+		funcs[name] = f
+	}
+	tmpl, err := template.New("format").Funcs(funcs).Parse(unescaper.Replace(text))
+	if err != nil {
+		return nil, fmt.Errorf("invalid output template: %w", err)
+	}
+	return &formattingRenderer{Renderer: r, tmpl: tmpl}, nil
+}
+
+// formattingRenderer formats result bodies before rendering
+type formattingRenderer struct {
+	Renderer
+	tmpl *template.Template
+}
+
+func (f *formattingRenderer) Result(res *Result) error {
+	// Failed calls have no body to format
+	if res.Error != "" || res.Body == "" {
+		return f.Renderer.Result(res)
+	}
+	dec := json.NewDecoder(strings.NewReader(res.Body))
+	dec.UseNumber()
+	var data any
+	if err := dec.Decode(&data); err != nil {
+		return fmt.Errorf("invalid JSON response: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := f.tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	formatted := *res
+	// The renderer ends the body with a newline
+	formatted.Body = strings.TrimSuffix(buf.String(), "\n")
+	return f.Renderer.Result(&formatted)
+}
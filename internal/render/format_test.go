@@ -0,0 +1,37 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFormatBodies(t *testing.T) {
+	body := `{"users": [{"id": "1", "name": "Alice", "age": 9007199254740993}, {"id": "2", "name": "Bob"}]}`
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`{{range .users}}{{.id}}\t{{.name}}\n{{end}}`, "1\tAlice\n2\tBob\n"},
+		{`{{(index .users 0).age}}`, "9007199254740993\n"},
+		{`{{range .users}}{{or .age 0}},{{end}}`, "9007199254740993,0,\n"},
+		{`{{json (index .users 1)}}`, `{"id":"2","name":"Bob"}` + "\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		base, _ := New("text", &buf)
+		r, err := FormatBodies(base, tt.text)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.text, err)
+		}
+		if err := r.Result(&Result{Body: body}); err != nil {
+			t.Fatalf("%s: %v", tt.text, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s: output = %q, want %q", tt.text, buf.String(), tt.want)
+		}
+	}
+
+	if _, err := FormatBodies(nil, "{{.id"); err == nil {
+		t.Error("expected an error for an invalid template")
+	}
+}
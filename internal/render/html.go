@@ -0,0 +1,77 @@
+package render
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+)
+
+//go:embed html.tmpl
+var htmlTemplateText string
+
+// htmlTemplate lays out the HTML report
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"headerLines": headerLines,
+	"duration": func(d time.Duration) string {
+		return fmt.Sprintf("%.1f ms", float64(d.Microseconds())/1000)
+	},
+}).Parse(htmlTemplateText))
+
+// headerLines formats h as sorted "Name: value" lines
+func headerLines(h http.Header) []string {
+	var lines []string
+	for _, name := range slices.Sorted(maps.Keys(h)) {
+		for _, v := range h[name] {
+			lines = append(lines, name+": "+v)
+		}
+	}
+	return lines
+}
+
+// htmlRenderer writes the results of a run as a self-contained HTML page on
+// Close, with each request's bodies, headers, timings, and assertions, for
+// sharing a run with people who cannot rerun it
+type htmlRenderer struct {
+	resultsOnly
+	w       io.Writer
+	results []*Result
+	now     func() time.Time
+}
+
+func (h *htmlRenderer) Result(r *Result) error {
+	h.results = append(h.results, r)
+	return nil
+}
+
+// htmlReport is the data of htmlTemplate
+type htmlReport struct {
+	Generated time.Time
+	Total     int
+	Passed    int
+	Failed    int
+	Errors    int
+	Skipped   int
+	Duration  time.Duration
+	Results   []htmlResult
+}
+
+// htmlResult is a result with its outcome: pass, fail, or error
+type htmlResult struct {
+	*Result
+	Outcome string
+}
+
+func (h *htmlRenderer) Close() error {
+	report := htmlReport{Generated: h.now(), Total: len(h.results)}
+	summary := Summarize(h.results)
+	report.Passed, report.Failed, report.Errors, report.Skipped, report.Duration = summary.Passed, summary.Failed, summary.Errors, summary.Skipped, summary.Duration
+	for _, r := range h.results {
+		report.Results = append(report.Results, htmlResult{Result: r, Outcome: r.Outcome()})
+	}
+	return htmlTemplate.Execute(h.w, report)
+}
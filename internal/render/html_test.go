@@ -0,0 +1,53 @@
+package render
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"grpc_client/internal/redact"
+)
+
+func TestHTMLReport(t *testing.T) {
+	var buf bytes.Buffer
+	r := Redact(&htmlRenderer{w: &buf, now: func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }}, redact.New())
+	results := []*Result{
+		{File: "users.grpc", Index: 1, Name: "Get user", Service: "example.UserService", Method: "GetUser", Status: "ok", Duration: 12 * time.Millisecond,
+			URL: "http://localhost/example.UserService/GetUser", Request: `{"id": "1"}`, RequestHeader: http.Header{"Authorization": {"Bearer hunter22"}},
+			Body: `{"name": "<b>Alice</b>"}`, Trailer: http.Header{"Grpc-Status": {"0"}},
+			Asserts: []Assertion{{Message: `FAIL: jsonpath "$.id" == "2"`}}},
+		{File: "users.grpc", Index: 2, Service: "example.UserService", Method: "GetUser", Status: "ok", Duration: 3 * time.Millisecond},
+	}
+	for _, res := range results {
+		if err := r.Result(res); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	for _, want := range []string{
+		"<span>2 requests</span>",
+		"<span>1 passed</span>",
+		"<span>1 failed</span>",
+		"generated 2025-01-02 03:04:05 UTC",
+		`<details class="fail" open>`,
+		`<details class="pass">`,
+		"POST http://localhost/example.UserService/GetUser\nAuthorization: ***</pre>",
+		"&lt;b&gt;Alice&lt;/b&gt;",
+		"<h3>Trailers</h3>\n<pre>Grpc-Status: 0\n</pre>",
+		`<li class="failed">FAIL: jsonpath &#34;$.id&#34; == &#34;2&#34;</li>`,
+		"15.0 ms",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in the report:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "hunter22") {
+		t.Error("secrets leaked into the report")
+	}
+}
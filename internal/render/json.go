@@ -0,0 +1,343 @@
+package render
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"grpc_client/internal/bench"
+	"grpc_client/internal/file"
+	"grpc_client/internal/gateway"
+	"grpc_client/internal/proto"
+)
+
+// jsonResult is the serialized form of a Result
+type jsonResult struct {
+	File     string          `json:"file,omitempty"`
+	Index    int             `json:"index,omitempty"`
+	Name     string          `json:"name,omitempty"`
+	Service  string          `json:"service"`
+	Method   string          `json:"method"`
+	Status   string          `json:"status,omitempty"`
+	Duration float64         `json:"duration_ms"`
+	Body     json.RawMessage `json:"body"`
+	Error    string          `json:"error,omitempty"`
+	Skipped  string          `json:"skipped,omitempty"`
+
+	Iteration int             `json:"iteration,omitempty"`
+	Captures  []jsonCapture   `json:"captures,omitempty"`
+	Asserts   []jsonAssertion `json:"asserts,omitempty"`
+
+	Certificates []jsonCertificate `json:"certificates,omitempty"`
+
+	URL          string              `json:"url,omitempty"`
+	StartedAt    *time.Time          `json:"started_at,omitempty"`
+	Request      *jsonResultRequest  `json:"request,omitempty"`
+	Headers      map[string][]string `json:"headers,omitempty"`
+	Trailers     map[string][]string `json:"trailers,omitempty"`
+	ResponseSize int                 `json:"response_size_bytes,omitempty"`
+	OutputFile   string              `json:"output_file,omitempty"`
+}
+
+// jsonResultRequest is the request of a jsonResult
+type jsonResultRequest struct {
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    json.RawMessage     `json:"body"`
+	Size    int                 `json:"size_bytes"`
+}
+
+type jsonCertificate struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	Serial    string    `json:"serial"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+	DNSNames  []string  `json:"dns_names,omitempty"`
+}
+
+type jsonCapture struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Value string `json:"value,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type jsonAssertion struct {
+	Pass    bool   `json:"pass"`
+	Message string `json:"message"`
+	Diff    string `json:"diff,omitempty"`
+}
+
+// jsonBench is the serialized form of a bench.Summary
+type jsonBench struct {
+	Count        int            `json:"count"`
+	Errors       int            `json:"errors"`
+	ElapsedMS    float64        `json:"elapsed_ms"`
+	RequestedQPS float64        `json:"requested_qps"`
+	Burst        int            `json:"burst,omitempty"`
+	ArrivalRate  float64        `json:"arrival_rate,omitempty"`
+	Arrival      string         `json:"arrival,omitempty"`
+	AchievedQPS  float64        `json:"achieved_qps"`
+	Latency      jsonLatency    `json:"latency_ms"`
+	RequestSize  *jsonSizes     `json:"request_size_bytes,omitempty"`
+	ResponseSize *jsonSizes     `json:"response_size_bytes,omitempty"`
+	Statuses     map[string]int `json:"statuses"`
+}
+
+type jsonLatency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+type jsonSizes struct {
+	Min  int     `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  int     `json:"p50"`
+	P90  int     `json:"p90"`
+	P99  int     `json:"p99"`
+	Max  int     `json:"max"`
+}
+
+// toJSONSizes converts size statistics, omitting them when nothing was measured
+func toJSONSizes(s bench.SizeStats) *jsonSizes {
+	if s.Count == 0 {
+		return nil
+	}
+	return &jsonSizes{Min: s.Min, Mean: s.Mean, P50: s.P50, P90: s.P90, P99: s.P99, Max: s.Max}
+}
+
+func toJSONBench(s *bench.Summary) jsonBench {
+	return jsonBench{
+		Count:        s.Count,
+		Errors:       s.Errors,
+		ElapsedMS:    milliseconds(s.Elapsed),
+		RequestedQPS: s.RequestedQPS,
+		Burst:        s.Burst,
+		ArrivalRate:  s.ArrivalRate,
+		Arrival:      s.Arrival,
+		AchievedQPS:  s.AchievedQPS,
+		Latency: jsonLatency{
+			Min:  milliseconds(s.Min),
+			Mean: milliseconds(s.Mean),
+			P50:  milliseconds(s.P50),
+			P90:  milliseconds(s.P90),
+			P99:  milliseconds(s.P99),
+			Max:  milliseconds(s.Max),
+		},
+		RequestSize:  toJSONSizes(s.RequestSize),
+		ResponseSize: toJSONSizes(s.ResponseSize),
+		Statuses:     s.Statuses,
+	}
+}
+
+// jsonGateway is the serialized form of a gateway.Matrix
+type jsonGateway struct {
+	Target  string              `json:"target"`
+	Call    string              `json:"call"`
+	Results []jsonGatewayResult `json:"results"`
+}
+
+type jsonGatewayResult struct {
+	Check    string `json:"check"`
+	Protocol string `json:"protocol"`
+	Outcome  string `json:"outcome"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+func toJSONGateway(m *gateway.Matrix) jsonGateway {
+	out := jsonGateway{Target: m.Target, Call: m.Call, Results: []jsonGatewayResult{}}
+	for _, r := range m.Results {
+		out.Results = append(out.Results, jsonGatewayResult(r))
+	}
+	return out
+}
+
+// jsonDiagnostic is the serialized form of a file.Diagnostic
+type jsonDiagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func toJSONDiagnostic(d file.Diagnostic) jsonDiagnostic {
+	return jsonDiagnostic{File: d.File, Line: d.Line, Column: d.Column, Severity: d.Severity, Message: d.Message}
+}
+
+// jsonRequest is the serialized form of a Request. The payload is encoded
+// as base64.
+type jsonRequest struct {
+	Name    string              `json:"name,omitempty"`
+	Service string              `json:"service"`
+	Method  string              `json:"method"`
+	URL     string              `json:"url"`
+	Headers map[string][]string `json:"headers"`
+	Body    json.RawMessage     `json:"body"`
+	Payload []byte              `json:"payload"`
+}
+
+func toJSONRequest(r *Request) jsonRequest {
+	return jsonRequest{
+		Name:    r.Name,
+		Service: r.Service,
+		Method:  r.Method,
+		URL:     r.URL,
+		Headers: r.Header,
+		Body:    rawBody(r.Body),
+		Payload: r.Payload,
+	}
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func toJSONResult(r *Result) jsonResult {
+	out := jsonResult{
+		Name:     r.Name,
+		Service:  r.Service,
+		Method:   r.Method,
+		Status:   r.Status,
+		Duration: milliseconds(r.Duration),
+		Body:     rawBody(r.Body),
+		Error:    r.Error,
+		Skipped:  r.Skipped,
+
+		Iteration: r.Iteration,
+	}
+	for _, c := range r.Captures {
+		out.Captures = append(out.Captures, jsonCapture(c))
+	}
+	for _, a := range r.Asserts {
+		out.Asserts = append(out.Asserts, jsonAssertion(a))
+	}
+	for _, c := range r.Certificates {
+		out.Certificates = append(out.Certificates, jsonCertificate(c))
+	}
+	out.URL, out.Headers, out.Trailers, out.ResponseSize = r.URL, r.Header, r.Trailer, r.ResponseSize
+	out.OutputFile = r.Output
+	if r.File != "" {
+		out.File, out.Index = r.File, r.Index
+	}
+	if !r.Started.IsZero() {
+		out.StartedAt = &r.Started
+	}
+	if r.Request != "" || r.RequestHeader != nil {
+		out.Request = &jsonResultRequest{Headers: r.RequestHeader, Body: rawBody(r.Request), Size: r.RequestSize}
+	}
+	return out
+}
+
+// rawBody embeds a JSON body verbatim, falling back to a JSON string
+// when the body is not valid JSON (and null when there is no body)
+func rawBody(body string) json.RawMessage {
+	if body == "" {
+		return json.RawMessage("null")
+	}
+	if json.Valid([]byte(body)) {
+		return json.RawMessage(body)
+	}
+	quoted, _ := json.Marshal(body)
+	return quoted
+}
+
+// jsonRenderer buffers everything and writes a single JSON array on Close
+type jsonRenderer struct {
+	w     io.Writer
+	items []any
+}
+
+func (j *jsonRenderer) Services(services []proto.ServiceInfo) error {
+	for _, svc := range services {
+		j.items = append(j.items, svc)
+	}
+	return nil
+}
+
+func (j *jsonRenderer) Result(r *Result) error {
+	j.items = append(j.items, toJSONResult(r))
+	return nil
+}
+
+func (j *jsonRenderer) Bench(s *bench.Summary) error {
+	j.items = append(j.items, toJSONBench(s))
+	return nil
+}
+
+func (j *jsonRenderer) Gateway(m *gateway.Matrix) error {
+	j.items = append(j.items, toJSONGateway(m))
+	return nil
+}
+
+func (j *jsonRenderer) Diagnostics(diags []file.Diagnostic) error {
+	for _, d := range diags {
+		j.items = append(j.items, toJSONDiagnostic(d))
+	}
+	return nil
+}
+
+func (j *jsonRenderer) Request(r *Request) error {
+	j.items = append(j.items, toJSONRequest(r))
+	return nil
+}
+
+func (j *jsonRenderer) Close() error {
+	items := j.items
+	if items == nil {
+		items = []any{}
+	}
+	enc := json.NewEncoder(j.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(items)
+}
+
+// ndjsonRenderer writes one compact JSON document per line as results arrive
+type ndjsonRenderer struct {
+	w io.Writer
+}
+
+func (n *ndjsonRenderer) Services(services []proto.ServiceInfo) error {
+	enc := json.NewEncoder(n.w)
+	for _, svc := range services {
+		if err := enc.Encode(svc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *ndjsonRenderer) Result(r *Result) error {
+	return json.NewEncoder(n.w).Encode(toJSONResult(r))
+}
+
+func (n *ndjsonRenderer) Bench(s *bench.Summary) error {
+	return json.NewEncoder(n.w).Encode(toJSONBench(s))
+}
+
+func (n *ndjsonRenderer) Gateway(m *gateway.Matrix) error {
+	return json.NewEncoder(n.w).Encode(toJSONGateway(m))
+}
+
+func (n *ndjsonRenderer) Diagnostics(diags []file.Diagnostic) error {
+	enc := json.NewEncoder(n.w)
+	for _, d := range diags {
+		if err := enc.Encode(toJSONDiagnostic(d)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *ndjsonRenderer) Request(r *Request) error {
+	return json.NewEncoder(n.w).Encode(toJSONRequest(r))
+}
+
+func (n *ndjsonRenderer) Close() error {
+	return nil
+}
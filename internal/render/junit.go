@@ -0,0 +1,196 @@
+package render
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"grpc_client/internal/bench"
+	"grpc_client/internal/file"
+	"grpc_client/internal/gateway"
+	"grpc_client/internal/proto"
+)
+
+// resultsOnly implements the non-result parts of Renderer for reports that
+// only describe the results of a run, ignoring everything else
+type resultsOnly struct{}
+
+func (resultsOnly) Services([]proto.ServiceInfo) error  { return nil }
+func (resultsOnly) Bench(*bench.Summary) error          { return nil }
+func (resultsOnly) Gateway(*gateway.Matrix) error       { return nil }
+func (resultsOnly) Diagnostics([]file.Diagnostic) error { return nil }
+func (resultsOnly) Request(*Request) error              { return nil }
+
+// junitRenderer writes the results of a run as a JUnit XML report on
+// Close: each request file is a test suite and each request a test case,
+// with failed assertions as failures and calls that failed without an
+// expected status as errors
+type junitRenderer struct {
+	resultsOnly
+	w       io.Writer
+	results []*Result
+}
+
+func (j *junitRenderer) Result(r *Result) error {
+	j.results = append(j.results, r)
+	return nil
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Errors    int         `xml:"errors,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr,omitempty"`
+	Cases     []junitCase `xml:"testcase"`
+
+	duration time.Duration
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure"`
+	Error     *junitProblem `xml:"error"`
+	Skipped   *junitSkipped `xml:"skipped"`
+	SystemOut *junitText    `xml:"system-out"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// junitSkipped marks a test case that did not run
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// junitText is element text written as CDATA, keeping JSON readable
+type junitText struct {
+	Text string `xml:",cdata"`
+}
+
+// seconds formats a duration as JUnit's fractional seconds
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// junitCaseOf converts a result to a test case
+func junitCaseOf(r *Result) junitCase {
+	name := fmt.Sprintf("%d", r.Index)
+	if r.Name != "" {
+		name += ": " + r.Name
+	}
+	if r.Iteration > 0 {
+		// Repeated test cases need distinct names
+		name += fmt.Sprintf(" (iteration %d)", r.Iteration)
+	}
+	c := junitCase{
+		Name:      name,
+		Classname: r.Service + "/" + r.Method,
+		Time:      seconds(r.Duration),
+	}
+	if r.Body != "" {
+		c.SystemOut = &junitText{Text: r.Body}
+	}
+
+	switch r.Outcome() {
+	case "fail":
+		if r.UnexpectedPass() {
+			c.Failure = &junitProblem{Message: unexpectedPass, Type: "expected-failure", Text: unexpectedPass}
+			break
+		}
+		failed := 0
+		var messages []string
+		for _, a := range r.Asserts {
+			messages = append(messages, a.Message)
+			if a.Diff != "" {
+				messages = append(messages, a.Diff)
+			}
+			if !a.Pass {
+				failed++
+			}
+		}
+		c.Failure = &junitProblem{
+			Message: fmt.Sprintf("%d of %d assertions failed", failed, len(r.Asserts)),
+			Type:    "assertion",
+			Text:    strings.Join(messages, "\n"),
+		}
+	case "error":
+		c.Error = &junitProblem{Message: r.Error, Type: r.Status, Text: r.Error}
+	case "skip":
+		c.Skipped = &junitSkipped{Message: r.Skipped}
+	}
+	return c
+}
+
+func (j *junitRenderer) Close() error {
+	report := junitSuites{Name: "grpc_client"}
+	var total time.Duration
+	bySuite := map[string]int{}
+	for _, r := range j.results {
+		name := r.File
+		if name == "" {
+			name = "grpc_client"
+		}
+		i, ok := bySuite[name]
+		if !ok {
+			i = len(report.Suites)
+			bySuite[name] = i
+			report.Suites = append(report.Suites, junitSuite{Name: name})
+			if !r.Started.IsZero() {
+				report.Suites[i].Timestamp = r.Started.UTC().Format("2006-01-02T15:04:05")
+			}
+		}
+		suite := &report.Suites[i]
+		c := junitCaseOf(r)
+		suite.Cases = append(suite.Cases, c)
+		suite.Tests++
+		suite.duration += r.Duration
+		total += r.Duration
+		if c.Failure != nil {
+			suite.Failures++
+			report.Failures++
+		}
+		if c.Skipped != nil {
+			suite.Skipped++
+		}
+		if c.Error != nil {
+			suite.Errors++
+			report.Errors++
+		}
+		report.Tests++
+	}
+	for i := range report.Suites {
+		report.Suites[i].Time = seconds(report.Suites[i].duration)
+	}
+	report.Time = seconds(total)
+
+	if _, err := io.WriteString(j.w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(j.w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(j.w, "\n")
+	return err
+}
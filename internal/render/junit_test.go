@@ -0,0 +1,88 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJUnitReport(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := NewReport("junit", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := []*Result{
+		{File: "users.grpc", Index: 1, Name: "Get user", Service: "example.UserService", Method: "GetUser", Status: "ok", Duration: 12 * time.Millisecond, Body: `{"id": "1"}`,
+			Asserts: []Assertion{{Pass: true, Message: "PASS: status == ok"}}},
+		{File: "users.grpc", Index: 2, Service: "example.UserService", Method: "GetUser", Status: "ok", Duration: 3 * time.Millisecond,
+			Asserts: []Assertion{{Pass: true, Message: "PASS: status == ok"}, {Message: `FAIL: jsonpath "$.id" == "2" (actual: "1")`}}},
+		{File: "orders.grpc", Index: 1, Service: "example.OrderService", Method: "GetOrder", Status: "unavailable", Error: "RPC call failed: connection refused"},
+		// An expected error status is a pass
+		{File: "orders.grpc", Index: 2, Service: "example.OrderService", Method: "GetOrder", Status: "not_found", Error: "gRPC error [not_found]",
+			Asserts: []Assertion{{Pass: true, Message: "PASS: status == not_found"}}},
+	}
+	for _, res := range results {
+		if err := r.Result(res); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Other output is not part of the report
+	if err := r.Request(&Request{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var report junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf.String())
+	}
+	if report.Tests != 4 || report.Failures != 1 || report.Errors != 1 || report.Time != "0.015" {
+		t.Errorf("totals = %d tests, %d failures, %d errors, %s s", report.Tests, report.Failures, report.Errors, report.Time)
+	}
+	if len(report.Suites) != 2 || report.Suites[0].Name != "users.grpc" || report.Suites[1].Name != "orders.grpc" {
+		t.Fatalf("suites = %+v", report.Suites)
+	}
+
+	users := report.Suites[0].Cases
+	if users[0].Name != "1: Get user" || users[0].Classname != "example.UserService/GetUser" || users[0].Time != "0.012" || users[0].Failure != nil {
+		t.Errorf("case = %+v", users[0])
+	}
+	if f := users[1].Failure; f == nil || f.Message != "1 of 2 assertions failed" || !strings.Contains(f.Text, `FAIL: jsonpath "$.id"`) {
+		t.Errorf("failure = %+v", f)
+	}
+	orders := report.Suites[1].Cases
+	if e := orders[0].Error; e == nil || e.Type != "unavailable" || e.Message != "RPC call failed: connection refused" {
+		t.Errorf("error = %+v", e)
+	}
+	if orders[1].Error != nil || orders[1].Failure != nil {
+		t.Errorf("expected an expected error status to pass, got %+v", orders[1])
+	}
+	if !strings.Contains(buf.String(), `<![CDATA[{"id": "1"}]]>`) {
+		t.Errorf("expected the body as system-out:\n%s", buf.String())
+	}
+
+	if _, err := NewReport("pdf", &buf); err == nil {
+		t.Error("expected an error for an unknown report")
+	}
+}
+
+func TestMulti(t *testing.T) {
+	var a, b bytes.Buffer
+	ra, _ := New("ndjson", &a)
+	rb, _ := New("ndjson", &b)
+	m := Multi(ra, nil, rb)
+	if err := m.Result(&Result{Service: "svc", Method: "M"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if a.Len() == 0 || a.String() != b.String() {
+		t.Errorf("expected both renderers to render the result, got %q and %q", a.String(), b.String())
+	}
+}
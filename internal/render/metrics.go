@@ -0,0 +1,235 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the request
+// duration histograms: the Prometheus client defaults
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metricsRenderer writes counters and histograms of the results of a run on
+// Close, as JSON or, with prometheus, in the Prometheus text exposition
+// format (e.g. for a Pushgateway or the node_exporter textfile collector)
+type metricsRenderer struct {
+	resultsOnly
+	w          io.Writer
+	prometheus bool
+	now        func() time.Time
+	results    []*Result
+}
+
+func (m *metricsRenderer) Result(r *Result) error {
+	m.results = append(m.results, r)
+	return nil
+}
+
+// runMetrics are the metrics of a run
+type runMetrics struct {
+	Timestamp  time.Time         `json:"timestamp"`
+	Requests   requestCounts     `json:"requests"`
+	Assertions assertionCounts   `json:"assertions"`
+	Duration   durationHistogram `json:"duration_seconds"`
+	Methods    []methodMetrics   `json:"methods"`
+}
+
+type requestCounts struct {
+	Total   int `json:"total"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Errors  int `json:"errors"`
+	Skipped int `json:"skipped"`
+}
+
+type assertionCounts struct {
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+}
+
+// methodMetrics are the metrics of the calls of one method
+type methodMetrics struct {
+	Service    string            `json:"service"`
+	Method     string            `json:"method"`
+	Requests   requestCounts     `json:"requests"`
+	Statuses   map[string]int    `json:"statuses"`
+	Assertions assertionCounts   `json:"assertions"`
+	Duration   durationHistogram `json:"duration_seconds"`
+}
+
+// durationHistogram is a cumulative histogram of durations, as Prometheus
+// defines it
+type durationHistogram struct {
+	Count   int              `json:"count"`
+	Sum     float64          `json:"sum"`
+	Buckets []durationBucket `json:"buckets"`
+}
+
+type durationBucket struct {
+	LE    float64 `json:"le"`
+	Count int     `json:"count"` // Observations less than or equal to LE
+}
+
+func newDurationHistogram() durationHistogram {
+	h := durationHistogram{Buckets: make([]durationBucket, len(durationBuckets))}
+	for i, le := range durationBuckets {
+		h.Buckets[i].LE = le
+	}
+	return h
+}
+
+func (h *durationHistogram) observe(d time.Duration) {
+	h.Count++
+	h.Sum += d.Seconds()
+	for i := range h.Buckets {
+		if d.Seconds() <= h.Buckets[i].LE {
+			h.Buckets[i].Count++
+		}
+	}
+}
+
+func (c *requestCounts) add(r *Result) {
+	c.Total++
+	switch r.Outcome() {
+	case "fail":
+		c.Failed++
+	case "error":
+		c.Errors++
+	case "skip":
+		c.Skipped++
+	default:
+		c.Passed++
+	}
+}
+
+func (c *assertionCounts) add(r *Result) {
+	for _, a := range r.Asserts {
+		if a.Pass {
+			c.Passed++
+		} else {
+			c.Failed++
+		}
+	}
+}
+
+// collectMetrics totals results, with the methods in the order of their
+// first call
+func collectMetrics(results []*Result, now time.Time) runMetrics {
+	m := runMetrics{Timestamp: now, Duration: newDurationHistogram(), Methods: []methodMetrics{}}
+	byMethod := map[string]int{}
+	for _, r := range results {
+		m.Requests.add(r)
+		m.Assertions.add(r)
+		if r.Skipped == "" {
+			m.Duration.observe(r.Duration)
+		}
+
+		key := r.Service + "/" + r.Method
+		i, ok := byMethod[key]
+		if !ok {
+			i = len(m.Methods)
+			byMethod[key] = i
+			m.Methods = append(m.Methods, methodMetrics{Service: r.Service, Method: r.Method, Statuses: map[string]int{}, Duration: newDurationHistogram()})
+		}
+		mm := &m.Methods[i]
+		mm.Requests.add(r)
+		mm.Assertions.add(r)
+		if r.Skipped != "" {
+			continue // Not sent
+		}
+		mm.Duration.observe(r.Duration)
+		status := r.Status
+		if status == "" {
+			status = "error"
+		}
+		mm.Statuses[status]++
+	}
+	return m
+}
+
+func (m *metricsRenderer) Close() error {
+	metrics := collectMetrics(m.results, m.now())
+	if m.prometheus {
+		return writePrometheus(m.w, metrics)
+	}
+	enc := json.NewEncoder(m.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(metrics)
+}
+
+// writePrometheus writes metrics in the Prometheus text exposition format
+func writePrometheus(w io.Writer, m runMetrics) error {
+	var b strings.Builder
+	family := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	sample := func(name string, value float64, labels ...string) {
+		b.WriteString(name)
+		if len(labels) > 0 {
+			b.WriteByte('{')
+			for i := 0; i < len(labels); i += 2 {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				fmt.Fprintf(&b, "%s=%s", labels[i], quoteLabel(labels[i+1]))
+			}
+			b.WriteByte('}')
+		}
+		fmt.Fprintf(&b, " %s\n", strconv.FormatFloat(value, 'f', -1, 64))
+	}
+
+	family("grpc_client_run_timestamp_seconds", "gauge", "Time the run finished, as seconds since the epoch.")
+	sample("grpc_client_run_timestamp_seconds", float64(m.Timestamp.UnixMilli())/1000)
+
+	family("grpc_client_requests_total", "counter", "Requests sent, by method and gRPC status.")
+	for _, mm := range m.Methods {
+		statuses := make([]string, 0, len(mm.Statuses))
+		for status := range mm.Statuses {
+			statuses = append(statuses, status)
+		}
+		slices.Sort(statuses)
+		for _, status := range statuses {
+			sample("grpc_client_requests_total", float64(mm.Statuses[status]), "service", mm.Service, "method", mm.Method, "status", status)
+		}
+	}
+
+	family("grpc_client_request_outcomes_total", "counter", "Requests by method and outcome: pass, fail (a failed assertion), error, or skip.")
+	for _, mm := range m.Methods {
+		for _, o := range []struct {
+			outcome string
+			count   int
+		}{{"pass", mm.Requests.Passed}, {"fail", mm.Requests.Failed}, {"error", mm.Requests.Errors}, {"skip", mm.Requests.Skipped}} {
+			sample("grpc_client_request_outcomes_total", float64(o.count), "service", mm.Service, "method", mm.Method, "outcome", o.outcome)
+		}
+	}
+
+	family("grpc_client_assertions_total", "counter", "Assertions evaluated, by method and result.")
+	for _, mm := range m.Methods {
+		sample("grpc_client_assertions_total", float64(mm.Assertions.Passed), "service", mm.Service, "method", mm.Method, "result", "pass")
+		sample("grpc_client_assertions_total", float64(mm.Assertions.Failed), "service", mm.Service, "method", mm.Method, "result", "fail")
+	}
+
+	family("grpc_client_request_duration_seconds", "histogram", "Duration of the requests, by method.")
+	for _, mm := range m.Methods {
+		for _, bk := range mm.Duration.Buckets {
+			sample("grpc_client_request_duration_seconds_bucket", float64(bk.Count), "service", mm.Service, "method", mm.Method, "le", strconv.FormatFloat(bk.LE, 'g', -1, 64))
+		}
+		sample("grpc_client_request_duration_seconds_bucket", float64(mm.Duration.Count), "service", mm.Service, "method", mm.Method, "le", "+Inf")
+		sample("grpc_client_request_duration_seconds_sum", mm.Duration.Sum, "service", mm.Service, "method", mm.Method)
+		sample("grpc_client_request_duration_seconds_count", float64(mm.Duration.Count), "service", mm.Service, "method", mm.Method)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// quoteLabel quotes a label value, escaping backslashes, quotes, and
+// newlines as the exposition format requires
+func quoteLabel(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
@@ -0,0 +1,102 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// metricsResults are the results of a run of two methods
+func metricsResults() []*Result {
+	return []*Result{
+		{Service: "example.UserService", Method: "GetUser", Status: "ok", Duration: 3 * time.Millisecond,
+			Asserts: []Assertion{{Pass: true}, {Pass: true}}},
+		{Service: "example.UserService", Method: "GetUser", Status: "ok", Duration: 40 * time.Millisecond,
+			Asserts: []Assertion{{Pass: true}, {Pass: false}}},
+		{Service: "example.UserService", Method: "GetUser", Status: "not_found", Duration: 20 * time.Millisecond, Error: "gRPC error [not_found]"},
+		{Service: "example.OrderService", Method: "GetOrder", Status: "ok", Duration: 2 * time.Second},
+	}
+}
+
+func TestMetricsReport(t *testing.T) {
+	var buf bytes.Buffer
+	r := &metricsRenderer{w: &buf, now: func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }}
+	for _, res := range metricsResults() {
+		if err := r.Result(res); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got runMetrics
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if got.Requests != (requestCounts{Total: 4, Passed: 2, Failed: 1, Errors: 1}) {
+		t.Errorf("requests = %+v", got.Requests)
+	}
+	if got.Assertions != (assertionCounts{Passed: 3, Failed: 1}) {
+		t.Errorf("assertions = %+v", got.Assertions)
+	}
+	if len(got.Methods) != 2 || got.Methods[0].Method != "GetUser" || got.Methods[1].Method != "GetOrder" {
+		t.Fatalf("methods = %+v", got.Methods)
+	}
+	users := got.Methods[0]
+	if users.Statuses["ok"] != 2 || users.Statuses["not_found"] != 1 {
+		t.Errorf("statuses = %v", users.Statuses)
+	}
+	if d := users.Duration; d.Count != 3 || d.Buckets[0].LE != 0.005 || d.Buckets[0].Count != 1 || d.Buckets[2].Count != 2 || d.Buckets[3].Count != 3 {
+		t.Errorf("duration = %+v", d)
+	}
+	if d := got.Duration; d.Count != 4 || d.Sum < 2.06 || d.Sum > 2.07 || d.Buckets[len(d.Buckets)-1].Count != 4 {
+		t.Errorf("run duration = %+v", d)
+	}
+	if !got.Timestamp.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("timestamp = %s", got.Timestamp)
+	}
+}
+
+func TestPrometheusReport(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := NewReport("prometheus", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range metricsResults() {
+		if err := r.Result(res); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	for _, want := range []string{
+		"# TYPE grpc_client_requests_total counter\n",
+		`grpc_client_requests_total{service="example.UserService",method="GetUser",status="not_found"} 1` + "\n",
+		`grpc_client_requests_total{service="example.UserService",method="GetUser",status="ok"} 2` + "\n",
+		`grpc_client_request_outcomes_total{service="example.UserService",method="GetUser",outcome="fail"} 1` + "\n",
+		`grpc_client_request_outcomes_total{service="example.UserService",method="GetUser",outcome="error"} 1` + "\n",
+		`grpc_client_assertions_total{service="example.UserService",method="GetUser",result="pass"} 3` + "\n",
+		"# TYPE grpc_client_request_duration_seconds histogram\n",
+		`grpc_client_request_duration_seconds_bucket{service="example.UserService",method="GetUser",le="0.005"} 1` + "\n",
+		`grpc_client_request_duration_seconds_bucket{service="example.UserService",method="GetUser",le="+Inf"} 3` + "\n",
+		`grpc_client_request_duration_seconds_bucket{service="example.OrderService",method="GetOrder",le="1"} 0` + "\n",
+		`grpc_client_request_duration_seconds_sum{service="example.OrderService",method="GetOrder"} 2` + "\n",
+		`grpc_client_request_duration_seconds_count{service="example.OrderService",method="GetOrder"} 1` + "\n",
+		"# TYPE grpc_client_run_timestamp_seconds gauge\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+
+	if got := quoteLabel("a\"b\\c\nd"); got != `"a\"b\\c\nd"` {
+		t.Errorf("quoteLabel = %s", got)
+	}
+}
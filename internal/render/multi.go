@@ -0,0 +1,63 @@
+package render
+
+import (
+	"errors"
+
+	"grpc_client/internal/bench"
+	"grpc_client/internal/file"
+	"grpc_client/internal/gateway"
+	"grpc_client/internal/proto"
+)
+
+// Multi returns a Renderer that renders everything with each of renderers,
+// e.g. the output of a run and its --report files. Nil renderers are
+// skipped.
+func Multi(renderers ...Renderer) Renderer {
+	var m multiRenderer
+	for _, r := range renderers {
+		if r != nil {
+			m = append(m, r)
+		}
+	}
+	return m
+}
+
+// multiRenderer renders with each renderer in turn, continuing past errors
+// so that every renderer sees every value
+type multiRenderer []Renderer
+
+func (m multiRenderer) each(f func(Renderer) error) error {
+	var errs []error
+	for _, r := range m {
+		errs = append(errs, f(r))
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiRenderer) Services(services []proto.ServiceInfo) error {
+	return m.each(func(r Renderer) error { return r.Services(services) })
+}
+
+func (m multiRenderer) Result(res *Result) error {
+	return m.each(func(r Renderer) error { return r.Result(res) })
+}
+
+func (m multiRenderer) Bench(s *bench.Summary) error {
+	return m.each(func(r Renderer) error { return r.Bench(s) })
+}
+
+func (m multiRenderer) Gateway(g *gateway.Matrix) error {
+	return m.each(func(r Renderer) error { return r.Gateway(g) })
+}
+
+func (m multiRenderer) Diagnostics(diags []file.Diagnostic) error {
+	return m.each(func(r Renderer) error { return r.Diagnostics(diags) })
+}
+
+func (m multiRenderer) Request(req *Request) error {
+	return m.each(func(r Renderer) error { return r.Request(req) })
+}
+
+func (m multiRenderer) Close() error {
+	return m.each(Renderer.Close)
+}
@@ -0,0 +1,124 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// spinnerFrames are drawn in turn, one per tick
+var spinnerFrames = []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+
+// progressDelay is how long a request runs before progress is shown, so
+// that fast requests do not flicker
+const progressDelay = 250 * time.Millisecond
+
+// Progress draws a spinner line on a terminal with the request in flight,
+// its position in the run, and its elapsed time, so that long requests do
+// not look hung. The line is erased when the request ends, before its
+// result is written. A nil Progress does nothing.
+type Progress struct {
+	w     io.Writer
+	total int
+	now   func() time.Time
+
+	mu      sync.Mutex
+	active  bool
+	index   int
+	label   string
+	started time.Time
+	frame   int
+	drawn   bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// StartProgress draws progress on w, a terminal that understands ANSI
+// escape sequences, every interval until Stop. total is the number of
+// requests of the run (0 for a single call).
+func StartProgress(w io.Writer, total int, interval time.Duration) *Progress {
+	p := &Progress{w: w, total: total, now: time.Now, stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.draw()
+			}
+		}
+	}()
+	return p
+}
+
+// Begin shows request index (from 1), described by label, as in flight
+func (p *Progress) Begin(index int, label string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active, p.index, p.label, p.started = true, index, label, p.now()
+}
+
+// End erases the progress line, if drawn, until the next Begin
+func (p *Progress) End() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active = false
+	p.erase()
+}
+
+// Stop erases the progress line and stops drawing. It may be called more
+// than once.
+func (p *Progress) Stop() {
+	if p == nil {
+		return
+	}
+	p.End()
+	select {
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+	<-p.done
+}
+
+// draw redraws the progress line of the request in flight
+func (p *Progress) draw() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	elapsed := p.now().Sub(p.started)
+	if !p.active || elapsed < progressDelay {
+		return
+	}
+	p.frame++
+	fmt.Fprintf(p.w, "\r\x1b[K%s", p.line(elapsed))
+	p.drawn = true
+}
+
+// erase clears the progress line; p.mu must be held
+func (p *Progress) erase() {
+	if p.drawn {
+		fmt.Fprint(p.w, "\r\x1b[K")
+		p.drawn = false
+	}
+}
+
+// line formats the progress of the request in flight, e.g.
+// "⠹ [2/5] Get user (example.UserService/GetUser) 3.2s"
+func (p *Progress) line(elapsed time.Duration) string {
+	s := string(spinnerFrames[p.frame%len(spinnerFrames)]) + " "
+	if p.total > 0 {
+		s += fmt.Sprintf("[%d/%d] ", p.index, p.total)
+	}
+	return s + p.label + " " + elapsed.Round(100*time.Millisecond).String()
+}
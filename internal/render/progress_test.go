@@ -0,0 +1,54 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	var out strings.Builder
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := &Progress{w: &out, total: 5, now: func() time.Time { return now }}
+
+	// Nothing is drawn before a request begins, or while it is fast
+	p.draw()
+	p.Begin(2, "Get user (example.UserService/GetUser)")
+	now = now.Add(100 * time.Millisecond)
+	p.draw()
+	if out.Len() != 0 {
+		t.Fatalf("expected nothing drawn yet, got %q", out.String())
+	}
+
+	now = now.Add(3130 * time.Millisecond)
+	p.draw()
+	if got, want := out.String(), "\r\x1b[K⠙ [2/5] Get user (example.UserService/GetUser) 3.2s"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Ending erases the line, once
+	out.Reset()
+	p.End()
+	p.End()
+	p.draw()
+	if got := out.String(); got != "\r\x1b[K" {
+		t.Errorf("expected the line to be erased, got %q", got)
+	}
+}
+
+func TestProgress_SingleCall(t *testing.T) {
+	var out strings.Builder
+	p := StartProgress(&out, 0, time.Hour)
+	p.frame, p.label = 2, "example.UserService/GetUser"
+	if got := p.line(1500 * time.Millisecond); got != "⠹ example.UserService/GetUser 1.5s" {
+		t.Errorf("got %q", got)
+	}
+	p.Stop()
+	p.Stop()
+
+	// A nil Progress shows nothing
+	var none *Progress
+	none.Begin(1, "users")
+	none.End()
+	none.Stop()
+}
@@ -0,0 +1,62 @@
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotResults is returned (wrapped) by ReadResults for JSON that is not
+// the output of a run
+var ErrNotResults = errors.New("not the results of a run")
+
+// ReadResults reads back the results a run wrote with the json or ndjson
+// renderer (e.g. with --output json): their file, position, name, method,
+// status, body, error, and iteration. Bodies are kept as JSON text.
+func ReadResults(r io.Reader) ([]*Result, error) {
+	dec := json.NewDecoder(r)
+	var results []*Result
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		// The json renderer writes an array, ndjson one result per line
+		items := []json.RawMessage{raw}
+		if len(raw) > 0 && raw[0] == '[' {
+			items = nil
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return nil, fmt.Errorf("invalid JSON: %w", err)
+			}
+		}
+		for _, item := range items {
+			var jr jsonResult
+			if err := json.Unmarshal(item, &jr); err != nil || jr.Service == "" || jr.Method == "" {
+				return nil, ErrNotResults
+			}
+			body := ""
+			if string(jr.Body) != "null" {
+				body = string(jr.Body)
+			}
+			results = append(results, &Result{
+				File:      jr.File,
+				Index:     jr.Index,
+				Name:      jr.Name,
+				Service:   jr.Service,
+				Method:    jr.Method,
+				Status:    jr.Status,
+				Body:      body,
+				Error:     jr.Error,
+				Skipped:   jr.Skipped,
+				Iteration: jr.Iteration,
+			})
+		}
+	}
+	if len(results) == 0 {
+		return nil, ErrNotResults
+	}
+	return results, nil
+}
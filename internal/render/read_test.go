@@ -0,0 +1,52 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadResults(t *testing.T) {
+	written := []*Result{
+		{File: "users.grpc", Index: 1, Name: "Get user", Service: "example.UserService", Method: "GetUser", Status: "ok", Body: `{"id": "1"}`},
+		{File: "users.grpc", Index: 2, Service: "example.UserService", Method: "DeleteUser", Status: "not_found", Error: "gRPC error [not_found]", Iteration: 2},
+	}
+	for _, format := range []string{"json", "ndjson"} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			r, _ := New(format, &buf)
+			for _, res := range written {
+				if err := r.Result(res); err != nil {
+					t.Fatalf("Result failed: %v", err)
+				}
+			}
+			if err := r.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			read, err := ReadResults(&buf)
+			if err != nil {
+				t.Fatalf("ReadResults failed: %v", err)
+			}
+			if len(read) != 2 {
+				t.Fatalf("read %d results, want 2", len(read))
+			}
+			if got := *read[0]; got.File != "users.grpc" || got.Index != 1 || got.Name != "Get user" || got.Status != "ok" || strings.Join(strings.Fields(got.Body), "") != `{"id":"1"}` {
+				t.Errorf("first result = %+v", got)
+			}
+			if got := *read[1]; got.Method != "DeleteUser" || got.Body != "" || got.Error != "gRPC error [not_found]" || got.Iteration != 2 {
+				t.Errorf("second result = %+v", got)
+			}
+		})
+	}
+
+	for _, input := range []string{`{"id": "1"}`, `[]`, `[{"service": "svc"}]`, ``} {
+		if _, err := ReadResults(strings.NewReader(input)); !errors.Is(err, ErrNotResults) {
+			t.Errorf("ReadResults(%q) error = %v, want ErrNotResults", input, err)
+		}
+	}
+	if _, err := ReadResults(strings.NewReader(`{"service": `)); err == nil || errors.Is(err, ErrNotResults) {
+		t.Errorf("expected a JSON error, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package render
+
+import (
+	"grpc_client/internal/bench"
+	"grpc_client/internal/file"
+	"grpc_client/internal/gateway"
+	"grpc_client/internal/proto"
+	"grpc_client/internal/redact"
+)
+
+// Redact wraps r so that sensitive header values and secrets are masked in
+// everything it renders. The values passed to r are copies; the caller's
+// are left untouched.
+func Redact(r Renderer, redactor *redact.Redactor) Renderer {
+	return &redactingRenderer{next: r, redactor: redactor}
+}
+
+// redactingRenderer masks sensitive values before rendering
+type redactingRenderer struct {
+	next     Renderer
+	redactor *redact.Redactor
+}
+
+func (r *redactingRenderer) Services(services []proto.ServiceInfo) error {
+	return r.next.Services(services)
+}
+
+func (r *redactingRenderer) Result(res *Result) error {
+	masked := *res
+	masked.Body = r.redactor.String(res.Body)
+	masked.Error = r.redactor.String(res.Error)
+	masked.URL = r.redactor.String(res.URL)
+	masked.Request = r.redactor.String(res.Request)
+	masked.RequestHeader = r.redactor.Header(res.RequestHeader)
+	masked.Header = r.redactor.Header(res.Header)
+	masked.Trailer = r.redactor.Header(res.Trailer)
+	masked.Captures = make([]Capture, len(res.Captures))
+	for i, c := range res.Captures {
+		c.Value = r.redactor.String(c.Value)
+		c.Error = r.redactor.String(c.Error)
+		masked.Captures[i] = c
+	}
+	masked.Asserts = make([]Assertion, len(res.Asserts))
+	for i, a := range res.Asserts {
+		a.Message = r.redactor.String(a.Message)
+		a.Diff = r.redactor.String(a.Diff)
+		masked.Asserts[i] = a
+	}
+	return r.next.Result(&masked)
+}
+
+func (r *redactingRenderer) Bench(s *bench.Summary) error {
+	return r.next.Bench(s)
+}
+
+func (r *redactingRenderer) Gateway(m *gateway.Matrix) error {
+	masked := *m
+	masked.Results = make([]gateway.Result, len(m.Results))
+	for i, res := range m.Results {
+		res.Detail = r.redactor.String(res.Detail)
+		masked.Results[i] = res
+	}
+	return r.next.Gateway(&masked)
+}
+
+func (r *redactingRenderer) Diagnostics(diags []file.Diagnostic) error {
+	masked := make([]file.Diagnostic, len(diags))
+	for i, d := range diags {
+		d.Message = r.redactor.String(d.Message)
+		d.Source = r.redactor.String(d.Source)
+		masked[i] = d
+	}
+	return r.next.Diagnostics(masked)
+}
+
+func (r *redactingRenderer) Request(req *Request) error {
+	masked := *req
+	masked.URL = r.redactor.String(req.URL)
+	masked.Header = r.redactor.Header(req.Header)
+	masked.Body = r.redactor.String(req.Body)
+	masked.Payload = r.redactor.Binary(req.Payload) // Same length, so gRPC frames stay valid
+	return r.next.Request(&masked)
+}
+
+func (r *redactingRenderer) Close() error {
+	return r.next.Close()
+}
@@ -0,0 +1,238 @@
+package render
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"grpc_client/internal/bench"
+	"grpc_client/internal/file"
+	"grpc_client/internal/gateway"
+	"grpc_client/internal/proto"
+)
+
+// Renderer formats the output of a command. Commands report what they
+// produced through a Renderer instead of printing directly, so the output
+// format can be selected with --render.
+type Renderer interface {
+	// Services renders the services discovered by the list command.
+	Services(services []proto.ServiceInfo) error
+	// Result renders the outcome of a single RPC.
+	Result(r *Result) error
+	// Bench renders the summary of a benchmark.
+	Bench(s *bench.Summary) error
+	// Gateway renders the compatibility matrix of the gateway-check command.
+	Gateway(m *gateway.Matrix) error
+	// Diagnostics renders the problems the lint command found in a file.
+	Diagnostics(diags []file.Diagnostic) error
+	// Request renders a request built with --dry-run instead of being sent.
+	Request(r *Request) error
+	// Close flushes any buffered output.
+	Close() error
+}
+
+// Result is the outcome of a single RPC as seen by a Renderer
+type Result struct {
+	File     string        // Request file the request came from (empty for a standalone call)
+	Index    int           // Position of the request in its file (0 for a standalone call)
+	Name     string        // Optional request name
+	Service  string        // Fully qualified service name
+	Method   string        // Method name
+	Status   string        // gRPC status code name (e.g. "ok")
+	Duration time.Duration // Time taken by the RPC
+	Body     string        // Response body as JSON, or prototext with --output-format text (empty when the call failed)
+	Output   string        // File Body was written to instead of being printed, e.g. with -o
+
+	// ExpectFailure is set when the request is expected to fail, which
+	// inverts its outcome
+	ExpectFailure bool
+
+	// Skipped is why the request was not sent, when it was skipped
+	Skipped string
+
+	// Iteration is the iteration of a repeated run the result is from,
+	// counted from 1 (0 when the run is not repeated)
+	Iteration int
+
+	RequestSize  int // Encoded size of the request message in bytes
+	ResponseSize int // Encoded size of the response message in bytes (0 when the call failed)
+
+	Error    string      // Error returned by the call, if any
+	Captures []Capture   // Variables captured from the response
+	Asserts  []Assertion // Assertion outcomes
+
+	Certificates []Certificate // Server certificate chain, leaf first, when requested (e.g. --show-certs)
+
+	// Details of the exchange, for machine-readable output
+	URL           string      // URL the request was sent to
+	Started       time.Time   // When the RPC was sent
+	Request       string      // Request message as JSON
+	RequestHeader http.Header // Headers set for the request, without those the protocol adds
+	Header        http.Header // Response headers
+	Trailer       http.Header // Response trailers
+}
+
+// Request is an RPC as it would be sent, as seen by a Renderer
+type Request struct {
+	Index   int         // Position of the request in its file (0 for a standalone call)
+	Name    string      // Optional request name
+	Service string      // Fully qualified service name
+	Method  string      // Method name
+	URL     string      // URL the request is sent to
+	Header  http.Header // HTTP headers, including those set by the protocol
+	Body    string      // Request message as JSON
+	Payload []byte      // Encoded HTTP body, framed as the protocol requires
+}
+
+// Passed reports whether every assertion of the result passed
+func (r *Result) Passed() bool {
+	for _, a := range r.Asserts {
+		if !a.Pass {
+			return false
+		}
+	}
+	return true
+}
+
+// Outcome classifies the result like a test case: "pass", "fail" when an
+// assertion failed, or "error" when the call failed without an expected
+// status (which assertions would check), or "skip" when it was not sent. A
+// request expected to fail passes when it fails either way, and fails when
+// it succeeds.
+func (r *Result) Outcome() string {
+	failed := r.Failed()
+	switch {
+	case r.Skipped != "":
+		return "skip"
+	case r.ExpectFailure && failed:
+		return "pass"
+	case r.ExpectFailure:
+		return "fail"
+	case !r.Passed():
+		return "fail"
+	case failed:
+		return "error"
+	default:
+		return "pass"
+	}
+}
+
+// Failed reports whether the request failed, by an assertion or by a call
+// that failed without an expected status, whether or not it was expected to
+func (r *Result) Failed() bool {
+	return !r.Passed() || (r.Error != "" && len(r.Asserts) == 0)
+}
+
+// unexpectedPass describes the failure of a request expected to fail that
+// succeeded
+const unexpectedPass = "expected to fail (ExpectFailure: true), but passed"
+
+// UnexpectedPass reports whether a request expected to fail succeeded
+func (r *Result) UnexpectedPass() bool {
+	return r.ExpectFailure && !r.Failed()
+}
+
+// Capture is a variable extracted from a response
+type Capture struct {
+	Name  string
+	Path  string
+	Value string
+	Error string // Set when the value could not be extracted
+}
+
+// Certificate describes a certificate of the server's chain
+type Certificate struct {
+	Subject   string
+	Issuer    string
+	Serial    string
+	NotBefore time.Time
+	NotAfter  time.Time
+	DNSNames  []string
+}
+
+// NewCertificates describes a certificate chain
+func NewCertificates(chain []*x509.Certificate) []Certificate {
+	var certs []Certificate
+	for _, c := range chain {
+		certs = append(certs, Certificate{
+			Subject:   c.Subject.String(),
+			Issuer:    c.Issuer.String(),
+			Serial:    c.SerialNumber.String(),
+			NotBefore: c.NotBefore,
+			NotAfter:  c.NotAfter,
+			DNSNames:  c.DNSNames,
+		})
+	}
+	return certs
+}
+
+// Assertion is the outcome of a single assertion
+type Assertion struct {
+	Pass    bool
+	Message string
+	Diff    string // Unified diff of the expected and actual documents, when they differ
+}
+
+// Formats lists the renderer names accepted by New
+var Formats = []string{"text", "json", "ndjson", "silent", "ghz", "fortio", "template=<go template>"}
+
+// New creates the renderer named by format, writing to w.
+// A custom template is selected with "template=<go template>".
+func New(format string, w io.Writer) (Renderer, error) {
+	if tmpl, ok := strings.CutPrefix(format, "template="); ok {
+		return newTemplateRenderer(tmpl, w)
+	}
+
+	switch format {
+	case "", "text":
+		return &textRenderer{w: w}, nil
+	case "json":
+		return &jsonRenderer{w: w}, nil
+	case "ndjson":
+		return &ndjsonRenderer{w: w}, nil
+	case "silent":
+		return silentRenderer{}, nil
+	case "ghz":
+		return &ghzRenderer{benchOnly: benchOnly{name: format}, w: w}, nil
+	case "fortio":
+		return &fortioRenderer{benchOnly: benchOnly{name: format}, w: w}, nil
+	default:
+		return nil, fmt.Errorf("invalid renderer %q, must be one of: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// Reports lists the report kinds accepted by NewReport
+var Reports = []string{"junit", "html", "csv", "metrics", "prometheus"}
+
+// NewReport creates the report renderer of kind, writing to w when closed.
+// Reports describe the results of a run and ignore other output.
+func NewReport(kind string, w io.Writer) (Renderer, error) {
+	switch kind {
+	case "junit":
+		return &junitRenderer{w: w}, nil
+	case "html":
+		return &htmlRenderer{w: w, now: time.Now}, nil
+	case "csv":
+		return &csvRenderer{w: w}, nil
+	case "metrics":
+		return &metricsRenderer{w: w, now: time.Now}, nil
+	case "prometheus":
+		return &metricsRenderer{w: w, prometheus: true, now: time.Now}, nil
+	default:
+		return nil, fmt.Errorf("invalid report %q, must be one of: %s", kind, strings.Join(Reports, ", "))
+	}
+}
+
+// silentRenderer discards all output
+type silentRenderer struct{}
+
+func (silentRenderer) Services([]proto.ServiceInfo) error  { return nil }
+func (silentRenderer) Result(*Result) error                { return nil }
+func (silentRenderer) Bench(*bench.Summary) error          { return nil }
+func (silentRenderer) Gateway(*gateway.Matrix) error       { return nil }
+func (silentRenderer) Diagnostics([]file.Diagnostic) error { return nil }
+func (silentRenderer) Request(*Request) error              { return nil }
+func (silentRenderer) Close() error                        { return nil }
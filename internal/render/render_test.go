@@ -0,0 +1,455 @@
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"grpc_client/internal/bench"
+	"grpc_client/internal/file"
+	"grpc_client/internal/gateway"
+	"grpc_client/internal/proto"
+	"grpc_client/internal/redact"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		wantErr bool
+	}{
+		{"Default", "", false},
+		{"Text", "text", false},
+		{"JSON", "json", false},
+		{"NDJSON", "ndjson", false},
+		{"Silent", "silent", false},
+		{"ghz", "ghz", false},
+		{"Fortio", "fortio", false},
+		{"Template", "template={{.Method}}", false},
+		{"Invalid template", "template={{.Method", true},
+		{"Unknown", "yaml", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.format, &bytes.Buffer{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New(%q) error = %v, wantErr %v", tt.format, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderers(t *testing.T) {
+	result := &Result{
+		Index:    1,
+		Name:     "Get user",
+		Service:  "example.UserService",
+		Method:   "GetUser",
+		Status:   "ok",
+		Duration: 12500 * time.Microsecond,
+		Body:     `{"id": "123"}`,
+		Captures: []Capture{
+			{Name: "user_id", Path: "id", Value: "123"},
+		},
+		Asserts: []Assertion{
+			{Pass: true, Message: `PASS: jsonpath "$.id" == "123"`},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{
+			name:   "Text",
+			format: "text",
+			want: "# Get user\n# example.UserService/GetUser\n\n{\"id\": \"123\"}\n" +
+				"\n# Captures:\n# user_id = 123\n" +
+				"\n# Asserts:\n# PASS: jsonpath \"$.id\" == \"123\"\n",
+		},
+		{
+			name:   "NDJSON",
+			format: "ndjson",
+			want: `{"name":"Get user","service":"example.UserService","method":"GetUser","status":"ok","duration_ms":12.5,"body":{"id":"123"},` +
+				`"captures":[{"name":"user_id","path":"id","value":"123"}],` +
+				`"asserts":[{"pass":true,"message":"PASS: jsonpath \"$.id\" == \"123\""}]}` + "\n",
+		},
+		{
+			name:   "Template",
+			format: "template={{.Name}}: {{.Body}}",
+			want:   "Get user: {\"id\": \"123\"}\n",
+		},
+		{
+			name:   "Template with jsonpath",
+			format: `template={{.Status}} {{.Duration}} {{.Body | jsonpath "$.id"}}`,
+			want:   "ok 12.5ms 123\n",
+		},
+		{
+			name:   "Silent",
+			format: "silent",
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r, err := New(tt.format, &buf)
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			if err := r.Result(result); err != nil {
+				t.Fatalf("Result failed: %v", err)
+			}
+			if err := r.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextRenderer_Separator(t *testing.T) {
+	var buf bytes.Buffer
+	r, _ := New("text", &buf)
+	_ = r.Result(&Result{Index: 1, Service: "svc", Method: "A", Body: "{}"})
+	_ = r.Result(&Result{Index: 2, Service: "svc", Method: "B", Body: "{}"})
+
+	want := "# Request 1\n# svc/A\n\n{}\n\n---\n# Request 2\n# svc/B\n\n{}\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestTextRenderer_Output(t *testing.T) {
+	var buf bytes.Buffer
+	r, _ := New("text", &buf)
+	_ = r.Result(&Result{Service: "svc", Method: "A", Body: `{"id": "1"}`, Output: "user.json"})
+
+	if want := "# Response written to user.json\n"; buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestTextRenderer_Include(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewText(&buf, TextOptions{Include: true})
+	_ = r.Result(&Result{Service: "svc", Method: "A", Status: "ok", Body: "{}",
+		Header:  http.Header{"X-Request-Id": {"r1"}, "Content-Type": {"application/grpc-web+proto"}},
+		Trailer: http.Header{"Grpc-Status": {"0"}}})
+	_ = r.Result(&Result{Service: "svc", Method: "A", Status: "not_found", Error: "gRPC error [not_found]: no user",
+		Header: http.Header{"Grpc-Message": {"no user"}}})
+
+	want := "# Status: ok\n< Content-Type: application/grpc-web+proto\n< X-Request-Id: r1\n\n{}\n\n# Trailers:\n< Grpc-Status: 0\n" +
+		"\n---\n# Status: not_found\n< Grpc-Message: no user\n\n# Error: gRPC error [not_found]: no user\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestTextRenderer_Certificates(t *testing.T) {
+	var buf bytes.Buffer
+	r, _ := New("text", &buf)
+	notBefore := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	_ = r.Result(&Result{Body: "{}", Certificates: []Certificate{
+		{Subject: "CN=api.example.com", Issuer: "CN=Example CA", Serial: "42", NotBefore: notBefore, NotAfter: notBefore.AddDate(1, 0, 0), DNSNames: []string{"api.example.com"}},
+		{Subject: "CN=Example CA", Issuer: "CN=Example CA", Serial: "1", NotBefore: notBefore, NotAfter: notBefore.AddDate(10, 0, 0)},
+	}})
+
+	want := `{}
+
+# Server certificates:
+# 0 subject: CN=api.example.com
+#   issuer:  CN=Example CA
+#   serial:  42
+#   valid:   2025-01-01T00:00:00Z to 2026-01-01T00:00:00Z
+#   names:   api.example.com
+# 1 subject: CN=Example CA
+#   issuer:  CN=Example CA
+#   serial:  1
+#   valid:   2025-01-01T00:00:00Z to 2035-01-01T00:00:00Z
+`
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	var buf bytes.Buffer
+	inner, _ := New("json", &buf)
+	redactor := redact.New()
+	redactor.AddSecret("hunter22")
+	r := Redact(inner, redactor)
+
+	res := &Result{
+		Service:  "svc",
+		Method:   "Login",
+		Body:     `{"token": "hunter22"}`,
+		Captures: []Capture{{Name: "token", Path: "$.token", Value: "hunter22"}},
+		Asserts:  []Assertion{{Message: `FAIL: jsonpath "$.token" == "x" (actual: "hunter22")`}},
+
+		Request:       `{"password": "hunter22"}`,
+		RequestHeader: http.Header{"Authorization": {"Bearer abc"}},
+		Header:        http.Header{"Set-Cookie": {"session=Bearer abc"}},
+	}
+	req := &Request{Service: "svc", Method: "Login", Header: http.Header{"Authorization": {"Bearer abc"}}, Body: `{"password": "hunter22"}`}
+	if err := r.Result(res); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Request(req); err != nil {
+		t.Fatal(err)
+	}
+	_ = r.Close()
+
+	if out := buf.String(); strings.Contains(out, "hunter22") || strings.Contains(out, "Bearer abc") {
+		t.Errorf("secrets leaked into output: %s", out)
+	}
+	if res.Body != `{"token": "hunter22"}` || res.Captures[0].Value != "hunter22" || req.Header.Get("Authorization") != "Bearer abc" {
+		t.Error("the rendered values must not be modified")
+	}
+}
+
+func TestRedact_FramedPayload(t *testing.T) {
+	var buf bytes.Buffer
+	inner, _ := New("json", &buf)
+	redactor := redact.New()
+	redactor.AddSecret("hunter22")
+	r := Redact(inner, redactor)
+
+	// A gRPC frame holding {password: "hunter22"}: flags, big-endian length, message
+	message := append([]byte{0x0a, 8}, "hunter22"...)
+	payload := append([]byte{0, 0, 0, 0, byte(len(message))}, message...)
+	if err := r.Request(&Request{Service: "svc", Method: "Login", Payload: payload}); err != nil {
+		t.Fatal(err)
+	}
+	_ = r.Close()
+
+	var requests []struct {
+		Payload []byte `json:"payload"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &requests); err != nil || len(requests) != 1 {
+		t.Fatalf("invalid output %s: %v", buf.String(), err)
+	}
+	out := requests[0]
+	if bytes.Contains(out.Payload, []byte("hunter22")) {
+		t.Errorf("secret leaked into payload %q", out.Payload)
+	}
+	if len(out.Payload) != len(payload) || int(binary.BigEndian.Uint32(out.Payload[1:5])) != len(out.Payload)-5 {
+		t.Errorf("payload %q no longer matches its frame length", out.Payload)
+	}
+	if !bytes.Equal(out.Payload[:7], payload[:7]) {
+		t.Errorf("frame and field headers changed: %q", out.Payload[:7])
+	}
+}
+
+func TestNDJSON_ResultDetails(t *testing.T) {
+	res := &Result{
+		Service:       "svc",
+		Method:        "Get",
+		Status:        "ok",
+		Duration:      time.Millisecond,
+		Body:          `{"id": "1"}`,
+		RequestSize:   3,
+		ResponseSize:  5,
+		URL:           "http://localhost/svc/Get",
+		Started:       time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Request:       `{"id": "1"}`,
+		RequestHeader: http.Header{"X-Tenant": {"acme"}},
+		Header:        http.Header{"Content-Type": {"application/grpc-web+proto"}},
+		Trailer:       http.Header{"Grpc-Status": {"0"}},
+	}
+	var buf bytes.Buffer
+	if err := (&ndjsonRenderer{w: &buf}).Result(res); err != nil {
+		t.Fatalf("Result failed: %v", err)
+	}
+	want := `{"service":"svc","method":"Get","status":"ok","duration_ms":1,"body":{"id":"1"},` +
+		`"url":"http://localhost/svc/Get","started_at":"2025-01-02T03:04:05Z",` +
+		`"request":{"headers":{"X-Tenant":["acme"]},"body":{"id":"1"},"size_bytes":3},` +
+		`"headers":{"Content-Type":["application/grpc-web+proto"]},"trailers":{"Grpc-Status":["0"]},"response_size_bytes":5}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %s\nwant     %s", got, want)
+	}
+}
+
+func TestJSONRenderer_Services(t *testing.T) {
+	var buf bytes.Buffer
+	r, _ := New("json", &buf)
+	_ = r.Services([]proto.ServiceInfo{
+		{FullName: "example.UserService", Methods: []proto.MethodInfo{
+			{Name: "GetUser", InputType: "example.GetUserRequest", OutputType: "example.User"},
+		}},
+	})
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	for _, want := range []string{`"name": "example.UserService"`, `"input_type": "example.GetUserRequest"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %s:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestBench_Sizes(t *testing.T) {
+	var text bytes.Buffer
+	if err := (&textRenderer{w: &text}).Bench(testSummary()); err != nil {
+		t.Fatalf("Bench failed: %v", err)
+	}
+	for _, want := range []string{
+		"  request:  mean 20 B, p50 20 B, p90 20 B, p99 20 B, max 20 B\n",
+		"  response: mean 50 B, p50 50 B, p90 90 B, p99 90 B, max 90 B\n",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text output missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := (&ndjsonRenderer{w: &js}).Bench(testSummary()); err != nil {
+		t.Fatalf("Bench failed: %v", err)
+	}
+	want := `"response_size_bytes":{"min":10,"mean":50,"p50":50,"p90":90,"p99":90,"max":90}`
+	if !strings.Contains(js.String(), want) {
+		t.Errorf("JSON output missing %s:\n%s", want, js.String())
+	}
+
+	// Sizes are omitted when nothing was measured
+	js.Reset()
+	if err := (&ndjsonRenderer{w: &js}).Bench(&bench.Summary{}); err != nil {
+		t.Fatalf("Bench failed: %v", err)
+	}
+	if strings.Contains(js.String(), "size") {
+		t.Errorf("unexpected sizes in %s", js.String())
+	}
+}
+
+func TestGateway(t *testing.T) {
+	m := &gateway.Matrix{
+		Target:    "http://localhost:8080",
+		Call:      "example.UserService.GetUser",
+		Checks:    []string{"unary", "text-mode"},
+		Protocols: []string{"grpc-web", "grpc"},
+		Results: []gateway.Result{
+			{Check: "unary", Protocol: "grpc-web", Outcome: gateway.Supported, Detail: "ok"},
+			{Check: "unary", Protocol: "grpc", Outcome: gateway.Unsupported, Detail: "unavailable: no healthy upstream"},
+			{Check: "text-mode", Protocol: "grpc-web", Outcome: gateway.Supported, Detail: "ok"},
+			{Check: "text-mode", Protocol: "grpc", Outcome: gateway.NotApplicable},
+		},
+	}
+
+	var text bytes.Buffer
+	if err := (&textRenderer{w: &text}).Gateway(m); err != nil {
+		t.Fatalf("Gateway failed: %v", err)
+	}
+	want := `Gateway compatibility of http://localhost:8080 (example.UserService.GetUser):
+
+             grpc-web  grpc
+  unary      yes       no
+  text-mode  yes       n/a
+
+Unsupported:
+  unary (grpc): unavailable: no healthy upstream
+`
+	if text.String() != want {
+		t.Errorf("text output:\n%s\nwant:\n%s", text.String(), want)
+	}
+
+	var js bytes.Buffer
+	if err := (&ndjsonRenderer{w: &js}).Gateway(m); err != nil {
+		t.Fatalf("Gateway failed: %v", err)
+	}
+	if !strings.Contains(js.String(), `{"check":"text-mode","protocol":"grpc","outcome":"n/a"}`) {
+		t.Errorf("JSON output = %s", js.String())
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	diags := []file.Diagnostic{
+		{File: "login.grpc", Line: 3, Severity: file.SeverityError, Message: `unknown operator "equals"`},
+		{File: "login.grpc", Line: 9, Severity: file.SeverityWarning, Message: `variable "id" is captured but never used by a later request`},
+	}
+
+	var text bytes.Buffer
+	if err := (&textRenderer{w: &text}).Diagnostics(diags); err != nil {
+		t.Fatalf("Diagnostics failed: %v", err)
+	}
+	want := `login.grpc:3: error: unknown operator "equals"
+login.grpc:9: warning: variable "id" is captured but never used by a later request
+`
+	if text.String() != want {
+		t.Errorf("text output:\n%s\nwant:\n%s", text.String(), want)
+	}
+
+	var js bytes.Buffer
+	if err := (&ndjsonRenderer{w: &js}).Diagnostics(diags[:1]); err != nil {
+		t.Fatalf("Diagnostics failed: %v", err)
+	}
+	if got := js.String(); got != `{"file":"login.grpc","line":3,"severity":"error","message":"unknown operator \"equals\""}`+"\n" {
+		t.Errorf("JSON output = %s", got)
+	}
+}
+
+func TestDiagnostics_Snippet(t *testing.T) {
+	d := file.Diagnostic{File: "a.grpc", Line: 7, Column: 17, Severity: file.SeverityError, Message: `unknown operator "equals"`, Source: `jsonpath "$.id" equals "1"`}
+
+	var text bytes.Buffer
+	if err := (&textRenderer{w: &text}).Diagnostics([]file.Diagnostic{d}); err != nil {
+		t.Fatalf("Diagnostics failed: %v", err)
+	}
+	want := `a.grpc:7:17: error: unknown operator "equals"
+    jsonpath "$.id" equals "1"
+                    ^
+`
+	if text.String() != want {
+		t.Errorf("text output:\n%s\nwant:\n%s", text.String(), want)
+	}
+}
+
+func TestRequest(t *testing.T) {
+	req := &Request{
+		Index:   1,
+		Name:    "Get user",
+		Service: "example.UserService",
+		Method:  "GetUser",
+		URL:     "http://localhost:8080/example.UserService/GetUser",
+		Header:  http.Header{"Content-Type": {"application/proto"}, "Authorization": {"Bearer t"}},
+		Body:    `{"id": "1"}`,
+		Payload: []byte{0x0a, 0x01, 0x31},
+	}
+
+	var text bytes.Buffer
+	if err := (&textRenderer{w: &text}).Request(req); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	want := `# Get user
+# example.UserService/GetUser
+
+POST http://localhost:8080/example.UserService/GetUser
+Authorization: Bearer t
+Content-Type: application/proto
+
+{"id": "1"}
+
+# Payload (3 bytes):
+00000000  0a 01 31                                          |..1|
+`
+	if text.String() != want {
+		t.Errorf("text output:\n%s\nwant:\n%s", text.String(), want)
+	}
+
+	var js bytes.Buffer
+	if err := (&ndjsonRenderer{w: &js}).Request(req); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if got := js.String(); !strings.Contains(got, `"body":{"id":"1"}`) || !strings.Contains(got, `"payload":"CgEx"`) {
+		t.Errorf("JSON output = %s", got)
+	}
+}
@@ -0,0 +1,295 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"grpc_client/internal/bench"
+	"grpc_client/internal/file"
+	"grpc_client/internal/gateway"
+	"grpc_client/internal/proto"
+)
+
+// histogramBuckets is the number of buckets in bench report histograms
+const histogramBuckets = 10
+
+// benchOnly implements the non-bench parts of Renderer for report formats
+// that only describe benchmarks
+type benchOnly struct {
+	name string
+}
+
+func (b benchOnly) Services([]proto.ServiceInfo) error {
+	return fmt.Errorf("the %s renderer only supports bench output", b.name)
+}
+
+func (b benchOnly) Result(*Result) error {
+	return fmt.Errorf("the %s renderer only supports bench output", b.name)
+}
+
+func (b benchOnly) Gateway(*gateway.Matrix) error {
+	return fmt.Errorf("the %s renderer only supports bench output", b.name)
+}
+
+func (b benchOnly) Diagnostics([]file.Diagnostic) error {
+	return fmt.Errorf("the %s renderer only supports bench output", b.name)
+}
+
+func (b benchOnly) Request(*Request) error {
+	return fmt.Errorf("the %s renderer only supports bench output", b.name)
+}
+
+func (b benchOnly) Close() error {
+	return nil
+}
+
+// statusCodeName converts a status name ("not_found") to the Go gRPC code
+// name ("NotFound") used by ghz and Fortio
+func statusCodeName(status string) string {
+	if status == "ok" {
+		return "OK"
+	}
+	var sb strings.Builder
+	for _, part := range strings.Split(status, "_") {
+		if part == "" {
+			continue
+		}
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return sb.String()
+}
+
+// ghzRenderer writes bench summaries in the ghz JSON report format
+// (https://ghz.sh/docs/output), readable by ghz-web and its comparison tools
+type ghzRenderer struct {
+	benchOnly
+	w io.Writer
+}
+
+type ghzReport struct {
+	Options             ghzOptions     `json:"options"`
+	Date                time.Time      `json:"date"`
+	Count               int            `json:"count"`
+	Total               time.Duration  `json:"total"`
+	Average             time.Duration  `json:"average"`
+	Fastest             time.Duration  `json:"fastest"`
+	Slowest             time.Duration  `json:"slowest"`
+	RPS                 float64        `json:"rps"`
+	ErrorDist           map[string]int `json:"errorDistribution"`
+	StatusCodeDist      map[string]int `json:"statusCodeDistribution"`
+	LatencyDistribution []ghzLatency   `json:"latencyDistribution"`
+	Histogram           []ghzBucket    `json:"histogram"`
+	Details             []any          `json:"details"` // Per-call details are not kept
+}
+
+type ghzOptions struct {
+	Call        string  `json:"call,omitempty"`
+	Host        string  `json:"host,omitempty"`
+	Concurrency int     `json:"concurrency,omitempty"`
+	Total       int     `json:"total,omitempty"`
+	Duration    int64   `json:"duration,omitempty"`
+	RPS         float64 `json:"rps,omitempty"`
+}
+
+type ghzLatency struct {
+	Percentage int           `json:"percentage"`
+	Latency    time.Duration `json:"latency"`
+}
+
+type ghzBucket struct {
+	Mark      float64 `json:"mark"` // Lower bound in seconds
+	Count     int     `json:"count"`
+	Frequency float64 `json:"frequency"`
+}
+
+func (g *ghzRenderer) Bench(s *bench.Summary) error {
+	report := ghzReport{
+		Options: ghzOptions{
+			Call:        s.Call,
+			Host:        s.Target,
+			Concurrency: s.Concurrency,
+			Total:       s.Total,
+			Duration:    int64(s.Duration),
+			RPS:         max(s.RequestedQPS, s.ArrivalRate),
+		},
+		Date:           s.Start,
+		Count:          s.Count,
+		Total:          s.Elapsed,
+		Average:        s.Mean,
+		Fastest:        s.Min,
+		Slowest:        s.Max,
+		RPS:            s.AchievedQPS,
+		ErrorDist:      map[string]int{},
+		StatusCodeDist: map[string]int{},
+		Details:        []any{},
+	}
+	for status, n := range s.Statuses {
+		name := statusCodeName(status)
+		report.StatusCodeDist[name] = n
+		if status != "ok" {
+			report.ErrorDist[name] = n
+		}
+	}
+	for _, p := range []int{10, 25, 50, 75, 90, 95, 99} {
+		report.LatencyDistribution = append(report.LatencyDistribution, ghzLatency{
+			Percentage: p,
+			Latency:    s.Percentile(float64(p)),
+		})
+	}
+	for _, b := range histogram(s.Latencies) {
+		report.Histogram = append(report.Histogram, ghzBucket{
+			Mark:      b.start.Seconds(),
+			Count:     b.count,
+			Frequency: float64(b.count) / float64(s.Count),
+		})
+	}
+
+	enc := json.NewEncoder(g.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// fortioRenderer writes bench summaries in the Fortio JSON result format,
+// which `fortio report` can browse and graph alongside Fortio's own runs
+type fortioRenderer struct {
+	benchOnly
+	w io.Writer
+}
+
+type fortioReport struct {
+	RunType           string
+	Labels            string
+	StartTime         time.Time
+	RequestedQPS      string
+	RequestedDuration string
+	ActualQPS         float64
+	ActualDuration    time.Duration
+	NumThreads        int
+	Version           string
+	DurationHistogram fortioHistogram
+	Exactly           int
+	RetCodes          map[string]int
+	Destination       string
+	ID                string
+}
+
+type fortioHistogram struct {
+	Count       int
+	Min         float64
+	Max         float64
+	Sum         float64
+	Avg         float64
+	StdDev      float64
+	Data        []fortioBucket
+	Percentiles []fortioPercentile
+}
+
+type fortioBucket struct {
+	Start   float64
+	End     float64
+	Percent float64 // Cumulative percentage of samples up to End
+	Count   int
+}
+
+type fortioPercentile struct {
+	Percentile float64
+	Value      float64
+}
+
+func (f *fortioRenderer) Bench(s *bench.Summary) error {
+	report := fortioReport{
+		RunType:           "GRPC",
+		Labels:            s.Call,
+		StartTime:         s.Start,
+		RequestedQPS:      "max",
+		RequestedDuration: s.Duration.String(),
+		ActualQPS:         s.AchievedQPS,
+		ActualDuration:    s.Elapsed,
+		NumThreads:        s.Concurrency,
+		Exactly:           s.Total,
+		RetCodes:          make(map[string]int, len(s.Statuses)),
+		Destination:       s.Target,
+		ID:                s.Start.Format("2006-01-02-150405"),
+	}
+	if rate := max(s.RequestedQPS, s.ArrivalRate); rate > 0 {
+		report.RequestedQPS = fmt.Sprintf("%g", rate)
+	}
+	if s.Duration == 0 {
+		report.RequestedDuration = fmt.Sprintf("exactly %d calls", s.Total)
+	}
+	for status, n := range s.Statuses {
+		report.RetCodes[statusCodeName(status)] = n
+	}
+
+	h := fortioHistogram{
+		Count: s.Count,
+		Min:   s.Min.Seconds(),
+		Max:   s.Max.Seconds(),
+		Avg:   s.Mean.Seconds(),
+	}
+	var variance float64
+	for _, l := range s.Latencies {
+		h.Sum += l.Seconds()
+		d := l.Seconds() - h.Avg
+		variance += d * d
+	}
+	if s.Count > 0 {
+		h.StdDev = math.Sqrt(variance / float64(s.Count))
+	}
+	seen := 0
+	for _, b := range histogram(s.Latencies) {
+		seen += b.count
+		h.Data = append(h.Data, fortioBucket{
+			Start:   b.start.Seconds(),
+			End:     b.end.Seconds(),
+			Percent: 100 * float64(seen) / float64(s.Count),
+			Count:   b.count,
+		})
+	}
+	for _, p := range []float64{50, 75, 90, 99, 99.9} {
+		h.Percentiles = append(h.Percentiles, fortioPercentile{
+			Percentile: p,
+			Value:      s.Percentile(p).Seconds(),
+		})
+	}
+	report.DurationHistogram = h
+
+	enc := json.NewEncoder(f.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// bucket is a histogram bucket covering latencies in [start, end]
+type bucket struct {
+	start, end time.Duration
+	count      int
+}
+
+// histogram splits sorted latencies into equal-width buckets between the
+// fastest and slowest sample
+func histogram(sorted []time.Duration) []bucket {
+	if len(sorted) == 0 {
+		return nil
+	}
+	lo, hi := sorted[0], sorted[len(sorted)-1]
+	width := (hi - lo) / histogramBuckets
+	if width == 0 {
+		return []bucket{{start: lo, end: hi, count: len(sorted)}}
+	}
+
+	buckets := make([]bucket, histogramBuckets)
+	for i := range buckets {
+		buckets[i].start = lo + time.Duration(i)*width
+		buckets[i].end = buckets[i].start + width
+	}
+	buckets[len(buckets)-1].end = hi
+	for _, l := range sorted {
+		i := min(int((l-lo)/width), histogramBuckets-1)
+		buckets[i].count++
+	}
+	return buckets
+}
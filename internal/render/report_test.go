@@ -0,0 +1,178 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"grpc_client/internal/bench"
+)
+
+// testSummary records ten calls of 1ms..10ms, one of which failed, with
+// 20-byte requests and responses of 10..90 bytes
+func testSummary() *bench.Summary {
+	recorder := bench.NewRecorder()
+	for i := 1; i <= 10; i++ {
+		out := bench.Outcome{Status: "ok", RequestSize: 20, ResponseSize: i * 10}
+		if i == 10 {
+			out = bench.Outcome{Status: "deadline_exceeded", RequestSize: 20}
+		}
+		recorder.Record(time.Duration(i)*time.Millisecond, out)
+	}
+	s := recorder.Summarize(time.Second)
+	s.Target = "http://localhost:8080"
+	s.Call = "example.UserService.GetUser"
+	s.Start = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	s.Concurrency = 2
+	s.Total = 10
+	return s
+}
+
+func TestStatusCodeName(t *testing.T) {
+	tests := map[string]string{
+		"ok":                "OK",
+		"not_found":         "NotFound",
+		"deadline_exceeded": "DeadlineExceeded",
+		"unknown":           "Unknown",
+	}
+	for in, want := range tests {
+		if got := statusCodeName(in); got != want {
+			t.Errorf("statusCodeName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestGhzRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	r, _ := New("ghz", &buf)
+	if err := r.Bench(testSummary()); err != nil {
+		t.Fatalf("Bench failed: %v", err)
+	}
+
+	var report struct {
+		Options struct {
+			Call        string `json:"call"`
+			Host        string `json:"host"`
+			Concurrency int    `json:"concurrency"`
+		} `json:"options"`
+		Count               int            `json:"count"`
+		Fastest             int64          `json:"fastest"`
+		Slowest             int64          `json:"slowest"`
+		RPS                 float64        `json:"rps"`
+		ErrorDist           map[string]int `json:"errorDistribution"`
+		StatusCodeDist      map[string]int `json:"statusCodeDistribution"`
+		LatencyDistribution []struct {
+			Percentage int   `json:"percentage"`
+			Latency    int64 `json:"latency"`
+		} `json:"latencyDistribution"`
+		Histogram []struct {
+			Mark      float64 `json:"mark"`
+			Count     int     `json:"count"`
+			Frequency float64 `json:"frequency"`
+		} `json:"histogram"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+
+	if report.Options.Call != "example.UserService.GetUser" || report.Options.Host != "http://localhost:8080" || report.Options.Concurrency != 2 {
+		t.Errorf("options = %+v", report.Options)
+	}
+	if report.Count != 10 || report.RPS != 10 {
+		t.Errorf("count/rps = %d/%v", report.Count, report.RPS)
+	}
+	// ghz encodes durations as nanoseconds
+	if report.Fastest != int64(time.Millisecond) || report.Slowest != int64(10*time.Millisecond) {
+		t.Errorf("fastest/slowest = %d/%d", report.Fastest, report.Slowest)
+	}
+	if report.StatusCodeDist["OK"] != 9 || report.StatusCodeDist["DeadlineExceeded"] != 1 {
+		t.Errorf("statusCodeDistribution = %v", report.StatusCodeDist)
+	}
+	if len(report.ErrorDist) != 1 || report.ErrorDist["DeadlineExceeded"] != 1 {
+		t.Errorf("errorDistribution = %v", report.ErrorDist)
+	}
+	if len(report.LatencyDistribution) != 7 || report.LatencyDistribution[2].Percentage != 50 ||
+		report.LatencyDistribution[2].Latency != int64(5*time.Millisecond) {
+		t.Errorf("latencyDistribution = %+v", report.LatencyDistribution)
+	}
+	total := 0
+	for _, b := range report.Histogram {
+		total += b.Count
+	}
+	if len(report.Histogram) != histogramBuckets || total != 10 || report.Histogram[0].Mark != 0.001 {
+		t.Errorf("histogram = %+v", report.Histogram)
+	}
+}
+
+func TestFortioRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	r, _ := New("fortio", &buf)
+	if err := r.Bench(testSummary()); err != nil {
+		t.Fatalf("Bench failed: %v", err)
+	}
+
+	var report struct {
+		RunType           string
+		RequestedQPS      string
+		RequestedDuration string
+		ActualQPS         float64
+		NumThreads        int
+		Exactly           int
+		RetCodes          map[string]int
+		Destination       string
+		ID                string
+		DurationHistogram struct {
+			Count int
+			Min   float64
+			Max   float64
+			Avg   float64
+			Data  []struct {
+				Start   float64
+				End     float64
+				Percent float64
+				Count   int
+			}
+			Percentiles []struct {
+				Percentile float64
+				Value      float64
+			}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+
+	if report.RunType != "GRPC" || report.RequestedQPS != "max" || report.RequestedDuration != "exactly 10 calls" {
+		t.Errorf("run = %q/%q/%q", report.RunType, report.RequestedQPS, report.RequestedDuration)
+	}
+	if report.NumThreads != 2 || report.Exactly != 10 || report.Destination != "http://localhost:8080" {
+		t.Errorf("threads/exactly/destination = %d/%d/%q", report.NumThreads, report.Exactly, report.Destination)
+	}
+	if report.ID != "2026-01-02-030405" {
+		t.Errorf("ID = %q", report.ID)
+	}
+	if report.RetCodes["OK"] != 9 || report.RetCodes["DeadlineExceeded"] != 1 {
+		t.Errorf("RetCodes = %v", report.RetCodes)
+	}
+	h := report.DurationHistogram
+	// Fortio reports durations in seconds
+	if h.Count != 10 || h.Min != 0.001 || h.Max != 0.01 || h.Avg != 0.0055 {
+		t.Errorf("histogram stats = %+v", h)
+	}
+	if last := h.Data[len(h.Data)-1]; last.Percent != 100 || last.End != 0.01 {
+		t.Errorf("last bucket = %+v", last)
+	}
+	if len(h.Percentiles) != 5 || h.Percentiles[0].Percentile != 50 || h.Percentiles[0].Value != 0.005 {
+		t.Errorf("percentiles = %+v", h.Percentiles)
+	}
+}
+
+func TestReportRenderers_RejectResults(t *testing.T) {
+	for _, format := range []string{"ghz", "fortio"} {
+		r, _ := New(format, &bytes.Buffer{})
+		if err := r.Result(&Result{}); err == nil {
+			t.Errorf("%s: expected an error rendering a call result", format)
+		}
+	}
+}
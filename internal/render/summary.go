@@ -0,0 +1,97 @@
+package render
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// slowestShown is the number of slowest requests a summary lists
+const slowestShown = 3
+
+// Summary totals the results of a run
+type Summary struct {
+	Requests int // Results rendered
+	Passed   int // Results whose outcome is "pass"
+	Failed   int // Results with a failed assertion
+	Errors   int // Results of calls that failed without an expected status
+	Skipped  int // Results of requests that were not sent
+
+	AssertsPassed int
+	AssertsFailed int
+
+	Duration time.Duration // Sum of the request durations
+	Slowest  []*Result     // The slowest requests, slowest first
+}
+
+// Summarize totals results
+func Summarize(results []*Result) Summary {
+	var s Summary
+	for _, r := range results {
+		s.Requests++
+		switch r.Outcome() {
+		case "fail":
+			s.Failed++
+		case "error":
+			s.Errors++
+		case "skip":
+			s.Skipped++
+		default:
+			s.Passed++
+		}
+		for _, a := range r.Asserts {
+			if a.Pass {
+				s.AssertsPassed++
+			} else {
+				s.AssertsFailed++
+			}
+		}
+		s.Duration += r.Duration
+	}
+	// Skipped requests were not sent
+	s.Slowest = slices.DeleteFunc(slices.Clone(results), func(r *Result) bool { return r.Skipped != "" })
+	slices.SortStableFunc(s.Slowest, func(a, b *Result) int {
+		return cmp.Compare(b.Duration, a.Duration)
+	})
+	s.Slowest = s.Slowest[:min(len(s.Slowest), slowestShown)]
+	return s
+}
+
+// writeSummary writes s as text comment lines
+func writeSummary(w io.Writer, s Summary) {
+	fmt.Fprintln(w, "\n# Summary")
+	fmt.Fprintf(w, "# Requests:   %d (%d passed, %d failed", s.Requests, s.Passed, s.Failed)
+	if s.Errors > 0 {
+		fmt.Fprintf(w, ", %d errors", s.Errors)
+	}
+	if s.Skipped > 0 {
+		fmt.Fprintf(w, ", %d skipped", s.Skipped)
+	}
+	fmt.Fprintln(w, ")")
+	fmt.Fprintf(w, "# Assertions: %d passed, %d failed\n", s.AssertsPassed, s.AssertsFailed)
+	fmt.Fprintf(w, "# Duration:   %s\n", s.Duration.Round(time.Microsecond))
+	fmt.Fprintln(w, "# Slowest:")
+	for _, r := range s.Slowest {
+		fmt.Fprintf(w, "#   %-10s %s\n", r.Duration.Round(time.Microsecond), Describe(r))
+	}
+}
+
+// Describe identifies a result in summaries and diffs, e.g.
+// "users.grpc #2 Get user (example.UserService/GetUser)", followed by its
+// iteration in repeated runs
+func Describe(r *Result) string {
+	s := fmt.Sprintf("#%d", r.Index)
+	if r.File != "" {
+		s = r.File + " " + s
+	}
+	if r.Name != "" {
+		s += " " + r.Name
+	}
+	s = fmt.Sprintf("%s (%s/%s)", s, r.Service, r.Method)
+	if r.Iteration > 0 {
+		s += fmt.Sprintf(" in iteration %d", r.Iteration)
+	}
+	return s
+}
@@ -0,0 +1,151 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	results := []*Result{
+		{Index: 1, Duration: 5 * time.Millisecond, Asserts: []Assertion{{Pass: true}, {Pass: true}}},
+		{Index: 2, Duration: 20 * time.Millisecond, Asserts: []Assertion{{Pass: true}, {Pass: false}}},
+		{Index: 3, Duration: 1 * time.Millisecond, Error: "gRPC error [unavailable]"},
+		{Index: 4, Duration: 10 * time.Millisecond},
+	}
+	s := Summarize(results)
+	if s.Requests != 4 || s.Passed != 2 || s.Failed != 1 || s.Errors != 1 {
+		t.Errorf("requests = %d (%d passed, %d failed, %d errors)", s.Requests, s.Passed, s.Failed, s.Errors)
+	}
+	if s.AssertsPassed != 3 || s.AssertsFailed != 1 || s.Duration != 36*time.Millisecond {
+		t.Errorf("asserts = %d/%d, duration = %s", s.AssertsPassed, s.AssertsFailed, s.Duration)
+	}
+	if len(s.Slowest) != 3 || s.Slowest[0].Index != 2 || s.Slowest[1].Index != 4 || s.Slowest[2].Index != 1 {
+		t.Errorf("slowest = %+v", s.Slowest)
+	}
+}
+
+func TestOutcome_ExpectFailure(t *testing.T) {
+	for _, tt := range []struct {
+		result *Result
+		want   string
+	}{
+		{&Result{ExpectFailure: true, Asserts: []Assertion{{Pass: false}}}, "pass"},
+		{&Result{ExpectFailure: true, Error: "gRPC error [not_found]"}, "pass"},
+		{&Result{ExpectFailure: true, Asserts: []Assertion{{Pass: true}}}, "fail"},
+		{&Result{ExpectFailure: true}, "fail"},
+		{&Result{Error: "gRPC error [not_found]"}, "error"},
+	} {
+		if got := tt.result.Outcome(); got != tt.want {
+			t.Errorf("Outcome of %+v = %q, want %q", tt.result, got, tt.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	r := NewText(&buf, TextOptions{Quiet: true})
+	_ = r.Result(&Result{Index: 1, Service: "svc", Method: "Get", ExpectFailure: true})
+	if want := "# FAIL #1 (svc/Get)\n#   " + unexpectedPass + "\n"; !strings.HasPrefix(buf.String(), want) {
+		t.Errorf("expected the unexpected pass to be reported, got %q", buf.String())
+	}
+}
+
+func TestOutcome_Skipped(t *testing.T) {
+	skipped := &Result{Index: 2, Service: "svc", Method: "Get", Skipped: `needs "Create user", which failed`}
+	if got := skipped.Outcome(); got != "skip" {
+		t.Errorf("Outcome = %q, want skip", got)
+	}
+	s := Summarize([]*Result{{Index: 1}, skipped})
+	if s.Requests != 2 || s.Passed != 1 || s.Skipped != 1 {
+		t.Errorf("requests = %d (%d passed, %d skipped)", s.Requests, s.Passed, s.Skipped)
+	}
+	if len(s.Slowest) != 1 || s.Slowest[0].Index != 1 {
+		t.Errorf("expected the skipped request not to be among the slowest, got %+v", s.Slowest)
+	}
+
+	var buf bytes.Buffer
+	r := NewText(&buf, TextOptions{Quiet: true})
+	_ = r.Result(skipped)
+	if want := "# SKIP #2 (svc/Get)\n#   needs \"Create user\", which failed\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestDescribe_Iteration(t *testing.T) {
+	r := &Result{File: "users.grpc", Index: 2, Name: "Get user", Service: "svc", Method: "Get", Iteration: 3}
+	if got, want := Describe(r), "users.grpc #2 Get user (svc/Get) in iteration 3"; got != want {
+		t.Errorf("describe = %q, want %q", got, want)
+	}
+	if got, want := junitCaseOf(r).Name, "2: Get user (iteration 3)"; got != want {
+		t.Errorf("JUnit name = %q, want %q", got, want)
+	}
+}
+
+func TestTextRenderer_Summary(t *testing.T) {
+	var buf bytes.Buffer
+	r, _ := New("text", &buf)
+	_ = r.Result(&Result{File: "users.grpc", Index: 1, Name: "Get user", Service: "svc", Method: "Get", Duration: 2 * time.Millisecond, Body: "{}"})
+	_ = r.Result(&Result{File: "users.grpc", Index: 2, Service: "svc", Method: "List", Duration: 3 * time.Millisecond, Body: "{}",
+		Asserts: []Assertion{{Message: "FAIL: status == ok"}}})
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := `
+# Summary
+# Requests:   2 (1 passed, 1 failed)
+# Assertions: 0 passed, 1 failed
+# Duration:   5ms
+# Slowest:
+#   3ms        users.grpc #2 (svc/List)
+#   2ms        users.grpc #1 Get user (svc/Get)
+`
+	if got := buf.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("output = %q, want suffix %q", got, want)
+	}
+
+	// A single call is not summarized
+	buf.Reset()
+	r, _ = New("text", &buf)
+	_ = r.Result(&Result{Service: "svc", Method: "Get", Body: "{}"})
+	_ = r.Close()
+	if strings.Contains(buf.String(), "# Summary") {
+		t.Errorf("unexpected summary: %q", buf.String())
+	}
+}
+
+func TestQuietText(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewText(&buf, TextOptions{Quiet: true})
+	_ = r.Result(&Result{File: "users.grpc", Index: 1, Name: "Get user", Service: "svc", Method: "Get", Duration: 2 * time.Millisecond, Body: `{"id": "1"}`,
+		Asserts: []Assertion{{Pass: true, Message: "PASS: status == ok"}}})
+	_ = r.Result(&Result{File: "users.grpc", Index: 2, Service: "svc", Method: "List", Duration: 3 * time.Millisecond, Body: `{"users": []}`,
+		Asserts: []Assertion{{Pass: true, Message: "PASS: status == ok"}, {Message: "FAIL: body.users length == 2"}}})
+	_ = r.Result(&Result{File: "users.grpc", Index: 3, Service: "svc", Method: "Delete", Status: "not_found", Error: "gRPC error [not_found]: no user"})
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"# FAIL users.grpc #2 (svc/List)\n#   FAIL: body.users length == 2\n",
+		"# ERROR users.grpc #3 (svc/Delete)\n#   gRPC error [not_found]: no user\n",
+		"# Requests:   3 (1 passed, 1 failed, 1 errors)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output = %q, want %q", got, want)
+		}
+	}
+	for _, unwanted := range []string{"# Get user", "PASS:", `"users"`, "---"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("output = %q, unexpected %q", got, unwanted)
+		}
+	}
+
+	// A quiet single call is still summarized
+	buf.Reset()
+	r = NewText(&buf, TextOptions{Quiet: true})
+	_ = r.Result(&Result{Service: "svc", Method: "Get", Body: "{}"})
+	_ = r.Close()
+	if !strings.Contains(buf.String(), "# Requests:   1 (1 passed, 0 failed)") {
+		t.Errorf("output = %q, want summary", buf.String())
+	}
+}
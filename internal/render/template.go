@@ -0,0 +1,86 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"text/template"
+
+	"grpc_client/internal/bench"
+	"grpc_client/internal/client"
+	"grpc_client/internal/file"
+	"grpc_client/internal/gateway"
+	"grpc_client/internal/proto"
+)
+
+// templateFuncs are the functions available to render templates.
+// jsonpath takes the path first so it can be used in a pipeline:
+// {{.Body | jsonpath "$.id"}}
+var templateFuncs = template.FuncMap{
+	"jsonpath": func(path, body string) (string, error) {
+		return client.EvaluateJSONPath(body, path)
+	},
+}
+
+// templateRenderer executes a user-supplied Go template once per item.
+// Results are rendered with a *Result as data, services with a proto.ServiceInfo,
+// bench summaries with a *bench.Summary, gateway checks with a
+// *gateway.Matrix, lint problems with a file.Diagnostic, and dry-run
+// requests with a *Request.
+type templateRenderer struct {
+	w    io.Writer
+	tmpl *template.Template
+}
+
+func newTemplateRenderer(text string, w io.Writer) (*templateRenderer, error) {
+	tmpl, err := template.New("render").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid render template: %w", err)
+	}
+	return &templateRenderer{w: w, tmpl: tmpl}, nil
+}
+
+func (t *templateRenderer) Services(services []proto.ServiceInfo) error {
+	for _, svc := range services {
+		if err := t.execute(svc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *templateRenderer) Result(r *Result) error {
+	return t.execute(r)
+}
+
+func (t *templateRenderer) Bench(s *bench.Summary) error {
+	return t.execute(s)
+}
+
+func (t *templateRenderer) Gateway(m *gateway.Matrix) error {
+	return t.execute(m)
+}
+
+func (t *templateRenderer) Diagnostics(diags []file.Diagnostic) error {
+	for _, d := range diags {
+		if err := t.execute(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *templateRenderer) Request(r *Request) error {
+	return t.execute(r)
+}
+
+func (t *templateRenderer) execute(data any) error {
+	if err := t.tmpl.Execute(t.w, data); err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+	_, err := fmt.Fprintln(t.w)
+	return err
+}
+
+func (t *templateRenderer) Close() error {
+	return nil
+}
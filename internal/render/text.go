@@ -0,0 +1,321 @@
+package render
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"grpc_client/internal/bench"
+	"grpc_client/internal/file"
+	"grpc_client/internal/gateway"
+	"grpc_client/internal/proto"
+)
+
+// textRenderer produces the human-oriented output format
+type textRenderer struct {
+	w       io.Writer
+	results int
+
+	// Results of a multi-request run, summarized on Close
+	rendered []*Result
+
+	opts TextOptions
+}
+
+// TextOptions adjust what the text renderer prints with each result
+type TextOptions struct {
+	// Quiet prints only failed results (the failed assertions or the error,
+	// without the body) and the summary of the run, e.g. for CI logs
+	Quiet bool
+
+	// Include prints the gRPC status and response headers before the body
+	// and the trailers after it, like curl -i
+	Include bool
+}
+
+// NewText creates a text renderer with opts (New("text") has none set)
+func NewText(w io.Writer, opts TextOptions) Renderer {
+	return &textRenderer{w: w, opts: opts}
+}
+
+func (t *textRenderer) Services(services []proto.ServiceInfo) error {
+	if len(services) == 0 {
+		_, err := fmt.Fprintln(t.w, "No services found in proto files.")
+		return err
+	}
+
+	fmt.Fprintln(t.w, "Services:")
+	for _, svc := range services {
+		fmt.Fprintf(t.w, "  %s\n", svc.FullName)
+		for _, method := range svc.Methods {
+			fmt.Fprintf(t.w, "    - %s (%s) → %s\n",
+				method.Name,
+				method.InputType,
+				method.OutputType,
+			)
+		}
+	}
+	return nil
+}
+
+func (t *textRenderer) Result(r *Result) error {
+	if t.opts.Quiet {
+		return t.quietResult(r)
+	}
+	t.banner(r.Index, r.Name, r.Service, r.Method)
+	if r.Index > 0 {
+		t.rendered = append(t.rendered, r)
+	}
+
+	if t.opts.Include {
+		fmt.Fprintf(t.w, "# Status: %s\n", r.Status)
+		writeHeaders(t.w, r.Header)
+		fmt.Fprintln(t.w)
+	}
+
+	if r.Skipped != "" {
+		fmt.Fprintf(t.w, "# Skipped: %s\n", r.Skipped)
+	} else if r.Error != "" {
+		fmt.Fprintf(t.w, "# Error: %s\n", r.Error)
+	} else if r.Output != "" {
+		fmt.Fprintf(t.w, "# Response written to %s\n", r.Output)
+	} else {
+		fmt.Fprintln(t.w, r.Body)
+	}
+
+	if t.opts.Include && len(r.Trailer) > 0 {
+		fmt.Fprintln(t.w, "\n# Trailers:")
+		writeHeaders(t.w, r.Trailer)
+	}
+
+	if len(r.Captures) > 0 {
+		fmt.Fprintln(t.w, "\n# Captures:")
+		for _, c := range r.Captures {
+			if c.Error != "" {
+				fmt.Fprintf(t.w, "# Warning: failed to capture variable '%s' from path '%s': %s\n", c.Name, c.Path, c.Error)
+				continue
+			}
+			fmt.Fprintf(t.w, "# %s = %s\n", c.Name, c.Value)
+		}
+	}
+
+	if len(r.Asserts) > 0 {
+		fmt.Fprintln(t.w, "\n# Asserts:")
+		for _, a := range r.Asserts {
+			fmt.Fprintf(t.w, "# %s\n", a.Message)
+			writeDiff(t.w, "#   ", a.Diff)
+		}
+	}
+	if r.UnexpectedPass() {
+		fmt.Fprintf(t.w, "\n# FAIL: %s\n", unexpectedPass)
+	} else if r.ExpectFailure {
+		fmt.Fprintln(t.w, "\n# Failed as expected")
+	}
+
+	if len(r.Certificates) > 0 {
+		fmt.Fprintln(t.w, "\n# Server certificates:")
+		for i, c := range r.Certificates {
+			fmt.Fprintf(t.w, "# %d subject: %s\n", i, c.Subject)
+			fmt.Fprintf(t.w, "#   issuer:  %s\n", c.Issuer)
+			fmt.Fprintf(t.w, "#   serial:  %s\n", c.Serial)
+			fmt.Fprintf(t.w, "#   valid:   %s to %s\n", c.NotBefore.UTC().Format(time.RFC3339), c.NotAfter.UTC().Format(time.RFC3339))
+			if len(c.DNSNames) > 0 {
+				fmt.Fprintf(t.w, "#   names:   %s\n", strings.Join(c.DNSNames, ", "))
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeDiff prints the lines of a diff, if any, after prefix
+func writeDiff(w io.Writer, prefix, diff string) {
+	if diff == "" {
+		return
+	}
+	for _, line := range strings.Split(diff, "\n") {
+		fmt.Fprintf(w, "%s%s\n", prefix, line)
+	}
+}
+
+// writeHeaders prints the lines of h, sorted by name, as received
+func writeHeaders(w io.Writer, h http.Header) {
+	for _, line := range headerLines(h) {
+		fmt.Fprintf(w, "< %s\n", line)
+	}
+}
+
+// quietResult prints r on one line with its failed assertions or error,
+// if it did not pass
+func (t *textRenderer) quietResult(r *Result) error {
+	t.rendered = append(t.rendered, r)
+	outcome := r.Outcome()
+	if outcome == "pass" {
+		return nil
+	}
+	fmt.Fprintf(t.w, "# %s %s\n", strings.ToUpper(outcome), Describe(r))
+	if r.Error != "" && outcome == "error" {
+		fmt.Fprintf(t.w, "#   %s\n", r.Error)
+	}
+	if outcome == "skip" {
+		fmt.Fprintf(t.w, "#   %s\n", r.Skipped)
+	}
+	for _, a := range r.Asserts {
+		if !a.Pass {
+			fmt.Fprintf(t.w, "#   %s\n", a.Message)
+			writeDiff(t.w, "#     ", a.Diff)
+		}
+	}
+	if r.UnexpectedPass() {
+		fmt.Fprintf(t.w, "#   %s\n", unexpectedPass)
+	}
+	return nil
+}
+
+// banner prints the separator between requests and the header of a request
+// (standalone calls have no banner)
+func (t *textRenderer) banner(index int, name, service, method string) {
+	if t.results > 0 {
+		fmt.Fprintln(t.w, "\n---")
+	}
+	t.results++
+
+	if index > 0 {
+		if name != "" {
+			fmt.Fprintf(t.w, "# %s\n", name)
+		} else {
+			fmt.Fprintf(t.w, "# Request %d\n", index)
+		}
+		fmt.Fprintf(t.w, "# %s/%s\n\n", service, method)
+	}
+}
+
+func (t *textRenderer) Bench(s *bench.Summary) error {
+	requested := "unlimited"
+	if s.RequestedQPS > 0 {
+		requested = fmt.Sprintf("%.2f (burst %d)", s.RequestedQPS, s.Burst)
+	}
+
+	fmt.Fprintln(t.w, "Summary:")
+	fmt.Fprintf(t.w, "  Requests:      %d\n", s.Count)
+	fmt.Fprintf(t.w, "  Errors:        %d\n", s.Errors)
+	fmt.Fprintf(t.w, "  Elapsed:       %s\n", s.Elapsed.Round(time.Millisecond))
+	if s.ArrivalRate > 0 {
+		fmt.Fprintf(t.w, "  Arrival rate:  %.2f (%s)\n", s.ArrivalRate, s.Arrival)
+	} else {
+		fmt.Fprintf(t.w, "  Requested QPS: %s\n", requested)
+	}
+	fmt.Fprintf(t.w, "  Achieved QPS:  %.2f\n", s.AchievedQPS)
+
+	fmt.Fprintln(t.w, "\nLatency:")
+	fmt.Fprintf(t.w, "  min:  %s\n", s.Min)
+	fmt.Fprintf(t.w, "  mean: %s\n", s.Mean)
+	fmt.Fprintf(t.w, "  p50:  %s\n", s.P50)
+	fmt.Fprintf(t.w, "  p90:  %s\n", s.P90)
+	fmt.Fprintf(t.w, "  p99:  %s\n", s.P99)
+	fmt.Fprintf(t.w, "  max:  %s\n", s.Max)
+
+	if s.RequestSize.Count > 0 {
+		fmt.Fprintln(t.w, "\nMessage sizes:")
+		fmt.Fprintf(t.w, "  request:  %s\n", formatSizes(s.RequestSize))
+		if s.ResponseSize.Count > 0 {
+			fmt.Fprintf(t.w, "  response: %s\n", formatSizes(s.ResponseSize))
+		}
+	}
+
+	fmt.Fprintln(t.w, "\nStatus codes:")
+	statuses := make([]string, 0, len(s.Statuses))
+	for status := range s.Statuses {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		fmt.Fprintf(t.w, "  %s: %d\n", status, s.Statuses[status])
+	}
+	return nil
+}
+
+func (t *textRenderer) Gateway(m *gateway.Matrix) error {
+	fmt.Fprintf(t.w, "Gateway compatibility of %s (%s):\n\n", m.Target, m.Call)
+
+	tw := tabwriter.NewWriter(t.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "  \t%s\n", strings.Join(m.Protocols, "\t"))
+	for _, check := range m.Checks {
+		outcomes := make([]string, 0, len(m.Protocols))
+		for _, protocol := range m.Protocols {
+			outcomes = append(outcomes, m.Result(check, protocol).Outcome)
+		}
+		fmt.Fprintf(tw, "  %s\t%s\n", check, strings.Join(outcomes, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// Explain what failed
+	var failures []gateway.Result
+	for _, r := range m.Results {
+		if r.Outcome == gateway.Unsupported {
+			failures = append(failures, r)
+		}
+	}
+	if len(failures) > 0 {
+		fmt.Fprintln(t.w, "\nUnsupported:")
+		for _, r := range failures {
+			fmt.Fprintf(t.w, "  %s (%s): %s\n", r.Check, r.Protocol, r.Detail)
+		}
+	}
+	return nil
+}
+
+func (t *textRenderer) Diagnostics(diags []file.Diagnostic) error {
+	for _, d := range diags {
+		if _, err := fmt.Fprintln(t.w, d.String()); err != nil {
+			return err
+		}
+		if snippet := d.Snippet(); snippet != "" {
+			for _, line := range strings.Split(snippet, "\n") {
+				fmt.Fprintf(t.w, "    %s\n", line)
+			}
+		}
+	}
+	return nil
+}
+
+func (t *textRenderer) Request(r *Request) error {
+	t.banner(r.Index, r.Name, r.Service, r.Method)
+
+	fmt.Fprintf(t.w, "POST %s\n", r.URL)
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range r.Header[name] {
+			fmt.Fprintf(t.w, "%s: %s\n", name, value)
+		}
+	}
+	fmt.Fprintf(t.w, "\n%s\n", r.Body)
+
+	fmt.Fprintf(t.w, "\n# Payload (%d bytes):\n", len(r.Payload))
+	_, err := fmt.Fprint(t.w, hex.Dump(r.Payload))
+	return err
+}
+
+// formatSizes formats message size statistics on one line
+func formatSizes(s bench.SizeStats) string {
+	return fmt.Sprintf("mean %.0f B, p50 %d B, p90 %d B, p99 %d B, max %d B", s.Mean, s.P50, s.P90, s.P99, s.Max)
+}
+
+func (t *textRenderer) Close() error {
+	// A summary saves scrolling back through the output of longer runs
+	if len(t.rendered) > 1 || (t.opts.Quiet && len(t.rendered) > 0) {
+		writeSummary(t.w, Summarize(t.rendered))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"grpc_client/internal/file"
+)
+
+// Graph orders the requests of a file by the requests they need
+type Graph struct {
+	// Needs lists, for each request, the indexes of the requests it needs
+	Needs [][]int
+
+	// Order is a topological order of the requests: each one after those
+	// it needs, and otherwise in file order
+	Order []int
+}
+
+// NewGraph resolves the Needs of requests to the requests named so, failing
+// on unknown or ambiguous names and on cycles. It returns nil when no
+// request declares Needs, as such files run in file order.
+func NewGraph(requests []*file.RequestFile) (*Graph, error) {
+	if !slices.ContainsFunc(requests, func(r *file.RequestFile) bool { return len(r.Needs) > 0 }) {
+		return nil, nil
+	}
+	byName := map[string][]int{}
+	for i, r := range requests {
+		if r.Name != "" {
+			byName[r.Name] = append(byName[r.Name], i)
+		}
+	}
+
+	g := &Graph{Needs: make([][]int, len(requests))}
+	for i, r := range requests {
+		for _, name := range r.Needs {
+			named := byName[name]
+			switch {
+			case len(named) == 0:
+				return nil, fmt.Errorf("request %d needs %q, but no request of the file is named so", i+1, name)
+			case len(named) > 1:
+				return nil, fmt.Errorf("request %d needs %q, but %d requests of the file are named so", i+1, name, len(named))
+			case named[0] == i:
+				return nil, fmt.Errorf("request %d needs itself", i+1)
+			}
+			if !slices.Contains(g.Needs[i], named[0]) {
+				g.Needs[i] = append(g.Needs[i], named[0])
+			}
+		}
+	}
+
+	// Take the first request whose needs are all ordered, until none is left
+	ordered := make([]bool, len(requests))
+	for len(g.Order) < len(requests) {
+		next := -1
+		for i := range requests {
+			if !ordered[i] && !slices.ContainsFunc(g.Needs[i], func(n int) bool { return !ordered[n] }) {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			var cycle []string
+			for i, r := range requests {
+				if !ordered[i] {
+					cycle = append(cycle, describeRequest(i, r))
+				}
+			}
+			return nil, fmt.Errorf("the Needs of requests form a cycle: %s", strings.Join(cycle, ", "))
+		}
+		ordered[next] = true
+		g.Order = append(g.Order, next)
+	}
+	return g, nil
+}
+
+// describeRequest identifies the request at index i of its file, e.g.
+// `2 ("Create user")`
+func describeRequest(i int, r *file.RequestFile) string {
+	if r.Name == "" {
+		return fmt.Sprint(i + 1)
+	}
+	return fmt.Sprintf("%d (%q)", i+1, r.Name)
+}
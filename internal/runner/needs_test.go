@@ -0,0 +1,58 @@
+package runner
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"grpc_client/internal/file"
+)
+
+// namedRequests returns requests with the given names and needs
+func namedRequests(specs ...[]string) []*file.RequestFile {
+	var requests []*file.RequestFile
+	for _, spec := range specs {
+		requests = append(requests, &file.RequestFile{Name: spec[0], Needs: spec[1:]})
+	}
+	return requests
+}
+
+func TestNewGraph(t *testing.T) {
+	if g, err := NewGraph(namedRequests([]string{"Login"}, []string{"Get user"})); g != nil || err != nil {
+		t.Errorf("expected no graph without Needs, got %+v, %v", g, err)
+	}
+
+	requests := namedRequests(
+		[]string{"Get user", "Create user"},
+		[]string{"Login"},
+		[]string{"Create user", "Login"},
+		[]string{"Delete user", "Create user", "Get user"},
+		[]string{""},
+	)
+	g, err := NewGraph(requests)
+	if err != nil {
+		t.Fatalf("NewGraph failed: %v", err)
+	}
+	if want := []int{1, 2, 0, 3, 4}; !slices.Equal(g.Order, want) {
+		t.Errorf("Order = %v, want %v", g.Order, want)
+	}
+	if !slices.Equal(g.Needs[3], []int{2, 0}) || len(g.Needs[1]) != 0 {
+		t.Errorf("Needs = %v", g.Needs)
+	}
+}
+
+func TestNewGraph_Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		requests []*file.RequestFile
+		want     string
+	}{
+		{namedRequests([]string{"Get user", "Create user"}), `request 1 needs "Create user", but no request of the file is named so`},
+		{namedRequests([]string{"Login"}, []string{"Login"}, []string{"Get user", "Login"}), `request 3 needs "Login", but 2 requests of the file are named so`},
+		{namedRequests([]string{"Login", "Login"}), "request 1 needs itself"},
+		{namedRequests([]string{"Login"}, []string{"A", "B"}, []string{"B", "A"}), `form a cycle: 2 ("A"), 3 ("B")`},
+	} {
+		if _, err := NewGraph(tt.requests); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("expected an error containing %q, got %v", tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,564 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"grpc_client/internal/assert"
+	"grpc_client/internal/bench"
+	"grpc_client/internal/capture"
+	"grpc_client/internal/client"
+	"grpc_client/internal/file"
+	"grpc_client/internal/proto"
+	"grpc_client/internal/render"
+	"grpc_client/internal/template"
+)
+
+// Runner executes parsed requests against the services in a proto registry.
+// It is safe for concurrent use as long as each goroutine passes its own
+// variables map.
+type Runner struct {
+	registry *proto.Registry
+
+	// UpdateGolden rewrites the golden files of body == file assertions
+	// with the actual responses instead of comparing against them
+	UpdateGolden bool
+
+	// Snapshots compares every response with its snapshot (see
+	// file.SnapshotPath), not only those of requests with Snapshot: true.
+	// Missing snapshots are written; UpdateSnapshots rewrites those that
+	// differ.
+	Snapshots       bool
+	UpdateSnapshots bool
+
+	// Normalize applies to the body comparisons of every request, before
+	// the rules of its [Normalize] section
+	Normalize []file.Normalization
+
+	// Strict fails a request with an *UnresolvedError, before sending it,
+	// when any of its placeholders cannot be resolved, instead of sending
+	// them literally
+	Strict bool
+
+	// TLS holds the TLS settings of every request; the ClientCert and
+	// ClientKey of a request override its client certificate, its CACert
+	// the trusted CAs, and its Insecure disables verification
+	TLS client.TLSConfig
+
+	// ShowCertificates includes the server certificate chain in results
+	ShowCertificates bool
+
+	// JSON selects how messages are converted to JSON, for results as well
+	// as captures and assertions
+	JSON client.JSONOptions
+
+	// TextFormat renders response bodies in protobuf text format instead of
+	// JSON. Captures and assertions still evaluate the JSON form.
+	TextFormat bool
+
+	// ClientOptions are applied to the client of every call, e.g.
+	// client.WithVerbose
+	ClientOptions []client.Option
+
+	// Jar, if set, stores the cookies of responses and sends them with
+	// later requests, e.g. those of a --session
+	Jar http.CookieJar
+
+	// OnSecret, if set, is called with the values a request declares secret
+	// in its [Secrets] section, those of variables before the call and the
+	// jsonpath matches of the response after it, so that they can be masked
+	// in all output
+	OnSecret func(value string)
+
+	mu          sync.Mutex
+	httpClients map[client.TLSConfig]*http.Client // Reused across requests
+}
+
+// New creates a Runner for the services in registry
+func New(registry *proto.Registry) *Runner {
+	return &Runner{registry: registry}
+}
+
+// FindMethod resolves the method descriptor of a request, listing the
+// available services when it cannot be found
+func (r *Runner) FindMethod(req *file.RequestFile) (protoreflect.MethodDescriptor, error) {
+	methodDesc, err := r.registry.FindMethod(req.Service, req.Method)
+	if err != nil {
+		// Provide helpful error with available services
+		services := r.registry.ListServices()
+		var available []string
+		for _, s := range services {
+			available = append(available, s.FullName)
+		}
+		return nil, fmt.Errorf("%w\n\nAvailable services: %s", err, strings.Join(available, ", "))
+	}
+	return methodDesc, nil
+}
+
+// Execute resolves variables into req, calls it, and evaluates its captures
+// and assertions. Captured values are stored in variables. index is the
+// request's 1-based position in its file.
+//
+// A failed call is returned as an error unless the request declares an
+// expected status or is expected to fail, in which case it is evaluated
+// like any other response.
+// Assertion failures are reported in the result (see Result.Passed).
+func (r *Runner) Execute(ctx context.Context, index int, req *file.RequestFile, variables map[string]interface{}) (*render.Result, error) {
+	call, err := r.prepare(req, variables)
+	if err != nil {
+		return nil, err
+	}
+	reqFile, c, methodDesc, inputMsg := call.req, call.client, call.method, call.input
+	r.declareSecrets(reqFile, variables, "")
+
+	// Make the call
+	callCtx, cancel := context.WithTimeout(ctx, reqFile.Timeout)
+	start := time.Now()
+	response, err := c.Call(callCtx, methodDesc, inputMsg)
+	elapsed := time.Since(start)
+	cancel()
+
+	result := &render.Result{
+		Index:    index,
+		Name:     reqFile.Name,
+		Service:  reqFile.Service,
+		Method:   reqFile.Method,
+		Status:   client.StatusOK,
+		Duration: elapsed,
+
+		RequestSize: protobuf.Size(inputMsg),
+
+		Started:       start,
+		RequestHeader: client.HTTPHeader(reqFile.Headers),
+		Output:        reqFile.Output,
+		ExpectFailure: reqFile.ExpectFailure,
+	}
+	result.URL, _ = c.MethodURL(methodDesc)
+	if body, err := r.JSON.Format(inputMsg); err == nil {
+		result.Request = body
+	}
+	actual := &assert.Response{Status: client.StatusOK, Normalize: slices.Concat(r.Normalize, reqFile.Normalize)}
+
+	if err != nil {
+		// A failed call is only evaluated when the request declares
+		// an expected status or expects to fail; otherwise it aborts
+		// the run
+		var rpcErr *client.Error
+		if !errors.As(err, &rpcErr) || !(assert.ExpectsStatus(reqFile.Asserts) || reqFile.ExpectFailure) {
+			return nil, fmt.Errorf("RPC call failed: %w", err)
+		}
+		result.Status = rpcErr.Status()
+		result.Error = rpcErr.Error()
+		result.Header, result.Trailer = rpcErr.Header, rpcErr.Trailer
+		actual.Status = rpcErr.Status()
+		actual.Header = rpcErr.Header
+		actual.Trailer = rpcErr.Trailer
+	} else {
+		// Convert response to JSON
+		jsonOutput, err := r.JSON.Format(response.Msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to format response: %w", err)
+		}
+		result.Body = jsonOutput
+		result.ResponseSize = response.Size
+		result.Header, result.Trailer = response.Header, response.Trailer
+		actual.Body = jsonOutput
+		actual.Header = response.Header
+		actual.Trailer = response.Trailer
+		actual.Size = response.Size
+		if response.TLS != nil {
+			actual.Certificates = response.TLS.PeerCertificates
+			if r.ShowCertificates {
+				result.Certificates = render.NewCertificates(response.TLS.PeerCertificates)
+			}
+		}
+
+		// Handle Captures
+		for varName, c := range reqFile.Captures {
+			val, err := capture.Extract(c, jsonOutput)
+			if err != nil {
+				result.Captures = append(result.Captures, render.Capture{Name: varName, Path: c.Path, Error: err.Error()})
+				continue
+			}
+			variables[varName] = val
+			result.Captures = append(result.Captures, render.Capture{Name: varName, Path: c.Path, Value: val})
+		}
+		r.declareSecrets(reqFile, variables, jsonOutput)
+
+		if r.TextFormat {
+			if result.Body, err = client.ProtoToText(response.Msg); err != nil {
+				return nil, fmt.Errorf("failed to format response: %w", err)
+			}
+		}
+	}
+
+	// Handle Asserts
+	for _, a := range reqFile.Asserts {
+		check := assert.Check
+		if r.UpdateGolden && a.File && a.Operator == "==" && !a.Negate {
+			check = assert.UpdateGolden
+		}
+		res, err := check(a, actual)
+		if err != nil {
+			// Error executing check (e.g. invalid jsonpath)
+			result.Asserts = append(result.Asserts, render.Assertion{Message: fmt.Sprintf("ERROR: %v", err)})
+			continue
+		}
+		result.Asserts = append(result.Asserts, render.Assertion{Pass: res.Pass, Message: res.Message, Diff: res.Diff})
+	}
+
+	// A failed call has no body to snapshot
+	if (r.Snapshots || reqFile.Snapshot) && reqFile.SnapshotPath != "" && actual.Body != "" {
+		res, err := assert.CheckSnapshot(reqFile.SnapshotPath, actual, r.UpdateSnapshots)
+		if err != nil {
+			res = assert.Result{Message: fmt.Sprintf("ERROR: %v", err)}
+		}
+		result.Asserts = append(result.Asserts, render.Assertion{Pass: res.Pass, Message: res.Message, Diff: res.Diff})
+	}
+
+	return result, nil
+}
+
+// declareSecrets passes the values req declares secret to OnSecret: those
+// of its secret variables and, given a response body, the values its
+// secret jsonpaths select in it
+func (r *Runner) declareSecrets(req *file.RequestFile, variables map[string]interface{}, body string) {
+	if r.OnSecret == nil {
+		return
+	}
+	for _, secret := range req.Secrets {
+		if !strings.HasPrefix(secret, "$") {
+			if value, ok := variables[secret]; ok {
+				r.OnSecret(fmt.Sprint(value))
+			}
+			continue
+		}
+		if body == "" {
+			continue
+		}
+		// A path that selects nothing has nothing to hide
+		matches, _, err := client.EvaluateJSONPathMatches(body, secret)
+		if err != nil {
+			continue
+		}
+		for _, m := range matches {
+			if value, err := client.FormatJSONValue(m); err == nil {
+				r.OnSecret(value)
+			}
+		}
+	}
+}
+
+// DryRun resolves variables into req and validates its body like Execute,
+// but returns the request that would be sent instead of sending it. Nothing
+// is captured, so variables captured by earlier requests stay unresolved.
+func (r *Runner) DryRun(ctx context.Context, index int, req *file.RequestFile, variables map[string]interface{}) (*render.Request, error) {
+	call, err := r.prepare(req, variables)
+	if err != nil {
+		return nil, err
+	}
+	r.declareSecrets(call.req, variables, "")
+
+	ctx, cancel := context.WithTimeout(ctx, call.req.Timeout)
+	defer cancel()
+	sent, err := call.client.DryRun(ctx, call.method, call.input)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := r.JSON.Format(call.input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format request: %w", err)
+	}
+	return &render.Request{
+		Index:   index,
+		Name:    call.req.Name,
+		Service: call.req.Service,
+		Method:  call.req.Method,
+		URL:     sent.URL,
+		Header:  sent.Header,
+		Body:    body,
+		Payload: sent.Body,
+	}, nil
+}
+
+// preparedCall is a resolved request with the client and input message
+// needed to call it
+type preparedCall struct {
+	req    *file.RequestFile
+	client *client.Client
+	method protoreflect.MethodDescriptor
+	input  protobuf.Message
+}
+
+// prepare resolves variables into a copy of req (the parsed request stays
+// untouched) and builds its client and input message
+func (r *Runner) prepare(req *file.RequestFile, variables map[string]interface{}) (*preparedCall, error) {
+	var (
+		reqFile *file.RequestFile
+		err     error
+	)
+	if r.Strict {
+		if reqFile, err = ResolveStrict(req, variables); err != nil {
+			return nil, err
+		}
+	} else {
+		reqFile = Resolve(req, variables)
+	}
+
+	if reqFile.TimeoutTemplate != "" {
+		if reqFile.Timeout, err = time.ParseDuration(reqFile.TimeoutTemplate); err != nil {
+			return nil, fmt.Errorf("invalid timeout duration %q: %w", reqFile.TimeoutTemplate, err)
+		}
+	}
+
+	methodDesc, err := r.FindMethod(reqFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse protocol
+	proto, err := client.ParseProtocol(reqFile.Protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	// BasicAuth replaces any Authorization header
+	if reqFile.BasicAuth != "" {
+		authorization, err := client.BasicAuth(reqFile.BasicAuth)
+		if err != nil {
+			return nil, err
+		}
+		client.SetHeader(reqFile.Headers, "Authorization", authorization)
+	}
+
+	httpClient, err := r.httpClient(reqFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Normalize the address
+	address, err := client.ParseAddress(reqFile.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert JSON input to proto message
+	inputMsg, err := client.JSONToProto(reqFile.Body, methodDesc.Input())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON input: %w", err)
+	}
+
+	opts := append([]client.Option{client.WithHTTPClient(httpClient)}, r.ClientOptions...)
+	return &preparedCall{
+		req:    reqFile,
+		client: client.NewClient(address.String(), reqFile.Prefix, proto, reqFile.Headers, opts...),
+		method: methodDesc,
+		input:  inputMsg,
+	}, nil
+}
+
+// httpClient returns the HTTP client for the TLS settings of req, creating
+// it on first use so that connections are reused
+func (r *Runner) httpClient(req *file.RequestFile) (*http.Client, error) {
+	settings := r.TLS
+	if req.ClientCert != "" || req.ClientKey != "" {
+		settings.CertFile, settings.KeyFile = req.ClientCert, req.ClientKey
+		settings.SPIFFESocket = ""
+	}
+	if req.CACert != "" {
+		settings.CAFile = req.CACert
+	}
+	settings.Insecure = settings.Insecure || req.Insecure
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.httpClients[settings]; ok {
+		return c, nil
+	}
+	c, err := client.NewHTTPClient(settings)
+	if err != nil {
+		return nil, err
+	}
+	if r.Jar != nil {
+		c = client.WithJar(c, r.Jar)
+	}
+	if r.httpClients == nil {
+		r.httpClients = make(map[client.TLSConfig]*http.Client)
+	}
+	r.httpClients[settings] = c
+	return c, nil
+}
+
+// Scenario runs requests in order with a fresh copy of variables, so the
+// captures of one run never leak into another, stopping at the first failure. The outcome's status is "ok" when every request succeeded
+// and passed its assertions, the gRPC status name of an unexpected RPC
+// error, "assertion_failed", or "error" for anything else. Its sizes are the
+// totals of the messages exchanged.
+func (r *Runner) Scenario(ctx context.Context, requests []*file.RequestFile, variables map[string]interface{}) bench.Outcome {
+	variables = maps.Clone(variables)
+	if variables == nil {
+		variables = make(map[string]interface{})
+	}
+	var out bench.Outcome
+	for i, req := range requests {
+		result, err := r.Execute(ctx, i+1, req, variables)
+		if err != nil {
+			var rpcErr *client.Error
+			if errors.As(err, &rpcErr) {
+				out.Status = rpcErr.Status()
+			} else {
+				out.Status = "error"
+			}
+			return out
+		}
+		out.RequestSize += result.RequestSize
+		out.ResponseSize += result.ResponseSize
+		if !result.Passed() {
+			out.Status = "assertion_failed"
+			return out
+		}
+	}
+	out.Status = client.StatusOK
+	return out
+}
+
+// Resolve returns a copy of req with variables substituted in Address,
+// Service, Method, Protocol, Prefix, Timeout, Headers, Body, BasicAuth,
+// ClientCert, ClientKey, CACert, Output, and expected assertion values. The parsed
+// request is never mutated, so it can be resolved again with a different variable set.
+// Placeholders that cannot be resolved are left untouched.
+func Resolve(req *file.RequestFile, variables map[string]interface{}) *file.RequestFile {
+	return resolve(req, func(s string) string {
+		result, _ := template.SubstituteStrictIn(req.Dir, s, variables)
+		return result
+	})
+}
+
+// ResolveStrict is like Resolve, but fails with an *UnresolvedError listing
+// every placeholder that cannot be resolved
+func ResolveStrict(req *file.RequestFile, variables map[string]interface{}) (*file.RequestFile, error) {
+	unresolved := &UnresolvedError{}
+	resolved := resolve(req, func(s string) string {
+		result, err := template.SubstituteStrictIn(req.Dir, s, variables)
+		unresolved.add(err)
+		return result
+	})
+	if len(unresolved.Placeholders) > 0 {
+		return nil, unresolved
+	}
+	return resolved, nil
+}
+
+// resolve returns a copy of req with substitute applied to its templated fields
+func resolve(req *file.RequestFile, substitute func(string) string) *file.RequestFile {
+	resolved := req.Clone()
+	resolved.Address = substitute(req.Address)
+	resolved.Service = substitute(req.Service)
+	resolved.Method = substitute(req.Method)
+	resolved.Protocol = substitute(req.Protocol)
+	resolved.Prefix = substitute(req.Prefix)
+	resolved.TimeoutTemplate = substitute(req.TimeoutTemplate)
+	resolved.Body = substitute(req.Body)
+	resolved.BasicAuth = substitute(req.BasicAuth)
+	resolved.ClientCert = substitute(req.ClientCert)
+	resolved.ClientKey = substitute(req.ClientKey)
+	resolved.CACert = substitute(req.CACert)
+	resolved.Output = substitute(req.Output)
+	// In order, so unresolved placeholders are reported deterministically
+	for _, k := range slices.Sorted(maps.Keys(req.Headers)) {
+		resolved.Headers[k] = substitute(req.Headers[k])
+	}
+	for i, a := range req.Asserts {
+		resolved.Asserts[i].Value = substitute(a.Value)
+	}
+	return resolved
+}
+
+// UnresolvedError lists the placeholders that could not be resolved, each
+// with the reason, e.g. {{token}}: variable "token" is not defined
+type UnresolvedError struct {
+	Placeholders []string
+	Missing      []string // Names of the variables that are not defined, in order of use
+}
+
+func (e *UnresolvedError) Error() string {
+	return "unresolved placeholders:\n  " + strings.Join(e.Placeholders, "\n  ")
+}
+
+// add records the placeholders of an error returned by
+// template.SubstituteStrict, skipping duplicates
+func (e *UnresolvedError) add(err error) {
+	if err == nil {
+		return
+	}
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	for _, err := range errs {
+		if !slices.Contains(e.Placeholders, err.Error()) {
+			e.Placeholders = append(e.Placeholders, err.Error())
+		}
+		var undefined *template.UndefinedError
+		if errors.As(err, &undefined) && !slices.Contains(e.Missing, undefined.Name) {
+			e.Missing = append(e.Missing, undefined.Name)
+		}
+	}
+}
+
+// CheckPlaceholders reports, before anything is sent, the placeholders of
+// requests that cannot be resolved with variables, counting the variables
+// captured by earlier requests as defined. order lists the indexes of the
+// requests that run, in the order they run (e.g. the Order of a Graph); nil
+// checks every request in file order. It returns an *UnresolvedError whose
+// placeholders are prefixed with their request, e.g.
+// request 2: {{token}}: variable "token" is not defined
+func CheckPlaceholders(requests []*file.RequestFile, order []int, variables map[string]interface{}) error {
+	defined := maps.Clone(variables)
+	if defined == nil {
+		defined = make(map[string]interface{})
+	}
+	if order == nil {
+		order = make([]int, len(requests))
+		for i := range order {
+			order[i] = i
+		}
+	}
+	all := &UnresolvedError{}
+	for _, i := range order {
+		req := requests[i]
+		_, err := ResolveStrict(req, defined)
+		var unresolved *UnresolvedError
+		if errors.As(err, &unresolved) {
+			for _, p := range unresolved.Placeholders {
+				all.Placeholders = append(all.Placeholders, fmt.Sprintf("request %d: %s", i+1, p))
+			}
+			for _, name := range unresolved.Missing {
+				if !slices.Contains(all.Missing, name) {
+					all.Missing = append(all.Missing, name)
+				}
+			}
+		}
+		// Captured values are only known at run time
+		for name := range req.Captures {
+			if _, ok := defined[name]; !ok {
+				defined[name] = template.Unknown
+			}
+		}
+	}
+	if len(all.Placeholders) > 0 {
+		return all
+	}
+	return nil
+}
@@ -0,0 +1,494 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+
+	"grpc_client/internal/client"
+	"grpc_client/internal/file"
+	"grpc_client/internal/proto"
+	"grpc_client/internal/render"
+)
+
+const testProto = `syntax = "proto3";
+package test;
+service EchoService {
+  rpc Echo(EchoRequest) returns (EchoResponse);
+}
+message EchoRequest { string text = 1; }
+message EchoResponse { string text = 1; }
+`
+
+// newTestRunner compiles testProto into a Runner and starts a Connect
+// server that echoes each request message back (the messages share a layout)
+func newTestRunner(t *testing.T) (*Runner, string) {
+	t.Helper()
+	compiler := protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{"test.proto": testProto}),
+		},
+	}
+	files, err := compiler.Compile(context.Background(), "test.proto")
+	if err != nil {
+		t.Fatalf("failed to compile test proto: %v", err)
+	}
+	registry := proto.NewRegistry()
+	registry.AddFile(files[0])
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/proto")
+		_, _ = io.Copy(w, r.Body)
+	}))
+	t.Cleanup(srv.Close)
+
+	return New(registry), srv.URL
+}
+
+func echoRequest(address, body string) *file.RequestFile {
+	return &file.RequestFile{
+		Address:  address,
+		Service:  "test.EchoService",
+		Method:   "Echo",
+		Protocol: "connect",
+		Timeout:  5 * time.Second,
+		Headers:  map[string]string{},
+		Body:     body,
+	}
+}
+
+func TestExecute_CapturesAndAsserts(t *testing.T) {
+	r, address := newTestRunner(t)
+
+	first := echoRequest(address, `{"text": "hello"}`)
+	first.Captures = map[string]file.Capture{"greeting": {Path: "text"}}
+	second := echoRequest(address, `{"text": "{{greeting}} again"}`)
+	second.Asserts = []file.Assertion{
+		{Type: "jsonpath", Key: "$.text", Operator: "==", Value: "{{greeting}} again"},
+		{Type: "jsonpath", Key: "$.text", Operator: "==", Value: "bye"},
+	}
+
+	variables := map[string]interface{}{}
+	result, err := r.Execute(context.Background(), 1, first, variables)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if variables["greeting"] != "hello" || len(result.Captures) != 1 {
+		t.Fatalf("capture not stored: %v, %+v", variables, result.Captures)
+	}
+
+	result, err = r.Execute(context.Background(), 2, second, variables)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.Index != 2 || result.Status != "ok" {
+		t.Errorf("index/status = %d/%q", result.Index, result.Status)
+	}
+	if len(result.Asserts) != 2 || !result.Asserts[0].Pass || result.Asserts[1].Pass || result.Passed() {
+		t.Errorf("asserts = %+v", result.Asserts)
+	}
+	// The parsed request is not mutated by variable resolution
+	if second.Body != `{"text": "{{greeting}} again"}` || second.Asserts[0].Value != "{{greeting}} again" {
+		t.Errorf("parsed request was mutated: %q, %+v", second.Body, second.Asserts[0])
+	}
+}
+
+func TestExecute_Details(t *testing.T) {
+	r, address := newTestRunner(t)
+
+	req := echoRequest(address, `{"text": "hi"}`)
+	req.Headers["x-tenant"] = "acme"
+	before := time.Now()
+	result, err := r.Execute(context.Background(), 1, req, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.URL != address+"/test.EchoService/Echo" {
+		t.Errorf("URL = %q", result.URL)
+	}
+	if result.Started.Before(before) || result.RequestHeader.Get("X-Tenant") != "acme" {
+		t.Errorf("started/request header = %v/%v", result.Started, result.RequestHeader)
+	}
+	if !strings.Contains(result.Request, `"hi"`) || result.Header.Get("Content-Type") != "application/proto" {
+		t.Errorf("request/response header = %q/%v", result.Request, result.Header)
+	}
+}
+
+func TestExecute_Secrets(t *testing.T) {
+	r, address := newTestRunner(t)
+	var secrets []string
+	r.OnSecret = func(value string) { secrets = append(secrets, value) }
+
+	first := echoRequest(address, `{"text": "s3cr3t"}`)
+	first.Captures = map[string]file.Capture{"token": {Path: "text"}}
+	first.Secrets = []string{"token", "$.text", "$.missing", "undefined"}
+	second := echoRequest(address, `{"text": "Bearer {{token}}"}`)
+	second.Secrets = []string{"token"}
+
+	variables := map[string]interface{}{}
+	if _, err := r.Execute(context.Background(), 1, first, variables); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	result, err := r.Execute(context.Background(), 2, second, variables)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	// A secret variable is still substituted
+	if !strings.Contains(result.Body, "Bearer s3cr3t") {
+		t.Errorf("expected the secret to be substituted, got %q", result.Body)
+	}
+	// The first request declares the captured variable and the jsonpath
+	// after its call, the second the variable before and after its call
+	if want := []string{"s3cr3t", "s3cr3t", "s3cr3t", "s3cr3t"}; !slices.Equal(secrets, want) {
+		t.Errorf("expected secrets %q, got %q", want, secrets)
+	}
+}
+
+func TestExecute_TextFormat(t *testing.T) {
+	r, address := newTestRunner(t)
+	r.TextFormat = true
+
+	req := echoRequest(address, `{"text": "hi"}`)
+	req.Captures = map[string]file.Capture{"said": {Path: "text"}}
+	req.Asserts = []file.Assertion{{Type: "jsonpath", Key: "$.text", Operator: "==", Value: "hi"}}
+	variables := map[string]interface{}{}
+	result, err := r.Execute(context.Background(), 1, req, variables)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	// Captures and assertions see the JSON form, the result the text format
+	if !strings.HasPrefix(result.Body, "text:") || !strings.Contains(result.Body, `"hi"`) {
+		t.Errorf("body = %q, want text format", result.Body)
+	}
+	if variables["said"] != "hi" || !result.Passed() {
+		t.Errorf("captures = %v, asserts = %+v", variables, result.Asserts)
+	}
+}
+
+func TestExecute_UnknownMethod(t *testing.T) {
+	r, address := newTestRunner(t)
+	req := echoRequest(address, `{}`)
+	req.Method = "Missing"
+
+	if _, err := r.Execute(context.Background(), 1, req, map[string]interface{}{}); err == nil {
+		t.Fatal("expected an error for an unknown method")
+	}
+}
+
+func TestExecute_ExpectFailure(t *testing.T) {
+	r, _ := newTestRunner(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"code": "not_found", "message": "no such user"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	req := echoRequest(srv.URL, `{}`)
+	if _, err := r.Execute(context.Background(), 1, req, map[string]interface{}{}); err == nil {
+		t.Fatal("expected the failed call to be an error")
+	}
+
+	// A request expected to fail is evaluated like a response instead
+	req.ExpectFailure = true
+	result, err := r.Execute(context.Background(), 1, req, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.Status != "not_found" || !result.ExpectFailure || result.Outcome() != "pass" {
+		t.Errorf("status/outcome = %q/%q", result.Status, result.Outcome())
+	}
+}
+
+func TestExecute_Snapshot(t *testing.T) {
+	r, address := newTestRunner(t)
+	req := echoRequest(address, `{"text": "hello"}`)
+	req.SnapshotPath = filepath.Join(t.TempDir(), "__snapshots__", "echo", "request-1.json")
+
+	run := func() render.Assertion {
+		t.Helper()
+		result, err := r.Execute(context.Background(), 1, req, map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if len(result.Asserts) != 1 {
+			t.Fatalf("expected a snapshot assertion, got %+v", result.Asserts)
+		}
+		return result.Asserts[0]
+	}
+
+	// Only requests with Snapshot: true, or all with Snapshots
+	if result, _ := r.Execute(context.Background(), 1, req, map[string]interface{}{}); len(result.Asserts) != 0 {
+		t.Errorf("expected no snapshot, got %+v", result.Asserts)
+	}
+	req.Snapshot = true
+	if a := run(); !a.Pass || !strings.HasPrefix(a.Message, "WRITTEN: ") {
+		t.Errorf("first run = %+v", a)
+	}
+	if a := run(); !a.Pass || !strings.HasPrefix(a.Message, "PASS: ") {
+		t.Errorf("second run = %+v", a)
+	}
+
+	req.Body = `{"text": "bye"}`
+	if a := run(); a.Pass || !strings.Contains(a.Diff, `+  "text": "bye"`) {
+		t.Errorf("changed response = %+v", a)
+	}
+	r.UpdateSnapshots = true
+	if a := run(); !a.Pass || !strings.HasPrefix(a.Message, "UPDATED: ") {
+		t.Errorf("update = %+v", a)
+	}
+}
+
+func TestScenario(t *testing.T) {
+	r, address := newTestRunner(t)
+
+	login := echoRequest(address, `{"text": "token-1"}`)
+	login.Captures = map[string]file.Capture{"token": {Path: "text"}}
+	use := echoRequest(address, `{"text": "{{token}}"}`)
+	use.Asserts = []file.Assertion{{Type: "jsonpath", Key: "$.text", Operator: "==", Value: "token-1"}}
+
+	out := r.Scenario(context.Background(), []*file.RequestFile{login, use}, nil)
+	if out.Status != "ok" {
+		t.Errorf("status = %q, want ok", out.Status)
+	}
+	// Both messages are {text: "token-1"}: a 2-byte tag and length plus 7 bytes
+	if out.RequestSize != 18 || out.ResponseSize != 18 {
+		t.Errorf("request/response size = %d/%d, want 18/18", out.RequestSize, out.ResponseSize)
+	}
+
+	use.Asserts[0].Value = "other"
+	if out := r.Scenario(context.Background(), []*file.RequestFile{login, use}, nil); out.Status != "assertion_failed" {
+		t.Errorf("status = %q, want assertion_failed", out.Status)
+	}
+
+	unreachable := echoRequest("http://127.0.0.1:1", `{}`)
+	if out := r.Scenario(context.Background(), []*file.RequestFile{unreachable}, nil); out.Status != "unavailable" {
+		t.Errorf("status = %q, want unavailable", out.Status)
+	}
+}
+
+func TestScenario_Variables(t *testing.T) {
+	r, address := newTestRunner(t)
+
+	greet := echoRequest(address, `{"text": "{{greeting}}"}`)
+	greet.Asserts = []file.Assertion{{Type: "jsonpath", Key: "$.text", Operator: "==", Value: "hi"}}
+	greet.Captures = map[string]file.Capture{"greeting": {Path: "text", Regex: "(h)"}}
+	variables := map[string]interface{}{"greeting": "hi"}
+
+	// Every run starts from the given variables, not the captures of the last
+	for i := 0; i < 2; i++ {
+		if out := r.Scenario(context.Background(), []*file.RequestFile{greet}, variables); out.Status != "ok" {
+			t.Errorf("run %d: status = %q, want ok", i+1, out.Status)
+		}
+	}
+	if variables["greeting"] != "hi" {
+		t.Errorf("variables were modified: %v", variables)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	r, _ := newTestRunner(t)
+
+	// The address is unroutable, so any network activity would fail
+	req := echoRequest("http://192.0.2.1:1", `{"text": "{{greeting}}"}`)
+	req.Headers["Authorization"] = "Bearer {{token}}"
+	variables := map[string]interface{}{"greeting": "hi", "token": "t"}
+
+	got, err := r.DryRun(context.Background(), 1, req, variables)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if got.URL != "http://192.0.2.1:1/test.EchoService/Echo" {
+		t.Errorf("unexpected URL %q", got.URL)
+	}
+	if got.Header.Get("Authorization") != "Bearer t" {
+		t.Errorf("unexpected headers %v", got.Header)
+	}
+	if !strings.Contains(got.Body, `"hi"`) || string(got.Payload) != "\n\x02hi" {
+		t.Errorf("unexpected body %s, payload %q", got.Body, got.Payload)
+	}
+
+	// The body is validated against the input message
+	if _, err := r.DryRun(context.Background(), 1, echoRequest("http://192.0.2.1:1", `{"nope": 1}`), variables); err == nil {
+		t.Error("expected error for a body that does not match the input message")
+	}
+}
+
+func TestDryRun_FileRelativeToRequestFile(t *testing.T) {
+	r, _ := newTestRunner(t)
+
+	dir := t.TempDir()
+	content := "GRPC http://192.0.2.1:1\nService: test.EchoService\nMethod: Echo\nProtocol: connect\n\n{\"text\": \"{{file \"payload.txt\"}}\"}\n"
+	if err := os.WriteFile(filepath.Join(dir, "echo.grpc"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "payload.txt"), []byte("from file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	requests, err := file.ParseMultiple(filepath.Join(dir, "echo.grpc"))
+	if err != nil {
+		t.Fatalf("ParseMultiple failed: %v", err)
+	}
+
+	// Run from elsewhere: the path is still relative to the request file
+	t.Chdir(t.TempDir())
+	got, err := r.DryRun(context.Background(), 1, requests[0], map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if !strings.Contains(got.Body, `"from file"`) {
+		t.Errorf("body = %s, want the contents of payload.txt", got.Body)
+	}
+}
+
+func TestDryRun_BasicAuth(t *testing.T) {
+	r, _ := newTestRunner(t)
+
+	req := echoRequest("http://192.0.2.1:1", `{"text": "hi"}`)
+	req.Headers["authorization"] = "Bearer replaced"
+	req.BasicAuth = "{{user}}:s3cret"
+
+	got, err := r.DryRun(context.Background(), 1, req, map[string]interface{}{"user": "alice"})
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if auth := got.Header.Values("Authorization"); len(auth) != 1 || auth[0] != "Basic YWxpY2U6czNjcmV0" {
+		t.Errorf("unexpected Authorization %q", auth)
+	}
+
+	req.BasicAuth = "alice"
+	if _, err := r.DryRun(context.Background(), 1, req, nil); err == nil {
+		t.Error("expected error for credentials without a colon")
+	}
+}
+
+func TestDryRun_TemplatedFields(t *testing.T) {
+	r, _ := newTestRunner(t)
+
+	req := echoRequest("http://192.0.2.1:1", `{"text": "hi"}`)
+	req.Service, req.Method = "{{pkg}}.EchoService", "{{method}}"
+	req.Protocol, req.Prefix = "{{protocol}}", "/{{tenant}}"
+	req.TimeoutTemplate = "{{timeout}}"
+	variables := map[string]interface{}{"pkg": "test", "method": "Echo", "protocol": "grpc-web", "tenant": "acme", "timeout": "2s"}
+
+	got, err := r.DryRun(context.Background(), 1, req, variables)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if got.Service != "test.EchoService" || got.Method != "Echo" {
+		t.Errorf("unexpected method %s/%s", got.Service, got.Method)
+	}
+	if got.URL != "http://192.0.2.1:1/acme/test.EchoService/Echo" {
+		t.Errorf("unexpected URL %q", got.URL)
+	}
+	if ct := got.Header.Get("Content-Type"); ct != "application/grpc-web+proto" {
+		t.Errorf("expected a gRPC-Web request, got Content-Type %q", ct)
+	}
+	if req.Service != "{{pkg}}.EchoService" {
+		t.Error("the parsed request was mutated")
+	}
+
+	variables["timeout"] = "soon"
+	if _, err := r.DryRun(context.Background(), 1, req, variables); err == nil || !strings.Contains(err.Error(), "invalid timeout") {
+		t.Errorf("expected an invalid timeout error, got %v", err)
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	r, _ := newTestRunner(t)
+
+	req := echoRequest("http://192.0.2.1:1", `{}`)
+	first, err := r.httpClient(req)
+	if err != nil {
+		t.Fatalf("httpClient failed: %v", err)
+	}
+	if second, _ := r.httpClient(req); second != first {
+		t.Error("expected the HTTP client to be reused")
+	}
+
+	// A request's client certificate overrides the runner's
+	r.TLS = client.TLSConfig{CertFile: "runner.crt"}
+	req.ClientCert = "missing.crt"
+	if _, err := r.httpClient(req); err == nil || !strings.Contains(err.Error(), "missing.crt") {
+		t.Errorf("expected the request's certificate to be loaded, got %v", err)
+	}
+}
+
+func TestExecute_Strict(t *testing.T) {
+	r, address := newTestRunner(t)
+	r.Strict = true
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	t.Cleanup(srv.Close)
+
+	req := echoRequest(srv.URL, `{"text": "{{greeting}} {{greeting}}"}`)
+	req.Headers["Authorization"] = "Bearer {{token}}"
+	_, err := r.Execute(context.Background(), 1, req, map[string]interface{}{})
+
+	var unresolved *UnresolvedError
+	if !errors.As(err, &unresolved) {
+		t.Fatalf("expected an UnresolvedError, got %v", err)
+	}
+	want := []string{
+		`{{greeting}}: variable "greeting" is not defined`,
+		`{{token}}: variable "token" is not defined`,
+	}
+	if !slices.Equal(unresolved.Placeholders, want) {
+		t.Errorf("placeholders = %q, want %q", unresolved.Placeholders, want)
+	}
+	if calls != 0 {
+		t.Errorf("expected nothing to be sent, got %d calls", calls)
+	}
+
+	ok := echoRequest(address, `{"text": "{{greeting}}"}`)
+	if _, err := r.Execute(context.Background(), 1, ok, map[string]interface{}{"greeting": "hi"}); err != nil {
+		t.Errorf("Execute failed: %v", err)
+	}
+}
+
+func TestCheckPlaceholders(t *testing.T) {
+	login := echoRequest("http://localhost", `{"text": "{{user}}"}`)
+	login.Captures = map[string]file.Capture{"token": {Path: "text"}}
+	use := echoRequest("{{host}}", `{"text": "{{token}} {{missing | default "x"}}"}`)
+
+	err := CheckPlaceholders([]*file.RequestFile{login, use}, nil, map[string]interface{}{"user": "ann"})
+	var unresolved *UnresolvedError
+	if !errors.As(err, &unresolved) {
+		t.Fatalf("expected an UnresolvedError, got %v", err)
+	}
+	want := []string{`request 2: {{host}}: variable "host" is not defined`}
+	if !slices.Equal(unresolved.Placeholders, want) {
+		t.Errorf("placeholders = %q, want %q", unresolved.Placeholders, want)
+	}
+	if !slices.Equal(unresolved.Missing, []string{"host"}) {
+		t.Errorf("missing = %q, want [host]", unresolved.Missing)
+	}
+
+	if err := CheckPlaceholders([]*file.RequestFile{login, use}, nil, map[string]interface{}{"user": "ann", "host": "h"}); err != nil {
+		t.Errorf("CheckPlaceholders failed: %v", err)
+	}
+
+	// Captures count in the order the requests run, and requests that do
+	// not run are not checked
+	err = CheckPlaceholders([]*file.RequestFile{use, login}, []int{1, 0}, map[string]interface{}{"user": "ann", "host": "h"})
+	if err != nil {
+		t.Errorf("CheckPlaceholders in run order failed: %v", err)
+	}
+	err = CheckPlaceholders([]*file.RequestFile{login, use}, []int{1}, map[string]interface{}{"host": "h"})
+	if !errors.As(err, &unresolved) || !slices.Equal(unresolved.Placeholders, []string{`request 2: {{token}}: variable "token" is not defined`}) {
+		t.Errorf("expected only the placeholders of request 2, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Service is the keyring service under which secrets are stored, e.g.
+// security add-generic-password -s grpc_client -a api-token -w
+const Service = "grpc_client"
+
+// vaultPrefix selects Vault instead of the OS keyring, e.g.
+// vault:secret/data/api#token
+const vaultPrefix = "vault:"
+
+var (
+	mu    sync.Mutex
+	cache = make(map[string]string)
+)
+
+// keyring reads a secret from the OS keyring (replaced in tests)
+var keyring = readKeyring
+
+// Lookup returns the secret with the given name. A name of the form
+// vault:<path>#<field> is read from HashiCorp Vault at VAULT_ADDR with
+// VAULT_TOKEN (the field defaults to "value"); any other name is read from
+// the OS keyring under the grpc_client service. Secrets are read once and
+// cached for the life of the process.
+func Lookup(name string) (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if value, ok := cache[name]; ok {
+		return value, nil
+	}
+
+	var (
+		value string
+		err   error
+	)
+	if path, ok := strings.CutPrefix(name, vaultPrefix); ok {
+		value, err = readVault(path)
+	} else {
+		value, err = keyring(name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("secret %q: %w", name, err)
+	}
+	cache[name] = value
+	return value, nil
+}
+
+// Values returns the secrets read so far, e.g. to redact them from output
+func Values() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	values := make([]string, 0, len(cache))
+	for _, v := range cache {
+		values = append(values, v)
+	}
+	return values
+}
+
+// readKeyring reads a secret with the keyring tool of the platform:
+// security on macOS and secret-tool (libsecret) on Linux
+func readKeyring(name string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", Service, "-a", name, "-w")
+	case "linux", "freebsd", "openbsd", "netbsd":
+		cmd = exec.Command("secret-tool", "lookup", "service", Service, "account", name)
+	default:
+		return "", fmt.Errorf("the OS keyring is not supported on %s, use a vault: secret", runtime.GOOS)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("not found in the OS keyring (%s: %s)", cmd.Args[0], strings.TrimSpace(stderr.String()))
+		}
+		return "", fmt.Errorf("failed to read the OS keyring: %w", err)
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// vaultTimeout bounds a Vault request
+const vaultTimeout = 10 * time.Second
+
+// readVault reads field of the secret at path (e.g. secret/data/api#token)
+// from Vault. Both KV version 2 (data.data) and version 1 (data) responses
+// are accepted.
+func readVault(ref string) (string, error) {
+	addr, token := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		return "", errors.New("VAULT_ADDR and VAULT_TOKEN must be set to read Vault secrets")
+	}
+	path, field, _ := strings.Cut(ref, "#")
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "", errors.New("missing Vault path, expected vault:<path>#<field>")
+	}
+	if field == "" {
+		field = "value"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), vaultTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(addr, "/")+"/v1/"+path, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid VAULT_ADDR: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach Vault: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Vault response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("request to Vault failed: %s for %s", resp.Status, path)
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("invalid Vault response: %w", err)
+	}
+	data := secret.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested // KV version 2
+	}
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("field %q not found at %s", field, path)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprintf("%v", value), nil
+}
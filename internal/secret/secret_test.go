@@ -0,0 +1,89 @@
+package secret
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLookup_Keyring(t *testing.T) {
+	calls := 0
+	keyring = func(name string) (string, error) {
+		calls++
+		if name == "api-token" {
+			return "s3cret", nil
+		}
+		return "", errors.New("not found")
+	}
+	t.Cleanup(func() { keyring = readKeyring; cache = make(map[string]string) })
+
+	for range 2 {
+		got, err := Lookup("api-token")
+		if err != nil || got != "s3cret" {
+			t.Errorf("Lookup = %q, %v; want s3cret", got, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("keyring read %d times, want 1 (cached)", calls)
+	}
+
+	if _, err := Lookup("missing"); err == nil || !strings.Contains(err.Error(), `secret "missing"`) {
+		t.Errorf("expected error naming the secret, got %v", err)
+	}
+}
+
+func TestLookup_Vault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/api": // KV version 2
+			w.Write([]byte(`{"data": {"data": {"token": "v2-token", "value": "v2-value"}, "metadata": {}}}`))
+		case "/v1/kv/api": // KV version 1
+			w.Write([]byte(`{"data": {"token": "v1-token", "port": 8080}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "root")
+	t.Cleanup(func() { cache = make(map[string]string) })
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"vault:secret/data/api#token", "v2-token", false},
+		{"vault:secret/data/api", "v2-value", false},
+		{"vault:kv/api#token", "v1-token", false},
+		{"vault:kv/api#port", "8080", false},
+		{"vault:kv/api#missing", "", true},
+		{"vault:kv/unknown#token", "", true},
+		{"vault:#token", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lookup(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Lookup error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Lookup = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookup_VaultNotConfigured(t *testing.T) {
+	t.Setenv("VAULT_ADDR", "")
+	t.Setenv("VAULT_TOKEN", "")
+	if _, err := Lookup("vault:secret/data/api#token"); err == nil || !strings.Contains(err.Error(), "VAULT_ADDR") {
+		t.Errorf("expected an error about VAULT_ADDR, got %v", err)
+	}
+}
@@ -0,0 +1,253 @@
+// Package session persists cookies, captured variables, and OAuth tokens
+// across invocations, so that a login flow does not have to be repeated for
+// every command.
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"grpc_client/internal/oauth"
+)
+
+// Dir returns the directory sessions are stored in:
+// $XDG_DATA_HOME/grpc_client/sessions, by default
+// ~/.local/share/grpc_client/sessions
+func Dir() (string, error) {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate the session directory: %w", err)
+		}
+		data = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(data, "grpc_client", "sessions"), nil
+}
+
+// Session is a named set of cookies, variables, and OAuth tokens stored in
+// a directory
+type Session struct {
+	Name string
+	Path string // Directory of the session
+	Jar  *Jar   // Cookies sent and received by requests of the session
+
+	mu     sync.Mutex
+	tokens map[string]oauth.Token // OAuth tokens by oauth.Config.Key
+}
+
+// Open loads the session with the given name, creating it if needed
+func Open(name string) (*Session, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid session name %q", name)
+	}
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	s := &Session{Name: name, Path: filepath.Join(dir, name)}
+	if err := os.MkdirAll(s.Path, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create session %s: %w", name, err)
+	}
+	if s.Jar, err = loadJar(s.cookiesPath()); err != nil {
+		return nil, fmt.Errorf("session %s: %w", name, err)
+	}
+	if s.tokens, err = loadTokens(s.tokensPath()); err != nil {
+		return nil, fmt.Errorf("session %s: %w", name, err)
+	}
+	return s, nil
+}
+
+// VarsPath returns the path of the variable store of the session (see
+// vars.LoadStore)
+func (s *Session) VarsPath() string {
+	return filepath.Join(s.Path, "vars.json")
+}
+
+// cookiesPath returns the path the cookies of the session are saved to
+func (s *Session) cookiesPath() string {
+	return filepath.Join(s.Path, "cookies.json")
+}
+
+// tokensPath returns the path the OAuth tokens of the session are saved to
+func (s *Session) tokensPath() string {
+	return filepath.Join(s.Path, "tokens.json")
+}
+
+// Token returns the OAuth token cached for key (see oauth.Config.Key), if
+// it has not expired
+func (s *Session) Token(key string) (oauth.Token, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tok, ok := s.tokens[key]
+	return tok, ok && tok.Valid()
+}
+
+// SetToken caches tok for key until it expires
+func (s *Session) SetToken(key string, tok oauth.Token) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tokens == nil {
+		s.tokens = make(map[string]oauth.Token)
+	}
+	s.tokens[key] = tok
+}
+
+// Save writes the cookies and OAuth tokens of the session. Like the
+// variable store, the files are only readable by the current user.
+func (s *Session) Save() error {
+	data, err := json.MarshalIndent(s.Jar.entries(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session cookies: %w", err)
+	}
+	if err := os.WriteFile(s.cookiesPath(), append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write session cookies: %w", err)
+	}
+
+	s.mu.Lock()
+	tokens := make(map[string]oauth.Token, len(s.tokens))
+	for key, tok := range s.tokens {
+		if tok.Valid() {
+			tokens[key] = tok
+		}
+	}
+	s.mu.Unlock()
+	if data, err = json.MarshalIndent(tokens, "", "  "); err != nil {
+		return fmt.Errorf("failed to encode session tokens: %w", err)
+	}
+	if err := os.WriteFile(s.tokensPath(), append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write session tokens: %w", err)
+	}
+	return nil
+}
+
+// loadTokens returns the OAuth tokens saved at path that have not expired,
+// or none if the file does not exist
+func loadTokens(path string) (map[string]oauth.Token, error) {
+	tokens := make(map[string]oauth.Token)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokens: %w", err)
+	}
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("invalid tokens file %s: %w", path, err)
+	}
+	for key, tok := range tokens {
+		if !tok.Valid() {
+			delete(tokens, key)
+		}
+	}
+	return tokens, nil
+}
+
+// Jar is an http.CookieJar that remembers the cookies set, so they can be
+// saved. Expiry and domain matching are left to net/http/cookiejar.
+type Jar struct {
+	jar *cookiejar.Jar
+
+	mu  sync.Mutex
+	set []jarEntry // Cookies in the order they were set
+}
+
+// jarEntry is a Set-Cookie received from a URL
+type jarEntry struct {
+	URL     string         `json:"url"`
+	Cookies []*http.Cookie `json:"cookies"`
+}
+
+// NewJar returns an empty Jar
+func NewJar() *Jar {
+	jar, _ := cookiejar.New(nil) // Only fails for invalid options
+	return &Jar{jar: jar}
+}
+
+// loadJar returns a Jar with the cookies saved at path, or an empty one if
+// the file does not exist
+func loadJar(path string) (*Jar, error) {
+	jar := NewJar()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return jar, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cookies: %w", err)
+	}
+	var entries []jarEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid cookies file %s: %w", path, err)
+	}
+	for _, e := range entries {
+		u, err := url.Parse(e.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cookie URL %q: %w", e.URL, err)
+		}
+		jar.SetCookies(u, e.Cookies)
+	}
+	return jar, nil
+}
+
+// SetCookies implements http.CookieJar
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	// Max-Age is relative to now, so it is saved as an expiry date
+	saved := make([]*http.Cookie, len(cookies))
+	for i, c := range cookies {
+		copied := *c
+		if c.MaxAge > 0 {
+			copied.Expires = time.Now().Add(time.Duration(c.MaxAge) * time.Second)
+			copied.MaxAge = 0
+		}
+		saved[i] = &copied
+	}
+	origin := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}
+	j.set = append(j.set, jarEntry{URL: origin.String(), Cookies: saved})
+}
+
+// Cookies implements http.CookieJar
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// entries returns the cookies set so far, without those that have since
+// been replaced by a cookie of the same name, domain, and path
+func (j *Jar) entries() []jarEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	type key struct{ url, name, domain, path string }
+	seen := make(map[key]bool)
+	out := []jarEntry{}
+	for i := len(j.set) - 1; i >= 0; i-- {
+		e := j.set[i]
+		var kept []*http.Cookie
+		for k := len(e.Cookies) - 1; k >= 0; k-- {
+			c := e.Cookies[k]
+			id := key{e.URL, c.Name, c.Domain, c.Path}
+			if !seen[id] {
+				seen[id] = true
+				kept = append(kept, c)
+			}
+		}
+		if len(kept) > 0 {
+			slices.Reverse(kept)
+			out = append(out, jarEntry{URL: e.URL, Cookies: kept})
+		}
+	}
+	// Restore the order the cookies were set in
+	slices.Reverse(out)
+	return out
+}
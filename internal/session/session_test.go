@@ -0,0 +1,115 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"grpc_client/internal/oauth"
+)
+
+func TestSession(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "first", Path: "/"})
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/", MaxAge: 3600})
+			return
+		}
+		c, err := r.Cookie("session")
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(c.Value))
+	}))
+	defer srv.Close()
+
+	s, err := Open("dev")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	httpClient := &http.Client{Jar: s.Jar}
+	resp, err := httpClient.Get(srv.URL + "/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(s.Path, "cookies.json")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected cookies.json readable by the user only, got %v, %v", info, err)
+	}
+
+	// A later invocation sends the saved cookie
+	reopened, err := Open("dev")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	httpClient = &http.Client{Jar: reopened.Jar}
+	resp, err = httpClient.Get(srv.URL + "/whoami")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the session cookie to be sent, got %s", resp.Status)
+	}
+	if got := reopened.Jar.entries(); len(got) != 1 || len(got[0].Cookies) != 1 || got[0].Cookies[0].Value != "abc" {
+		t.Errorf("expected only the latest cookie to be kept, got %+v", got)
+	}
+	if want := filepath.Join(reopened.Path, "vars.json"); reopened.VarsPath() != want {
+		t.Errorf("VarsPath = %s, want %s", reopened.VarsPath(), want)
+	}
+}
+
+func TestSession_Tokens(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	s, err := Open("dev")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	expiry := time.Now().Add(time.Hour).Round(time.Second)
+	s.SetToken("idp client", oauth.Token{AccessToken: "tok-1", Expiry: expiry})
+	s.SetToken("idp other", oauth.Token{AccessToken: "tok-2", Expiry: time.Now().Add(-time.Minute)})
+	if _, ok := s.Token("idp other"); ok {
+		t.Error("expected an expired token not to be reused")
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(s.Path, "tokens.json")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected tokens.json readable by the user only, got %v, %v", info, err)
+	}
+
+	// A later invocation reuses the token, with its expiry, until it expires
+	reopened, err := Open("dev")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	tok, ok := reopened.Token("idp client")
+	if !ok || tok.AccessToken != "tok-1" || !tok.Expiry.Equal(expiry) {
+		t.Errorf("Token = %+v, %v, want the saved token", tok, ok)
+	}
+	if _, ok := reopened.Token("idp other"); ok {
+		t.Error("expected the expired token not to be saved")
+	}
+	if _, ok := reopened.Token("unknown"); ok {
+		t.Error("expected no token for an unknown key")
+	}
+}
+
+func TestOpen_InvalidName(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	for _, name := range []string{"", "..", "a/b"} {
+		if _, err := Open(name); err == nil {
+			t.Errorf("Open(%q) should fail", name)
+		}
+	}
+}
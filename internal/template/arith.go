@@ -0,0 +1,134 @@
+package template
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Unknown stands for a value that is only known at run time, e.g. a capture
+// when placeholders are checked before anything is sent. It substitutes as
+// an empty string, and arithmetic on it succeeds without a result.
+var Unknown fmt.Stringer = unknown{}
+
+type unknown struct{}
+
+func (unknown) String() string { return "" }
+
+// operators are the arithmetic operators; * / % bind tighter than + -
+var operators = map[string]bool{"+": true, "-": true, "*": true, "/": true, "%": true}
+
+// arithmetic evaluates an expression such as page + 1 or count * 2, whose
+// operands are variables or numbers separated from the operators by spaces.
+// ok is false when expr is not an expression. Results are exact; a
+// fraction is formatted as a decimal, e.g. 5 / 2 gives 2.5.
+func arithmetic(expr string, variables map[string]interface{}) (value string, ok bool, err error) {
+	tokens := strings.Fields(expr)
+	if len(tokens) < 3 || len(tokens)%2 == 0 || funcs[tokens[0]] != nil {
+		return "", false, nil
+	}
+	for i := 1; i < len(tokens); i += 2 {
+		if !operators[tokens[i]] {
+			return "", false, nil
+		}
+	}
+
+	var (
+		operands []*big.Rat
+		ops      []string
+	)
+	for i, token := range tokens {
+		if i%2 == 1 {
+			ops = append(ops, token)
+			continue
+		}
+		n, err := operand(token, variables)
+		if err != nil {
+			return "", true, err
+		}
+		if n == nil {
+			return "", true, nil // Unknown until run time
+		}
+		operands = append(operands, n)
+	}
+
+	// Fold * / % into their left operand, then apply + - from left to right
+	terms := []*big.Rat{operands[0]}
+	var termOps []string
+	for i, op := range ops {
+		if op == "+" || op == "-" {
+			terms = append(terms, operands[i+1])
+			termOps = append(termOps, op)
+			continue
+		}
+		last := len(terms) - 1
+		if terms[last], err = apply(terms[last], op, operands[i+1]); err != nil {
+			return "", true, err
+		}
+	}
+	result := terms[0]
+	for i, op := range termOps {
+		if result, err = apply(result, op, terms[i+1]); err != nil {
+			return "", true, err
+		}
+	}
+	return formatRat(result), true, nil
+}
+
+// operand resolves a number literal or a numeric variable, returning nil
+// for Unknown
+func operand(token string, variables map[string]interface{}) (*big.Rat, error) {
+	raw := token
+	if v, found := variables[token]; found {
+		if v == Unknown {
+			return nil, nil
+		}
+		raw = fmt.Sprintf("%v", v)
+	} else if token[0] != '-' && token[0] != '.' && (token[0] < '0' || token[0] > '9') {
+		return nil, &UndefinedError{Name: token}
+	}
+	n, ok := new(big.Rat).SetString(strings.TrimSpace(raw))
+	if !ok {
+		if raw != token {
+			return nil, fmt.Errorf("variable %q is not a number: %q", token, raw)
+		}
+		return nil, fmt.Errorf("invalid number %q", token)
+	}
+	return n, nil
+}
+
+// apply computes a op b
+func apply(a *big.Rat, op string, b *big.Rat) (*big.Rat, error) {
+	switch op {
+	case "+":
+		return new(big.Rat).Add(a, b), nil
+	case "-":
+		return new(big.Rat).Sub(a, b), nil
+	case "*":
+		return new(big.Rat).Mul(a, b), nil
+	case "/":
+		if b.Sign() == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return new(big.Rat).Quo(a, b), nil
+	default: // %
+		if !a.IsInt() || !b.IsInt() {
+			return nil, fmt.Errorf("%% requires integers")
+		}
+		if b.Sign() == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return new(big.Rat).SetInt(new(big.Int).Rem(a.Num(), b.Num())), nil
+	}
+}
+
+// formatRat formats n as an integer when it is one, and as a decimal
+// otherwise
+func formatRat(n *big.Rat) string {
+	if n.IsInt() {
+		return n.Num().String()
+	}
+	f, _ := n.Float64()
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,298 @@
+package template
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"grpc_client/internal/secret"
+)
+
+// function is a built-in template function, called with the arguments that
+// follow its name in the placeholder, e.g. ["1", "100"] for
+// {{randomInt 1 100}}
+type function func(args []string) (string, error)
+
+// funcs are the built-in template functions. Each call produces a new value,
+// so {{uuid}} used twice yields two different UUIDs.
+var funcs = map[string]function{
+	"uuid":         uuid,
+	"now":          now,
+	"date":         date,
+	"file":         readFile,
+	"randomInt":    randomInt,
+	"randomString": randomString,
+	"secret":       secretValue,
+}
+
+// clock returns the current time (replaced in tests)
+var clock = time.Now
+
+// lookupSecret reads a secret from the OS keyring or Vault (replaced in tests)
+var lookupSecret = secret.Lookup
+
+// call evaluates a function call such as randomInt 1 100, reading the
+// relative paths of file from dir. ok is false when expr does not call a
+// known function.
+func call(expr, dir string) (value string, ok bool, err error) {
+	args, err := splitArgs(expr)
+	if err != nil || len(args) == 0 {
+		return "", false, err
+	}
+	name := args[0]
+	// An offset may be attached to the name, e.g. now+1h
+	if i := strings.IndexAny(name, "+-"); i > 0 && funcs[name[:i]] != nil {
+		args = append([]string{name[:i], name[i:]}, args[1:]...)
+		name = name[:i]
+	}
+	fn, ok := funcs[name]
+	if !ok {
+		return "", false, nil
+	}
+	args = args[1:]
+	if name == "file" && len(args) > 0 && !filepath.IsAbs(args[0]) {
+		args[0] = filepath.Join(dir, args[0])
+	}
+	value, err = fn(args)
+	if err != nil {
+		return "", true, fmt.Errorf("%s: %w", name, err)
+	}
+	return value, true, nil
+}
+
+// splitArgs splits expr at whitespace outside double quotes, unquoting the
+// quoted arguments
+func splitArgs(expr string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg, quoted := false, false
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quoted && c == '\\' && i+1 < len(expr) && expr[i+1] == '"':
+			current.WriteByte('"')
+			i++
+		case c == '"':
+			quoted = !quoted
+			inArg = true
+		case !quoted && (c == ' ' || c == '\t'):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteByte(c)
+			inArg = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quoted string in %q", expr)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// uuid returns a random (version 4) UUID
+func uuid(args []string) (string, error) {
+	if len(args) != 0 {
+		return "", fmt.Errorf("takes no arguments")
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// timeFormats are the named layouts accepted by format=
+var timeFormats = map[string]string{
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"date":        time.DateOnly,
+	"datetime":    time.DateTime,
+}
+
+// now returns the current time, shifted by any offsets and formatted with
+// format= (default rfc3339), e.g. now+1h format=unix. The format is a name
+// (rfc3339, rfc3339nano, date, datetime, unix, unixms) or a Go layout.
+func now(args []string) (string, error) {
+	return shiftTime(clock(), "rfc3339", args)
+}
+
+// dateLayouts are the layouts date accepts, each with the format its result
+// keeps by default
+var dateLayouts = []struct {
+	layout, format string
+}{
+	{time.RFC3339Nano, "rfc3339"},
+	{time.DateTime, "datetime"},
+	{time.DateOnly, "date"},
+}
+
+// date parses a date or time (2006-01-02, 2006-01-02 15:04:05, RFC 3339,
+// or unix seconds), shifts it by any offsets, and formats it like now, e.g.
+// date "2024-01-01" +7d. The result keeps the format of the input unless
+// format= is given.
+func date(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("expected a date, e.g. date \"2024-01-01\" +7d")
+	}
+	value := args[0]
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return shiftTime(time.Unix(secs, 0).UTC(), "unix", args[1:])
+	}
+	for _, l := range dateLayouts {
+		if t, err := time.Parse(l.layout, value); err == nil {
+			return shiftTime(t, l.format, args[1:])
+		}
+	}
+	return "", fmt.Errorf("invalid date %q, expected 2006-01-02, 2006-01-02 15:04:05, RFC 3339, or unix seconds", value)
+}
+
+// shiftTime applies the offsets in args to t and formats it with the
+// format= argument, or format if there is none
+func shiftTime(t time.Time, format string, args []string) (string, error) {
+	for _, arg := range args {
+		if f, ok := strings.CutPrefix(arg, "format="); ok {
+			format = f
+			continue
+		}
+		if !strings.HasPrefix(arg, "+") && !strings.HasPrefix(arg, "-") {
+			return "", fmt.Errorf("unexpected argument %q, expected an offset such as +1h or format=<layout>", arg)
+		}
+		shifted, err := addOffset(t, arg)
+		if err != nil {
+			return "", fmt.Errorf("invalid offset %q: %w", arg, err)
+		}
+		t = shifted
+	}
+	return formatTime(t, format), nil
+}
+
+// addOffset adds a signed offset to t: a Go duration, optionally preceded by
+// a number of calendar days, e.g. -24h, +7d, or +1d12h
+func addOffset(t time.Time, offset string) (time.Time, error) {
+	sign, rest := offset[:1], offset[1:]
+	if days, duration, ok := strings.Cut(rest, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return t, fmt.Errorf("invalid number of days %q", days)
+		}
+		if sign == "-" {
+			n = -n
+		}
+		t = t.AddDate(0, 0, n)
+		if duration == "" {
+			return t, nil
+		}
+		rest = duration
+	}
+	d, err := time.ParseDuration(sign + rest)
+	if err != nil {
+		return t, err
+	}
+	return t.Add(d), nil
+}
+
+// formatTime formats t with a named format or a Go layout
+func formatTime(t time.Time, format string) string {
+	switch format {
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "unixms":
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	}
+	if layout, ok := timeFormats[format]; ok {
+		format = layout
+	}
+	return t.Format(format)
+}
+
+// readFile returns the contents of a file, relative to the request file
+// (see call), without trailing newlines, e.g. file "payloads/avatar.b64".
+// With base64, the exact bytes are base64-encoded instead, e.g. for bytes
+// fields: file "avatar.png" base64.
+func readFile(args []string) (string, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return "", fmt.Errorf("expected a path and an optional encoding (base64), got %d arguments", len(args))
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return "", err
+	}
+	if len(args) == 1 {
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	if args[1] != "base64" {
+		return "", fmt.Errorf("unknown encoding %q, expected base64", args[1])
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// randomInt returns a random integer between min and max, inclusive
+func randomInt(args []string) (string, error) {
+	if len(args) != 2 {
+		return "", fmt.Errorf("expected 2 arguments (min max), got %d", len(args))
+	}
+	lo, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid min %q", args[0])
+	}
+	hi, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid max %q", args[1])
+	}
+	if hi < lo {
+		return "", fmt.Errorf("max %d is less than min %d", hi, lo)
+	}
+	// In big.Int, as the width of a range such as all of int64 overflows it
+	width := new(big.Int).Sub(big.NewInt(hi), big.NewInt(lo))
+	n, err := rand.Int(rand.Reader, width.Add(width, big.NewInt(1)))
+	if err != nil {
+		return "", err
+	}
+	return n.Add(n, big.NewInt(lo)).String(), nil
+}
+
+// randomAlphabet is the alphabet of randomString
+const randomAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// randomString returns a random alphanumeric string of the given length
+func randomString(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("expected 1 argument (length), got %d", len(args))
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 {
+		return "", fmt.Errorf("invalid length %q", args[0])
+	}
+	b := make([]byte, n)
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(randomAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		b[i] = randomAlphabet[idx.Int64()]
+	}
+	return string(b), nil
+}
+
+// secretValue returns a secret from the OS keyring, or from Vault for a
+// name of the form vault:<path>#<field>, e.g. {{secret "api-token"}}
+func secretValue(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("expected 1 argument (name), got %d", len(args))
+	}
+	return lookupSecret(args[0])
+}
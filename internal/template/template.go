@@ -1,22 +1,158 @@
 package template
 
 import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"net/url"
+	"regexp"
 	"strings"
 )
 
-// Substitute replaces variables in the format {{key}} with values from the map.
+// placeholderPattern matches {{...}} placeholders
+var placeholderPattern = regexp.MustCompile(`\{\{(.*?)\}\}`)
+
+// Substitute replaces placeholders in input. A placeholder is either a
+// variable, e.g. {{token}}, replaced with its value from the map, or a call
+// to a built-in function: {{uuid}}, {{now}} (e.g. {{now+1h format=unix}}),
+// {{date "2024-01-01" +7d}}, {{file "payload.txt"}}, {{randomInt 1 100}},
+// or {{randomString 16}}.
+// Variables take precedence over functions of the same name.
+//
+// Numeric variables and numbers can be combined with + - * / and %, e.g.
+// {{page + 1}} or {{count * 2}}; the operators must be surrounded by spaces.
+//
+// The value can be piped through filters: default, which supplies a value
+// for a variable that is not defined, e.g. {{host | default "localhost:8080"}},
+// and base64, hex, and urlencode, which encode it, e.g. {{token | base64}}.
+// Filters are applied left to right.
+//
+// Placeholders that are neither a variable nor a function, or whose
+// function fails, are left untouched (see SubstituteStrict).
 func Substitute(input string, variables map[string]interface{}) string {
-	if len(variables) == 0 {
-		return input
+	result, _ := SubstituteStrict(input, variables)
+	return result
+}
+
+// SubstituteStrict is like Substitute, but also returns an error listing
+// every placeholder it left untouched and why, e.g.
+// {{token}}: variable "token" is not defined
+func SubstituteStrict(input string, variables map[string]interface{}) (string, error) {
+	return SubstituteStrictIn("", input, variables)
+}
+
+// SubstituteStrictIn is like SubstituteStrict, but reads the relative
+// paths of {{file}} from dir, e.g. the directory of the request file
+func SubstituteStrictIn(dir, input string, variables map[string]interface{}) (string, error) {
+	if !strings.Contains(input, "{{") {
+		return input, nil
+	}
+	var errs []error
+	result := placeholderPattern.ReplaceAllStringFunc(input, func(placeholder string) string {
+		value, err := evaluate(placeholder[2:len(placeholder)-2], variables, dir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", placeholder, err))
+			return placeholder
+		}
+		return value
+	})
+	return result, errors.Join(errs...)
+}
+
+// UndefinedError reports a placeholder using a variable that is not defined
+type UndefinedError struct {
+	Name string
+}
+
+func (e *UndefinedError) Error() string {
+	return fmt.Sprintf("variable %q is not defined", e.Name)
+}
+
+// evaluate evaluates the expression of a placeholder: a variable,
+// arithmetic expression, or function call, optionally piped through filters.
+// Relative file paths are read from dir.
+func evaluate(expr string, variables map[string]interface{}, dir string) (string, error) {
+	stages := splitPipeline(expr)
+	operand := strings.TrimSpace(stages[0])
+	var (
+		value string
+		ok    bool // Whether value is defined
+	)
+	if v, found := variables[operand]; found {
+		value, ok = fmt.Sprintf("%v", v), true
+	} else {
+		var err error
+		if value, ok, err = arithmetic(operand, variables); err != nil {
+			return "", err
+		}
+		if !ok {
+			if value, ok, err = call(operand, dir); err != nil {
+				return "", err
+			}
+		}
 	}
 
-	result := input
-	for key, value := range variables {
-		placeholder := fmt.Sprintf("{{%s}}", key)
-		valStr := fmt.Sprintf("%v", value)
-		result = strings.ReplaceAll(result, placeholder, valStr)
+	for _, stage := range stages[1:] {
+		args, err := splitArgs(stage)
+		if err != nil {
+			return "", err
+		}
+		if len(args) == 0 {
+			return "", fmt.Errorf("empty filter")
+		}
+		switch args[0] {
+		case "default":
+			if len(args) != 2 {
+				return "", fmt.Errorf("default: expected 1 argument, got %d", len(args)-1)
+			}
+			if !ok {
+				value, ok = args[1], true
+			}
+		default:
+			filter, found := filters[args[0]]
+			if !found {
+				return "", fmt.Errorf("unknown filter %q", args[0])
+			}
+			if len(args) != 1 {
+				return "", fmt.Errorf("%s: takes no arguments", args[0])
+			}
+			if ok {
+				value = filter(value)
+			}
+		}
 	}
 
-	return result
+	if !ok {
+		if name, _, isCall := strings.Cut(operand, " "); isCall {
+			return "", fmt.Errorf("unknown function %q", name)
+		}
+		return "", &UndefinedError{Name: operand}
+	}
+	return value, nil
+}
+
+// filters are the filters that encode a value, e.g. {{token | base64}}
+var filters = map[string]func(string) string{
+	"base64":    func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"hex":       func(s string) string { return hex.EncodeToString([]byte(s)) },
+	"urlencode": url.QueryEscape,
+}
+
+// splitPipeline splits expr at each | outside double quotes
+func splitPipeline(expr string) []string {
+	var stages []string
+	start, quoted := 0, false
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case quoted && c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case !quoted && c == '|':
+			stages = append(stages, expr[start:i])
+			start = i + 1
+		}
+	}
+	return append(stages, expr[start:])
 }
@@ -1,7 +1,17 @@
 package template
 
 import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"grpc_client/internal/secret"
 )
 
 func TestSubstitute(t *testing.T) {
@@ -36,3 +46,291 @@ func TestSubstitute(t *testing.T) {
 		})
 	}
 }
+
+func TestSubstitute_Funcs(t *testing.T) {
+	clock = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	defer func() { clock = time.Now }()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Now", "{{now}}", "2024-01-02T03:04:05Z"},
+		{"Now with offset", "{{now+1h}}", "2024-01-02T04:04:05Z"},
+		{"Now with separate offsets", "{{now -24h +30m}}", "2024-01-01T03:34:05Z"},
+		{"Now unix", "{{now+1h format=unix}}", "1704168245"},
+		{"Now named format", "{{now format=date}}", "2024-01-02"},
+		{"Now Go layout", `{{now format="Jan 2, 2006"}}`, "Jan 2, 2024"},
+		{"Now minus days", "{{now -1d format=date}}", "2024-01-01"},
+		{"Now days and hours", "{{now+1d12h}}", "2024-01-03T15:04:05Z"},
+		{"Date plus days", `{{date "2024-01-01" +7d}}`, "2024-01-08"},
+		{"Date across months", `{{date "2024-01-31" +1d format=unix}}`, "1706745600"},
+		{"Datetime", `{{date "2024-01-01 10:00:00" -30m}}`, "2024-01-01 09:30:00"},
+		{"RFC 3339", `{{date "2024-01-01T00:00:00Z" -1d}}`, "2023-12-31T00:00:00Z"},
+		{"Unix seconds", `{{date 1704067200 +1h}}`, "1704070800"},
+		{"Unix to date", `{{date 1704067200 format=date}}`, "2024-01-01"},
+		{"Bad date", `{{date "01/02/2024"}}`, `{{date "01/02/2024"}}`},
+		{"Bad days", "{{now+xd}}", "{{now+xd}}"},
+		{"Fixed range", "{{randomInt 7 7}}", "7"},
+		{"Empty string", "[{{randomString 0}}]", "[]"},
+		{"Bad argument", "{{randomInt 1}}", "{{randomInt 1}}"},
+		{"Bad offset", "{{now+1x}}", "{{now+1x}}"},
+		{"Unknown function", "{{nope 1}}", "{{nope 1}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Substitute(tt.input, nil); got != tt.want {
+				t.Errorf("Substitute(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubstitute_RandomFuncs(t *testing.T) {
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	first, second := Substitute("{{uuid}}", nil), Substitute("{{uuid}}", nil)
+	if !uuidPattern.MatchString(first) || first == second {
+		t.Errorf("expected two different v4 UUIDs, got %q and %q", first, second)
+	}
+
+	for range 100 {
+		n, err := strconv.Atoi(Substitute("{{randomInt 1 6}}", nil))
+		if err != nil || n < 1 || n > 6 {
+			t.Fatalf("randomInt out of range: %d, %v", n, err)
+		}
+	}
+
+	// Ranges whose width overflows int64
+	for _, tt := range []struct{ lo, hi int64 }{
+		{math.MinInt64, math.MaxInt64},
+		{-5, math.MaxInt64},
+		{math.MinInt64, math.MinInt64},
+		{math.MaxInt64, math.MaxInt64},
+	} {
+		input := fmt.Sprintf("{{randomInt %d %d}}", tt.lo, tt.hi)
+		got, err := SubstituteStrict(input, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+		if n, err := strconv.ParseInt(got, 10, 64); err != nil || n < tt.lo || n > tt.hi {
+			t.Errorf("%s = %q, out of range", input, got)
+		}
+	}
+
+	if s := Substitute("{{randomString 16}}", nil); !regexp.MustCompile(`^[a-zA-Z0-9]{16}$`).MatchString(s) {
+		t.Errorf("unexpected random string %q", s)
+	}
+
+	// Variables take precedence over functions
+	if got := Substitute("{{uuid}}", map[string]interface{}{"uuid": "fixed"}); got != "fixed" {
+		t.Errorf("expected the variable, got %q", got)
+	}
+}
+
+func TestSubstitute_File(t *testing.T) {
+	dir := t.TempDir()
+	text := filepath.Join(dir, "token.txt")
+	if err := os.WriteFile(text, []byte("abc123\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	binary := filepath.Join(dir, "avatar.bin")
+	if err := os.WriteFile(binary, []byte{0x89, 'P', 'N', 'G', '\n'}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Text", `Bearer {{file "` + text + `"}}`, "Bearer abc123"},
+		{"Base64", `{{file "` + binary + `" base64}}`, "iVBORwo="},
+		{"Base64 filter", `{{file "` + text + `" | base64}}`, "YWJjMTIz"},
+		{"Missing file", `{{file "missing.txt"}}`, `{{file "missing.txt"}}`},
+		{"Unknown encoding", `{{file "` + text + `" hex}}`, `{{file "` + text + `" hex}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Substitute(tt.input, nil); got != tt.want {
+				t.Errorf("Substitute(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubstituteStrictIn_File(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "token.txt"), []byte("abc123\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+
+	got, err := SubstituteStrictIn(dir, `{{file "token.txt"}}`, nil)
+	if err != nil || got != "abc123" {
+		t.Errorf("relative path = %q, %v, want the file next to dir", got, err)
+	}
+	abs := filepath.Join(dir, "token.txt")
+	if got, err := SubstituteStrictIn(t.TempDir(), `{{file "`+abs+`"}}`, nil); err != nil || got != "abc123" {
+		t.Errorf("absolute path = %q, %v", got, err)
+	}
+	if _, err := SubstituteStrict(`{{file "token.txt"}}`, nil); err == nil {
+		t.Error("expected the working directory to be used without a dir")
+	}
+}
+
+func TestSubstitute_Secret(t *testing.T) {
+	lookupSecret = func(name string) (string, error) {
+		if name == "api-token" {
+			return "s3cret", nil
+		}
+		return "", fmt.Errorf("secret %q: not found", name)
+	}
+	t.Cleanup(func() { lookupSecret = secret.Lookup })
+
+	if got := Substitute(`Bearer {{secret "api-token"}}`, nil); got != "Bearer s3cret" {
+		t.Errorf("got %q, want Bearer s3cret", got)
+	}
+
+	_, err := SubstituteStrict(`{{secret "missing"}}`, nil)
+	if err == nil || !strings.Contains(err.Error(), `secret "missing": not found`) {
+		t.Errorf("expected lookup error, got %v", err)
+	}
+	if _, err := SubstituteStrict(`{{secret}}`, nil); err == nil {
+		t.Error("expected error for a missing name")
+	}
+}
+
+func TestSubstitute_Default(t *testing.T) {
+	vars := map[string]interface{}{"host": "example.com:443", "empty": ""}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Defined variable", `{{host | default "localhost:8080"}}`, "example.com:443"},
+		{"Undefined variable", `{{port | default "8080"}}`, "8080"},
+		{"Empty value is defined", `[{{empty | default "x"}}]`, "[]"},
+		{"Quoted pipe", `{{sep | default "a|b"}}`, "a|b"},
+		{"Function", `{{randomInt 3 3 | default "1"}}`, "3"},
+		{"Chained defaults", `{{a | default "1" | default "2"}}`, "1"},
+		{"Missing argument", `{{port | default}}`, `{{port | default}}`},
+		{"Unknown filter", `{{host | nope}}`, `{{host | nope}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Substitute(tt.input, vars); got != tt.want {
+				t.Errorf("Substitute(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubstitute_EncodingFilters(t *testing.T) {
+	vars := map[string]interface{}{"creds": "alice:s3cret", "query": "a b&c=d/é", "bytes": "\x01\xff"}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Base64", `{{creds | base64}}`, "YWxpY2U6czNjcmV0"},
+		{"Hex", `{{bytes | hex}}`, "01ff"},
+		{"URL encode", `{{query | urlencode}}`, "a+b%26c%3Dd%2F%C3%A9"},
+		{"Chained", `{{creds | base64 | urlencode}}`, "YWxpY2U6czNjcmV0"},
+		{"After default", `{{user | default "bob" | base64}}`, "Ym9i"},
+		{"Function", `{{randomString 0 | hex}}`, ""},
+		{"Undefined variable", `{{user | base64}}`, `{{user | base64}}`},
+		{"Unexpected argument", `{{creds | base64 std}}`, `{{creds | base64 std}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Substitute(tt.input, vars); got != tt.want {
+				t.Errorf("Substitute(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubstituteStrict(t *testing.T) {
+	vars := map[string]interface{}{"token": "secret-123"}
+
+	got, err := SubstituteStrict(`{{token}} {{host | default "localhost"}} {{uuid}}`, vars)
+	if err != nil {
+		t.Fatalf("SubstituteStrict failed: %v", err)
+	}
+	if !strings.HasPrefix(got, "secret-123 localhost ") {
+		t.Errorf("unexpected result %q", got)
+	}
+
+	input := `{{user_id}} {{token}} {{nope 1}} {{randomInt 1}}`
+	got, err = SubstituteStrict(input, vars)
+	if got != `{{user_id}} secret-123 {{nope 1}} {{randomInt 1}}` {
+		t.Errorf("unexpected result %q", got)
+	}
+	want := `{{user_id}}: variable "user_id" is not defined
+{{nope 1}}: unknown function "nope"
+{{randomInt 1}}: randomInt: expected 2 arguments (min max), got 1`
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want:\n%s", err, want)
+	}
+}
+
+func TestSubstitute_Arithmetic(t *testing.T) {
+	vars := map[string]interface{}{
+		"page":   "3",
+		"count":  float64(21), // Captured JSON numbers are float64
+		"big":    "9007199254740993",
+		"price":  "1.5",
+		"name":   "alice",
+		"future": Unknown,
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{"Add", "{{page + 1}}", "4", ""},
+		{"Multiply", "{{count * 2}}", "42", ""},
+		{"Precedence", "{{page + count * 2 - 1}}", "44", ""},
+		{"Left to right", "{{10 - page - 2}}", "5", ""},
+		{"Fraction", "{{5 / 2}}", "2.5", ""},
+		{"Decimal", "{{price * 3}}", "4.5", ""},
+		{"Modulo", "{{count % 4}}", "1", ""},
+		{"Negative number", "{{page * -1}}", "-3", ""},
+		{"Exact large integers", "{{big + 1}}", "9007199254740994", ""},
+		{"Filter", "{{page + 1 | base64}}", "NA==", ""},
+		{"Unknown operand", "[{{future + 1}}]", "[]", ""},
+		{"Undefined variable", "{{total + 1}}", "", `variable "total" is not defined`},
+		{"Not a number", "{{name + 1}}", "", `variable "name" is not a number: "alice"`},
+		{"Division by zero", "{{page / 0}}", "", "division by zero"},
+		{"Fractional modulo", "{{price % 2}}", "", "% requires integers"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SubstituteStrict(tt.input, vars)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("SubstituteStrict(%q) error = %v, want %q", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("SubstituteStrict(%q) = %q, %v; want %q", tt.input, got, err, tt.want)
+			}
+		})
+	}
+
+	// Without spaces, a name such as page-1 is a variable
+	if got := Substitute("{{page-1}}", map[string]interface{}{"page-1": "x"}); got != "x" {
+		t.Errorf("expected the variable page-1, got %q", got)
+	}
+}
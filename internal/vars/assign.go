@@ -0,0 +1,22 @@
+package vars
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseAssignments parses name=value pairs, e.g. from repeated --var flags.
+// The value is everything after the first '=' and may be empty; a later
+// assignment to the same name wins.
+func ParseAssignments(pairs []string) (map[string]interface{}, error) {
+	variables := make(map[string]interface{}, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid variable %q, expected 'name=value'", pair)
+		}
+		variables[name] = value
+	}
+	return variables, nil
+}
@@ -0,0 +1,23 @@
+package vars
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseAssignments(t *testing.T) {
+	got, err := ParseAssignments([]string{"token=abc", "user_id=42", "query=a=b", "empty=", "token=xyz"})
+	if err != nil {
+		t.Fatalf("ParseAssignments failed: %v", err)
+	}
+	want := map[string]interface{}{"token": "xyz", "user_id": "42", "query": "a=b", "empty": ""}
+	if !maps.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, invalid := range []string{"token", "=abc"} {
+		if _, err := ParseAssignments([]string{invalid}); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
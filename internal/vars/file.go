@@ -0,0 +1,77 @@
+package vars
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadFile reads variables from a JSON (.json) or YAML (.yaml, .yml) file
+// holding a mapping, e.g. from --var-file. Nested values are flattened into
+// dotted names, so {"auth": {"token": "abc"}} defines auth.token, and list
+// elements are addressed by index, e.g. users.0.id.
+func LoadFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read variable file: %w", err)
+	}
+
+	var doc map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		// Keep numbers as written, e.g. large IDs instead of 1.2e+18
+		decoder.UseNumber()
+		err = decoder.Decode(&doc)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	default:
+		return nil, fmt.Errorf("unsupported variable file %s: expected .json, .yaml or .yml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid variable file %s: %w", path, err)
+	}
+
+	return Flatten(doc), nil
+}
+
+// Flatten flattens nested maps and lists into dotted variable names, e.g.
+// {"auth": {"token": "abc"}} into auth.token. A null value becomes an empty
+// string.
+func Flatten(doc map[string]interface{}) map[string]interface{} {
+	variables := make(map[string]interface{})
+	flatten("", doc, variables)
+	return variables
+}
+
+// flatten adds value to variables under name, descending into maps and
+// lists with dotted names
+func flatten(name string, value interface{}, variables map[string]interface{}) {
+	join := func(key string) string {
+		if name == "" {
+			return key
+		}
+		return name + "." + key
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			flatten(join(key), child, variables)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flatten(join(strconv.Itoa(i)), child, variables)
+		}
+	case nil:
+		variables[name] = ""
+	default:
+		variables[name] = v
+	}
+}
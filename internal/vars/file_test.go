@@ -0,0 +1,99 @@
+package vars
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	want := map[string]string{
+		"host":         "localhost:8080",
+		"auth.token":   "abc",
+		"auth.user.id": "1234567890123456789",
+		"users.0.name": "alice",
+		"users.1.name": "bob",
+		"debug":        "true",
+		"empty":        "",
+		"ratio":        "0.5",
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "json",
+			file: "vars.json",
+			content: `{
+  "host": "localhost:8080",
+  "auth": {"token": "abc", "user": {"id": 1234567890123456789}},
+  "users": [{"name": "alice"}, {"name": "bob"}],
+  "debug": true,
+  "empty": null,
+  "ratio": 0.5
+}`,
+		},
+		{
+			name: "yaml",
+			file: "vars.yaml",
+			content: `host: localhost:8080
+auth:
+  token: abc
+  user:
+    id: 1234567890123456789
+users:
+  - name: alice
+  - name: bob
+debug: true
+empty:
+ratio: 0.5
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadFile(path)
+			if err != nil {
+				t.Fatalf("LoadFile failed: %v", err)
+			}
+			if len(got) != len(want) {
+				t.Errorf("got %d variables %v, want %d", len(got), got, len(want))
+			}
+			for name, value := range want {
+				if v, ok := got[name]; !ok || fmt.Sprintf("%v", v) != value {
+					t.Errorf("%s = %v, want %q", name, v, value)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadFile_Errors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"vars.toml":  `token = "abc"`,
+		"list.json":  `["abc"]`,
+		"bad.yaml":   "token: [abc",
+		"scalar.yml": "abc",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if _, err := LoadFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
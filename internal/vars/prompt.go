@@ -0,0 +1,58 @@
+package vars
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// secretWords mark variable names whose values are not echoed when prompted
+var secretWords = []string{"token", "secret", "password", "passwd", "credential", "apikey", "api_key", "private"}
+
+// IsSecretName reports whether a variable name looks like it holds a secret,
+// e.g. auth.token, dbPassword, or API_KEY
+func IsSecretName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, word := range secretWords {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Prompter asks the user for the values of variables
+type Prompter struct {
+	In  *bufio.Reader // Answers, one per line
+	Out io.Writer     // Where questions are written
+
+	// ReadSecret reads an answer without echoing it, for secret names
+	ReadSecret func() (string, error)
+}
+
+// Ask prompts for each of names in order and returns the answers
+func (p *Prompter) Ask(names []string) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		fmt.Fprintf(p.Out, "%s: ", name)
+		var (
+			answer string
+			err    error
+		)
+		if IsSecretName(name) && p.ReadSecret != nil {
+			answer, err = p.ReadSecret()
+			fmt.Fprintln(p.Out) // The user's newline was not echoed
+		} else {
+			answer, err = p.In.ReadString('\n')
+			if err == io.EOF && answer != "" {
+				err = nil
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read a value for %s: %w", name, err)
+		}
+		values[name] = strings.TrimRight(answer, "\r\n")
+	}
+	return values, nil
+}
@@ -0,0 +1,59 @@
+package vars
+
+import (
+	"bufio"
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestIsSecretName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"token":         true,
+		"auth.token":    true,
+		"dbPassword":    true,
+		"API_KEY":       true,
+		"client_secret": true,
+		"user_id":       false,
+		"host":          false,
+		"monkey":        false,
+	} {
+		if got := IsSecretName(name); got != want {
+			t.Errorf("IsSecretName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestPrompter_Ask(t *testing.T) {
+	var out strings.Builder
+	secrets := 0
+	p := &Prompter{
+		In:  bufio.NewReader(strings.NewReader("42\r\nstaging\n")),
+		Out: &out,
+		ReadSecret: func() (string, error) {
+			secrets++
+			return "s3cret", nil
+		},
+	}
+
+	got, err := p.Ask([]string{"user_id", "api_token", "env"})
+	if err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	want := map[string]interface{}{"user_id": "42", "api_token": "s3cret", "env": "staging"}
+	if !maps.Equal(got, want) {
+		t.Errorf("Ask = %v, want %v", got, want)
+	}
+	if secrets != 1 {
+		t.Errorf("expected the secret to be read without echo once, got %d", secrets)
+	}
+	if out.String() != "user_id: api_token: \nenv: " {
+		t.Errorf("unexpected prompts %q", out.String())
+	}
+
+	// Running out of input is an error
+	p.In = bufio.NewReader(strings.NewReader(""))
+	if _, err := p.Ask([]string{"user_id"}); err == nil {
+		t.Error("expected error at end of input")
+	}
+}
@@ -0,0 +1,64 @@
+package vars
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+)
+
+// Scope holds the variables of a run as layers of increasing precedence.
+// The [Variables] sections of a file are file-scoped and lowest; global
+// layers (profile, capture store, --var-file, --var) override them in the
+// order they are added. Captures made during the run override them all.
+type Scope struct {
+	layers []Layer
+}
+
+// Layer is a set of variables from one origin, e.g. "--var" or
+// "profile staging"
+type Layer struct {
+	Origin string
+	Vars   map[string]interface{}
+}
+
+// Variable is an effective variable and the origin of its value
+type Variable struct {
+	Name   string
+	Value  interface{}
+	Origin string
+}
+
+// Add adds a global layer that overrides the layers added before it
+func (s *Scope) Add(origin string, variables map[string]interface{}) {
+	s.layers = append(s.layers, Layer{Origin: origin, Vars: variables})
+}
+
+// Resolve returns the variables visible to the requests of a file whose
+// [Variables] sections are file. The result is a new map, so captures
+// stored in it do not leak into the scope.
+func (s *Scope) Resolve(file Layer) map[string]interface{} {
+	variables := make(map[string]interface{})
+	for _, layer := range s.stack(file) {
+		maps.Copy(variables, layer.Vars)
+	}
+	return variables
+}
+
+// Explain returns the variables visible to a file, as Resolve does, sorted
+// by name with the origin of each value
+func (s *Scope) Explain(file Layer) []Variable {
+	effective := make(map[string]Variable)
+	for _, layer := range s.stack(file) {
+		for name, value := range layer.Vars {
+			effective[name] = Variable{Name: name, Value: value, Origin: layer.Origin}
+		}
+	}
+	return slices.SortedFunc(maps.Values(effective), func(a, b Variable) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+}
+
+// stack returns the layers visible to a file, lowest precedence first
+func (s *Scope) stack(file Layer) []Layer {
+	return append([]Layer{file}, s.layers...)
+}
@@ -0,0 +1,42 @@
+package vars
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	var scope Scope
+	scope.Add("profile staging", map[string]interface{}{"host": "staging", "token": "profile"})
+	scope.Add("--var", map[string]interface{}{"token": "flag"})
+
+	file := Layer{Origin: "[Variables]", Vars: map[string]interface{}{"host": "localhost", "user_id": "42"}}
+
+	got := scope.Resolve(file)
+	want := map[string]interface{}{"host": "staging", "token": "flag", "user_id": "42"}
+	if !maps.Equal(got, want) {
+		t.Errorf("Resolve = %v, want %v", got, want)
+	}
+
+	// Captures stored in a resolved map stay out of the scope
+	got["captured"] = "x"
+	if _, ok := scope.Resolve(file)["captured"]; ok {
+		t.Error("captures leaked into the scope")
+	}
+
+	// File-scoped variables are not visible to other files
+	if _, ok := scope.Resolve(Layer{})["user_id"]; ok {
+		t.Error("[Variables] of one file leaked into another")
+	}
+
+	explained := scope.Explain(file)
+	wantExplained := []Variable{
+		{Name: "host", Value: "staging", Origin: "profile staging"},
+		{Name: "token", Value: "flag", Origin: "--var"},
+		{Name: "user_id", Value: "42", Origin: "[Variables]"},
+	}
+	if !slices.Equal(explained, wantExplained) {
+		t.Errorf("Explain = %v, want %v", explained, wantExplained)
+	}
+}
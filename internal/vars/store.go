@@ -0,0 +1,42 @@
+package vars
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// LoadStore reads a JSON variable store written by SaveStore.
+// A missing file is not an error and yields an empty store, so the first
+// run of a flow can create it.
+func LoadStore(path string) (map[string]interface{}, error) {
+	variables := make(map[string]interface{})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return variables, nil
+		}
+		return nil, fmt.Errorf("failed to read variable store: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &variables); err != nil {
+		return nil, fmt.Errorf("invalid variable store %s: %w", path, err)
+	}
+	return variables, nil
+}
+
+// SaveStore writes variables to path as JSON. The file is only readable by
+// the current user since it typically holds tokens.
+func SaveStore(path string, variables map[string]interface{}) error {
+	data, err := json.MarshalIndent(variables, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode variable store: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write variable store: %w", err)
+	}
+	return nil
+}
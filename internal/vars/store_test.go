@@ -0,0 +1,46 @@
+package vars
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vars.json")
+
+	want := map[string]interface{}{
+		"token":   "abc-123",
+		"user_id": "42",
+	}
+	if err := SaveStore(path, want); err != nil {
+		t.Fatalf("SaveStore failed: %v", err)
+	}
+
+	got, err := LoadStore(path)
+	if err != nil {
+		t.Fatalf("LoadStore failed: %v", err)
+	}
+	if len(got) != len(want) || got["token"] != "abc-123" || got["user_id"] != "42" {
+		t.Errorf("LoadStore() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadStore_Missing(t *testing.T) {
+	got, err := LoadStore(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("LoadStore failed: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("expected empty store, got %v", got)
+	}
+}
+
+func TestLoadStore_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vars.json")
+	_ = os.WriteFile(path, []byte("not json"), 0600)
+
+	if _, err := LoadStore(path); err == nil {
+		t.Error("expected error for invalid store")
+	}
+}